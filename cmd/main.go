@@ -48,14 +48,19 @@ func main() {
 		exit(fmt.Sprintf("migrating db %s", cfg.DB.Url), err)
 	}
 
-	minio, err := storage.NewMinio(ctx, cfg)
+	blob, err := storage.NewBlob(ctx, cfg)
 	if err != nil {
-		exit("creating minio client", err)
+		exit(fmt.Sprintf("creating %s storage", cfg.Storage.Backend), err)
 	}
 
-	storages := modules.NewStorages(cfg, gorm, pool, minio)
+	storages := modules.NewStorages(cfg, gorm, pool, blob)
 	services := modules.NewServices(storages, pool, lg)
 
+	// Таксономия категорий читается из базы; пустая база заполняется файлами categories
+	if err := services.Category.Init(ctx); err != nil {
+		exit("loading category taxonomy", err)
+	}
+
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
@@ -63,6 +68,21 @@ func main() {
 	stopChan := make(chan struct{})
 
 	go services.Image.DeleteImageSync(stopChan)
+	go services.Boost.ExpireBoostsSync(stopChan)
+	go services.Listing.ExpireListingsSync(stopChan)
+	go services.Category.RefreshCategoriesSync(stopChan)
+	go services.Translation.TranslateListingsSync(stopChan)
+
+	if cfg.Taxonomy.Watch {
+		err := config.WatchTaxonomy(stopChan, func() {
+			if _, err := services.Category.ReloadCategories(ctx); err != nil {
+				lg.Errorf("importing category taxonomy: %v", err)
+			}
+		})
+		if err != nil {
+			lg.Errorf("watching category taxonomy: %v", err)
+		}
+	}
 
 	controller := modules.NewControllers(services)
 	// init router
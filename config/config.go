@@ -1,11 +1,11 @@
 package config
 
 import (
-	"encoding/json"
 	"log"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"fmt"
 
@@ -31,55 +31,136 @@ type Config struct {
 		Password string
 		Bucket   string
 	}
+	// Storage задает бэкенд файлового хранилища изображений: minio, fs или memory
+	Storage struct {
+		Backend string
+		// Path директория для бэкенда fs
+		Path string
+	}
+	// Moderation задает правила локальной модерации изображений
+	Moderation struct {
+		// SkinRatio доля пикселей телесного цвета, начиная с которой изображение помечается как NSFW; 0 отключает проверку
+		SkinRatio float64
+		// HashDistance расстояние Хэмминга между перцептивными хешами, при котором изображения считаются одинаковыми
+		HashDistance int
+		// BlockedHashes pHash запрещенных изображений в шестнадцатеричном виде
+		BlockedHashes []string
+	}
+	// Ranking задает веса ранжирования поисковой выдачи и продвигаемые места на странице
+	Ranking struct {
+		// RelevanceWeight вес текстовой релевантности
+		RelevanceWeight float64
+		// BoostWeight вес уровня действующего буста
+		BoostWeight float64
+		// FreshnessWeight вес свежести объявления
+		FreshnessWeight float64
+		// FreshnessHalfLifeHours через сколько часов вклад свежести уменьшается вдвое
+		FreshnessHalfLifeHours float64
+		// BoostTiers уровень каждого типа буста, чем больше, тем выше объявление
+		BoostTiers map[string]int
+		// PromotedSlots число продвигаемых мест в начале каждой страницы; 0 отключает продвижение
+		PromotedSlots int
+		// PromotedBoostTypes типы бустов, дающие право на продвигаемое место
+		PromotedBoostTypes []string
+	}
+	// Listings задает жизненный цикл объявлений
+	Listings struct {
+		// Moderation отправляет объявления на проверку модератору перед публикацией
+		Moderation bool
+		// TTLDays срок публикации объявления в днях, после которого оно снимается с публикации
+		TTLDays int
+	}
+	// Map задает поиск по области карты
+	Map struct {
+		// ClusterMaxZoom масштаб, до которого включительно объявления объединяются в кластеры
+		ClusterMaxZoom int
+		// GridCells число ячеек сетки кластеров на ширину тайла карты
+		GridCells int
+		// MaxListings максимальное число объявлений в ответе без кластеризации
+		MaxListings int
+	}
 	Logger struct {
 		Level string
 	}
-	Categories struct {
-		// Toml содержит данные категорий в формате TOML
-		Toml string
-		// Категории в структурированном виде
-		Data CategoriesData
-		// LangCategories содержит переводы категорий в формате JSON
-		LangCategories struct {
-			Ru string
-			En string
-			Es string
-		}
-		// LangCharacteristics содержит переводы характеристик категорий
-		LangCharacteristics struct {
-			Ru string
-			En string
-			Es string
-		}
-		// LangOptions содержит переводы опций характеристик в формате JSON
-		LangOptions struct {
-			Ru string
-			En string
-			Es string
-		}
-		// OptionsTranslations содержит распарсенные переводы опций
-		OptionsTranslations struct {
-			Ru map[string]map[string]string
-			En map[string]map[string]string
-			Es map[string]map[string]string
-		}
-		CategoryOptions map[string][]string
-		// CategoryCharacteristics содержит характеристики для категорий
-		CategoryCharacteristics map[string][]string
-		// CategoryIds содержит все доступные ID категорий для быстрой валидации
-		CategoryIds map[string]struct{}
+	Categories CategoriesConfig
+	// Taxonomy задает перезагрузку таксономии категорий
+	Taxonomy struct {
+		// Watch импортирует файлы categories в базу при их изменении. Импорт заменяет
+		// правки, сделанные через API, поэтому наблюдение нужно только при разработке
+		Watch bool
+	}
+	// Admin задает пользователей с доступом к административным методам
+	Admin struct {
+		// Actors идентификаторы пользователей из заголовка X-User-ID, которым доступны
+		// модерация объявлений и изображений, изменение таксономии категорий и каталога бустов
+		Actors []string
 	}
 	Binance struct {
 		APIKey    string
 		SecretKey string
 		Local     bool
 	}
+	// Payments платежный провайдер заказов
+	Payments struct {
+		// Local включает локальную заглушку провайдера, хранящую платежи в памяти
+		Local bool
+	}
+	// Translation перевод заголовков и описаний объявлений на языки интерфейса
+	Translation struct {
+		// Provider провайдер перевода: echo оставляет текст без изменений,
+		// dictionary переводит слова по локальному словарю и нужен только в тестах
+		Provider string
+		// Dictionary путь к словарю провайдера dictionary относительно корня проекта
+		Dictionary string
+		// QueueSize размер очереди фонового перевода; не попавшие в очередь объявления
+		// переводит периодическая проверка
+		QueueSize int
+	}
 	Nominatim struct {
 		BaseUrl string
+		// UserAgent идентифицирует приложение, как требует политика использования Nominatim
+		UserAgent string
+		// TimeoutMs таймаут запроса к Nominatim
+		TimeoutMs int
+		// MinIntervalMs минимальный интервал между запросами; политика разрешает не больше 1 запроса в секунду
+		MinIntervalMs int
+		// CacheTTLHours срок, в течение которого ответ из кэша считается актуальным
+		CacheTTLHours int
 	}
 }
 
-var cfg = Config{}
+var (
+	cfg = Config{}
+	// mu защищает cfg при перезагрузке таксономии
+	mu sync.RWMutex
+)
+
+// CategoriesConfig таксономия категорий: дерево, реестр характеристик и переводы.
+// При запуске загружается из файлов функцией LoadTaxonomy, затем заменяется
+// таксономией из базы через SetCategories
+type CategoriesConfig struct {
+	// Категории в структурированном виде
+	Data CategoriesData
+	// LangCategories содержит переводы категорий в формате JSON
+	LangCategories LangFiles
+	// LangCharacteristics содержит переводы характеристик категорий
+	LangCharacteristics LangFiles
+	// LangOptions содержит переводы опций характеристик в формате JSON
+	LangOptions LangFiles
+	// OptionsTranslations содержит распарсенные переводы опций
+	OptionsTranslations struct {
+		Ru map[string]map[string]string
+		En map[string]map[string]string
+		Es map[string]map[string]string
+	}
+	// Characteristics реестр характеристик: тип, опции, единицы измерения и поведение в фильтрах
+	Characteristics []CharacteristicDefinition
+	// CategoryCharacteristics плоский список характеристик категорий из category_characteristics.json.
+	// Схема характеристик с наследованием строится по дереву Data
+	CategoryCharacteristics map[string][]string
+	// CategoryIds содержит все доступные ID категорий для быстрой валидации
+	CategoryIds map[string]struct{}
+}
 
 // CategoriesData представляет структуру данных категорий в TOML
 type CategoriesData struct {
@@ -89,14 +170,48 @@ type CategoriesData struct {
 // CategoryNode представляет узел категории
 type CategoryNode struct {
 	ID              string               `toml:"id"`
-	Characteristics []CharacteristicNode `toml:"characteristics"`
-	Subcategories   []CategoryNode       `toml:"subcategories"`
+	Characteristics []CharacteristicNode `toml:"characteristics,omitempty"`
+	Subcategories   []CategoryNode       `toml:"subcategories,omitempty"`
 }
 
-// CharacteristicNode представляет характеристику категории
+// CharacteristicDefinition описывает характеристику в реестре categories/characteristics.json.
+// Новая характеристика добавляется только записью в этот файл
+type CharacteristicDefinition struct {
+	Role string `json:"role"`
+	// Kind тип значения: color, dropdown, multiselect, checkbox, amount, integer, text или date
+	Kind string `json:"kind"`
+	// Options допустимые значения для color, dropdown и multiselect
+	Options []string `json:"options,omitempty"`
+	// Units допустимые единицы измерения для amount, DefaultUnit предлагается по умолчанию
+	Units       []string `json:"units,omitempty"`
+	DefaultUnit string   `json:"defaultUnit,omitempty"`
+	// Min и Max необязательные границы для integer
+	Min *int64 `json:"min,omitempty"`
+	Max *int64 `json:"max,omitempty"`
+	// MaxLength максимальная длина значения text
+	MaxLength int `json:"maxLength,omitempty"`
+	// Filter поведение в фильтрах поиска; пустое значение выбирает поведение по типу, none скрывает фильтр
+	Filter string `json:"filter,omitempty"`
+}
+
+// CharacteristicNode характеристика на уровне категории. Подкатегории наследуют характеристики
+// предков и могут переопределить для себя опции, обязательность и порядок
 type CharacteristicNode struct {
-	Role    string   `toml:"role"`
-	Options []string `toml:"options"`
+	Role string `toml:"role" json:"role"`
+	// Options сужает опции реестра для категории и ее потомков; пустой список наследуется
+	Options []string `toml:"options,omitempty" json:"options,omitempty"`
+	// Required делает характеристику обязательной; без значения наследуется от предка
+	Required *bool `toml:"required,omitempty" json:"required,omitempty"`
+	// Order позиция характеристики в схеме категории; без значения наследуется от предка
+	Order *int `toml:"order,omitempty" json:"order,omitempty"`
+	// Min и Max границы значения для целых и величин; без значения наследуются от предка
+	Min *float64 `toml:"min,omitempty" json:"min,omitempty"`
+	Max *float64 `toml:"max,omitempty" json:"max,omitempty"`
+	// Unit единица границ величины; по умолчанию единица реестра
+	Unit string `toml:"unit,omitempty" json:"unit,omitempty"`
+	// Strict требует, чтобы значение выпадающего списка было одной из опций; без значения
+	// наследуется от предка, по умолчанию опции списка только подсказки
+	Strict *bool `toml:"strict,omitempty" json:"strict,omitempty"`
 }
 
 func LoadConfig() {
@@ -130,6 +245,9 @@ func LoadConfig() {
 		log.Fatalf("Ошибка при загрузке переменных окружения: %v", err)
 	}
 
+	mu.Lock()
+	defer mu.Unlock()
+
 	// Unmarshal config into struct
 	if err := k.Unmarshal("", &cfg); err != nil {
 		log.Fatalf("Ошибка при разборе конфигурации: %v", err)
@@ -142,177 +260,16 @@ func LoadConfig() {
 		cfg.Minio.Endpoint = strings.TrimPrefix(cfg.Minio.Endpoint, "https://")
 	}
 
-	// Read categories.toml
-	categoriesPath := filepath.Join(projectRoot, "./categories/categories.toml")
-	categoriesFile, err := os.ReadFile(categoriesPath)
-	if err != nil {
-		log.Fatalf("Ошибка чтения файла categories.toml: %v", err)
+	// Таксономия категорий загружается целиком и с перекрестной проверкой файлов
+	categoriesDir := filepath.Join(projectRoot, "categories")
+	categories, report := LoadTaxonomy(categoriesDir)
+	for _, issue := range report.Issues {
+		log.Printf("Таксономия категорий: %s %s: %s", issue.Level, issue.File, issue.Message)
 	}
-
-	cfg.Categories.Toml = string(categoriesFile)
-
-	// Инициализируем map для ID категорий
-	cfg.Categories.CategoryIds = make(map[string]struct{})
-
-	// Парсим категории и собираем их ID
-	var categoriesData CategoriesData
-	// Используем koanf для парсинга TOML
-	catK := koanf.New(".")
-	if err := catK.Load(file.Provider(categoriesPath), toml.Parser()); err != nil {
-		log.Printf("Ошибка парсинга конфигурации категорий TOML: %v\n", err)
-	} else if err := catK.Unmarshal("categories", &categoriesData.Categories); err != nil {
-		log.Printf("Ошибка преобразования данных категорий: %v\n", err)
-	} else {
-		// Сохраняем структурированные данные
-		cfg.Categories.Data = categoriesData
-
-		// Рекурсивно собираем все ID категорий
-		var collectCategoryIds func(nodes []CategoryNode)
-		collectCategoryIds = func(nodes []CategoryNode) {
-			for _, node := range nodes {
-				cfg.Categories.CategoryIds[node.ID] = struct{}{}
-				collectCategoryIds(node.Subcategories)
-			}
-		}
-		collectCategoryIds(categoriesData.Categories)
-	}
-
-	// Загрузка переводов категорий
-	categoriesPath = filepath.Join(projectRoot, "./categories/lang/ru.json")
-	categoriesFile, err = os.ReadFile(categoriesPath)
-	if err != nil {
-		log.Fatalf("Ошибка чтения файла categories/lang/ru.json: %v", err)
-	}
-
-	cfg.Categories.LangCategories.Ru = string(categoriesFile)
-
-	categoriesPath = filepath.Join(projectRoot, "./categories/lang/en.json")
-	categoriesFile, err = os.ReadFile(categoriesPath)
-	if err != nil {
-		log.Fatalf("Ошибка чтения файла categories/lang/en.json: %v", err)
-	}
-
-	cfg.Categories.LangCategories.En = string(categoriesFile)
-
-	categoriesPath = filepath.Join(projectRoot, "./categories/lang/es.json")
-	categoriesFile, err = os.ReadFile(categoriesPath)
-	if err != nil {
-		log.Fatalf("Ошибка чтения файла categories/lang/es.json: %v", err)
-	}
-
-	cfg.Categories.LangCategories.Es = string(categoriesFile)
-
-	// Загрузка переводов опций характеристик
-	// Загрузка переводов опций характеристик
-	optionsPath := filepath.Join(projectRoot, "./categories/lang_options/ru.json")
-	optionsFile, err := os.ReadFile(optionsPath)
-	if err != nil {
-		log.Printf("Ошибка чтения файла lang_options/ru.json: %v", err)
-	} else {
-		cfg.Categories.LangOptions.Ru = string(optionsFile)
-	}
-
-	optionsPath = filepath.Join(projectRoot, "./categories/lang_options/en.json")
-	optionsFile, err = os.ReadFile(optionsPath)
-	if err != nil {
-		log.Printf("Ошибка чтения файла lang_options/en.json: %v", err)
-	} else {
-		cfg.Categories.LangOptions.En = string(optionsFile)
+	if report.HasErrors() {
+		log.Fatalf("Ошибка загрузки таксономии категорий из %s", categoriesDir)
 	}
-
-	optionsPath = filepath.Join(projectRoot, "./categories/lang_options/es.json")
-	optionsFile, err = os.ReadFile(optionsPath)
-	if err != nil {
-		log.Printf("Ошибка чтения файла lang_options/es.json: %v", err)
-	} else {
-		cfg.Categories.LangOptions.Es = string(optionsFile)
-	}
-
-	// Инициализация структур для переводов опций
-	cfg.Categories.OptionsTranslations.Ru = make(map[string]map[string]string)
-	cfg.Categories.OptionsTranslations.En = make(map[string]map[string]string)
-	cfg.Categories.OptionsTranslations.Es = make(map[string]map[string]string)
-
-	// Парсим переводы из JSON
-	if cfg.Categories.LangOptions.Ru != "" {
-		if err := json.Unmarshal([]byte(cfg.Categories.LangOptions.Ru), &cfg.Categories.OptionsTranslations.Ru); err != nil {
-			log.Printf("Ошибка при парсинге переводов опций на русском: %v", err)
-		}
-	}
-
-	if cfg.Categories.LangOptions.En != "" {
-		if err := json.Unmarshal([]byte(cfg.Categories.LangOptions.En), &cfg.Categories.OptionsTranslations.En); err != nil {
-			log.Printf("Ошибка при парсинге переводов опций на английском: %v", err)
-		}
-	}
-
-	if cfg.Categories.LangOptions.Es != "" {
-		if err := json.Unmarshal([]byte(cfg.Categories.LangOptions.Es), &cfg.Categories.OptionsTranslations.Es); err != nil {
-			log.Printf("Ошибка при парсинге переводов опций на испанском: %v", err)
-		}
-	}
-
-	// Загрузка опций характеристик
-	characteristicOptionsPath := filepath.Join(projectRoot, "./categories/characteristic_options.json")
-	characteristicOptionsFile, err := os.ReadFile(characteristicOptionsPath)
-	if err != nil {
-		log.Printf("Ошибка чтения файла characteristic_options.json: %v", err)
-	} else {
-		var CharOpt []struct {
-			Name    string   `json:"name"`
-			Options []string `json:"options"`
-		}
-
-		if err := json.Unmarshal(characteristicOptionsFile, &CharOpt); err != nil {
-			log.Printf("Ошибка парсинга файла characteristic_options.json: %v", err)
-		} else {
-			cfg.Categories.CategoryOptions = make(map[string][]string)
-			for _, item := range CharOpt {
-				cfg.Categories.CategoryOptions[item.Name] = item.Options
-			}
-		}
-	}
-
-	// Загрузка характеристики категорий
-	categoryCharacteristicsPath := filepath.Join(projectRoot, "./categories/category_characteristics.json")
-	categoryCharacteristicsFile, err := os.ReadFile(categoryCharacteristicsPath)
-	if err != nil {
-		log.Printf("Ошибка чтения файла category_characteristics.json: %v", err)
-	} else {
-		// Файл имеет формат {"category": ["characteristic1", "characteristic2", ...]}
-		var categoryCharacteristics map[string][]string
-
-		if err := json.Unmarshal(categoryCharacteristicsFile, &categoryCharacteristics); err != nil {
-			log.Printf("Ошибка парсинга файла category_characteristics.json: %v", err)
-		} else {
-			cfg.Categories.CategoryCharacteristics = categoryCharacteristics
-		}
-	}
-
-	// Загрузка переводов характеристик категорий
-	langCharPath := filepath.Join(projectRoot, "./categories/lang_characteristics/ru.json")
-	langCharFile, err := os.ReadFile(langCharPath)
-	if err != nil {
-		log.Fatalf("Ошибка чтения файла lang_characteristics/ru.json: %v", err)
-	}
-
-	cfg.Categories.LangCharacteristics.Ru = string(langCharFile)
-
-	langCharPath = filepath.Join(projectRoot, "./categories/lang_characteristics/en.json")
-	langCharFile, err = os.ReadFile(langCharPath)
-	if err != nil {
-		log.Fatalf("Ошибка чтения файла lang_characteristics/en.json: %v", err)
-	}
-
-	cfg.Categories.LangCharacteristics.En = string(langCharFile)
-
-	langCharPath = filepath.Join(projectRoot, "./categories/lang_characteristics/es.json")
-	langCharFile, err = os.ReadFile(langCharPath)
-	if err != nil {
-		log.Fatalf("Ошибка чтения файла lang_characteristics/es.json: %v", err)
-	}
-
-	cfg.Categories.LangCharacteristics.Es = string(langCharFile)
+	cfg.Categories = categories
 }
 
 // getProjectRoot returns the absolute path to the project root directory
@@ -336,6 +293,17 @@ func getProjectRoot() (string, error) {
 	}
 }
 
+// ProjectPath возвращает путь относительно корня проекта
+func ProjectPath(elem ...string) (string, error) {
+	projectRoot, err := getProjectRoot()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(append([]string{projectRoot}, elem...)...), nil
+}
+
 func GetConfig() Config {
+	mu.RLock()
+	defer mu.RUnlock()
 	return cfg
 }
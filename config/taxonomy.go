@@ -0,0 +1,532 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+
+	"github.com/knadh/koanf"
+	"github.com/knadh/koanf/parsers/toml"
+	"github.com/knadh/koanf/providers/rawbytes"
+	gotoml "github.com/pelletier/go-toml"
+)
+
+// Файлы таксономии в директории categories
+const (
+	taxonomyCategoriesFile              = "categories.toml"
+	taxonomyCharacteristicsFile         = "characteristics.json"
+	taxonomyCategoryCharacteristicsFile = "category_characteristics.json"
+	taxonomyLangCategoriesDir           = "lang"
+	taxonomyLangCharacteristicsDir      = "lang_characteristics"
+	taxonomyLangOptionsDir              = "lang_options"
+)
+
+// Уровни замечаний к таксономии
+const (
+	// TaxonomyError битая ссылка или нечитаемый файл; такая таксономия не применяется
+	TaxonomyError = "error"
+	// TaxonomyWarning недостающий перевод; таксономия применяется, а вместо перевода показывается идентификатор
+	TaxonomyWarning = "warning"
+)
+
+// ErrInvalidTaxonomy таксономия содержит ошибки и не может быть применена
+var ErrInvalidTaxonomy = errors.New("taxonomy has errors")
+
+// TaxonomyLanguages языки переводов таксономии
+var TaxonomyLanguages = []string{"ru", "en", "es"}
+
+// taxonomyBuiltinRoles роли, которые переводятся, но не описываются в реестре: цена есть у любого объявления
+var taxonomyBuiltinRoles = map[string]bool{"price": true}
+
+// checkboxOptions значения чекбокса, которые можно перечислить в категории и перевести
+var checkboxOptions = []string{"true", "false"}
+
+// categoryIDPattern допустимый идентификатор категории
+var categoryIDPattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// TaxonomyIssue замечание к таксономии. File указывает файл в раскладке директории categories
+type TaxonomyIssue struct {
+	Level   string `json:"level"`
+	File    string `json:"file"`
+	Message string `json:"message"`
+}
+
+// TaxonomyReport результат перекрестной проверки таксономии
+type TaxonomyReport struct {
+	Issues []TaxonomyIssue `json:"issues"`
+}
+
+// HasErrors проверяет, есть ли в отчете ошибки
+func (r TaxonomyReport) HasErrors() bool {
+	for _, issue := range r.Issues {
+		if issue.Level == TaxonomyError {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *TaxonomyReport) errorf(file, format string, args ...interface{}) {
+	r.Issues = append(r.Issues, TaxonomyIssue{Level: TaxonomyError, File: file, Message: fmt.Sprintf(format, args...)})
+}
+
+func (r *TaxonomyReport) warnf(file, format string, args ...interface{}) {
+	r.Issues = append(r.Issues, TaxonomyIssue{Level: TaxonomyWarning, File: file, Message: fmt.Sprintf(format, args...)})
+}
+
+// TaxonomyFiles содержимое файлов таксономии по путям относительно директории categories
+type TaxonomyFiles map[string]string
+
+// Taxonomy таксономия категорий в разобранном виде: дерево, реестр характеристик и переводы
+type Taxonomy struct {
+	Categories      []CategoryNode
+	Characteristics []CharacteristicDefinition
+	// Translations переводы по языкам
+	Translations map[string]TaxonomyTranslations
+}
+
+// TaxonomyTranslations переводы таксономии на один язык
+type TaxonomyTranslations struct {
+	Categories      map[string]string
+	Characteristics map[string]string
+	// Options переводы опций по ролям характеристик
+	Options map[string]map[string]string
+}
+
+// LangFiles содержимое файлов перевода по языкам
+type LangFiles struct {
+	Ru string
+	En string
+	Es string
+}
+
+func (f *LangFiles) set(lang, raw string) {
+	switch lang {
+	case "ru":
+		f.Ru = raw
+	case "en":
+		f.En = raw
+	case "es":
+		f.Es = raw
+	}
+}
+
+func langCategoriesFile(lang string) string {
+	return path.Join(taxonomyLangCategoriesDir, lang+".json")
+}
+
+func langCharacteristicsFile(lang string) string {
+	return path.Join(taxonomyLangCharacteristicsDir, lang+".json")
+}
+
+func langOptionsFile(lang string) string {
+	return path.Join(taxonomyLangOptionsDir, lang+".json")
+}
+
+// taxonomyFileNames все файлы таксономии
+func taxonomyFileNames() []string {
+	names := []string{taxonomyCategoriesFile, taxonomyCharacteristicsFile, taxonomyCategoryCharacteristicsFile}
+	for _, lang := range TaxonomyLanguages {
+		names = append(names, langCategoriesFile(lang), langCharacteristicsFile(lang), langOptionsFile(lang))
+	}
+	return names
+}
+
+// ReadTaxonomyFiles читает файлы таксономии из директории dir. Отсутствующие файлы
+// не попадают в результат, их найдет ParseTaxonomy
+func ReadTaxonomyFiles(dir string) (TaxonomyFiles, error) {
+	files := make(TaxonomyFiles)
+	for _, name := range taxonomyFileNames() {
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		files[name] = string(data)
+	}
+	return files, nil
+}
+
+// LoadTaxonomy читает и проверяет таксономию из директории dir
+func LoadTaxonomy(dir string) (CategoriesConfig, TaxonomyReport) {
+	files, err := ReadTaxonomyFiles(dir)
+	if err != nil {
+		var report TaxonomyReport
+		report.errorf("", "read %s: %v", dir, err)
+		return CategoriesConfig{}, report
+	}
+
+	taxonomy, report := ParseTaxonomy(files)
+	return taxonomy.Config(), report
+}
+
+// ParseTaxonomy разбирает файлы таксономии и проверяет ссылки между ними. Нечитаемые
+// файлы и битые ссылки попадают в отчет ошибками, недостающие переводы — предупреждениями
+func ParseTaxonomy(files TaxonomyFiles) (Taxonomy, TaxonomyReport) {
+	var t Taxonomy
+	var report TaxonomyReport
+
+	// Дерево категорий
+	if content, ok := files[taxonomyCategoriesFile]; !ok {
+		report.errorf(taxonomyCategoriesFile, "file is missing")
+	} else {
+		catK := koanf.New(".")
+		if err := catK.Load(rawbytes.Provider([]byte(content)), toml.Parser()); err != nil {
+			report.errorf(taxonomyCategoriesFile, "parse: %v", err)
+		} else if err := catK.Unmarshal("categories", &t.Categories); err != nil {
+			report.errorf(taxonomyCategoriesFile, "parse: %v", err)
+		}
+	}
+
+	// Реестр характеристик
+	parseTaxonomyJSON(files, taxonomyCharacteristicsFile, &t.Characteristics, &report)
+
+	// Переводы
+	t.Translations = make(map[string]TaxonomyTranslations, len(TaxonomyLanguages))
+	for _, lang := range TaxonomyLanguages {
+		translations := TaxonomyTranslations{
+			Categories:      make(map[string]string),
+			Characteristics: make(map[string]string),
+			Options:         make(map[string]map[string]string),
+		}
+		parseTaxonomyJSON(files, langCategoriesFile(lang), &translations.Categories, &report)
+		parseTaxonomyJSON(files, langCharacteristicsFile(lang), &translations.Characteristics, &report)
+		parseTaxonomyJSON(files, langOptionsFile(lang), &translations.Options, &report)
+		t.Translations[lang] = translations
+	}
+
+	report.Issues = append(report.Issues, t.Validate().Issues...)
+
+	// Плоский список должен повторять характеристики уровней дерева
+	var categoryCharacteristics map[string][]string
+	if parseTaxonomyJSON(files, taxonomyCategoryCharacteristicsFile, &categoryCharacteristics, &report) {
+		registry := t.registry()
+		treeRoles := t.CategoryCharacteristics()
+		for _, id := range sortedKeys(categoryCharacteristics) {
+			roles, ok := treeRoles[id]
+			if !ok {
+				report.errorf(taxonomyCategoryCharacteristicsFile, "unknown category %q", id)
+				continue
+			}
+			for _, role := range categoryCharacteristics[id] {
+				if _, ok := registry[role]; !ok {
+					report.errorf(taxonomyCategoryCharacteristicsFile, "category %q: unknown characteristic %q", id, role)
+				}
+			}
+			if !sameStrings(roles, categoryCharacteristics[id]) {
+				report.errorf(taxonomyCategoryCharacteristicsFile, "category %q: characteristics %v differ from %s %v",
+					id, categoryCharacteristics[id], taxonomyCategoriesFile, roles)
+			}
+		}
+		for _, id := range sortedKeys(treeRoles) {
+			if _, ok := categoryCharacteristics[id]; !ok {
+				report.errorf(taxonomyCategoryCharacteristicsFile, "category %q is missing", id)
+			}
+		}
+	}
+
+	return t, report
+}
+
+// parseTaxonomyJSON разбирает JSON-файл таксономии в out; false, если файла нет или он нечитаем
+func parseTaxonomyJSON(files TaxonomyFiles, name string, out interface{}, report *TaxonomyReport) bool {
+	content, ok := files[name]
+	if !ok {
+		report.errorf(name, "file is missing")
+		return false
+	}
+	if err := json.Unmarshal([]byte(content), out); err != nil {
+		report.errorf(name, "parse: %v", err)
+		return false
+	}
+	return true
+}
+
+func (t Taxonomy) registry() map[string]CharacteristicDefinition {
+	registry := make(map[string]CharacteristicDefinition, len(t.Characteristics))
+	for _, def := range t.Characteristics {
+		registry[def.Role] = def
+	}
+	return registry
+}
+
+// CategoryCharacteristics возвращает роли, заданные на уровне каждой категории дерева
+func (t Taxonomy) CategoryCharacteristics() map[string][]string {
+	roles := make(map[string][]string)
+	var walk func(nodes []CategoryNode)
+	walk = func(nodes []CategoryNode) {
+		for _, node := range nodes {
+			nodeRoles := make([]string, 0, len(node.Characteristics))
+			for _, ch := range node.Characteristics {
+				nodeRoles = append(nodeRoles, ch.Role)
+			}
+			roles[node.ID] = nodeRoles
+			walk(node.Subcategories)
+		}
+	}
+	walk(t.Categories)
+	return roles
+}
+
+// Validate проверяет, что дерево и переводы ссылаются только на существующие категории,
+// характеристики и опции, а у каждой из них есть перевод
+func (t Taxonomy) Validate() TaxonomyReport {
+	var report TaxonomyReport
+
+	registry := make(map[string]CharacteristicDefinition, len(t.Characteristics))
+	for _, def := range t.Characteristics {
+		if _, ok := registry[def.Role]; ok {
+			report.errorf(taxonomyCharacteristicsFile, "duplicate characteristic %q", def.Role)
+		}
+		if def.DefaultUnit != "" && !containsString(def.Units, def.DefaultUnit) {
+			report.errorf(taxonomyCharacteristicsFile, "characteristic %q: default unit %q is not in units", def.Role, def.DefaultUnit)
+		}
+		registry[def.Role] = def
+	}
+
+	// Дерево категорий. inherited хранит опции, до которых характеристику сузили предки
+	categories := make(map[string]bool)
+	var walk func(nodes []CategoryNode, inherited map[string][]string)
+	walk = func(nodes []CategoryNode, inherited map[string][]string) {
+		for _, node := range nodes {
+			if categories[node.ID] {
+				report.errorf(taxonomyCategoriesFile, "duplicate category %q", node.ID)
+			}
+			if !categoryIDPattern.MatchString(node.ID) {
+				report.errorf(taxonomyCategoriesFile, "invalid category id %q", node.ID)
+			}
+			categories[node.ID] = true
+
+			narrowed := inherited
+			for _, ch := range node.Characteristics {
+				def, ok := registry[ch.Role]
+				if !ok {
+					report.errorf(taxonomyCategoriesFile, "category %q: unknown characteristic %q", node.ID, ch.Role)
+					continue
+				}
+				options := definitionOptions(def)
+				allowed, isNarrowed := inherited[ch.Role]
+				for _, option := range ch.Options {
+					if !containsString(options, option) {
+						report.errorf(taxonomyCategoriesFile, "category %q: characteristic %q has unknown option %q", node.ID, ch.Role, option)
+					} else if isNarrowed && !containsString(allowed, option) {
+						report.errorf(taxonomyCategoriesFile, "category %q: characteristic %q option %q is not allowed by parent categories", node.ID, ch.Role, option)
+					}
+				}
+				if len(ch.Options) > 0 && len(options) > 0 {
+					narrowed = withOptions(narrowed, ch.Role, ch.Options)
+				}
+				if ch.Unit != "" && !containsString(def.Units, ch.Unit) {
+					report.errorf(taxonomyCategoriesFile, "category %q: characteristic %q has unknown unit %q", node.ID, ch.Role, ch.Unit)
+				}
+			}
+			walk(node.Subcategories, narrowed)
+		}
+	}
+	walk(t.Categories, nil)
+
+	// Переводы
+	for _, lang := range TaxonomyLanguages {
+		translations := t.Translations[lang]
+
+		categoriesFile := langCategoriesFile(lang)
+		for _, id := range sortedKeys(categories) {
+			if _, ok := translations.Categories[id]; !ok {
+				report.warnf(categoriesFile, "missing translation for category %q", id)
+			}
+		}
+		for _, id := range sortedKeys(translations.Categories) {
+			if !categories[id] {
+				report.errorf(categoriesFile, "translation for unknown category %q", id)
+			}
+		}
+
+		characteristicsFile := langCharacteristicsFile(lang)
+		for _, role := range sortedKeys(registry) {
+			if _, ok := translations.Characteristics[role]; !ok {
+				report.warnf(characteristicsFile, "missing translation for characteristic %q", role)
+			}
+		}
+		for _, role := range sortedKeys(translations.Characteristics) {
+			if _, ok := registry[role]; !ok && !taxonomyBuiltinRoles[role] {
+				report.errorf(characteristicsFile, "translation for unknown characteristic %q", role)
+			}
+		}
+
+		optionsFile := langOptionsFile(lang)
+		for _, role := range sortedKeys(registry) {
+			for _, option := range definitionOptions(registry[role]) {
+				if _, ok := translations.Options[role][option]; !ok {
+					report.warnf(optionsFile, "missing translation for option %q of characteristic %q", option, role)
+				}
+			}
+		}
+		for _, role := range sortedKeys(translations.Options) {
+			def, ok := registry[role]
+			if !ok {
+				report.errorf(optionsFile, "translation for options of unknown characteristic %q", role)
+				continue
+			}
+			options := definitionOptions(def)
+			for _, option := range sortedKeys(translations.Options[role]) {
+				if !containsString(options, option) {
+					report.errorf(optionsFile, "translation for unknown option %q of characteristic %q", option, role)
+				}
+			}
+		}
+	}
+
+	return report
+}
+
+// Config собирает из таксономии настройки категорий, которые читает приложение
+func (t Taxonomy) Config() CategoriesConfig {
+	c := CategoriesConfig{
+		Data:                    CategoriesData{Categories: t.Categories},
+		Characteristics:         t.Characteristics,
+		CategoryCharacteristics: t.CategoryCharacteristics(),
+		CategoryIds:             make(map[string]struct{}),
+	}
+	for id := range c.CategoryCharacteristics {
+		c.CategoryIds[id] = struct{}{}
+	}
+
+	options := make(map[string]map[string]map[string]string, len(TaxonomyLanguages))
+	for _, lang := range TaxonomyLanguages {
+		translations := t.Translations[lang]
+		c.LangCategories.set(lang, mustJSON(nonNilMap(translations.Categories)))
+		c.LangCharacteristics.set(lang, mustJSON(nonNilMap(translations.Characteristics)))
+		c.LangOptions.set(lang, mustJSON(nonNilMap(translations.Options)))
+		options[lang] = nonNilMap(translations.Options)
+	}
+	c.OptionsTranslations.Ru = options["ru"]
+	c.OptionsTranslations.En = options["en"]
+	c.OptionsTranslations.Es = options["es"]
+
+	return c
+}
+
+// Files выгружает таксономию в формате файлов директории categories
+func (t Taxonomy) Files() (TaxonomyFiles, error) {
+	var categories bytes.Buffer
+	err := gotoml.NewEncoder(&categories).Order(gotoml.OrderPreserve).
+		Encode(CategoriesData{Categories: t.Categories})
+	if err != nil {
+		return nil, err
+	}
+
+	files := TaxonomyFiles{taxonomyCategoriesFile: categories.String()}
+	if files[taxonomyCharacteristicsFile], err = indentJSON(t.Characteristics); err != nil {
+		return nil, err
+	}
+	if files[taxonomyCategoryCharacteristicsFile], err = indentJSON(t.CategoryCharacteristics()); err != nil {
+		return nil, err
+	}
+	for _, lang := range TaxonomyLanguages {
+		translations := t.Translations[lang]
+		if files[langCategoriesFile(lang)], err = indentJSON(nonNilMap(translations.Categories)); err != nil {
+			return nil, err
+		}
+		if files[langCharacteristicsFile(lang)], err = indentJSON(nonNilMap(translations.Characteristics)); err != nil {
+			return nil, err
+		}
+		if files[langOptionsFile(lang)], err = indentJSON(nonNilMap(translations.Options)); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+func indentJSON(v interface{}) (string, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data) + "\n", nil
+}
+
+// mustJSON сериализует карту переводов; карты строк сериализуются без ошибок
+func mustJSON(v interface{}) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+func nonNilMap[V any](m map[string]V) map[string]V {
+	if m == nil {
+		return make(map[string]V)
+	}
+	return m
+}
+
+// definitionOptions опции характеристики, на которые можно ссылаться из категорий и переводов
+func definitionOptions(def CharacteristicDefinition) []string {
+	if def.Kind == "checkbox" {
+		return checkboxOptions
+	}
+	return def.Options
+}
+
+// withOptions копия набора суженных опций с опциями options для характеристики role
+func withOptions(inherited map[string][]string, role string, options []string) map[string][]string {
+	narrowed := make(map[string][]string, len(inherited)+1)
+	for r, o := range inherited {
+		narrowed[r] = o
+	}
+	narrowed[role] = options
+	return narrowed
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// sameStrings сравнивает наборы строк без учета порядка
+func sameStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	a = append([]string(nil), a...)
+	b = append([]string(nil), b...)
+	sort.Strings(a)
+	sort.Strings(b)
+	return reflect.DeepEqual(a, b)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// TaxonomyDir директория файлов таксономии
+func TaxonomyDir() (string, error) {
+	projectRoot, err := getProjectRoot()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(projectRoot, "categories"), nil
+}
+
+// SetCategories заменяет действующую таксономию без перезапуска сервиса
+func SetCategories(categories CategoriesConfig) {
+	mu.Lock()
+	cfg.Categories = categories
+	mu.Unlock()
+}
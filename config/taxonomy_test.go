@@ -0,0 +1,140 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTaxonomy(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	return dir
+}
+
+func TestLoadTaxonomy(t *testing.T) {
+	t.Run("Файлы проекта согласованы", func(t *testing.T) {
+		categories, report := LoadTaxonomy("../categories")
+		assert.Empty(t, report.Issues)
+		assert.Contains(t, categories.CategoryIds, "iphone")
+		assert.NotEmpty(t, categories.Characteristics)
+		assert.Equal(t, "Apple", categories.OptionsTranslations.En["brand"]["apple"])
+	})
+
+	files := map[string]string{
+		"categories.toml": `
+[[categories]]
+id = "electronics"
+
+  [[categories.characteristics]]
+  role = "brand"
+  options = ["apple", "nokia"]
+
+  [[categories.characteristics]]
+  role = "stocked"
+  options = ["true", "false"]
+
+  [[categories.characteristics]]
+  role = "battery"
+
+  [[categories.subcategories]]
+  id = "phones"
+`,
+		"characteristics.json": `[
+  {"role": "brand", "kind": "dropdown", "options": ["apple", "samsung"]},
+  {"role": "stocked", "kind": "checkbox"}
+]`,
+		"category_characteristics.json": `{"electronics": ["brand", "stocked"], "phones": [], "tablets": []}`,
+		"lang/ru.json":                  `{"electronics": "Электроника", "phones": "Телефоны"}`,
+		"lang/en.json":                  `{"electronics": "Electronics"}`,
+		"lang/es.json":                  `{"electronics": "Electrónica", "phones": "Teléfonos", "tv": "TV"}`,
+		"lang_characteristics/ru.json":  `{"price": "Цена", "brand": "Бренд", "stocked": "В наличии"}`,
+		"lang_characteristics/en.json":  `{"brand": "Brand", "stocked": "In stock"}`,
+		"lang_characteristics/es.json":  `{"brand": "Marca", "stocked": "En stock"}`,
+		"lang_options/ru.json":          `{"brand": {"apple": "Apple", "samsung": "Samsung"}, "stocked": {"true": "Да", "false": "Нет"}}`,
+		"lang_options/en.json":          `{"brand": {"apple": "Apple", "samsung": "Samsung", "nokia": "Nokia"}, "stocked": {"true": "Yes", "false": "No"}}`,
+		"lang_options/es.json":          `{"brand": {"apple": "Apple", "samsung": "Samsung"}, "stocked": {"true": "Sí", "false": "No"}}`,
+	}
+
+	t.Run("Битые ссылки и недостающие переводы", func(t *testing.T) {
+		categories, report := LoadTaxonomy(writeTaxonomy(t, files))
+		assert.True(t, report.HasErrors())
+		assert.Contains(t, categories.CategoryIds, "phones")
+
+		assert.ElementsMatch(t, []TaxonomyIssue{
+			{Level: TaxonomyError, File: "categories.toml", Message: `category "electronics": characteristic "brand" has unknown option "nokia"`},
+			{Level: TaxonomyError, File: "categories.toml", Message: `category "electronics": unknown characteristic "battery"`},
+			{Level: TaxonomyError, File: "category_characteristics.json", Message: `unknown category "tablets"`},
+			{Level: TaxonomyError, File: "category_characteristics.json",
+				Message: `category "electronics": characteristics [brand stocked] differ from categories.toml [brand stocked battery]`},
+			{Level: TaxonomyWarning, File: filepath.Join("lang", "en.json"), Message: `missing translation for category "phones"`},
+			{Level: TaxonomyError, File: filepath.Join("lang", "es.json"), Message: `translation for unknown category "tv"`},
+			{Level: TaxonomyError, File: filepath.Join("lang_options", "en.json"),
+				Message: `translation for unknown option "nokia" of characteristic "brand"`},
+		}, report.Issues)
+	})
+
+	t.Run("Подкатегория сужает опции за пределы предка", func(t *testing.T) {
+		narrowed := make(map[string]string, len(files))
+		for name, content := range files {
+			narrowed[name] = content
+		}
+		narrowed["categories.toml"] = `
+[[categories]]
+id = "electronics"
+
+  [[categories.characteristics]]
+  role = "brand"
+  options = ["apple"]
+
+  [[categories.subcategories]]
+  id = "phones"
+
+    [[categories.subcategories.characteristics]]
+    role = "brand"
+    options = ["samsung"]
+`
+
+		_, report := LoadTaxonomy(writeTaxonomy(t, narrowed))
+		assert.Contains(t, report.Issues, TaxonomyIssue{Level: TaxonomyError, File: "categories.toml",
+			Message: `category "phones": characteristic "brand" option "samsung" is not allowed by parent categories`})
+	})
+
+	t.Run("Выгрузка в файлы читается обратно без изменений", func(t *testing.T) {
+		files, err := ReadTaxonomyFiles("../categories")
+		require.NoError(t, err)
+		taxonomy, report := ParseTaxonomy(files)
+		require.False(t, report.HasErrors())
+
+		exported, err := taxonomy.Files()
+		require.NoError(t, err)
+		restored, report := ParseTaxonomy(exported)
+		assert.Empty(t, report.Issues)
+		assert.Equal(t, taxonomy, restored)
+	})
+
+	t.Run("Нечитаемый файл", func(t *testing.T) {
+		broken := make(map[string]string, len(files))
+		for name, content := range files {
+			broken[name] = content
+		}
+		broken["characteristics.json"] = `{`
+		delete(broken, "lang_options/es.json")
+
+		_, report := LoadTaxonomy(writeTaxonomy(t, broken))
+		require.True(t, report.HasErrors())
+		files := make([]string, 0, len(report.Issues))
+		for _, issue := range report.Issues {
+			files = append(files, issue.File)
+		}
+		assert.Contains(t, files, "characteristics.json")
+		assert.Contains(t, files, filepath.Join("lang_options", "es.json"))
+	})
+}
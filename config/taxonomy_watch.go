@@ -0,0 +1,65 @@
+package config
+
+import (
+	"log"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// taxonomyReloadDelay пауза после последнего изменения файла перед перезагрузкой:
+// редакторы сохраняют файл несколькими записями подряд
+const taxonomyReloadDelay = 500 * time.Millisecond
+
+// WatchTaxonomy следит за файлами таксономии до закрытия stopChan и вызывает onChange,
+// когда файлы перестают меняться
+func WatchTaxonomy(stopChan chan struct{}, onChange func()) error {
+	dir, err := TaxonomyDir()
+	if err != nil {
+		return err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	for _, sub := range []string{"", taxonomyLangCategoriesDir, taxonomyLangCharacteristicsDir, taxonomyLangOptionsDir} {
+		if err := watcher.Add(filepath.Join(dir, sub)); err != nil {
+			watcher.Close()
+			return err
+		}
+	}
+
+	go func() {
+		defer watcher.Close()
+
+		timer := time.NewTimer(taxonomyReloadDelay)
+		timer.Stop()
+
+		for {
+			select {
+			case <-stopChan:
+				timer.Stop()
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Remove|fsnotify.Rename) != 0 {
+					timer.Reset(taxonomyReloadDelay)
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Printf("Ошибка наблюдения за таксономией категорий: %v", err)
+			case <-timer.C:
+				onChange()
+			}
+		}
+	}()
+
+	return nil
+}
@@ -0,0 +1,57 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Идентификатор изображения, общий для всех его размеров (UUID-400px.webp, UUID-200px.webp)
+ALTER TABLE image_links
+ADD COLUMN image_id UUID;
+
+-- Состояние изображения: uploaded, attached, detached, deleted
+ALTER TABLE image_links
+ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'uploaded';
+
+ALTER TABLE image_links
+ADD COLUMN created_at TIMESTAMP NOT NULL DEFAULT NOW();
+
+-- Переносим существующие записи в новую модель состояний
+UPDATE image_links
+SET
+    image_id = substring(image_name from '^([0-9a-fA-F-]{36})')::uuid,
+    status = CASE WHEN linked THEN 'attached' ELSE 'detached' END,
+    created_at = updated_at;
+
+-- Файлы со старыми именами без UUID сохраняются: им выдается постоянный идентификатор по имени
+UPDATE image_links
+SET image_id = md5(image_name)::uuid
+WHERE image_id IS NULL;
+
+ALTER TABLE image_links
+ALTER COLUMN image_id SET NOT NULL;
+
+ALTER TABLE image_links
+DROP COLUMN linked;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_image_links_image_name_unique ON image_links(image_name);
+CREATE INDEX IF NOT EXISTS idx_image_links_image_id ON image_links(image_id);
+CREATE INDEX IF NOT EXISTS idx_image_links_listing_id ON image_links(listing_id);
+CREATE INDEX IF NOT EXISTS idx_image_links_status_updated_at ON image_links(status, updated_at);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_image_links_status_updated_at;
+DROP INDEX IF EXISTS idx_image_links_listing_id;
+DROP INDEX IF EXISTS idx_image_links_image_id;
+DROP INDEX IF EXISTS idx_image_links_image_name_unique;
+
+ALTER TABLE image_links
+ADD COLUMN linked BOOLEAN DEFAULT TRUE;
+
+UPDATE image_links SET linked = (status = 'attached');
+
+ALTER TABLE image_links DROP COLUMN created_at;
+ALTER TABLE image_links DROP COLUMN status;
+ALTER TABLE image_links DROP COLUMN image_id;
+
+-- +goose StatementEnd
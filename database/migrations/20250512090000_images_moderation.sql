@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Метаданные изображения: перцептивные хеши и результат модерации.
+-- Поиск дубликатов считает расстояние Хэмминга по всем строкам, btree индекс по хешу ему не помогает
+CREATE TABLE IF NOT EXISTS images (
+    id UUID PRIMARY KEY,
+    phash BIGINT NOT NULL,
+    dhash BIGINT NOT NULL,
+    is_nsfw BOOLEAN NOT NULL DEFAULT FALSE,
+    moderation_reason TEXT NOT NULL DEFAULT '',
+    moderated_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Флаг NSFW объявления пересчитывается по привязанным изображениям
+ALTER TABLE listings
+ADD COLUMN is_nsfw BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE listings DROP COLUMN is_nsfw;
+
+DROP TABLE IF EXISTS images;
+
+-- +goose StatementEnd
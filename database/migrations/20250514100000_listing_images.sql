@@ -0,0 +1,73 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Изображения объявления в порядке показа
+CREATE TABLE IF NOT EXISTS listing_images (
+    listing_id UUID NOT NULL REFERENCES listings(id) ON DELETE CASCADE,
+    image_id UUID NOT NULL,
+    position INT NOT NULL,
+    is_cover BOOLEAN NOT NULL DEFAULT FALSE,
+    alt TEXT NOT NULL DEFAULT '',
+    PRIMARY KEY (listing_id, image_id),
+    -- Проверка откладывается до конца транзакции, чтобы порядок можно было переставлять одним запросом
+    CONSTRAINT listing_images_position_unique UNIQUE (listing_id, position) DEFERRABLE INITIALLY DEFERRED
+);
+
+-- У объявления может быть только одна обложка
+CREATE UNIQUE INDEX IF NOT EXISTS idx_listing_images_cover ON listing_images(listing_id) WHERE is_cover;
+CREATE INDEX IF NOT EXISTS idx_listing_images_image_id ON listing_images(image_id);
+
+ALTER TABLE images
+ADD COLUMN width INT NOT NULL DEFAULT 0;
+
+ALTER TABLE images
+ADD COLUMN height INT NOT NULL DEFAULT 0;
+
+-- Переносим порядок изображений из массива URL, первое изображение становится обложкой
+WITH ordered AS (
+    SELECT l.id AS listing_id, il.image_id, MIN(u.ord) AS ord
+    FROM listings l
+    CROSS JOIN LATERAL unnest(l.images) WITH ORDINALITY AS u(url, ord)
+    JOIN image_links il
+        ON il.image_name = substring(u.url from '[^/]+$')
+        AND il.listing_id = l.id
+        AND il.status = 'attached'
+    GROUP BY l.id, il.image_id
+), numbered AS (
+    SELECT
+        listing_id,
+        image_id,
+        (ROW_NUMBER() OVER (PARTITION BY listing_id ORDER BY ord) - 1)::int AS position
+    FROM ordered
+)
+INSERT INTO listing_images (listing_id, image_id, position, is_cover)
+SELECT listing_id, image_id, position, position = 0
+FROM numbered;
+
+ALTER TABLE listings
+DROP COLUMN images;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE listings
+ADD COLUMN images TEXT[];
+
+-- Восстанавливаем массив из имен полноразмерных файлов: адрес сервера в базе не хранится
+UPDATE listings l
+SET images = ARRAY(
+    SELECT il.image_name
+    FROM listing_images li
+    JOIN image_links il ON il.image_id = li.image_id
+    WHERE li.listing_id = l.id AND il.image_name LIKE '%-400px.webp'
+    ORDER BY li.position
+);
+
+ALTER TABLE images DROP COLUMN height;
+ALTER TABLE images DROP COLUMN width;
+
+DROP TABLE IF EXISTS listing_images;
+
+-- +goose StatementEnd
@@ -0,0 +1,95 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Буст действует в окне [starts_at, ends_at); статус поддерживается фоновой задачей.
+-- ends_at = NULL у бустов, купленных до появления окон: они действуют без срока
+ALTER TABLE listing_boosts
+ADD COLUMN id UUID NOT NULL DEFAULT gen_random_uuid();
+
+ALTER TABLE listing_boosts
+ADD COLUMN starts_at TIMESTAMP NOT NULL DEFAULT NOW();
+
+ALTER TABLE listing_boosts
+ADD COLUMN ends_at TIMESTAMP;
+
+-- Статус буста: scheduled, active, expired, cancelled
+ALTER TABLE listing_boosts
+ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'active';
+
+ALTER TABLE listing_boosts
+ADD COLUMN created_at TIMESTAMP NOT NULL DEFAULT NOW();
+
+-- Существующие бусты начинаются с момента миграции, новые всегда задают окно явно
+ALTER TABLE listing_boosts ALTER COLUMN starts_at DROP DEFAULT;
+ALTER TABLE listing_boosts ALTER COLUMN status DROP DEFAULT;
+
+ALTER TABLE listing_boosts
+ADD CONSTRAINT pk_listing_boosts PRIMARY KEY (id);
+
+ALTER TABLE listing_boosts
+ADD CONSTRAINT chk_listing_boosts_window CHECK (ends_at IS NULL OR ends_at > starts_at);
+
+CREATE INDEX IF NOT EXISTS idx_listing_boosts_status_starts_at ON listing_boosts(status, starts_at);
+CREATE INDEX IF NOT EXISTS idx_listing_boosts_status_ends_at ON listing_boosts(status, ends_at);
+
+-- Действующий или запланированный буст каждого типа у объявления один: параллельные
+-- покупки одного буста не проходят и не списывают комиссию дважды
+CREATE UNIQUE INDEX IF NOT EXISTS idx_listing_boosts_live_type ON listing_boosts(listing_id, boost_type)
+    WHERE status IN ('active', 'scheduled');
+
+-- Покупка буста: одна запись на каждую активацию с зафиксированной комиссией
+CREATE TABLE IF NOT EXISTS boost_purchases (
+    id UUID PRIMARY KEY,
+    boost_id UUID NOT NULL REFERENCES listing_boosts(id) ON DELETE CASCADE,
+    listing_id UUID NOT NULL REFERENCES listings(id) ON DELETE CASCADE,
+    boost_type VARCHAR(255) NOT NULL,
+    commission_percent FLOAT NOT NULL,
+    listing_price DECIMAL(10, 2) NOT NULL,
+    commission_amount DECIMAL(10, 2) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_boost_purchases_listing_id ON boost_purchases(listing_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_boost_purchases_boost_id ON boost_purchases(boost_id);
+
+-- Журнал комиссий, только добавление записей:
+-- accrual — комиссия начислена при покупке, charge — списана при активации буста,
+-- reversal — начисление отменено вместе с запланированным бустом
+CREATE TABLE IF NOT EXISTS boost_ledger (
+    id UUID PRIMARY KEY,
+    listing_id UUID NOT NULL REFERENCES listings(id) ON DELETE CASCADE,
+    purchase_id UUID NOT NULL REFERENCES boost_purchases(id) ON DELETE CASCADE,
+    entry_type VARCHAR(16) NOT NULL,
+    amount DECIMAL(10, 2) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_boost_ledger_listing_id ON boost_ledger(listing_id, created_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_boost_ledger_purchase_entry ON boost_ledger(purchase_id, entry_type);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS boost_ledger;
+DROP TABLE IF EXISTS boost_purchases;
+
+DROP INDEX IF EXISTS idx_listing_boosts_live_type;
+DROP INDEX IF EXISTS idx_listing_boosts_status_ends_at;
+DROP INDEX IF EXISTS idx_listing_boosts_status_starts_at;
+
+-- Прежняя схема хранила только текущие бусты
+DELETE FROM listing_boosts WHERE status <> 'active';
+
+ALTER TABLE listing_boosts DROP CONSTRAINT chk_listing_boosts_window;
+ALTER TABLE listing_boosts DROP CONSTRAINT pk_listing_boosts;
+ALTER TABLE listing_boosts DROP COLUMN created_at;
+ALTER TABLE listing_boosts DROP COLUMN status;
+ALTER TABLE listing_boosts DROP COLUMN ends_at;
+ALTER TABLE listing_boosts DROP COLUMN starts_at;
+ALTER TABLE listing_boosts DROP COLUMN id;
+
+-- +goose StatementEnd
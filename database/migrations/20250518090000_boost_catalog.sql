@@ -0,0 +1,58 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Каталог типов бустов; position задает порядок вывода
+CREATE TABLE IF NOT EXISTS boost_types (
+    boost_type VARCHAR(32) PRIMARY KEY,
+    position INT NOT NULL DEFAULT 0,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Тарифы комиссий: тариф без категории действует по умолчанию,
+-- тариф с категорией переопределяет его для объявлений этой категории
+CREATE TABLE IF NOT EXISTS boost_tariffs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    boost_type VARCHAR(32) NOT NULL REFERENCES boost_types(boost_type) ON DELETE CASCADE,
+    category_id VARCHAR(255),
+    commission_percent FLOAT NOT NULL,
+    -- Минимальная комиссия по валютам: {"RUB": 50, "USD": 1}
+    min_amounts JSONB NOT NULL DEFAULT '{}',
+    valid_from TIMESTAMP,
+    valid_to TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT chk_boost_tariffs_commission CHECK (commission_percent >= 0 AND commission_percent < 1),
+    CONSTRAINT chk_boost_tariffs_validity CHECK (valid_from IS NULL OR valid_to IS NULL OR valid_to > valid_from)
+);
+
+CREATE INDEX IF NOT EXISTS idx_boost_tariffs_type_category ON boost_tariffs(boost_type, category_id);
+
+-- Переносим значения, которые раньше были зашиты в коде
+INSERT INTO boost_types (boost_type, position) VALUES
+    ('base', 1),
+    ('highlight', 2),
+    ('upfront', 3)
+ON CONFLICT (boost_type) DO NOTHING;
+
+INSERT INTO boost_tariffs (boost_type, commission_percent) VALUES
+    ('base', 0.02),
+    ('highlight', 0.07),
+    ('upfront', 0.12);
+
+-- Покупка фиксирует тариф, по которому посчитана комиссия
+ALTER TABLE boost_purchases
+ADD COLUMN tariff_id UUID REFERENCES boost_tariffs(id) ON DELETE SET NULL;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE boost_purchases DROP COLUMN tariff_id;
+
+DROP TABLE IF EXISTS boost_tariffs;
+DROP TABLE IF EXISTS boost_types;
+
+-- +goose StatementEnd
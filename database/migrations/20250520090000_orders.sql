@@ -0,0 +1,39 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Заказы на покупку объявлений с бустом upfront
+CREATE TABLE IF NOT EXISTS orders (
+    id UUID PRIMARY KEY,
+    listing_id UUID NOT NULL REFERENCES listings(id) ON DELETE CASCADE,
+    -- Покупатель из заголовка X-User-ID; пустая строка, если неизвестен
+    buyer TEXT NOT NULL DEFAULT '',
+    -- Состояние заказа: pending, paid, shipped, completed, cancelled
+    status VARCHAR(16) NOT NULL,
+    amount DECIMAL(10, 2) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    commission_percent FLOAT NOT NULL DEFAULT 0,
+    commission_amount DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    payment_id VARCHAR(255) NOT NULL DEFAULT '',
+    cancel_reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    paid_at TIMESTAMP,
+    shipped_at TIMESTAMP,
+    completed_at TIMESTAMP,
+    cancelled_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_orders_listing_id ON orders(listing_id);
+
+-- Товар объявления может удерживать только один незавершенный заказ
+CREATE UNIQUE INDEX IF NOT EXISTS idx_orders_listing_open
+ON orders(listing_id) WHERE status IN ('pending', 'paid', 'shipped');
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS orders;
+
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Структурированный адрес локации, полученный от геокодера
+ALTER TABLE locations ADD COLUMN IF NOT EXISTS address JSONB;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE locations DROP COLUMN IF EXISTS address;
+
+-- +goose StatementEnd
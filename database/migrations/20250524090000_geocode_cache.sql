@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Кэш ответов геокодера. Ключ включает округленные координаты, zoom и язык
+-- для обратного геокодирования или нормализованный текст запроса для прямого
+CREATE TABLE IF NOT EXISTS geocode_cache (
+    cache_key TEXT PRIMARY KEY,
+    -- Вид запроса: reverse или search
+    kind VARCHAR(16) NOT NULL,
+    lang VARCHAR(8) NOT NULL,
+    -- Округленные координаты и zoom заполняются только для reverse
+    latitude DOUBLE PRECISION,
+    longitude DOUBLE PRECISION,
+    zoom INTEGER,
+    response JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_geocode_cache_reverse
+ON geocode_cache(lang, latitude, longitude) WHERE kind = 'reverse';
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS geocode_cache;
+
+-- +goose StatementEnd
@@ -0,0 +1,59 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Точка локации хранится как geography, чтобы поиск по радиусу и сортировка
+-- по расстоянию использовали пространственный индекс
+ALTER TABLE locations ADD COLUMN IF NOT EXISTS geog geography(Point, 4326);
+
+UPDATE locations SET geog = ST_SetSRID(ST_MakePoint(longitude, latitude), 4326)::geography;
+
+ALTER TABLE locations ALTER COLUMN geog SET NOT NULL;
+
+-- Зона обслуживания объявления: круг радиуса radius вокруг точки. Продавец включает ее
+-- явно флагом is_service_area, у остальных объявлений зоны нет.
+-- Поддерживается триггером, чтобы не расходиться с geog и radius
+ALTER TABLE locations ADD COLUMN IF NOT EXISTS is_service_area BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE locations ADD COLUMN IF NOT EXISTS service_area geography;
+
+CREATE OR REPLACE FUNCTION locations_set_service_area() RETURNS trigger AS $$
+BEGIN
+    NEW.service_area := CASE WHEN NEW.is_service_area THEN ST_Buffer(NEW.geog, NEW.radius) END;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_locations_service_area
+BEFORE INSERT OR UPDATE OF geog, radius, is_service_area ON locations
+FOR EACH ROW EXECUTE FUNCTION locations_set_service_area();
+
+CREATE INDEX IF NOT EXISTS idx_locations_geog ON locations USING GIST (geog);
+CREATE INDEX IF NOT EXISTS idx_locations_service_area ON locations USING GIST (service_area);
+
+DROP INDEX IF EXISTS idx_locations_coords;
+ALTER TABLE locations DROP COLUMN IF EXISTS latitude;
+ALTER TABLE locations DROP COLUMN IF EXISTS longitude;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE locations ADD COLUMN IF NOT EXISTS latitude DOUBLE PRECISION;
+ALTER TABLE locations ADD COLUMN IF NOT EXISTS longitude DOUBLE PRECISION;
+
+UPDATE locations SET latitude = ST_Y(geog::geometry), longitude = ST_X(geog::geometry);
+
+ALTER TABLE locations ALTER COLUMN latitude SET NOT NULL;
+ALTER TABLE locations ALTER COLUMN longitude SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_locations_coords ON locations(latitude, longitude);
+
+DROP TRIGGER IF EXISTS trg_locations_service_area ON locations;
+DROP FUNCTION IF EXISTS locations_set_service_area();
+DROP INDEX IF EXISTS idx_locations_service_area;
+DROP INDEX IF EXISTS idx_locations_geog;
+ALTER TABLE locations DROP COLUMN IF EXISTS service_area;
+ALTER TABLE locations DROP COLUMN IF EXISTS is_service_area;
+ALTER TABLE locations DROP COLUMN IF EXISTS geog;
+
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Поиск по области карты сравнивает точки с прямоугольником в градусах,
+-- поэтому нужен индекс по геометрии, а не по географии
+CREATE INDEX IF NOT EXISTS idx_locations_geom ON locations USING GIST ((geog::geometry));
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_locations_geom;
+
+-- +goose StatementEnd
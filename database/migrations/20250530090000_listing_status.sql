@@ -0,0 +1,64 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Состояние объявления: draft, pending_review, published, paused, sold или expired.
+-- Объявления, созданные до появления состояний, считаются опубликованными
+ALTER TABLE listings
+    ADD COLUMN IF NOT EXISTS status VARCHAR(32) NOT NULL DEFAULT 'published',
+    ADD COLUMN IF NOT EXISTS status_changed_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    ADD COLUMN IF NOT EXISTS published_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS moderation_reason TEXT NOT NULL DEFAULT '';
+
+-- Существующие объявления получают срок публикации по умолчанию — 30 дней
+UPDATE listings
+SET published_at = created_at,
+    status_changed_at = created_at,
+    expires_at = NOW() + INTERVAL '30 days'
+WHERE published_at IS NULL;
+
+ALTER TABLE listings ADD CONSTRAINT listings_status_check
+    CHECK (status IN ('draft', 'pending_review', 'published', 'paused', 'sold', 'expired'));
+
+CREATE INDEX IF NOT EXISTS idx_listings_status ON listings(status) WHERE deleted_at IS NULL;
+
+-- Очередь модерации и снятие с публикации по сроку
+CREATE INDEX IF NOT EXISTS idx_listings_pending_review
+ON listings(status_changed_at, id) WHERE status = 'pending_review' AND deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_listings_expires_at
+ON listings(expires_at) WHERE status = 'published' AND deleted_at IS NULL;
+
+-- Решения модераторов по объявлениям
+CREATE TABLE IF NOT EXISTS listing_moderations (
+    id UUID PRIMARY KEY,
+    listing_id UUID NOT NULL REFERENCES listings(id) ON DELETE CASCADE,
+    -- approved или rejected
+    decision VARCHAR(16) NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_listing_moderations_listing ON listing_moderations(listing_id, created_at);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS listing_moderations;
+
+DROP INDEX IF EXISTS idx_listings_expires_at;
+DROP INDEX IF EXISTS idx_listings_pending_review;
+DROP INDEX IF EXISTS idx_listings_status;
+
+ALTER TABLE listings DROP CONSTRAINT IF EXISTS listings_status_check;
+
+ALTER TABLE listings
+    DROP COLUMN IF EXISTS moderation_reason,
+    DROP COLUMN IF EXISTS expires_at,
+    DROP COLUMN IF EXISTS published_at,
+    DROP COLUMN IF EXISTS status_changed_at,
+    DROP COLUMN IF EXISTS status;
+
+-- +goose StatementEnd
@@ -0,0 +1,43 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- История изменений объявлений. Записи только добавляются: восстановление
+-- старой ревизии создает новую ревизию с restored_from
+CREATE TABLE IF NOT EXISTS listing_revisions (
+    id UUID PRIMARY KEY,
+    listing_id UUID NOT NULL REFERENCES listings(id) ON DELETE CASCADE,
+    revision INTEGER NOT NULL,
+    actor TEXT NOT NULL DEFAULT '',
+    -- Состояние объявления после изменения и измененные поля
+    snapshot JSONB NOT NULL,
+    changes JSONB NOT NULL DEFAULT '{}',
+    -- Цена вынесена из snapshot для истории цен
+    price DOUBLE PRECISION NOT NULL,
+    currency VARCHAR(8) NOT NULL,
+    restored_from INTEGER,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (listing_id, revision)
+);
+
+CREATE INDEX IF NOT EXISTS idx_listing_revisions_price ON listing_revisions(listing_id, created_at);
+
+-- Ревизии не изменяются; удаляются они только вместе с объявлением
+CREATE OR REPLACE FUNCTION listing_revisions_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'listing_revisions is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_listing_revisions_append_only
+BEFORE UPDATE ON listing_revisions
+FOR EACH ROW EXECUTE FUNCTION listing_revisions_append_only();
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS listing_revisions;
+DROP FUNCTION IF EXISTS listing_revisions_append_only();
+
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Версия объявления для оптимистичной блокировки: каждое изменение содержимого
+-- или состояния увеличивает ее на единицу
+ALTER TABLE listings ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE listings DROP COLUMN IF EXISTS version;
+
+-- +goose StatementEnd
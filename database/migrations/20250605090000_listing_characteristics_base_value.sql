@@ -0,0 +1,53 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Величины хранятся рядом с единицей пользователя еще и в базовой единице (base_value),
+-- по ней работают фильтры. Множители повторяют таблицу unitScales в internal/models/unit.go
+WITH unit_scales(unit, factor) AS (
+    VALUES
+        ('cm', 0.01), ('m', 1), ('km', 1000),
+        ('cm2', 0.0001), ('m2', 1), ('km2', 1000000),
+        ('ml', 0.001), ('l', 1), ('cm3', 0.001), ('m3', 1000), ('km3', 1000000000000),
+        ('g', 0.001), ('kg', 1), ('t', 1000),
+        ('ma', 0.001), ('a', 1), ('w', 1), ('kw', 1000), ('om', 1)
+)
+UPDATE listing_characteristics lch
+SET characteristics = (
+    SELECT jsonb_object_agg(
+        ch.key,
+        CASE
+            WHEN us.factor IS NOT NULL AND jsonb_typeof(ch.value->'value') = 'number'
+                THEN ch.value || jsonb_build_object('base_value', (ch.value->>'value')::float * us.factor)
+            ELSE ch.value
+        END
+    )
+    FROM jsonb_each(lch.characteristics) ch
+    LEFT JOIN unit_scales us
+        ON jsonb_typeof(ch.value) = 'object' AND us.unit = ch.value->>'dimension'
+)
+WHERE EXISTS (
+    SELECT 1
+    FROM jsonb_each(lch.characteristics) ch
+    WHERE jsonb_typeof(ch.value) = 'object' AND ch.value ? 'dimension'
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+UPDATE listing_characteristics lch
+SET characteristics = (
+    SELECT jsonb_object_agg(
+        ch.key,
+        CASE WHEN jsonb_typeof(ch.value) = 'object' THEN ch.value - 'base_value' ELSE ch.value END
+    )
+    FROM jsonb_each(lch.characteristics) ch
+)
+WHERE EXISTS (
+    SELECT 1
+    FROM jsonb_each(lch.characteristics) ch
+    WHERE jsonb_typeof(ch.value) = 'object' AND ch.value ? 'base_value'
+);
+
+-- +goose StatementEnd
@@ -0,0 +1,84 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Таксономия категорий. Файлы директории categories остаются начальными данными:
+-- при пустой таблице categories сервис импортирует их при запуске
+
+-- Реестр характеристик; position задает порядок в characteristics.json
+CREATE TABLE IF NOT EXISTS characteristic_definitions (
+    role VARCHAR(64) PRIMARY KEY,
+    kind VARCHAR(32) NOT NULL,
+    options TEXT[] NOT NULL DEFAULT '{}',
+    units TEXT[] NOT NULL DEFAULT '{}',
+    default_unit VARCHAR(32) NOT NULL DEFAULT '',
+    min_value BIGINT,
+    max_value BIGINT,
+    max_length INT NOT NULL DEFAULT 0,
+    filter_mode VARCHAR(32) NOT NULL DEFAULT '',
+    position INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Дерево категорий; position задает порядок среди соседей
+CREATE TABLE IF NOT EXISTS categories (
+    id VARCHAR(255) PRIMARY KEY,
+    parent_id VARCHAR(255) REFERENCES categories(id),
+    position INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT chk_categories_parent CHECK (parent_id IS NULL OR parent_id <> id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_categories_parent ON categories(parent_id, position);
+
+-- Характеристики уровня категории с переопределениями для нее и потомков
+CREATE TABLE IF NOT EXISTS category_characteristics (
+    category_id VARCHAR(255) NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+    role VARCHAR(64) NOT NULL REFERENCES characteristic_definitions(role),
+    position INT NOT NULL DEFAULT 0,
+    options TEXT[] NOT NULL DEFAULT '{}',
+    required BOOLEAN,
+    sort_order INT,
+    min_value DOUBLE PRECISION,
+    max_value DOUBLE PRECISION,
+    unit VARCHAR(32) NOT NULL DEFAULT '',
+    PRIMARY KEY (category_id, role)
+);
+
+CREATE TABLE IF NOT EXISTS category_translations (
+    category_id VARCHAR(255) NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+    lang VARCHAR(8) NOT NULL,
+    name TEXT NOT NULL,
+    PRIMARY KEY (category_id, lang)
+);
+
+-- Переводы характеристик без внешнего ключа: переводится и встроенная роль price
+CREATE TABLE IF NOT EXISTS characteristic_translations (
+    role VARCHAR(64) NOT NULL,
+    lang VARCHAR(8) NOT NULL,
+    name TEXT NOT NULL,
+    PRIMARY KEY (role, lang)
+);
+
+CREATE TABLE IF NOT EXISTS characteristic_option_translations (
+    role VARCHAR(64) NOT NULL REFERENCES characteristic_definitions(role) ON DELETE CASCADE,
+    option VARCHAR(255) NOT NULL,
+    lang VARCHAR(8) NOT NULL,
+    label TEXT NOT NULL,
+    PRIMARY KEY (role, option, lang)
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS characteristic_option_translations;
+DROP TABLE IF EXISTS characteristic_translations;
+DROP TABLE IF EXISTS category_translations;
+DROP TABLE IF EXISTS category_characteristics;
+DROP TABLE IF EXISTS categories;
+DROP TABLE IF EXISTS characteristic_definitions;
+
+-- +goose StatementEnd
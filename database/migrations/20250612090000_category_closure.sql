@@ -0,0 +1,38 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Замыкание дерева категорий: каждая категория связана с собой и всеми потомками.
+-- Поиск по категории находит объявления подкатегорий одним соединением без рекурсии.
+-- Таблица перестраивается вместе с каждым изменением таксономии
+CREATE TABLE IF NOT EXISTS category_closure (
+    ancestor_id VARCHAR(255) NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+    descendant_id VARCHAR(255) NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+    depth INT NOT NULL,
+    PRIMARY KEY (ancestor_id, descendant_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_category_closure_descendant ON category_closure(descendant_id);
+
+-- Выборка объявлений по набору категорий для подсчета и поиска по ветке
+CREATE INDEX IF NOT EXISTS idx_listing_categories_category ON listing_categories(category_id, listing_id);
+
+INSERT INTO category_closure (ancestor_id, descendant_id, depth)
+WITH RECURSIVE tree AS (
+    SELECT id AS ancestor_id, id AS descendant_id, 0 AS depth FROM categories
+    UNION ALL
+    SELECT tree.ancestor_id, c.id, tree.depth + 1
+    FROM tree
+    JOIN categories c ON c.parent_id = tree.descendant_id
+)
+SELECT ancestor_id, descendant_id, depth FROM tree
+ON CONFLICT DO NOTHING;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_listing_categories_category;
+DROP TABLE IF EXISTS category_closure;
+
+-- +goose StatementEnd
@@ -0,0 +1,61 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Язык, на котором автор написал заголовок и описание объявления
+ALTER TABLE listings ADD COLUMN IF NOT EXISTS original_lang VARCHAR(8) NOT NULL DEFAULT 'es';
+
+-- Кэш переводов заголовка и описания на языки интерфейса. source_hash — md5 исходного
+-- текста: перевод устаревшего текста не отдается и заменяется фоновым переводом
+CREATE TABLE IF NOT EXISTS listing_translations (
+    listing_id UUID NOT NULL REFERENCES listings(id) ON DELETE CASCADE,
+    lang VARCHAR(8) NOT NULL,
+    title TEXT NOT NULL,
+    description TEXT NOT NULL,
+    source_hash CHAR(32) NOT NULL,
+    provider VARCHAR(32) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (listing_id, lang)
+);
+
+-- Состояние фонового перевода: translated сбрасывается при изменении текста, а попытки
+-- перевода учитываются, чтобы объявления с ошибками провайдера не занимали каждую проверку
+ALTER TABLE listings ADD COLUMN IF NOT EXISTS translated BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE listings ADD COLUMN IF NOT EXISTS translation_attempts INT NOT NULL DEFAULT 0;
+ALTER TABLE listings ADD COLUMN IF NOT EXISTS translation_attempted_at TIMESTAMP;
+
+CREATE OR REPLACE FUNCTION listings_reset_translation() RETURNS trigger AS $$
+BEGIN
+    IF NEW.title IS DISTINCT FROM OLD.title
+        OR NEW.original_description IS DISTINCT FROM OLD.original_description
+        OR NEW.original_lang IS DISTINCT FROM OLD.original_lang THEN
+        NEW.translated := FALSE;
+        NEW.translation_attempts := 0;
+        NEW.translation_attempted_at := NULL;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_listings_reset_translation
+BEFORE UPDATE OF title, original_description, original_lang ON listings
+FOR EACH ROW EXECUTE FUNCTION listings_reset_translation();
+
+CREATE INDEX IF NOT EXISTS idx_listings_untranslated ON listings (translation_attempted_at NULLS FIRST)
+    WHERE NOT translated AND deleted_at IS NULL;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_listings_untranslated;
+DROP TRIGGER IF EXISTS trg_listings_reset_translation ON listings;
+DROP FUNCTION IF EXISTS listings_reset_translation();
+ALTER TABLE listings DROP COLUMN IF EXISTS translation_attempted_at;
+ALTER TABLE listings DROP COLUMN IF EXISTS translation_attempts;
+ALTER TABLE listings DROP COLUMN IF EXISTS translated;
+DROP TABLE IF EXISTS listing_translations;
+ALTER TABLE listings DROP COLUMN IF EXISTS original_lang;
+
+-- +goose StatementEnd
@@ -0,0 +1,178 @@
+// Package imagehash вычисляет перцептивные хеши изображений (pHash, dHash),
+// устойчивые к масштабированию, пересжатию и небольшим изменениям цвета
+package imagehash
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"math/bits"
+	"sort"
+	"strconv"
+)
+
+const (
+	// phashSize размер уменьшенного изображения для DCT
+	phashSize = 32
+	// hashSide размер стороны блока низких частот (8x8 = 64 бита)
+	hashSide = 8
+)
+
+// DHash вычисляет разностный хеш: изображение уменьшается до 9x8 в оттенках серого,
+// каждый бит показывает, светлее ли пиксель своего правого соседа
+func DHash(img image.Image) uint64 {
+	gray := grayscale(img, hashSide+1, hashSide)
+
+	var hash uint64
+	for y := 0; y < hashSide; y++ {
+		for x := 0; x < hashSide; x++ {
+			hash <<= 1
+			if gray[y][x] > gray[y][x+1] {
+				hash |= 1
+			}
+		}
+	}
+
+	return hash
+}
+
+// PHash вычисляет перцептивный хеш: изображение уменьшается до 32x32 в оттенках серого,
+// к нему применяется DCT, и биты блока низких частот 8x8 сравниваются с медианой
+func PHash(img image.Image) uint64 {
+	gray := grayscale(img, phashSize, phashSize)
+	freq := dct2D(gray)
+
+	coefficients := make([]float64, 0, hashSide*hashSide)
+	for y := 0; y < hashSide; y++ {
+		for x := 0; x < hashSide; x++ {
+			coefficients = append(coefficients, freq[y][x])
+		}
+	}
+
+	// Постоянную составляющую не учитываем в медиане: она отражает только общую яркость
+	median := medianOf(coefficients[1:])
+
+	var hash uint64
+	for _, c := range coefficients {
+		hash <<= 1
+		if c > median {
+			hash |= 1
+		}
+	}
+
+	return hash
+}
+
+// Distance возвращает расстояние Хэмминга между двумя хешами
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// Format представляет хеш в виде шестнадцатеричной строки фиксированной длины
+func Format(hash uint64) string {
+	return fmt.Sprintf("%016x", hash)
+}
+
+// Parse разбирает хеш из шестнадцатеричной строки
+func Parse(s string) (uint64, error) {
+	return strconv.ParseUint(s, 16, 64)
+}
+
+// grayscale уменьшает изображение до width x height, усредняя яркость пикселей каждой ячейки
+func grayscale(img image.Image, width, height int) [][]float64 {
+	bounds := img.Bounds()
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+
+	out := make([][]float64, height)
+	for y := 0; y < height; y++ {
+		out[y] = make([]float64, width)
+
+		y0 := bounds.Min.Y + y*srcH/height
+		y1 := bounds.Min.Y + (y+1)*srcH/height
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + x*srcW/width
+			x1 := bounds.Min.X + (x+1)*srcW/width
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+
+			var sum float64
+			var count int
+			for py := y0; py < y1 && py < bounds.Max.Y; py++ {
+				for px := x0; px < x1 && px < bounds.Max.X; px++ {
+					sum += luminance(img, px, py)
+					count++
+				}
+			}
+			if count > 0 {
+				out[y][x] = sum / float64(count)
+			}
+		}
+	}
+
+	return out
+}
+
+// luminance возвращает яркость пикселя по формуле ITU-R BT.601
+func luminance(img image.Image, x, y int) float64 {
+	r, g, b, _ := img.At(x, y).RGBA()
+	return 0.299*float64(r>>8) + 0.587*float64(g>>8) + 0.114*float64(b>>8)
+}
+
+// dct2D применяет двумерное дискретное косинусное преобразование (DCT-II)
+func dct2D(in [][]float64) [][]float64 {
+	n := len(in)
+
+	cos := make([][]float64, n)
+	for k := 0; k < n; k++ {
+		cos[k] = make([]float64, n)
+		for i := 0; i < n; i++ {
+			cos[k][i] = math.Cos(math.Pi * float64(k) * (2*float64(i) + 1) / float64(2*n))
+		}
+	}
+
+	// Сначала преобразуем строки, затем столбцы
+	rows := make([][]float64, n)
+	for y := 0; y < n; y++ {
+		rows[y] = make([]float64, n)
+		for k := 0; k < n; k++ {
+			var sum float64
+			for x := 0; x < n; x++ {
+				sum += in[y][x] * cos[k][x]
+			}
+			rows[y][k] = sum
+		}
+	}
+
+	out := make([][]float64, n)
+	for k := 0; k < n; k++ {
+		out[k] = make([]float64, n)
+	}
+	for x := 0; x < n; x++ {
+		for k := 0; k < n; k++ {
+			var sum float64
+			for y := 0; y < n; y++ {
+				sum += rows[y][x] * cos[k][y]
+			}
+			out[k][x] = sum
+		}
+	}
+
+	return out
+}
+
+func medianOf(values []float64) float64 {
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
@@ -0,0 +1,67 @@
+package imagehash
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gradient создает тестовое изображение с диагональным градиентом и темным квадратом
+func gradient(width, height int, shift uint8) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			v := uint8((x*230/width + y*230/height) / 2)
+			if x > width/4 && x < width/2 && y > height/4 && y < height/2 {
+				v = 20
+			}
+			img.Set(x, y, color.RGBA{R: v + shift, G: v + shift, B: v + shift, A: 255})
+		}
+	}
+	return img
+}
+
+func TestHashes(t *testing.T) {
+	original := gradient(400, 300, 0)
+
+	t.Run("Масштабирование почти не меняет хеш", func(t *testing.T) {
+		scaled := gradient(200, 150, 0)
+
+		assert.LessOrEqual(t, Distance(PHash(original), PHash(scaled)), 4)
+		assert.LessOrEqual(t, Distance(DHash(original), DHash(scaled)), 4)
+	})
+
+	t.Run("Изменение яркости почти не меняет хеш", func(t *testing.T) {
+		brighter := gradient(400, 300, 10)
+
+		assert.LessOrEqual(t, Distance(PHash(original), PHash(brighter)), 4)
+		assert.LessOrEqual(t, Distance(DHash(original), DHash(brighter)), 4)
+	})
+
+	t.Run("Разные изображения имеют далекие хеши", func(t *testing.T) {
+		flipped := image.NewRGBA(original.Bounds())
+		b := original.Bounds()
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				flipped.Set(b.Max.X-1-x, b.Max.Y-1-y, original.At(x, y))
+			}
+		}
+
+		assert.Greater(t, Distance(PHash(original), PHash(flipped)), 10)
+		assert.Greater(t, Distance(DHash(original), DHash(flipped)), 10)
+	})
+}
+
+func TestFormatParse(t *testing.T) {
+	hash := uint64(0x00ff00ff00ff00ff)
+
+	s := Format(hash)
+	assert.Equal(t, "00ff00ff00ff00ff", s)
+
+	parsed, err := Parse(s)
+	require.NoError(t, err)
+	assert.Equal(t, hash, parsed)
+}
@@ -53,3 +53,9 @@ func QueryParser(c *fiber.Ctx, out interface{}) error {
 func GetLang(ctx context.Context) models.Localization {
 	return ctx.Value(models.KeyLanguage).(models.Localization)
 }
+
+// GetActor возвращает идентификатор пользователя запроса; пустая строка, если он неизвестен
+func GetActor(ctx context.Context) models.Actor {
+	actor, _ := ctx.Value(models.KeyActor).(models.Actor)
+	return actor
+}
@@ -2,75 +2,126 @@ package validator
 
 import (
 	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 
-	"github.com/go-playground/validator/v10"
-	"github.com/yaroslavvasilenko/argon/config"
 	"github.com/yaroslavvasilenko/argon/internal/models"
 )
 
-// ValidateCharacteristics проверяет, что характеристики соответствуют категориям
-func ValidateCharacteristicsValue(fl validator.FieldLevel) bool {
-	// Получаем значение поля характеристик
-	characteristicsValue := fl.Field()
-	if characteristicsValue.Kind() != reflect.Map {
-		return false
+// CheckCharacteristics проверяет характеристики по действующей схеме категорий и возвращает
+// ошибки по полям с сообщениями на языке lang. Ошибки идут в порядке схемы, следом
+// характеристики, которых нет в схеме
+func CheckCharacteristics(schema models.CategorySchema, values models.CharacteristicValue, lang models.Localization) []models.FieldError {
+	var errs []models.FieldError
+
+	for _, rule := range schema.Characteristics {
+		role := rule.Definition.Role
+		value, ok := values[role]
+		switch {
+		case (!ok || value == nil) && rule.Required:
+			errs = append(errs, models.NewFieldError(lang, characteristicField(role), models.FieldErrorRequired, ""))
+		case !ok:
+		case value == nil:
+			errs = append(errs, models.NewFieldError(lang, characteristicField(role), models.FieldErrorInvalid, ""))
+		default:
+			if code, arg := characteristicError(rule, value); code != "" {
+				errs = append(errs, models.NewFieldError(lang, characteristicField(role), code, arg))
+			}
+		}
 	}
 
-	// Получаем значение поля категорий из родительской структуры
-	parentValue := fl.Parent()
-	categoriesField := parentValue.FieldByName("Categories")
-	if !categoriesField.IsValid() || categoriesField.Kind() != reflect.Slice {
-		return false
+	unknown := make([]string, 0)
+	for role := range values {
+		if _, ok := schema.Get(role); !ok {
+			unknown = append(unknown, role)
+		}
 	}
-
-	categories := make([]string, categoriesField.Len())
-	for i := 0; i < categoriesField.Len(); i++ {
-		categories[i] = categoriesField.Index(i).String()
+	sort.Strings(unknown)
+	for _, role := range unknown {
+		errs = append(errs, models.NewFieldError(lang, characteristicField(role), models.FieldErrorNotAllowed, ""))
 	}
 
-	// Получаем все допустимые характеристики для выбранных категорий
-	allowedCharacteristics := getAllCharacteristicsForCategories(categories)
-	allowedCharacteristicsMap := make(map[string]bool)
-	for _, characteristic := range allowedCharacteristics {
-		allowedCharacteristicsMap[characteristic] = true
-	}
+	return errs
+}
 
-	// Проверяем, что все характеристики допустимы для выбранных категорий
-	for _, key := range characteristicsValue.MapKeys() {
-		characteristicName := key.String()
-		if !allowedCharacteristicsMap[characteristicName] {
-			return false
-		}
+func characteristicField(role string) string {
+	return "characteristics." + role
+}
 
-		// Получаем значение характеристики
-		characteristicValue := characteristicsValue.MapIndex(key)
-		if characteristicValue.IsNil() {
-			return false
-		}
+// characteristicError возвращает код ошибки значения и аргумент сообщения; пустой код — значение корректно
+func characteristicError(rule models.CharacteristicRule, value interface{}) (string, string) {
+	def := rule.Definition
 
-		if !validateCharacteristicValue(characteristicName, characteristicValue) {
-			return false
+	// Опции и единицы проверяются до формата, чтобы клиент получил точную причину
+	switch v := value.(type) {
+	case models.Color:
+		if v.Color != "" && !def.HasOption(v.Color) {
+			return models.FieldErrorOption, v.Color
+		}
+	case string:
+		if def.Kind == models.KindColor && v != "" && !def.HasOption(v) {
+			return models.FieldErrorOption, v
+		}
+	case models.DropdownOption:
+		// Значение выпадающего списка свободное, пока правило категории не ограничило его опциями
+		if v.Value != "" && rule.Strict && !def.HasOption(v.Value) {
+			return models.FieldErrorOption, v.Value
+		}
+	case models.MultiSelectValue:
+		for _, option := range v {
+			if !def.HasOption(option) {
+				return models.FieldErrorOption, option
+			}
+		}
+	case models.Amount:
+		if def.Kind == models.KindAmount && !def.AllowsUnit(v.Dimension) {
+			return models.FieldErrorUnit, string(v.Dimension)
+		}
+		if def.Kind == models.KindAmount {
+			amount, ok := models.ConvertUnit(v.Value, v.Dimension, def.DefaultUnit)
+			if ok {
+				if code, arg := boundError(rule, amount); code != "" {
+					return code, arg + " " + string(def.DefaultUnit)
+				}
+			}
+		}
+	case models.IntegerValue:
+		if code, arg := boundError(rule, float64(v)); code != "" {
+			return code, arg
 		}
 	}
 
-	return true
+	if !validateCharacteristicValue(def, reflect.ValueOf(&value).Elem()) {
+		return models.FieldErrorInvalid, ""
+	}
+	return "", ""
 }
 
-// validateCharacteristicValue проверяет, что значение характеристики соответствует ожидаемому типу
-func validateCharacteristicValue(characteristicName string, interfaceValue reflect.Value) bool {
-	paramType, ok := models.CharacteristicValueMap[characteristicName]
-	if !ok {
-		return false
+// boundError сравнивает значение с границами правила
+func boundError(rule models.CharacteristicRule, value float64) (string, string) {
+	if rule.Min != nil && value < *rule.Min {
+		return models.FieldErrorMin, strconv.FormatFloat(*rule.Min, 'f', -1, 64)
+	}
+	if rule.Max != nil && value > *rule.Max {
+		return models.FieldErrorMax, strconv.FormatFloat(*rule.Max, 'f', -1, 64)
 	}
+	return "", ""
+}
+
+// validateCharacteristicValue проверяет, что значение характеристики соответствует ее описанию в схеме категории
+func validateCharacteristicValue(def models.CharacteristicDefinition, interfaceValue reflect.Value) bool {
 	characteristicValue := interfaceValue.Elem()
 
-	switch paramType.(type) {
-	case models.Color:
+	switch def.Kind {
+	case models.KindColor:
 
 		// Проверяем, что значение является Color
 		colorValue, ok := characteristicValue.Interface().(models.Color)
 		if ok {
-			return isValidColor(colorValue.Color)
+			return def.HasOption(colorValue.Color)
 		}
 
 		// Проверяем, что значение является ColorParam
@@ -85,12 +136,12 @@ func validateCharacteristicValue(characteristicName string, interfaceValue refle
 			if color == "" {
 				return false
 			}
-			return isValidColor(color)
+			return def.HasOption(color)
 		}
 
 		return false
 
-	case models.DropdownOption:
+	case models.KindDropdown:
 		// Проверяем, что значение является DropdownOption
 		dropdownOption, ok := characteristicValue.Interface().(models.DropdownOption)
 		if ok {
@@ -117,19 +168,15 @@ func validateCharacteristicValue(characteristicName string, interfaceValue refle
 		}
 
 		return false
-	case models.Amount:
+	case models.KindAmount:
 		amountParam, ok := characteristicValue.Interface().(models.Amount)
 		if !ok {
 			return false
 		}
 
-		if !isValidDimension(characteristicName, []models.Dimension{amountParam.Dimension}) {
-			return false
-		}
-
-		return true
+		return def.AllowsUnit(amountParam.Dimension)
 
-	case models.CheckboxValue:
+	case models.KindCheckbox:
 		// Проверяем, что значение является CheckboxValue
 		_, ok := characteristicValue.Interface().(models.CheckboxValue)
 		if ok {
@@ -143,81 +190,46 @@ func validateCharacteristicValue(characteristicName string, interfaceValue refle
 		}
 
 		return false
-	default:
-		return false
-	}
-}
-
-// isValidColor проверяет, что цвет входит в список допустимых
-func isValidColor(color string) bool {
-	// Получаем список допустимых цветов из конфига
-	cfg := config.GetConfig()
 
-	validColors := cfg.Categories.CategoryOptions[models.CHAR_COLOR]
-
-	// Проверяем наличие цвета в списке
-	for _, validColor := range validColors {
-		if validColor == color {
-			return true
+	case models.KindMultiSelect:
+		// Нужна хотя бы одна опция, все опции из реестра и без повторов
+		values, ok := characteristicValue.Interface().(models.MultiSelectValue)
+		if !ok || len(values) == 0 {
+			return false
 		}
-	}
-
-	return false
-}
-
-// isValidDimension проверяет, что единица измерения допустима для данной характеристики
-func isValidDimension(characteristicName string, dimensions []models.Dimension) bool {
-	// Определяем допустимые единицы измерения в зависимости от типа характеристики
-	var validDimensions []string
-
-	switch characteristicName {
-	case models.CHAR_HEIGHT, models.CHAR_WIDTH, models.CHAR_DEPTH:
-		// Для линейных размеров
-		validDimensions = []string{models.CM, models.M, models.KM}
-
-	case models.CHAR_AREA:
-		// Для площади
-		validDimensions = []string{models.CM2, models.M2, models.KM2}
-
-	case models.CHAR_VOLUME:
-		// Для объема
-		validDimensions = []string{models.CM3, models.M3, models.KM3, models.ML, models.L}
-
-	case models.CHAR_WEIGHT:
-		// Для веса
-		validDimensions = []string{models.G, models.KG, models.T}
-
-	}
-
-	// Проверяем наличие единицы измерения в списке допустимых
-	for _, validDimension := range validDimensions {
-		for _, dimension := range dimensions {
-			if validDimension == string(dimension) {
-				return true
+		seen := make(map[string]bool, len(values))
+		for _, value := range values {
+			if seen[value] || !def.HasOption(value) {
+				return false
 			}
+			seen[value] = true
 		}
-	}
+		return true
 
-	return false
-}
+	case models.KindInteger:
+		value, ok := characteristicValue.Interface().(models.IntegerValue)
+		if !ok {
+			return false
+		}
+		return def.InRange(int64(value))
 
-// Получение всех характеристик для категории, включая характеристики родительских категорий
-func getAllCharacteristicsForCategories(categories []string) []string {
-	allCharacteristics := make(map[string]bool)
+	case models.KindText:
+		value, ok := characteristicValue.Interface().(models.TextValue)
+		if !ok {
+			return false
+		}
+		text := strings.TrimSpace(string(value))
+		return text != "" && utf8.RuneCountInString(text) <= def.MaxLength
 
-	for _, category := range categories {
-		if characteristics, ok := config.GetConfig().Categories.CategoryCharacteristics[category]; ok {
-			for _, characteristic := range characteristics {
-				allCharacteristics[characteristic] = true
-			}
+	case models.KindDate:
+		value, ok := characteristicValue.Interface().(models.DateValue)
+		if !ok {
+			return false
 		}
-	}
+		_, err := time.Parse(models.DateLayout, string(value))
+		return err == nil
 
-	// Преобразуем map в slice
-	result := make([]string, 0, len(allCharacteristics))
-	for characteristic := range allCharacteristics {
-		result = append(result, characteristic)
+	default:
+		return false
 	}
-
-	return result
 }
@@ -0,0 +1,86 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yaroslavvasilenko/argon/config"
+	"github.com/yaroslavvasilenko/argon/internal/models"
+)
+
+func TestCheckCharacteristics(t *testing.T) {
+	required, strict := true, true
+	minWeight, maxWeight, minYear := 0.1, 500.0, 1990.0
+	registry := models.NewCharacteristicRegistry([]config.CharacteristicDefinition{
+		{Role: "brand", Kind: "dropdown", Options: []string{"apple", "samsung", "ikea"}},
+		{Role: "weight", Kind: "amount", Units: []string{"g", "kg"}, DefaultUnit: "kg"},
+		{Role: "year", Kind: "integer"},
+	})
+	schema := models.NewCategoryTree([]config.CategoryNode{{
+		ID: "furniture",
+		Characteristics: []config.CharacteristicNode{
+			{Role: "brand", Options: []string{"ikea"}, Required: &required, Strict: &strict},
+			{Role: "weight", Min: &minWeight, Max: &maxWeight, Unit: "kg"},
+			{Role: "year", Min: &minYear},
+		},
+	}}).Resolve([]string{"furniture"}, registry)
+
+	check := func(values models.CharacteristicValue, lang models.Localization) []models.FieldError {
+		return CheckCharacteristics(schema, values, lang)
+	}
+	brand := models.DropdownOption{Value: "ikea", Label: "IKEA"}
+
+	t.Run("Корректные значения", func(t *testing.T) {
+		assert.Empty(t, check(models.CharacteristicValue{
+			"brand":  brand,
+			"weight": models.Amount{Value: 200, Dimension: models.G},
+			"year":   models.IntegerValue(2001),
+		}, models.LanguageEn))
+	})
+
+	t.Run("Ошибки по полям", func(t *testing.T) {
+		errs := check(models.CharacteristicValue{
+			"weight": models.Amount{Value: 600, Dimension: models.KG},
+			"year":   models.IntegerValue(1980),
+			"color":  models.Color{Color: "red"},
+		}, models.LanguageEn)
+		assert.Equal(t, []models.FieldError{
+			{Field: "characteristics.brand", Code: models.FieldErrorRequired, Message: "This characteristic is required"},
+			{Field: "characteristics.weight", Code: models.FieldErrorMax, Message: "Value must be at most 500 kg"},
+			{Field: "characteristics.year", Code: models.FieldErrorMin, Message: "Value must be at least 1990"},
+			{Field: "characteristics.color", Code: models.FieldErrorNotAllowed,
+				Message: "Characteristic is not available in the selected categories"},
+		}, errs)
+	})
+
+	t.Run("Опции и единицы категории", func(t *testing.T) {
+		errs := check(models.CharacteristicValue{
+			"brand":  models.DropdownOption{Value: "apple", Label: "Apple"},
+			"weight": models.Amount{Value: 50, Dimension: models.G},
+		}, models.LanguageRu)
+		assert.Equal(t, []models.FieldError{
+			{Field: "characteristics.brand", Code: models.FieldErrorOption,
+				Message: "Значение apple недоступно в выбранных категориях"},
+			{Field: "characteristics.weight", Code: models.FieldErrorMin, Message: "Значение должно быть не меньше 0.1 kg"},
+		}, errs)
+
+		errs = check(models.CharacteristicValue{
+			"brand":  brand,
+			"weight": models.Amount{Value: 1, Dimension: models.T},
+		}, models.LanguageEs)
+		assert.Equal(t, []models.FieldError{
+			{Field: "characteristics.weight", Code: models.FieldErrorUnit, Message: "La unidad t no está permitida"},
+		}, errs)
+	})
+
+	t.Run("Без ограничения категорией значение списка свободное", func(t *testing.T) {
+		schema := models.NewCategoryTree([]config.CategoryNode{{
+			ID:              "electronics",
+			Characteristics: []config.CharacteristicNode{{Role: "brand", Options: []string{"apple", "samsung"}}},
+		}}).Resolve([]string{"electronics"}, registry)
+
+		assert.Empty(t, CheckCharacteristics(schema, models.CharacteristicValue{
+			"brand": models.DropdownOption{Value: "Brand1", Label: "Brand1"},
+		}, models.LanguageEn))
+	})
+}
@@ -46,9 +46,6 @@ func Validate(s interface{}) *Validator {
 	
 	// Регистрация кастомной валидации для категорий
 	_ = val.RegisterValidation("categories_validation", ValidateCategories)
-	
-	// Регистрация кастомной валидации для характеристик
-	_ = val.RegisterValidation("characteristics_value", ValidateCharacteristicsValue)
 
 	if err := val.StructExcept(s); err != nil {
 		for _, e := range err.(validator.ValidationErrors) {
@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yaroslavvasilenko/argon/internal/models"
+)
+
+// Actor middleware добавляет в контекст запроса идентификатор пользователя из заголовка шлюза
+func Actor() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		actor := models.Actor(c.Get(models.HeaderActor))
+
+		ctx := context.WithValue(c.UserContext(), models.KeyActor, actor)
+		c.SetUserContext(ctx)
+
+		return c.Next()
+	}
+}
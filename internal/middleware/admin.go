@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/yaroslavvasilenko/argon/config"
+	"github.com/yaroslavvasilenko/argon/internal/core/parser"
+)
+
+// Admin middleware пропускает только запросы администраторов из конфигурации.
+// Идентификатор пользователя берется из контекста, поэтому Actor должен выполняться раньше
+func Admin() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		actor := parser.GetActor(c.UserContext())
+		if actor == "" {
+			return fiber.NewError(fiber.StatusUnauthorized, "user is not authenticated")
+		}
+
+		for _, admin := range config.GetConfig().Admin.Actors {
+			if string(actor) == admin {
+				return c.Next()
+			}
+		}
+
+		return fiber.NewError(fiber.StatusForbidden, "user is not an administrator")
+	}
+}
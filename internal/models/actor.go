@@ -0,0 +1,11 @@
+package models
+
+// Actor идентификатор пользователя, от имени которого выполняется запрос
+type Actor string
+
+const (
+	// HeaderActor заголовок с идентификатором пользователя, которого аутентифицировал шлюз
+	HeaderActor = "X-User-ID"
+	// KeyActor ключ идентификатора пользователя в контексте запроса
+	KeyActor Actor = "actor"
+)
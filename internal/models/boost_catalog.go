@@ -0,0 +1,55 @@
+package models
+
+import (
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BoostCatalogType тип буста из каталога; Position задает порядок вывода
+type BoostCatalogType struct {
+	Type      BoostType `json:"type"`
+	Position  int       `json:"position"`
+	IsActive  bool      `json:"is_active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// BoostTariff правило комиссии для типа буста. Тариф без категории действует по умолчанию,
+// тариф с категорией переопределяет его для объявлений этой категории
+type BoostTariff struct {
+	ID         uuid.UUID `json:"id"`
+	Type       BoostType `json:"type"`
+	CategoryID *string   `json:"category_id,omitempty"`
+	// CommissionPercent доля цены объявления, например 0.07 — 7%
+	CommissionPercent float64 `json:"commission_percent"`
+	// MinAmounts минимальная комиссия в валюте объявления
+	MinAmounts map[Currency]float64 `json:"min_amounts"`
+	// ValidFrom и ValidTo ограничивают период действия тарифа; пустые границы не ограничивают
+	ValidFrom *time.Time `json:"valid_from,omitempty"`
+	ValidTo   *time.Time `json:"valid_to,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// IsValidAt проверяет, действует ли тариф в момент t
+func (t BoostTariff) IsValidAt(at time.Time) bool {
+	if t.ValidFrom != nil && at.Before(*t.ValidFrom) {
+		return false
+	}
+	if t.ValidTo != nil && !at.Before(*t.ValidTo) {
+		return false
+	}
+	return true
+}
+
+// CommissionAmount считает комиссию в валюте объявления: процент от цены,
+// но не меньше минимальной суммы для этой валюты, с округлением до копеек
+func (t BoostTariff) CommissionAmount(price float64, currency Currency) float64 {
+	amount := price * t.CommissionPercent
+	if minAmount, ok := t.MinAmounts[currency]; ok && amount < minAmount {
+		amount = minAmount
+	}
+	return math.Round(amount*100) / 100
+}
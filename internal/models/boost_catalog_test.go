@@ -0,0 +1,34 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBoostTariff(t *testing.T) {
+	now := time.Now()
+	from := now.Add(-time.Hour)
+	to := now.Add(time.Hour)
+
+	t.Run("Период действия", func(t *testing.T) {
+		assert.True(t, BoostTariff{}.IsValidAt(now), "тариф без границ действует всегда")
+		assert.True(t, BoostTariff{ValidFrom: &from, ValidTo: &to}.IsValidAt(now))
+		assert.False(t, BoostTariff{ValidFrom: &to}.IsValidAt(now), "тариф еще не начал действовать")
+		assert.False(t, BoostTariff{ValidTo: &now}.IsValidAt(now), "правая граница не входит в период")
+	})
+
+	t.Run("Расчет комиссии", func(t *testing.T) {
+		tariff := BoostTariff{
+			CommissionPercent: 0.07,
+			MinAmounts:        map[Currency]float64{RUB: 100},
+		}
+
+		assert.Equal(t, 140.0, tariff.CommissionAmount(2000, RUB))
+		// Для дешевого объявления действует минимальная сумма
+		assert.Equal(t, 100.0, tariff.CommissionAmount(500, RUB))
+		// Минимальная сумма задана только для рублей
+		assert.Equal(t, 0.35, tariff.CommissionAmount(5, USD))
+	})
+}
@@ -0,0 +1,214 @@
+package models
+
+import (
+	"sort"
+
+	"github.com/yaroslavvasilenko/argon/config"
+)
+
+// CharacteristicRule характеристика в действующей схеме категории
+type CharacteristicRule struct {
+	// Definition описание из реестра с опциями, суженными категориями пути
+	Definition CharacteristicDefinition
+	Required   bool
+	Order      int
+	// Strict значение выпадающего списка должно быть одной из опций
+	Strict bool
+	// Min и Max границы значения; для величин в единице реестра по умолчанию
+	Min *float64
+	Max *float64
+	// Category категория, в которой характеристика появилась на пути
+	Category string
+}
+
+// CategorySchema действующая схема характеристик для пути категорий
+type CategorySchema struct {
+	Characteristics []CharacteristicRule
+}
+
+// Get возвращает правило характеристики по роли
+func (s CategorySchema) Get(role string) (CharacteristicRule, bool) {
+	for _, rule := range s.Characteristics {
+		if rule.Definition.Role == role {
+			return rule, true
+		}
+	}
+	return CharacteristicRule{}, false
+}
+
+// Roles возвращает роли характеристик схемы в порядке схемы
+func (s CategorySchema) Roles() []string {
+	roles := make([]string, 0, len(s.Characteristics))
+	for _, rule := range s.Characteristics {
+		roles = append(roles, rule.Definition.Role)
+	}
+	return roles
+}
+
+// categoryNode узел дерева категорий со ссылкой на родителя
+type categoryNode struct {
+	parent          string
+	characteristics []config.CharacteristicNode
+}
+
+// CategoryTree дерево категорий из categories.toml
+type CategoryTree struct {
+	nodes map[string]categoryNode
+}
+
+// NewCategoryTree собирает дерево из узлов конфигурации
+func NewCategoryTree(categories []config.CategoryNode) CategoryTree {
+	tree := CategoryTree{nodes: make(map[string]categoryNode)}
+	var collect func(nodes []config.CategoryNode, parent string)
+	collect = func(nodes []config.CategoryNode, parent string) {
+		for _, node := range nodes {
+			tree.nodes[node.ID] = categoryNode{parent: parent, characteristics: node.Characteristics}
+			collect(node.Subcategories, node.ID)
+		}
+	}
+	collect(categories, "")
+	return tree
+}
+
+// Categories возвращает дерево категорий из текущей конфигурации
+func Categories() CategoryTree {
+	return NewCategoryTree(config.GetConfig().Categories.Data.Categories)
+}
+
+// Has проверяет, что категория есть в дереве
+func (t CategoryTree) Has(id string) bool {
+	_, ok := t.nodes[id]
+	return ok
+}
+
+// Ancestors возвращает путь от корня дерева до категории включительно
+func (t CategoryTree) Ancestors(id string) []string {
+	var path []string
+	for id != "" {
+		node, ok := t.nodes[id]
+		if !ok {
+			break
+		}
+		path = append([]string{id}, path...)
+		id = node.parent
+	}
+	return path
+}
+
+// Deepest возвращает самую глубокую из категорий в дереве; из равных по глубине — первую.
+// Неизвестные категории пропускаются
+func (t CategoryTree) Deepest(ids []string) string {
+	deepest, depth := "", 0
+	for _, id := range ids {
+		if d := len(t.Ancestors(id)); d > depth {
+			deepest, depth = id, d
+		}
+	}
+	return deepest
+}
+
+// Resolve возвращает действующую схему для пути категорий. Для каждой категории пути
+// учитываются все ее предки: характеристики идут от корня к листу, а более глубокий
+// уровень переопределяет опции, обязательность, границы и порядок. Неизвестные категории и роли
+// не из реестра пропускаются
+func (t CategoryTree) Resolve(path []string, registry CharacteristicRegistry) CategorySchema {
+	visited := make(map[string]bool)
+	rules := make(map[string]*CharacteristicRule)
+	var order []string
+
+	for _, id := range path {
+		for _, categoryID := range t.Ancestors(id) {
+			if visited[categoryID] {
+				continue
+			}
+			visited[categoryID] = true
+
+			for _, node := range t.nodes[categoryID].characteristics {
+				def, ok := registry.Get(node.Role)
+				if !ok {
+					continue
+				}
+
+				rule, ok := rules[node.Role]
+				if !ok {
+					rule = &CharacteristicRule{Definition: def, Order: len(order), Category: categoryID,
+						Min: int64Bound(def.Min), Max: int64Bound(def.Max)}
+					rules[node.Role] = rule
+					order = append(order, node.Role)
+				}
+				if options := narrowOptions(rule.Definition, node.Options); len(options) > 0 {
+					rule.Definition.Options = options
+				}
+				if node.Strict != nil {
+					rule.Strict = *node.Strict
+				}
+				if node.Required != nil {
+					rule.Required = *node.Required
+				}
+				if node.Order != nil {
+					rule.Order = *node.Order
+				}
+				if min, ok := ruleBound(def, node.Min, node.Unit); ok {
+					rule.Min = min
+				}
+				if max, ok := ruleBound(def, node.Max, node.Unit); ok {
+					rule.Max = max
+				}
+			}
+		}
+	}
+
+	schema := CategorySchema{Characteristics: make([]CharacteristicRule, 0, len(order))}
+	for _, role := range order {
+		schema.Characteristics = append(schema.Characteristics, *rules[role])
+	}
+	sort.SliceStable(schema.Characteristics, func(i, j int) bool {
+		return schema.Characteristics[i].Order < schema.Characteristics[j].Order
+	})
+	return schema
+}
+
+// ResolveCategories возвращает действующую схему пути категорий по текущей конфигурации
+func ResolveCategories(path []string) CategorySchema {
+	return Categories().Resolve(path, Characteristics())
+}
+
+// narrowOptions оставляет из опций уровня только те, что разрешены выше по пути.
+// Для типов без опций в реестре уровень опции не задает. Опции, не разрешенные предками,
+// отклоняются проверкой таксономии при загрузке, поэтому здесь пересечение не бывает пустым
+func narrowOptions(def CharacteristicDefinition, options []string) []string {
+	if len(def.Options) == 0 {
+		return nil
+	}
+	narrowed := make([]string, 0, len(options))
+	for _, option := range options {
+		if def.HasOption(option) {
+			narrowed = append(narrowed, option)
+		}
+	}
+	return narrowed
+}
+
+// ruleBound переводит границу уровня в единицу реестра. Граница в единице другой
+// величины не применяется
+func ruleBound(def CharacteristicDefinition, bound *float64, unit string) (*float64, bool) {
+	if bound == nil {
+		return nil, false
+	}
+	if def.Kind != KindAmount || unit == "" {
+		return bound, true
+	}
+	value, ok := ConvertUnit(*bound, Dimension(unit), def.DefaultUnit)
+	if !ok {
+		return nil, false
+	}
+	return &value, true
+}
+
+func int64Bound(bound *int64) *float64 {
+	if bound == nil {
+		return nil
+	}
+	value := float64(*bound)
+	return &value
+}
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yaroslavvasilenko/argon/config"
+)
+
+func TestCategoryTreeResolve(t *testing.T) {
+	required := true
+	first := -1
+	minWeight, maxWeight := 100.0, 2.0
+	registry := NewCharacteristicRegistry([]config.CharacteristicDefinition{
+		{Role: "brand", Kind: "dropdown", Options: []string{"apple", "samsung", "ikea"}},
+		{Role: "color", Kind: "color", Options: []string{"black", "white"}},
+		{Role: "weight", Kind: "amount", Units: []string{"g", "kg"}, DefaultUnit: "kg"},
+	})
+	tree := NewCategoryTree([]config.CategoryNode{{
+		ID: "electronics",
+		Characteristics: []config.CharacteristicNode{
+			{Role: "brand", Options: []string{"apple", "samsung"}},
+			{Role: "weight", Max: &maxWeight},
+			{Role: "unknown"},
+		},
+		Subcategories: []config.CategoryNode{{
+			ID: "smartphones",
+			Characteristics: []config.CharacteristicNode{
+				{Role: "color", Order: &first},
+				{Role: "brand", Options: []string{"apple", "ikea"}, Required: &required},
+				{Role: "weight", Min: &minWeight, Unit: "g"},
+			},
+			Subcategories: []config.CategoryNode{{ID: "iphone"}},
+		}},
+	}})
+
+	t.Run("Путь до категории", func(t *testing.T) {
+		assert.Equal(t, []string{"electronics", "smartphones", "iphone"}, tree.Ancestors("iphone"))
+		assert.Empty(t, tree.Ancestors("missing"))
+	})
+
+	t.Run("Самая глубокая категория", func(t *testing.T) {
+		assert.Equal(t, "iphone", tree.Deepest([]string{"electronics", "iphone", "smartphones"}))
+		assert.Equal(t, "electronics", tree.Deepest([]string{"missing", "electronics"}))
+		assert.Empty(t, tree.Deepest(nil))
+	})
+
+	t.Run("Лист наследует характеристики предков", func(t *testing.T) {
+		schema := tree.Resolve([]string{"iphone"}, registry)
+		assert.Equal(t, []string{"color", "brand", "weight"}, schema.Roles())
+
+		brand, ok := schema.Get("brand")
+		require.True(t, ok)
+		assert.True(t, brand.Required)
+		assert.Equal(t, "electronics", brand.Category)
+		assert.Equal(t, []string{"apple"}, brand.Definition.Options, "опции сужаются только внутри разрешенных предком")
+	})
+
+	t.Run("Переопределения не влияют на предка", func(t *testing.T) {
+		schema := tree.Resolve([]string{"electronics"}, registry)
+		assert.Equal(t, []string{"brand", "weight"}, schema.Roles())
+
+		brand, _ := schema.Get("brand")
+		assert.False(t, brand.Required)
+		assert.Equal(t, []string{"apple", "samsung"}, brand.Definition.Options)
+	})
+
+	t.Run("Границы наследуются и переводятся в единицу реестра", func(t *testing.T) {
+		weight, ok := tree.Resolve([]string{"iphone"}, registry).Get("weight")
+		require.True(t, ok)
+		require.NotNil(t, weight.Min)
+		require.NotNil(t, weight.Max)
+		assert.Equal(t, 0.1, *weight.Min, "100 г переводятся в килограммы")
+		assert.Equal(t, 2.0, *weight.Max, "верхняя граница наследуется от предка")
+
+		weight, _ = tree.Resolve([]string{"electronics"}, registry).Get("weight")
+		assert.Nil(t, weight.Min)
+	})
+
+	t.Run("Полный путь совпадает с листом", func(t *testing.T) {
+		assert.Equal(t, tree.Resolve([]string{"iphone"}, registry),
+			tree.Resolve([]string{"electronics", "smartphones", "iphone"}, registry))
+	})
+
+	t.Run("Схема из конфигурации", func(t *testing.T) {
+		schema := ResolveCategories([]string{"iphone"})
+		assert.Contains(t, schema.Roles(), CHAR_BRAND, "iphone наследует характеристики электроники")
+		assert.Contains(t, schema.Roles(), CHAR_COLOR)
+
+		year, ok := ResolveCategories([]string{"vehicles"}).Get("year")
+		require.True(t, ok)
+		assert.True(t, year.Required, "required читается из categories.toml")
+	})
+}
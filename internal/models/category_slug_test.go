@@ -0,0 +1,22 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlugify(t *testing.T) {
+	cases := map[string]string{
+		"Smartphones":          "smartphones",
+		"Men's Clothing":       "mens-clothing",
+		"Электроника":          "elektronika",
+		"Мужская одежда":       "muzhskaya-odezhda",
+		"Teléfonos & Tablets":  "telefonos-tablets",
+		"  iPhone 15 Pro Max ": "iphone-15-pro-max",
+		"Ñandú":                "nandu",
+	}
+	for name, slug := range cases {
+		assert.Equal(t, slug, Slugify(name), name)
+	}
+}
@@ -23,13 +23,44 @@ type CheckboxParam struct {
 type AmountParam struct {
 	Value     float64   `json:"value" validate:"required"`
 	Dimension Dimension `json:"dimension" validate:"required"`
+	// Min и Max границы значения в категории, в единице Dimension
+	Min *float64 `json:"min,omitempty"`
+	Max *float64 `json:"max,omitempty"`
 }
 
+// MultiSelectParam представляет параметр множественного выбора
+type MultiSelectParam struct {
+	Options []DropdownOption `json:"options"`
+	// Multiple всегда true и отличает параметр от выпадающего списка
+	Multiple bool `json:"multiple"`
+}
+
+// IntegerParam представляет параметр целого значения с необязательными границами
+type IntegerParam struct {
+	Min *int64 `json:"min,omitempty"`
+	Max *int64 `json:"max,omitempty"`
+}
+
+// TextParam представляет параметр свободного текста
+type TextParam struct {
+	MaxLength int `json:"max_length"`
+}
+
+// DateParam представляет параметр даты
+type DateParam struct {
+	Format string `json:"format"`
+}
+
+// DateParamFormat формат даты DateLayout в записи для клиентов
+const DateParamFormat = "YYYY-MM-DD"
+
 // CharacteristicParamItem представляет отдельную характеристику с ролью и параметром
 // Используется только для сериализации и десериализации
 type CharacteristicParamItem struct {
 	Role  string      `json:"role"`
 	Param interface{} `json:"param"`
+	// Required характеристика обязательна в схеме категории
+	Required bool `json:"required"`
 }
 
 // CharacteristicParam представляет собой карту параметров характеристик
@@ -38,30 +69,6 @@ type CharacteristicParam map[string]interface{}
 
 
 
-// CharacteristicParamMap сопоставляет характеристики с их типами параметров
-var CharacteristicParamMap = map[string]interface{}{
-	// Цвет
-	CHAR_COLOR: ColorParam{},
-
-	// Выпадающие списки
-	CHAR_CONDITION: StringParam{},
-	CHAR_SEASON:    StringParam{},
-	CHAR_BRAND:     StringParam{},
-
-	// Чекбоксы
-	CHAR_STOCKED: CheckboxParam{},
-
-	// Размеры и измерения
-	CHAR_HEIGHT: AmountParam{},
-	CHAR_WIDTH:  AmountParam{},
-	CHAR_DEPTH:  AmountParam{},
-	CHAR_WEIGHT: AmountParam{},
-	CHAR_AREA:   AmountParam{},
-	CHAR_VOLUME: AmountParam{},
-}
-
-
-
 // MarshalJSON реализует интерфейс json.Marshaler для типа CharacteristicParam
 func (c CharacteristicParam) MarshalJSON() ([]byte, error) {
 	if c == nil {
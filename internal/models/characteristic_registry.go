@@ -0,0 +1,189 @@
+package models
+
+import (
+	"sort"
+
+	"github.com/yaroslavvasilenko/argon/config"
+)
+
+// CharacteristicKind тип значения характеристики
+type CharacteristicKind string
+
+const (
+	KindColor       CharacteristicKind = "color"
+	KindDropdown    CharacteristicKind = "dropdown"
+	KindMultiSelect CharacteristicKind = "multiselect"
+	KindCheckbox    CharacteristicKind = "checkbox"
+	KindAmount      CharacteristicKind = "amount"
+	KindInteger     CharacteristicKind = "integer"
+	KindText        CharacteristicKind = "text"
+	KindDate        CharacteristicKind = "date"
+)
+
+// DefaultTextMaxLength максимальная длина текстовой характеристики, если реестр ее не задает
+const DefaultTextMaxLength = 100
+
+// FilterMode поведение характеристики в фильтрах поиска и в агрегатах по категории
+type FilterMode string
+
+const (
+	// FilterOptions объявление подходит, если его значение входит в выбранные опции
+	FilterOptions FilterMode = "options"
+	// FilterFlag объявление подходит, если флаг совпадает с выбранным
+	FilterFlag FilterMode = "flag"
+	// FilterRange объявление подходит, если значение попадает в диапазон
+	FilterRange FilterMode = "range"
+	// FilterText объявление подходит, если значение содержит искомую строку
+	FilterText FilterMode = "text"
+	// FilterNone характеристика не участвует в фильтрах
+	FilterNone FilterMode = "none"
+)
+
+// defaultFilterModes поведение в фильтрах для каждого типа характеристики
+var defaultFilterModes = map[CharacteristicKind]FilterMode{
+	KindColor:       FilterOptions,
+	KindDropdown:    FilterOptions,
+	KindMultiSelect: FilterOptions,
+	KindCheckbox:    FilterFlag,
+	KindAmount:      FilterRange,
+	KindInteger:     FilterRange,
+	KindText:        FilterText,
+	KindDate:        FilterRange,
+}
+
+// CharacteristicDefinition описание характеристики из реестра
+type CharacteristicDefinition struct {
+	Role        string
+	Kind        CharacteristicKind
+	Options     []string
+	Units       []Dimension
+	DefaultUnit Dimension
+	// Min и Max границы целого значения, nil — без ограничения
+	Min       *int64
+	Max       *int64
+	MaxLength int
+	Filter    FilterMode
+}
+
+// AllowsUnit проверяет, что единица измерения допустима для характеристики
+func (d CharacteristicDefinition) AllowsUnit(unit Dimension) bool {
+	for _, allowed := range d.Units {
+		if allowed == unit {
+			return true
+		}
+	}
+	return false
+}
+
+// HasOption проверяет, что значение входит в опции характеристики
+func (d CharacteristicDefinition) HasOption(value string) bool {
+	for _, option := range d.Options {
+		if option == value {
+			return true
+		}
+	}
+	return false
+}
+
+// InRange проверяет, что целое значение попадает в границы характеристики
+func (d CharacteristicDefinition) InRange(value int64) bool {
+	if d.Min != nil && value < *d.Min {
+		return false
+	}
+	if d.Max != nil && value > *d.Max {
+		return false
+	}
+	return true
+}
+
+// Filterable проверяет, что по характеристике можно фильтровать
+func (d CharacteristicDefinition) Filterable() bool {
+	return d.Filter != FilterNone
+}
+
+// CharacteristicRegistry характеристики по ролям
+type CharacteristicRegistry map[string]CharacteristicDefinition
+
+// NewCharacteristicRegistry собирает реестр из описаний конфигурации. Описания с неизвестным
+// типом или несовместимым поведением в фильтрах пропускаются
+func NewCharacteristicRegistry(definitions []config.CharacteristicDefinition) CharacteristicRegistry {
+	registry := make(CharacteristicRegistry, len(definitions))
+	for _, def := range definitions {
+		kind := CharacteristicKind(def.Kind)
+		defaultMode, ok := defaultFilterModes[kind]
+		if !ok || def.Role == "" {
+			continue
+		}
+
+		mode := FilterMode(def.Filter)
+		switch mode {
+		case "":
+			mode = defaultMode
+		case defaultMode, FilterNone:
+		default:
+			continue
+		}
+
+		units := make([]Dimension, 0, len(def.Units))
+		for _, unit := range def.Units {
+			units = append(units, Dimension(unit))
+		}
+		defaultUnit := Dimension(def.DefaultUnit)
+		if defaultUnit == "" && len(units) > 0 {
+			defaultUnit = units[0]
+		}
+		maxLength := def.MaxLength
+		if kind == KindText && maxLength <= 0 {
+			maxLength = DefaultTextMaxLength
+		}
+
+		registry[def.Role] = CharacteristicDefinition{
+			Role:        def.Role,
+			Kind:        kind,
+			Options:     def.Options,
+			Units:       units,
+			DefaultUnit: defaultUnit,
+			Min:         def.Min,
+			Max:         def.Max,
+			MaxLength:   maxLength,
+			Filter:      mode,
+		}
+	}
+
+	return registry
+}
+
+// Characteristics возвращает реестр характеристик из текущей конфигурации
+func Characteristics() CharacteristicRegistry {
+	return NewCharacteristicRegistry(config.GetConfig().Categories.Characteristics)
+}
+
+// Get возвращает описание характеристики по роли
+func (r CharacteristicRegistry) Get(role string) (CharacteristicDefinition, bool) {
+	def, ok := r[role]
+	return def, ok
+}
+
+// RolesByFilter возвращает роли характеристик с указанным поведением в фильтрах
+func (r CharacteristicRegistry) RolesByFilter(mode FilterMode) []string {
+	roles := make([]string, 0)
+	for role, def := range r {
+		if def.Filter == mode {
+			roles = append(roles, role)
+		}
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+// RolesByKind возвращает роли характеристик указанного типа, по которым можно фильтровать
+func (r CharacteristicRegistry) RolesByKind(kind CharacteristicKind) []string {
+	roles := make([]string, 0)
+	for role, def := range r {
+		if def.Kind == kind && def.Filterable() {
+			roles = append(roles, role)
+		}
+	}
+	sort.Strings(roles)
+	return roles
+}
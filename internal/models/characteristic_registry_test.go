@@ -0,0 +1,80 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yaroslavvasilenko/argon/config"
+)
+
+func TestNewCharacteristicRegistry(t *testing.T) {
+	registry := NewCharacteristicRegistry([]config.CharacteristicDefinition{
+		{Role: "color", Kind: "color", Options: []string{"black", "white"}},
+		{Role: "stocked", Kind: "checkbox"},
+		{Role: "height", Kind: "amount", Units: []string{"cm", "m"}},
+		{Role: "serial", Kind: "dropdown", Filter: "none"},
+		{Role: "broken", Kind: "unknown"},
+		{Role: "mismatch", Kind: "checkbox", Filter: "range"},
+		{Role: "year", Kind: "integer", Min: int64Ptr(1900), Max: int64Ptr(2100)},
+		{Role: "model", Kind: "text"},
+		{Role: "amenities", Kind: "multiselect", Options: []string{"parking"}},
+		{Role: "available_from", Kind: "date"},
+	})
+
+	t.Run("Поведение в фильтрах по типу", func(t *testing.T) {
+		color, ok := registry.Get("color")
+		require.True(t, ok)
+		assert.Equal(t, FilterOptions, color.Filter)
+		assert.True(t, color.HasOption("black"))
+		assert.False(t, color.HasOption("pink"))
+
+		stocked, ok := registry.Get("stocked")
+		require.True(t, ok)
+		assert.Equal(t, FilterFlag, stocked.Filter)
+	})
+
+	t.Run("Единицы измерения", func(t *testing.T) {
+		height, ok := registry.Get("height")
+		require.True(t, ok)
+		assert.Equal(t, FilterRange, height.Filter)
+		assert.Equal(t, Dimension("cm"), height.DefaultUnit, "без явной единицы по умолчанию берется первая")
+		assert.True(t, height.AllowsUnit("m"))
+		assert.False(t, height.AllowsUnit("kg"))
+	})
+
+	t.Run("Характеристика без фильтра", func(t *testing.T) {
+		serial, ok := registry.Get("serial")
+		require.True(t, ok)
+		assert.False(t, serial.Filterable())
+		assert.NotContains(t, registry.RolesByFilter(FilterOptions), "serial")
+	})
+
+	t.Run("Целые значения, текст, даты и множественный выбор", func(t *testing.T) {
+		year, ok := registry.Get("year")
+		require.True(t, ok)
+		assert.Equal(t, FilterRange, year.Filter)
+		assert.True(t, year.InRange(2020))
+		assert.False(t, year.InRange(1800))
+
+		model, ok := registry.Get("model")
+		require.True(t, ok)
+		assert.Equal(t, FilterText, model.Filter)
+		assert.Equal(t, DefaultTextMaxLength, model.MaxLength, "без явной длины берется длина по умолчанию")
+
+		assert.Contains(t, registry.RolesByFilter(FilterOptions), "amenities")
+		assert.Equal(t, []string{"available_from"}, registry.RolesByKind(KindDate))
+		assert.Equal(t, []string{"height"}, registry.RolesByKind(KindAmount))
+	})
+
+	t.Run("Некорректные описания пропускаются", func(t *testing.T) {
+		_, ok := registry.Get("broken")
+		assert.False(t, ok)
+		_, ok = registry.Get("mismatch")
+		assert.False(t, ok)
+	})
+}
+
+func int64Ptr(v int64) *int64 {
+	return &v
+}
@@ -2,10 +2,13 @@ package models
 
 import (
 	"encoding/json"
+	"math"
 	"strconv"
+	"strings"
 )
 
-// Константы для типов характеристик
+// Роли характеристик, на которые код ссылается напрямую. Типы, опции и единицы
+// измерения характеристик задаются в реестре categories/characteristics.json
 const (
 	CHAR_PRICE = "price"
 
@@ -59,28 +62,6 @@ const (
 	OM = "om" // ом
 )
 
-// CharacteristicValueMap сопоставляет характеристики с их типами параметров
-var CharacteristicValueMap = map[string]interface{}{
-	// Цвет
-	CHAR_COLOR: Color{},
-
-	// Выпадающие списки
-	CHAR_CONDITION: DropdownOption{},
-	CHAR_SEASON:    DropdownOption{},
-	CHAR_BRAND:     DropdownOption{},
-
-	// Чекбоксы
-	CHAR_STOCKED: CheckboxValue{},
-
-	// Размеры и измерения
-	CHAR_HEIGHT: Amount{},
-	CHAR_WIDTH:  Amount{},
-	CHAR_DEPTH:  Amount{},
-	CHAR_WEIGHT: Amount{},
-	CHAR_AREA:   Amount{},
-	CHAR_VOLUME: Amount{},
-}
-
 type Color struct {
 	Color string `json:"color"`
 }
@@ -97,61 +78,112 @@ type CheckboxValue struct {
 type Amount struct {
 	Value     float64   `json:"value" validate:"required"`
 	Dimension Dimension `json:"dimension" validate:"required"`
+	// BaseValue значение в базовой единице величины, по нему работают фильтры.
+	// Считается при разборе и не принимается от клиента
+	BaseValue *float64 `json:"base_value,omitempty"`
+}
+
+// Normalized возвращает значение с пересчитанным BaseValue; для неизвестной единицы BaseValue пуст
+func (a Amount) Normalized() Amount {
+	a.BaseValue = nil
+	if base, ok := ToBaseUnit(a.Value, a.Dimension); ok {
+		a.BaseValue = &base
+	}
+	return a
 }
 
+// MultiSelectValue выбранные опции характеристики с множественным выбором
+type MultiSelectValue []string
+
+// IntegerValue целое значение, например год выпуска или пробег
+type IntegerValue int64
+
+// TextValue короткий свободный текст, например модель
+type TextValue string
+
+// DateValue дата в формате DateLayout, например "доступно с"
+type DateValue string
+
+// DateLayout формат дат в характеристиках и фильтрах
+const DateLayout = "2006-01-02"
+
 // CharacteristicValue представляет собой карту характеристик, где ключ - это роль, а значение - это значение характеристики
 type CharacteristicValue map[string]interface{}
 
 // SetColor устанавливает значение цвета для указанной роли
 func (c CharacteristicValue) SetColor(role string, value Color) {
-    c[role] = value
+	c[role] = value
 }
 
 // SetDropdownOption устанавливает значение выпадающего списка для указанной роли
 func (c CharacteristicValue) SetDropdownOption(role string, value DropdownOption) {
-    c[role] = value
+	c[role] = value
 }
 
 // SetCheckboxValue устанавливает значение чекбокса для указанной роли
 func (c CharacteristicValue) SetCheckboxValue(role string, value bool) {
-    c[role] = value
+	c[role] = value
 }
 
 // SetAmount устанавливает числовое значение с единицей измерения для указанной роли
 func (c CharacteristicValue) SetAmount(role string, value Amount) {
-    c[role] = value
+	c[role] = value.Normalized()
 }
 
 // GetColor возвращает значение цвета для указанной роли
 func (c CharacteristicValue) GetColor(role string) (Color, bool) {
-    if v, ok := c[role].(Color); ok {
-        return v, true
-    }
-    return Color{}, false
+	if v, ok := c[role].(Color); ok {
+		return v, true
+	}
+	return Color{}, false
 }
 
 // GetDropdownOption возвращает значение выпадающего списка для указанной роли
 func (c CharacteristicValue) GetDropdownOption(role string) (DropdownOption, bool) {
-    if v, ok := c[role].(DropdownOption); ok {
-        return v, true
-    }
-    return DropdownOption{}, false
+	if v, ok := c[role].(DropdownOption); ok {
+		return v, true
+	}
+	return DropdownOption{}, false
 }
 
 // GetCheckboxValue возвращает значение чекбокса для указанной роли
 func (c CharacteristicValue) GetCheckboxValue(role string) (bool, bool) {
-    if v, ok := c[role].(bool); ok {
-        return v, true
-    }
-    return false, false
+	if v, ok := c[role].(bool); ok {
+		return v, true
+	}
+	return false, false
 }
 
 // GetAmount возвращает числовое значение с единицей измерения для указанной роли
 func (c CharacteristicValue) GetAmount(role string) (Amount, bool) {
-    if v, ok := c[role].(Amount); ok {
-        return v, true
-    }
-    return Amount{}, false
+	if v, ok := c[role].(Amount); ok {
+		return v, true
+	}
+	return Amount{}, false
+}
+
+// GetMultiSelect возвращает выбранные опции для указанной роли
+func (c CharacteristicValue) GetMultiSelect(role string) (MultiSelectValue, bool) {
+	v, ok := c[role].(MultiSelectValue)
+	return v, ok
+}
+
+// GetInteger возвращает целое значение для указанной роли
+func (c CharacteristicValue) GetInteger(role string) (IntegerValue, bool) {
+	v, ok := c[role].(IntegerValue)
+	return v, ok
+}
+
+// GetText возвращает текстовое значение для указанной роли
+func (c CharacteristicValue) GetText(role string) (TextValue, bool) {
+	v, ok := c[role].(TextValue)
+	return v, ok
+}
+
+// GetDate возвращает дату для указанной роли
+func (c CharacteristicValue) GetDate(role string) (DateValue, bool) {
+	v, ok := c[role].(DateValue)
+	return v, ok
 }
 
 // Dimension представляет единицу измерения
@@ -190,9 +222,10 @@ func (c *CharacteristicValue) UnmarshalJSON(data []byte) error {
 		*c = make(CharacteristicValue)
 	}
 
+	registry := Characteristics()
 	for _, item := range charItems {
-		// Определяем тип параметра для данной характеристики
-		paramTypeObj, ok := CharacteristicValueMap[item.Role]
+		// Определяем тип характеристики по реестру
+		def, ok := registry.Get(item.Role)
 		if !ok {
 			continue
 		}
@@ -201,8 +234,8 @@ func (c *CharacteristicValue) UnmarshalJSON(data []byte) error {
 		var processedValue interface{}
 
 		// Обрабатываем значение в зависимости от типа характеристики
-		switch paramTypeObj.(type) {
-		case Color:
+		switch def.Kind {
+		case KindColor:
 			// Для цвета ожидаем строку с кодом цвета или объект с полем color
 			switch v := item.Value.(type) {
 			case string:
@@ -213,7 +246,7 @@ func (c *CharacteristicValue) UnmarshalJSON(data []byte) error {
 				}
 			}
 
-		case DropdownOption:
+		case KindDropdown:
 			// Для выпадающих списков ожидаем объект с массивом options
 			switch v := item.Value.(type) {
 			case map[string]interface{}:
@@ -252,9 +285,7 @@ func (c *CharacteristicValue) UnmarshalJSON(data []byte) error {
 				}
 			}
 
-
-
-		case CheckboxValue:
+		case KindCheckbox:
 			// Для чекбокса ожидаем булево значение
 			if checkboxValue, ok := item.Value.(map[string]interface{}); ok {
 				checkboxValueParam := CheckboxValue{}
@@ -264,7 +295,7 @@ func (c *CharacteristicValue) UnmarshalJSON(data []byte) error {
 				processedValue = checkboxValueParam
 			}
 
-		case Amount:
+		case KindAmount:
 			// Для AmountParam ожидаем объект {value, dimension}
 			switch v := item.Value.(type) {
 			case map[string]interface{}:
@@ -298,12 +329,12 @@ func (c *CharacteristicValue) UnmarshalJSON(data []byte) error {
 					}
 				}
 
-				processedValue = amountParam
+				processedValue = amountParam.Normalized()
 			case float64:
 				// Если получили просто число, создаем AmountParam с дефолтной единицей измерения
-				amountParam := Amount{Value: v}
-				
-				processedValue = amountParam
+				amountParam := Amount{Value: v, Dimension: def.DefaultUnit}
+
+				processedValue = amountParam.Normalized()
 			case int, int64:
 				// Если получили целое число, преобразуем в float64
 				var floatVal float64
@@ -313,10 +344,51 @@ func (c *CharacteristicValue) UnmarshalJSON(data []byte) error {
 				case int64:
 					floatVal = float64(val)
 				}
-				
-				amountParam := Amount{Value: floatVal}
-				
-				processedValue = amountParam
+
+				amountParam := Amount{Value: floatVal, Dimension: def.DefaultUnit}
+
+				processedValue = amountParam.Normalized()
+			}
+
+		case KindMultiSelect:
+			// Для множественного выбора ожидаем массив строк или объектов {value, label}
+			if items, ok := item.Value.([]interface{}); ok {
+				values := make(MultiSelectValue, 0, len(items))
+				for _, opt := range items {
+					switch o := opt.(type) {
+					case string:
+						values = append(values, o)
+					case map[string]interface{}:
+						if value, ok := o["value"].(string); ok {
+							values = append(values, value)
+						}
+					}
+				}
+				processedValue = values
+			}
+
+		case KindInteger:
+			// Для целых значений ожидаем число без дробной части или строку с числом
+			switch v := item.Value.(type) {
+			case float64:
+				if v == math.Trunc(v) {
+					processedValue = IntegerValue(v)
+				}
+			case string:
+				if intVal, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64); err == nil {
+					processedValue = IntegerValue(intVal)
+				}
+			}
+
+		case KindText:
+			if text, ok := item.Value.(string); ok {
+				processedValue = TextValue(strings.TrimSpace(text))
+			}
+
+		case KindDate:
+			// Формат даты проверяется при валидации
+			if date, ok := item.Value.(string); ok {
+				processedValue = DateValue(date)
 			}
 		}
 
@@ -0,0 +1,118 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCharacteristicValueUnmarshalJSON(t *testing.T) {
+	data := `[
+		{"role": "amenities", "value": ["parking", {"value": "balcony", "label": "Балкон"}]},
+		{"role": "year", "value": 2018},
+		{"role": "mileage", "value": "120000"},
+		{"role": "model", "value": "  Corolla "},
+		{"role": "available_from", "value": "2025-07-01"}
+	]`
+
+	var value CharacteristicValue
+	require.NoError(t, json.Unmarshal([]byte(data), &value))
+
+	amenities, ok := value.GetMultiSelect("amenities")
+	require.True(t, ok)
+	assert.Equal(t, MultiSelectValue{"parking", "balcony"}, amenities)
+
+	year, ok := value.GetInteger("year")
+	require.True(t, ok)
+	assert.Equal(t, IntegerValue(2018), year)
+
+	mileage, ok := value.GetInteger("mileage")
+	require.True(t, ok)
+	assert.Equal(t, IntegerValue(120000), mileage)
+
+	model, ok := value.GetText("model")
+	require.True(t, ok)
+	assert.Equal(t, TextValue("Corolla"), model)
+
+	date, ok := value.GetDate("available_from")
+	require.True(t, ok)
+	assert.Equal(t, DateValue("2025-07-01"), date)
+
+	t.Run("Число без единицы измерения переводится в базовую единицу", func(t *testing.T) {
+		def, ok := Characteristics().Get(CHAR_HEIGHT)
+		require.True(t, ok)
+
+		var value CharacteristicValue
+		require.NoError(t, json.Unmarshal([]byte(`[{"role": "height", "value": 150}]`), &value))
+
+		amount, ok := value[CHAR_HEIGHT].(Amount)
+		require.True(t, ok)
+		assert.Equal(t, def.DefaultUnit, amount.Dimension)
+		require.NotNil(t, amount.BaseValue)
+		base, _ := ToBaseUnit(150, def.DefaultUnit)
+		assert.Equal(t, base, *amount.BaseValue)
+	})
+
+	t.Run("Дробный год не разбирается", func(t *testing.T) {
+		var value CharacteristicValue
+		require.NoError(t, json.Unmarshal([]byte(`[{"role": "year", "value": 2018.5}]`), &value))
+		assert.Contains(t, value, "year")
+		assert.Nil(t, value["year"])
+	})
+}
+
+func TestNewKindFilters(t *testing.T) {
+	params := FilterParams{
+		"year":           {Role: "year", Value: map[string]interface{}{"min": float64(2010)}},
+		"amenities":      {Role: "amenities", Value: []interface{}{"parking"}},
+		"model":          {Role: "model", Value: "corolla"},
+		"available_from": {Role: "available_from", Value: map[string]interface{}{"from": "2025-06-01", "to": "2025-09-01"}},
+	}
+
+	filters, err := params.ToFilters()
+	require.NoError(t, err)
+	require.NoError(t, filters.Validate())
+
+	year, ok := filters.GetIntegerRangeFilter("year")
+	require.True(t, ok)
+	require.NotNil(t, year.Min)
+	assert.Equal(t, int64(2010), *year.Min)
+	assert.Nil(t, year.Max)
+
+	amenities, ok := filters.GetDropdownFilter("amenities")
+	require.True(t, ok)
+	assert.Equal(t, DropdownFilter{"parking"}, amenities)
+
+	model, ok := filters.GetTextFilter("model")
+	require.True(t, ok)
+	assert.Equal(t, TextFilter("corolla"), model)
+
+	date, ok := filters.GetDateRangeFilter("available_from")
+	require.True(t, ok)
+	assert.Equal(t, DateRangeFilter{From: "2025-06-01", To: "2025-09-01"}, date)
+
+	t.Run("Некорректная дата", func(t *testing.T) {
+		_, err := FilterParams{
+			"available_from": {Role: "available_from", Value: map[string]interface{}{"from": "01.06.2025"}},
+		}.ToFilters()
+		assert.Error(t, err)
+	})
+
+	t.Run("Разбор JSON фильтров", func(t *testing.T) {
+		var filters Filters
+		require.NoError(t, json.Unmarshal([]byte(`[
+			{"role": "mileage", "param": {"max": 50000}},
+			{"role": "available_from", "param": {"from": "2025-06-01"}}
+		]`), &filters))
+
+		mileage, ok := filters.GetIntegerRangeFilter("mileage")
+		require.True(t, ok)
+		assert.Nil(t, mileage.Min)
+		assert.Equal(t, int64(50000), *mileage.Max)
+
+		_, ok = filters.GetDateRangeFilter("available_from")
+		assert.True(t, ok)
+	})
+}
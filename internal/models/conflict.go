@@ -0,0 +1,19 @@
+package models
+
+// ConflictError конфликт записи с текущим состоянием ресурса. Current возвращается
+// клиенту вместе с ответом 409, чтобы он мог повторить изменение поверх актуальных данных
+type ConflictError struct {
+	Message string
+	Current interface{}
+}
+
+func NewConflictError(message string, current interface{}) *ConflictError {
+	return &ConflictError{
+		Message: message,
+		Current: current,
+	}
+}
+
+func (e *ConflictError) Error() string {
+	return e.Message
+}
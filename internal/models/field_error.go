@@ -0,0 +1,90 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Коды ошибок значений полей
+const (
+	FieldErrorRequired   = "required"
+	FieldErrorNotAllowed = "not_allowed"
+	FieldErrorInvalid    = "invalid"
+	FieldErrorOption     = "option"
+	FieldErrorUnit       = "unit"
+	FieldErrorMin        = "min"
+	FieldErrorMax        = "max"
+)
+
+// fieldErrorMessages шаблоны сообщений по языкам; аргумент шаблона — опция, единица или граница
+var fieldErrorMessages = map[Localization]map[string]string{
+	LanguageRu: {
+		FieldErrorRequired:   "Обязательная характеристика",
+		FieldErrorNotAllowed: "Характеристика недоступна в выбранных категориях",
+		FieldErrorInvalid:    "Некорректное значение",
+		FieldErrorOption:     "Значение %s недоступно в выбранных категориях",
+		FieldErrorUnit:       "Недопустимая единица измерения %s",
+		FieldErrorMin:        "Значение должно быть не меньше %s",
+		FieldErrorMax:        "Значение должно быть не больше %s",
+	},
+	LanguageEn: {
+		FieldErrorRequired:   "This characteristic is required",
+		FieldErrorNotAllowed: "Characteristic is not available in the selected categories",
+		FieldErrorInvalid:    "Invalid value",
+		FieldErrorOption:     "Option %s is not available in the selected categories",
+		FieldErrorUnit:       "Unit %s is not allowed",
+		FieldErrorMin:        "Value must be at least %s",
+		FieldErrorMax:        "Value must be at most %s",
+	},
+	LanguageEs: {
+		FieldErrorRequired:   "Esta característica es obligatoria",
+		FieldErrorNotAllowed: "La característica no está disponible en las categorías seleccionadas",
+		FieldErrorInvalid:    "Valor no válido",
+		FieldErrorOption:     "La opción %s no está disponible en las categorías seleccionadas",
+		FieldErrorUnit:       "La unidad %s no está permitida",
+		FieldErrorMin:        "El valor debe ser al menos %s",
+		FieldErrorMax:        "El valor debe ser como máximo %s",
+	},
+}
+
+// FieldError ошибка значения отдельного поля запроса
+type FieldError struct {
+	// Field путь к полю, например characteristics.weight
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// NewFieldError создает ошибку поля с сообщением на языке lang; для неизвестного
+// языка сообщение берется на языке по умолчанию
+func NewFieldError(lang Localization, field, code string, arg string) FieldError {
+	messages, ok := fieldErrorMessages[lang]
+	if !ok {
+		messages = fieldErrorMessages[LanguageDefault]
+	}
+	message := messages[code]
+	if strings.Contains(message, "%s") {
+		message = fmt.Sprintf(message, arg)
+	}
+	return FieldError{Field: field, Code: code, Message: message}
+}
+
+// ValidationFailedError ошибки значений полей. Отдается клиенту с ответом 400
+// вместе со списком полей, чтобы форма могла подсветить каждое из них
+type ValidationFailedError struct {
+	Errors []FieldError
+}
+
+func NewValidationFailedError(errors []FieldError) *ValidationFailedError {
+	return &ValidationFailedError{
+		Errors: errors,
+	}
+}
+
+func (e *ValidationFailedError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, fieldError := range e.Errors {
+		messages = append(messages, fieldError.Field+": "+fieldError.Message)
+	}
+	return strings.Join(messages, "; ")
+}
@@ -3,6 +3,8 @@ package models
 import (
 	"encoding/json"
 	"fmt"
+	"math"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -22,6 +24,7 @@ const (
 	SORT_PRICE_DESC = "price_desc"
 	SORT_NEWEST     = "newest"
 	SORT_RELEVANCE  = "relevance"
+	SORT_DISTANCE   = "distance"
 )
 
 var RoleFilters = []string{
@@ -38,6 +41,7 @@ var SortOrders = []string{
 	SORT_PRICE_DESC,
 	SORT_NEWEST,
 	SORT_RELEVANCE,
+	SORT_DISTANCE,
 }
 
 type FilterParams map[string]FilterItem
@@ -75,18 +79,33 @@ type DimensionFilter struct {
 	Dimension string `json:"dimension"`
 }
 
+// IntegerRangeFilter диапазон целых значений, nil — граница не задана
+type IntegerRangeFilter struct {
+	Min *int64 `json:"min,omitempty"`
+	Max *int64 `json:"max,omitempty"`
+}
+
+// DateRangeFilter диапазон дат в формате DateLayout, пустая строка — граница не задана
+type DateRangeFilter struct {
+	From string `json:"from,omitempty"`
+	To   string `json:"to,omitempty"`
+}
+
+// TextFilter подстрока, которую должно содержать текстовое значение
+type TextFilter string
+
 // Filters теперь map с ключами-строками и значениями-интерфейсами
 type Filters map[string]interface{}
 
 // Validate проверяет валидность фильтров и возвращает ошибку, если фильтры недопустимы
 func (f Filters) Validate() error {
+	registry := Characteristics()
 	for key, value := range f {
 		if key == "" {
 			return fmt.Errorf("filter key cannot be empty")
 		}
 
-		switch key {
-		case CHAR_PRICE:
+		if key == CHAR_PRICE {
 			_, ok := value.(PriceFilter)
 			if !ok {
 				_, ok := value.(map[string]interface{})
@@ -94,7 +113,16 @@ func (f Filters) Validate() error {
 					return fmt.Errorf("filter '%s' must be of type PriceFilter", key)
 				}
 			}
-		case CHAR_COLOR:
+			continue
+		}
+
+		def, ok := registry.Get(key)
+		if !ok || !def.Filterable() {
+			continue
+		}
+
+		switch def.Kind {
+		case KindColor:
 			// Проверяем, что значение - это ColorFilter или может быть преобразовано в него
 			_, ok := value.(ColorFilter)
 			if !ok {
@@ -107,7 +135,7 @@ func (f Filters) Validate() error {
 					}
 				}
 			}
-		case CHAR_BRAND, CHAR_CONDITION, CHAR_SEASON:
+		case KindDropdown, KindMultiSelect:
 			// Проверяем, что значение - это DropdownFilter или может быть преобразовано в него
 			_, ok := value.(DropdownFilter)
 			if !ok {
@@ -120,7 +148,7 @@ func (f Filters) Validate() error {
 					}
 				}
 			}
-		case CHAR_STOCKED:
+		case KindCheckbox:
 			// Проверяем, что значение - это CheckboxFilter или может быть преобразовано в него
 			_, ok := value.(CheckboxFilter)
 			if !ok {
@@ -130,7 +158,7 @@ func (f Filters) Validate() error {
 					return fmt.Errorf("фильтр '%s' должен быть типа CheckboxFilter или bool", key)
 				}
 			}
-		case CHAR_HEIGHT, CHAR_WIDTH, CHAR_DEPTH, CHAR_WEIGHT, CHAR_AREA, CHAR_VOLUME:
+		case KindAmount:
 			// Проверяем, что значение - это DimensionFilter или может быть преобразовано в него
 			dimFilter, ok := value.(DimensionFilter)
 			if !ok {
@@ -154,36 +182,38 @@ func (f Filters) Validate() error {
 				}
 
 				// Проверяем, что единица измерения допустима для данного типа характеристики
-				if !isValidDimensionUnit(key, dimFilter.Dimension) {
+				if !def.AllowsUnit(Dimension(dimFilter.Dimension)) {
 					return fmt.Errorf("фильтр '%s': недопустимая единица измерения '%s'", key, dimFilter.Dimension)
 				}
 			}
+		case KindInteger:
+			intFilter, ok := value.(IntegerRangeFilter)
+			if !ok {
+				return fmt.Errorf("фильтр '%s' должен быть типа IntegerRangeFilter", key)
+			}
+			if intFilter.Min != nil && intFilter.Max != nil && *intFilter.Max < *intFilter.Min {
+				return fmt.Errorf("фильтр '%s': максимальное значение не может быть меньше минимального", key)
+			}
+		case KindDate:
+			dateFilter, ok := value.(DateRangeFilter)
+			if !ok {
+				return fmt.Errorf("фильтр '%s' должен быть типа DateRangeFilter", key)
+			}
+			if err := dateFilter.validate(); err != nil {
+				return fmt.Errorf("фильтр '%s': %v", key, err)
+			}
+		case KindText:
+			if _, ok := value.(TextFilter); !ok {
+				if _, ok := value.(string); !ok {
+					return fmt.Errorf("фильтр '%s' должен быть типа TextFilter или string", key)
+				}
+			}
 		}
 	}
 
 	return nil
 }
 
-// isValidDimensionUnit проверяет, является ли единица измерения допустимой для указанного типа характеристики
-func isValidDimensionUnit(characteristicType string, unit string) bool {
-	switch characteristicType {
-	case CHAR_HEIGHT, CHAR_WIDTH, CHAR_DEPTH:
-		// Для линейных размеров допустимы см, м, км
-		return unit == CM || unit == M || unit == KM
-	case CHAR_WEIGHT:
-		// Для веса допустимы г, кг, т
-		return unit == G || unit == KG || unit == T
-	case CHAR_AREA:
-		// Для площади допустимы см², м², км²
-		return unit == CM2 || unit == M2 || unit == KM2
-	case CHAR_VOLUME:
-		// Для объема допустимы см³, м³, км³, мл, л
-		return unit == CM3 || unit == M3 || unit == KM3 || unit == ML || unit == L
-	default:
-		return false
-	}
-}
-
 func (c Filters) GetPriceFilter(key string) (PriceFilter, bool) {
 	var priceFilter PriceFilter
 	value, ok := c[key]
@@ -275,6 +305,41 @@ func (c Filters) GetDimensionFilter(key string) (DimensionFilter, bool) {
 	return dimensionFilter, true
 }
 
+func (c Filters) GetIntegerRangeFilter(key string) (IntegerRangeFilter, bool) {
+	intFilter, ok := c[key].(IntegerRangeFilter)
+	return intFilter, ok
+}
+
+func (c Filters) GetDateRangeFilter(key string) (DateRangeFilter, bool) {
+	dateFilter, ok := c[key].(DateRangeFilter)
+	return dateFilter, ok
+}
+
+func (c Filters) GetTextFilter(key string) (TextFilter, bool) {
+	textFilter, ok := c[key].(TextFilter)
+	return textFilter, ok
+}
+
+// validate проверяет формат границ и их порядок
+func (f DateRangeFilter) validate() error {
+	var from, to time.Time
+	var err error
+	if f.From != "" {
+		if from, err = time.Parse(DateLayout, f.From); err != nil {
+			return fmt.Errorf("некорректная дата '%s'", f.From)
+		}
+	}
+	if f.To != "" {
+		if to, err = time.Parse(DateLayout, f.To); err != nil {
+			return fmt.Errorf("некорректная дата '%s'", f.To)
+		}
+	}
+	if f.From != "" && f.To != "" && to.Before(from) {
+		return fmt.Errorf("конечная дата не может быть раньше начальной")
+	}
+	return nil
+}
+
 func (c *Filters) UnmarshalJSON(data []byte) error {
 	// Инициализируем map, если он nil
 	if *c == nil {
@@ -293,9 +358,9 @@ func (c *Filters) UnmarshalJSON(data []byte) error {
 	}
 
 	// Обрабатываем фильтры
+	registry := Characteristics()
 	for _, filter := range filters {
-		switch filter.Role {
-		case CHAR_PRICE:
+		if filter.Role == CHAR_PRICE {
 			// Пробуем разобрать как объект PriceFilter
 			var priceFilter PriceFilter
 			if err := json.Unmarshal(filter.Param, &priceFilter); err == nil {
@@ -311,7 +376,16 @@ func (c *Filters) UnmarshalJSON(data []byte) error {
 
 			// Создаем фильтр цены с одинаковыми min и max
 			(*c)[filter.Role] = PriceFilter{Min: int(price), Max: int(price)}
-		case CHAR_COLOR:
+			continue
+		}
+
+		def, ok := registry.Get(filter.Role)
+		if !ok || !def.Filterable() {
+			continue
+		}
+
+		switch def.Kind {
+		case KindColor:
 			// Пробуем разобрать как объект ColorFilter
 			var colorFilter ColorFilter
 			if err := json.Unmarshal(filter.Param, &colorFilter); err == nil {
@@ -334,7 +408,7 @@ func (c *Filters) UnmarshalJSON(data []byte) error {
 
 			// Преобразуем строку в массив из одного элемента
 			(*c)[filter.Role] = ColorFilter{Options: []string{str}}
-		case CHAR_BRAND, CHAR_CONDITION, CHAR_SEASON:
+		case KindDropdown, KindMultiSelect:
 			// Пробуем разобрать как массив строк
 			var strArray []string
 			if err := json.Unmarshal(filter.Param, &strArray); err == nil {
@@ -350,18 +424,39 @@ func (c *Filters) UnmarshalJSON(data []byte) error {
 
 			// Преобразуем строку в массив из одного элемента
 			(*c)[filter.Role] = DropdownFilter([]string{str})
-		case CHAR_STOCKED:
+		case KindCheckbox:
 			var checkboxFilter CheckboxFilter
 			if err := json.Unmarshal(filter.Param, &checkboxFilter); err != nil {
 				return fmt.Errorf("failed to parse checkbox filter: %v", err)
 			}
 			(*c)[filter.Role] = checkboxFilter
-		case CHAR_HEIGHT, CHAR_WIDTH, CHAR_DEPTH, CHAR_WEIGHT, CHAR_AREA, CHAR_VOLUME:
+		case KindAmount:
 			var dimensionFilter DimensionFilter
 			if err := json.Unmarshal(filter.Param, &dimensionFilter); err != nil {
 				return fmt.Errorf("failed to parse dimension filter: %v", err)
 			}
 			(*c)[filter.Role] = dimensionFilter
+		case KindInteger:
+			var intFilter IntegerRangeFilter
+			if err := json.Unmarshal(filter.Param, &intFilter); err != nil {
+				return fmt.Errorf("failed to parse integer filter: %v", err)
+			}
+			(*c)[filter.Role] = intFilter
+		case KindDate:
+			var dateFilter DateRangeFilter
+			if err := json.Unmarshal(filter.Param, &dateFilter); err != nil {
+				return fmt.Errorf("failed to parse date filter: %v", err)
+			}
+			if err := dateFilter.validate(); err != nil {
+				return fmt.Errorf("failed to parse date filter: %v", err)
+			}
+			(*c)[filter.Role] = dateFilter
+		case KindText:
+			var text string
+			if err := json.Unmarshal(filter.Param, &text); err != nil {
+				return fmt.Errorf("failed to parse text filter: %v", err)
+			}
+			(*c)[filter.Role] = TextFilter(text)
 		}
 	}
 
@@ -496,6 +591,7 @@ func (fp FilterParams) ToFilters() (Filters, error) {
 	filters := make(Filters)
 
 	// Обрабатываем каждый элемент фильтра
+	registry := Characteristics()
 	for _, filter := range fp {
 		// Используем значение из Value, если оно есть, иначе из Param
 		value := filter.Value
@@ -505,48 +601,23 @@ func (fp FilterParams) ToFilters() (Filters, error) {
 			continue
 		}
 
-		switch filter.Role {
-		case CHAR_PRICE:
-			// Обрабатываем фильтр цены
-			priceMap, ok := value.(map[string]interface{})
-			if ok {
-				// Создаем объект PriceFilter
-				priceFilter := PriceFilter{}
-
-				// Получаем значения min и max
-				if min, ok := priceMap["min"]; ok {
-					switch v := min.(type) {
-					case float64:
-						priceFilter.Min = int(v)
-					case int:
-						priceFilter.Min = v
-					}
-				}
-
-				if max, ok := priceMap["max"]; ok {
-					switch v := max.(type) {
-					case float64:
-						priceFilter.Max = int(v)
-					case int:
-						priceFilter.Max = v
-					}
-				}
-
-				filters[filter.Role] = priceFilter
-			} else {
-				// Если значение не объект, пробуем обработать как число
-				switch v := filter.Value.(type) {
-				case float64:
-					price := int(v)
-					filters[filter.Role] = PriceFilter{Min: price, Max: price}
-				case int:
-					filters[filter.Role] = PriceFilter{Min: v, Max: v}
-				default:
-					return nil, fmt.Errorf("invalid price filter value: %v", filter.Value)
-				}
+		// Цена не характеристика: ее фильтр есть в любой категории
+		if filter.Role == CHAR_PRICE {
+			priceFilter, err := priceFilterFromValue(value)
+			if err != nil {
+				return nil, err
 			}
+			filters[filter.Role] = priceFilter
+			continue
+		}
+
+		def, ok := registry.Get(filter.Role)
+		if !ok || !def.Filterable() {
+			continue
+		}
 
-		case CHAR_COLOR:
+		switch def.Kind {
+		case KindColor:
 			// Обрабатываем фильтр цвета
 			switch v := value.(type) {
 			case []interface{}:
@@ -567,8 +638,8 @@ func (fp FilterParams) ToFilters() (Filters, error) {
 				return nil, fmt.Errorf("invalid color filter value: %v", filter.Value)
 			}
 
-		case CHAR_BRAND, CHAR_CONDITION, CHAR_SEASON:
-			// Обрабатываем фильтры выпадающих списков
+		case KindDropdown, KindMultiSelect:
+			// Обрабатываем фильтры выпадающих списков и множественного выбора
 			switch v := value.(type) {
 			case []interface{}:
 				// Преобразуем массив интерфейсов в массив строк
@@ -588,7 +659,7 @@ func (fp FilterParams) ToFilters() (Filters, error) {
 				return nil, fmt.Errorf("invalid dropdown filter value: %v", filter.Value)
 			}
 
-		case CHAR_STOCKED:
+		case KindCheckbox:
 			// Обрабатываем фильтр чекбокса
 			switch v := value.(type) {
 			case bool:
@@ -598,7 +669,7 @@ func (fp FilterParams) ToFilters() (Filters, error) {
 				return nil, fmt.Errorf("invalid checkbox filter value: %v", filter.Value)
 			}
 
-		case CHAR_HEIGHT, CHAR_WIDTH, CHAR_DEPTH, CHAR_WEIGHT, CHAR_AREA, CHAR_VOLUME:
+		case KindAmount:
 			// Обрабатываем фильтры размеров
 			dimMap, ok := value.(map[string]interface{})
 			if !ok {
@@ -634,12 +705,106 @@ func (fp FilterParams) ToFilters() (Filters, error) {
 			}
 
 			filters[filter.Role] = dimFilter
+
+		case KindInteger:
+			intMap, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("invalid integer filter value: %v", filter.Value)
+			}
+			intFilter := IntegerRangeFilter{}
+			var err error
+			if intFilter.Min, err = integerBound(intMap["min"]); err != nil {
+				return nil, err
+			}
+			if intFilter.Max, err = integerBound(intMap["max"]); err != nil {
+				return nil, err
+			}
+			filters[filter.Role] = intFilter
+
+		case KindDate:
+			dateMap, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("invalid date filter value: %v", filter.Value)
+			}
+			dateFilter := DateRangeFilter{}
+			dateFilter.From, _ = dateMap["from"].(string)
+			dateFilter.To, _ = dateMap["to"].(string)
+			if err := dateFilter.validate(); err != nil {
+				return nil, fmt.Errorf("invalid date filter value: %v", err)
+			}
+			filters[filter.Role] = dateFilter
+
+		case KindText:
+			text, ok := value.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid text filter value: %v", filter.Value)
+			}
+			filters[filter.Role] = TextFilter(text)
 		}
 	}
 
 	return filters, nil
 }
 
+// integerBound разбирает границу целого диапазона; отсутствующая граница дает nil
+func integerBound(value interface{}) (*int64, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case float64:
+		if v != math.Trunc(v) {
+			return nil, fmt.Errorf("invalid integer filter bound: %v", v)
+		}
+		bound := int64(v)
+		return &bound, nil
+	case int:
+		bound := int64(v)
+		return &bound, nil
+	default:
+		return nil, fmt.Errorf("invalid integer filter bound: %v", value)
+	}
+}
+
+// priceFilterFromValue разбирает фильтр цены из объекта {min, max} или из точной цены
+func priceFilterFromValue(value interface{}) (PriceFilter, error) {
+	priceMap, ok := value.(map[string]interface{})
+	if !ok {
+		// Если значение не объект, пробуем обработать как число
+		switch v := value.(type) {
+		case float64:
+			price := int(v)
+			return PriceFilter{Min: price, Max: price}, nil
+		case int:
+			return PriceFilter{Min: v, Max: v}, nil
+		default:
+			return PriceFilter{}, fmt.Errorf("invalid price filter value: %v", value)
+		}
+	}
+
+	priceFilter := PriceFilter{}
+
+	// Получаем значения min и max
+	if min, ok := priceMap["min"]; ok {
+		switch v := min.(type) {
+		case float64:
+			priceFilter.Min = int(v)
+		case int:
+			priceFilter.Min = v
+		}
+	}
+
+	if max, ok := priceMap["max"]; ok {
+		switch v := max.(type) {
+		case float64:
+			priceFilter.Max = int(v)
+		case int:
+			priceFilter.Max = v
+		}
+	}
+
+	return priceFilter, nil
+}
+
 // FromFilters конвертирует Filters в FilterParams
 func FromFilters(filters Filters) FilterParams {
 	// Создаем новый объект FilterParams
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Image представляет метаданные изображения, общие для всех его размеров
+type Image struct {
+	ID uuid.UUID `gorm:"column:id;primaryKey"`
+	// PHash и DHash перцептивные хеши изображения; хранятся как BIGINT, поэтому со знаком
+	PHash int64 `gorm:"column:phash"`
+	DHash int64 `gorm:"column:dhash"`
+	// IsNSFW изображение помечено модерацией как недопустимое для показа без предупреждения
+	IsNSFW bool `gorm:"column:is_nsfw"`
+	// ModerationReason причина, по которой модерация пометила изображение
+	ModerationReason string `gorm:"column:moderation_reason"`
+	// Width и Height размеры полноразмерного варианта изображения
+	Width       int        `gorm:"column:width"`
+	Height      int        `gorm:"column:height"`
+	ModeratedAt *time.Time `gorm:"column:moderated_at"`
+	CreatedAt   time.Time  `gorm:"column:created_at"`
+	UpdatedAt   time.Time  `gorm:"column:updated_at"`
+}
+
+func (Image) TableName() string {
+	return "images"
+}
+
+// ImageVariants URL вариантов изображения разного размера
+type ImageVariants struct {
+	// Full вариант 400px
+	Full string `json:"full"`
+	// Thumbnail вариант 200px для списков и поиска
+	Thumbnail string `json:"thumbnail"`
+}
+
+// ListingImage изображение объявления с порядком показа, признаком обложки и подписью
+type ListingImage struct {
+	ID       uuid.UUID     `json:"id"`
+	Position int           `json:"position"`
+	IsCover  bool          `json:"is_cover"`
+	Alt      string        `json:"alt"`
+	Width    int           `json:"width"`
+	Height   int           `json:"height"`
+	Variants ImageVariants `json:"variants"`
+}
+
+// ListingImageInput изображение в запросе на создание или обновление объявления.
+// Порядок изображений в запросе задает порядок показа
+type ListingImageInput struct {
+	ID      uuid.UUID `json:"id,omitempty"`
+	URL     string    `json:"url,omitempty"`
+	Alt     string    `json:"alt,omitempty"`
+	IsCover bool      `json:"is_cover,omitempty"`
+}
+
+// UnmarshalJSON принимает как объект, так и строку с URL изображения, как в прежнем формате запроса
+func (i *ListingImageInput) UnmarshalJSON(data []byte) error {
+	var url string
+	if err := json.Unmarshal(data, &url); err == nil {
+		*i = ListingImageInput{URL: url}
+		return nil
+	}
+
+	type plain ListingImageInput
+	var input plain
+	if err := json.Unmarshal(data, &input); err != nil {
+		return err
+	}
+
+	*i = ListingImageInput(input)
+	return nil
+}
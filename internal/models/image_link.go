@@ -6,10 +6,49 @@ import (
 	"github.com/google/uuid"
 )
 
-// ImageLink представляет связь между изображением и объявлением
+// ImageStatus представляет состояние изображения в жизненном цикле
+type ImageStatus string
+
+// Константы состояний изображения
+const (
+	// ImageStatusUploaded изображение загружено, но ещё не привязано к объявлению
+	ImageStatusUploaded ImageStatus = "uploaded"
+	// ImageStatusAttached изображение привязано к объявлению
+	ImageStatusAttached ImageStatus = "attached"
+	// ImageStatusDetached изображение отвязано от объявления и ожидает удаления
+	ImageStatusDetached ImageStatus = "detached"
+	// ImageStatusDeleted файл изображения удалён из хранилища
+	ImageStatusDeleted ImageStatus = "deleted"
+)
+
+// imageStatusTransitions описывает допустимые переходы между состояниями изображения
+var imageStatusTransitions = map[ImageStatus][]ImageStatus{
+	ImageStatusUploaded: {ImageStatusAttached, ImageStatusDeleted},
+	ImageStatusAttached: {ImageStatusDetached},
+	ImageStatusDetached: {ImageStatusAttached, ImageStatusDeleted},
+	ImageStatusDeleted:  {},
+}
+
+// CanTransitionTo проверяет, допустим ли переход из текущего состояния в указанное
+func (s ImageStatus) CanTransitionTo(next ImageStatus) bool {
+	for _, allowed := range imageStatusTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// ImageLink представляет связь между файлом изображения и объявлением
 type ImageLink struct {
-	NameImage  string
-	ListingID uuid.UUID
-	Linked    bool
-	UpdatedAt time.Time
+	ImageID   uuid.UUID   `gorm:"column:image_id"`
+	NameImage string      `gorm:"column:image_name"`
+	ListingID *uuid.UUID  `gorm:"column:listing_id"`
+	Status    ImageStatus `gorm:"column:status"`
+	CreatedAt time.Time   `gorm:"column:created_at"`
+	UpdatedAt time.Time   `gorm:"column:updated_at"`
+}
+
+func (ImageLink) TableName() string {
+	return "image_links"
 }
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImageStatusTransitions(t *testing.T) {
+	t.Run("Допустимые переходы", func(t *testing.T) {
+		assert.True(t, ImageStatusUploaded.CanTransitionTo(ImageStatusAttached))
+		assert.True(t, ImageStatusUploaded.CanTransitionTo(ImageStatusDeleted))
+		assert.True(t, ImageStatusAttached.CanTransitionTo(ImageStatusDetached))
+		assert.True(t, ImageStatusDetached.CanTransitionTo(ImageStatusAttached))
+		assert.True(t, ImageStatusDetached.CanTransitionTo(ImageStatusDeleted))
+	})
+
+	t.Run("Недопустимые переходы", func(t *testing.T) {
+		// Привязанное изображение нельзя удалить, не отвязав его от объявления
+		assert.False(t, ImageStatusAttached.CanTransitionTo(ImageStatusDeleted))
+		// Привязанное изображение нельзя повторно привязать к другому объявлению
+		assert.False(t, ImageStatusAttached.CanTransitionTo(ImageStatusAttached))
+		// Удаленное изображение не может вернуться в жизненный цикл
+		assert.False(t, ImageStatusDeleted.CanTransitionTo(ImageStatusAttached))
+		assert.False(t, ImageStatusDeleted.CanTransitionTo(ImageStatusUploaded))
+	})
+}
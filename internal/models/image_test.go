@@ -0,0 +1,24 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListingImageInputUnmarshalJSON(t *testing.T) {
+	id := uuid.New()
+
+	var inputs []ListingImageInput
+	data := `["http://127.0.0.1:8080/api/v1/images/get/a-400px.webp", {"id": "` + id.String() + `", "alt": "Вид сбоку", "is_cover": true}]`
+	require.NoError(t, json.Unmarshal([]byte(data), &inputs))
+
+	require.Len(t, inputs, 2)
+	assert.Equal(t, ListingImageInput{URL: "http://127.0.0.1:8080/api/v1/images/get/a-400px.webp"}, inputs[0])
+	assert.Equal(t, ListingImageInput{ID: id, Alt: "Вид сбоку", IsCover: true}, inputs[1])
+
+	assert.Error(t, json.Unmarshal([]byte(`[42]`), &inputs))
+}
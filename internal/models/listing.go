@@ -10,11 +10,24 @@ type Listing struct {
 	ID          uuid.UUID `json:"id" gorm:"primaryKey"`
 	Title       string    `json:"title"`
 	Description string    `json:"description,omitempty" gorm:"column:original_description"`
+	// OriginalLang язык, на котором написаны заголовок и описание
+	OriginalLang Localization `json:"original_lang,omitempty" gorm:"column:original_lang"`
 
 	Price      float64   `json:"price,omitempty"`
 	Currency   Currency  `json:"currency,omitempty"`
 	ViewsCount int       `json:"views_count,omitempty"`
-	Images     []string  `json:"images,omitempty"`
+	IsNSFW     bool      `json:"is_nsfw,omitempty" gorm:"column:is_nsfw"`
+
+	Status ListingStatus `json:"status"`
+	// StatusChangedAt момент последней смены состояния; по нему упорядочена очередь модерации
+	StatusChangedAt time.Time  `json:"status_changed_at"`
+	PublishedAt     *time.Time `json:"published_at,omitempty"`
+	// ExpiresAt момент, после которого опубликованное объявление снимается с публикации
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// ModerationReason причина последнего отклонения модератором
+	ModerationReason string `json:"moderation_reason,omitempty"`
+	// Version увеличивается при каждом изменении; по ней обнаруживаются конкурирующие записи
+	Version int `json:"version"`
 
 	CreatedAt time.Time  `json:"created_at"`
 	UpdatedAt time.Time  `json:"updated_at"`
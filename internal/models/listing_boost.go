@@ -1,14 +1,22 @@
 package models
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 )
 
-// ListingBoost представляет буст объявления в базе данных
+// ListingBoost представляет буст объявления в базе данных.
+// EndsAt равен nil у бустов без срока, купленных до появления окон действия
 type Boost struct {
-	ListingID  uuid.UUID `json:"listing_id"`
-	Type  BoostType `json:"type"`
-	Commission float64   `json:"commission"`
+	ID         uuid.UUID   `json:"id"`
+	ListingID  uuid.UUID   `json:"listing_id"`
+	Type       BoostType   `json:"type" gorm:"column:boost_type"`
+	Commission float64     `json:"commission"`
+	StartsAt   time.Time   `json:"starts_at"`
+	EndsAt     *time.Time  `json:"ends_at,omitempty"`
+	Status     BoostStatus `json:"status"`
+	CreatedAt  time.Time   `json:"created_at"`
 }
 
 // BoostType представляет типы бустов объявлений
@@ -16,21 +24,64 @@ type BoostType string
 
 // Константы для типов бустов
 const (
-	BoostTypeBase   BoostType = "base"
+	BoostTypeBase      BoostType = "base"
 	BoostTypeHighlight BoostType = "highlight"
-	BoostTypeUpfront BoostType = "upfront"
+	BoostTypeUpfront   BoostType = "upfront"
 )
 
-// GetBoostTypesWithCommissions возвращает список всех доступных типов бустов с комиссиями
-func GetBoostTypesWithCommissions() map[BoostType]float64 {
-	return map[BoostType]float64{
-		BoostTypeBase:   0.02,
-		BoostTypeHighlight: 0.07,
-		BoostTypeUpfront: 0.12,
-	}
-}
-
 // String возвращает строковое представление типа буста
 func (bt BoostType) String() string {
 	return string(bt)
 }
+
+// BoostStatus представляет состояние буста относительно его окна действия
+type BoostStatus string
+
+const (
+	// BoostStatusScheduled буст куплен, но окно действия еще не началось
+	BoostStatusScheduled BoostStatus = "scheduled"
+	// BoostStatusActive буст действует
+	BoostStatusActive BoostStatus = "active"
+	// BoostStatusExpired окно действия буста закончилось или буст был остановлен
+	BoostStatusExpired BoostStatus = "expired"
+	// BoostStatusCancelled запланированный буст отменен до начала действия
+	BoostStatusCancelled BoostStatus = "cancelled"
+)
+
+// BoostPurchase запись о покупке буста: одна на каждую активацию
+type BoostPurchase struct {
+	ID                uuid.UUID `json:"id"`
+	BoostID           uuid.UUID `json:"boost_id"`
+	ListingID         uuid.UUID `json:"listing_id"`
+	Type              BoostType `json:"type"`
+	CommissionPercent float64   `json:"commission_percent"`
+	ListingPrice      float64   `json:"listing_price"`
+	CommissionAmount  float64   `json:"commission_amount"`
+	Currency          Currency  `json:"currency"`
+	// TariffID тариф каталога, по которому посчитана комиссия
+	TariffID  *uuid.UUID `json:"tariff_id,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// LedgerEntryType тип записи в журнале комиссий
+type LedgerEntryType string
+
+const (
+	// LedgerEntryAccrual комиссия начислена при покупке буста
+	LedgerEntryAccrual LedgerEntryType = "accrual"
+	// LedgerEntryCharge комиссия списана при активации буста
+	LedgerEntryCharge LedgerEntryType = "charge"
+	// LedgerEntryReversal начисление отменено вместе с запланированным бустом
+	LedgerEntryReversal LedgerEntryType = "reversal"
+)
+
+// LedgerEntry запись журнала комиссий объявления
+type LedgerEntry struct {
+	ID         uuid.UUID       `json:"id"`
+	ListingID  uuid.UUID       `json:"listing_id"`
+	PurchaseID uuid.UUID       `json:"purchase_id"`
+	Type       LedgerEntryType `json:"type"`
+	Amount     float64         `json:"amount"`
+	Currency   Currency        `json:"currency"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
@@ -7,6 +7,15 @@ type ListingResult struct {
 	Boosts          []Boost                      `json:"boosts,omitempty"`
 	Characteristics map[string]interface{}       `json:"characteristics,omitempty"`
 	Location        Location                     `json:"location,omitempty"`
+	CoverThumbnail  string                       `json:"cover_thumbnail,omitempty"`
+	// RankScore ранг объявления в выдаче по релевантности, из него строится курсор
+	RankScore float64 `json:"rank_score,omitempty"`
+	// IsPromoted объявление занимает продвигаемое место на странице
+	IsPromoted bool `json:"is_promoted,omitempty"`
+	// Distance расстояние от покупателя до объявления в метрах
+	Distance *float64 `json:"distance,omitempty"`
+	// PreviousPrice более высокая цена объявления из истории цен, если оно подешевело
+	PreviousPrice *float64 `json:"previous_price,omitempty"`
 }
 
 // NewListingResult создает новый экземпляр ListingResult
@@ -34,6 +43,11 @@ func (r *ListingResult) SetCharacteristics(characteristics map[string]interface{
 	r.Characteristics = characteristics
 }
 
+// SetCoverThumbnail устанавливает миниатюру обложки объявления
+func (r *ListingResult) SetCoverThumbnail(coverThumbnail string) {
+	r.CoverThumbnail = coverThumbnail
+}
+
 // SetLocation устанавливает местоположение для объявления
 func (r *ListingResult) SetLocation(location Location) {
 	r.Location = location
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"encoding/json"
+	"reflect"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PriceDropWindow период истории цен, в котором ищется более высокая цена объявления
+const PriceDropWindow = 30 * 24 * time.Hour
+
+// ListingSnapshot редактируемое содержимое объявления на момент ревизии
+type ListingSnapshot struct {
+	Title           string                 `json:"title"`
+	Description     string                 `json:"description"`
+	Price           float64                `json:"price"`
+	Currency        Currency               `json:"currency"`
+	Categories      []string               `json:"categories"`
+	Location        *Location              `json:"location,omitempty"`
+	Characteristics map[string]interface{} `json:"characteristics,omitempty"`
+}
+
+// FieldChange значение поля до и после ревизии; отсутствующее значение равно nil
+type FieldChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// ListingRevision запись истории изменений объявления. История только дополняется:
+// восстановление старой ревизии создает новую
+type ListingRevision struct {
+	ID        uuid.UUID `json:"id"`
+	ListingID uuid.UUID `json:"listing_id"`
+	// Revision номер ревизии внутри объявления, начиная с 1
+	Revision int    `json:"revision"`
+	Actor    string `json:"actor"`
+	// Snapshot состояние объявления после изменения
+	Snapshot ListingSnapshot `json:"snapshot"`
+	// Changes измененные поля; характеристики сравниваются по отдельности как characteristics.<роль>
+	Changes map[string]FieldChange `json:"changes"`
+	// RestoredFrom номер ревизии, из которой восстановлено объявление
+	RestoredFrom *int      `json:"restored_from,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// DiffSnapshots возвращает поля, которые отличаются в next по сравнению с prev
+func DiffSnapshots(prev, next ListingSnapshot) map[string]FieldChange {
+	changes := map[string]FieldChange{}
+
+	add := func(field string, old, new interface{}) {
+		old, new = normalizeValue(old), normalizeValue(new)
+		if !reflect.DeepEqual(old, new) {
+			changes[field] = FieldChange{Old: old, New: new}
+		}
+	}
+
+	add("title", prev.Title, next.Title)
+	add("description", prev.Description, next.Description)
+	add("price", prev.Price, next.Price)
+	add("currency", prev.Currency, next.Currency)
+	add("categories", prev.Categories, next.Categories)
+	add("location", prev.Location, next.Location)
+
+	for role, value := range prev.Characteristics {
+		add("characteristics."+role, value, next.Characteristics[role])
+	}
+	for role, value := range next.Characteristics {
+		if _, ok := prev.Characteristics[role]; !ok {
+			add("characteristics."+role, nil, value)
+		}
+	}
+
+	return changes
+}
+
+// normalizeValue приводит значение к виду, в котором оно хранится в JSONB, чтобы структуры
+// из запроса и карты из базы сравнивались одинаково. Пустые списки равны отсутствующим
+func normalizeValue(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+
+	var normalized interface{}
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return v
+	}
+
+	if list, ok := normalized.([]interface{}); ok && len(list) == 0 {
+		return nil
+	}
+	return normalized
+}
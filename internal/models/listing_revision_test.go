@@ -0,0 +1,52 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffSnapshots(t *testing.T) {
+	prev := ListingSnapshot{
+		Title:      "Велосипед",
+		Price:      1000,
+		Currency:   RUB,
+		Categories: []string{"sport"},
+		Characteristics: map[string]interface{}{
+			CHAR_STOCKED: map[string]interface{}{"checkbox_value": true},
+			"color":      "red",
+		},
+	}
+
+	t.Run("Без изменений", func(t *testing.T) {
+		next := prev
+		// Характеристики из запроса приходят структурами, из базы — картами
+		next.Characteristics = map[string]interface{}{
+			CHAR_STOCKED: CheckboxValue{CheckboxValue: true},
+			"color":      "red",
+		}
+		assert.Empty(t, DiffSnapshots(prev, next))
+	})
+
+	t.Run("Измененные поля", func(t *testing.T) {
+		next := prev
+		next.Price = 800
+		next.Categories = []string{"sport", "bicycles"}
+		next.Characteristics = map[string]interface{}{
+			CHAR_STOCKED: map[string]interface{}{"checkbox_value": true},
+			"size":       "L",
+		}
+
+		changes := DiffSnapshots(prev, next)
+		assert.Equal(t, FieldChange{Old: 1000.0, New: 800.0}, changes["price"])
+		assert.Contains(t, changes, "categories")
+		assert.Equal(t, FieldChange{Old: "red", New: nil}, changes["characteristics.color"])
+		assert.Equal(t, FieldChange{Old: nil, New: "L"}, changes["characteristics.size"])
+		assert.NotContains(t, changes, "title")
+		assert.NotContains(t, changes, "characteristics."+CHAR_STOCKED)
+	})
+
+	t.Run("Пустой список категорий равен отсутствующему", func(t *testing.T) {
+		assert.Empty(t, DiffSnapshots(ListingSnapshot{}, ListingSnapshot{Categories: []string{}}))
+	})
+}
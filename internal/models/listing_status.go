@@ -0,0 +1,53 @@
+package models
+
+// ListingStatus состояние объявления в жизненном цикле
+type ListingStatus string
+
+const (
+	// ListingStatusDraft черновик, виден только продавцу; сюда же возвращается отклоненное объявление
+	ListingStatusDraft ListingStatus = "draft"
+	// ListingStatusPendingReview объявление ждет решения модератора
+	ListingStatusPendingReview ListingStatus = "pending_review"
+	// ListingStatusPublished объявление опубликовано и участвует в поиске
+	ListingStatusPublished ListingStatus = "published"
+	// ListingStatusPaused продавец временно снял объявление с публикации
+	ListingStatusPaused ListingStatus = "paused"
+	// ListingStatusSold товар продан
+	ListingStatusSold ListingStatus = "sold"
+	// ListingStatusExpired срок публикации истек, объявление можно продлить
+	ListingStatusExpired ListingStatus = "expired"
+)
+
+// listingStatusTransitions описывает допустимые переходы между состояниями объявления
+var listingStatusTransitions = map[ListingStatus][]ListingStatus{
+	ListingStatusDraft:         {ListingStatusPendingReview, ListingStatusPublished},
+	ListingStatusPendingReview: {ListingStatusPublished, ListingStatusDraft},
+	ListingStatusPublished:     {ListingStatusPaused, ListingStatusSold, ListingStatusExpired},
+	ListingStatusPaused:        {ListingStatusPublished, ListingStatusSold},
+	ListingStatusExpired:       {ListingStatusPublished, ListingStatusSold},
+	ListingStatusSold:          {},
+}
+
+// CanTransitionTo проверяет, допустим ли переход из текущего состояния в указанное
+func (s ListingStatus) CanTransitionTo(next ListingStatus) bool {
+	for _, allowed := range listingStatusTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValid проверяет, что состояние известно
+func (s ListingStatus) IsValid() bool {
+	_, ok := listingStatusTransitions[s]
+	return ok
+}
+
+// ModerationDecision решение модератора по объявлению
+type ModerationDecision string
+
+const (
+	ModerationApproved ModerationDecision = "approved"
+	ModerationRejected ModerationDecision = "rejected"
+)
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListingStatus(t *testing.T) {
+	t.Run("Допустимые переходы", func(t *testing.T) {
+		assert.True(t, ListingStatusDraft.CanTransitionTo(ListingStatusPendingReview))
+		assert.True(t, ListingStatusPendingReview.CanTransitionTo(ListingStatusPublished))
+		assert.True(t, ListingStatusPendingReview.CanTransitionTo(ListingStatusDraft), "отклоненное объявление возвращается в черновики")
+		assert.True(t, ListingStatusPublished.CanTransitionTo(ListingStatusPaused))
+		assert.True(t, ListingStatusPaused.CanTransitionTo(ListingStatusPublished))
+		assert.True(t, ListingStatusPublished.CanTransitionTo(ListingStatusExpired))
+		assert.True(t, ListingStatusExpired.CanTransitionTo(ListingStatusPublished))
+		assert.True(t, ListingStatusPaused.CanTransitionTo(ListingStatusSold))
+	})
+
+	t.Run("Недопустимые переходы", func(t *testing.T) {
+		assert.False(t, ListingStatusPendingReview.CanTransitionTo(ListingStatusPaused))
+		assert.False(t, ListingStatusDraft.CanTransitionTo(ListingStatusSold), "черновик нельзя продать")
+		assert.False(t, ListingStatusSold.CanTransitionTo(ListingStatusPublished))
+		assert.False(t, ListingStatusPaused.CanTransitionTo(ListingStatusExpired), "срок снятого объявления не истекает")
+		assert.False(t, ListingStatus("unknown").CanTransitionTo(ListingStatusPublished))
+	})
+
+	assert.True(t, ListingStatusSold.IsValid())
+	assert.False(t, ListingStatus("archived").IsValid())
+}
@@ -1,22 +1,98 @@
 package models
 
 import (
+	"math"
+
 	"github.com/google/uuid"
 )
 
+// earthRadius средний радиус Земли в метрах
+const earthRadius = 6371008.8
+
 type Location struct {
 	ID        string    `json:"id" validate:"required,not_blank"`
 	ListingID uuid.UUID `json:"-"`
 	Name      string    `json:"name" validate:"required,not_blank"`
 	Area      Area      `json:"area" validate:"required"`
+	// Address структурированный адрес на языке, в котором локация была найдена геокодером
+	Address *Address `json:"address,omitempty"`
+}
+
+// HasCoordinates сообщает, что у локации заданы координаты
+func (l Location) HasCoordinates() bool {
+	return l.Area.Coordinates.Lat != 0 || l.Area.Coordinates.Lng != 0
 }
 
 type Area struct {
 	Coordinates Coordinates `json:"coordinates" validate:"required"`
 	Radius int `json:"radius" validate:"required,min=1"`
+	// ServiceArea продавец обслуживает весь круг радиуса Radius: объявление находят покупатели
+	// внутри круга, даже если его точка дальше радиуса поиска
+	ServiceArea bool `json:"service_area,omitempty"`
 }
 
 type Coordinates struct {
 	Lat float64 `json:"lat" validate:"required,min=-90,max=90"`
 	Lng float64 `json:"lng" validate:"required,min=-180,max=180"`
 }
+
+// DistanceTo расстояние до точки по поверхности Земли в метрах
+func (c Coordinates) DistanceTo(other Coordinates) float64 {
+	lat1 := c.Lat * math.Pi / 180
+	lat2 := other.Lat * math.Pi / 180
+	dLat := (other.Lat - c.Lat) * math.Pi / 180
+	dLng := (other.Lng - c.Lng) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+
+	return 2 * earthRadius * math.Asin(math.Sqrt(h))
+}
+
+// Address компоненты адреса, полученные от геокодера
+type Address struct {
+	HouseNumber string `json:"house_number,omitempty"`
+	Road        string `json:"road,omitempty"`
+	Suburb      string `json:"suburb,omitempty"`
+	City        string `json:"city,omitempty"`
+	State       string `json:"state,omitempty"`
+	Postcode    string `json:"postcode,omitempty"`
+	Country     string `json:"country,omitempty"`
+	// CountryCode код страны ISO 3166-1 alpha-2 в нижнем регистре
+	CountryCode string `json:"country_code,omitempty"`
+}
+
+// BoundingBox прямоугольник, описывающий найденное место или видимую область карты
+type BoundingBox struct {
+	South float64 `json:"south"`
+	North float64 `json:"north"`
+	West  float64 `json:"west"`
+	East  float64 `json:"east"`
+}
+
+// IsValid проверяет границы прямоугольника. West больше East,
+// если прямоугольник пересекает антимеридиан
+func (b BoundingBox) IsValid() bool {
+	if b.South < -90 || b.North > 90 || b.South >= b.North {
+		return false
+	}
+	if b.West < -180 || b.West > 180 || b.East < -180 || b.East > 180 {
+		return false
+	}
+	return b.West != b.East
+}
+
+// CrossesAntimeridian сообщает, что прямоугольник пересекает меридиан 180°
+func (b BoundingBox) CrossesAntimeridian() bool {
+	return b.West > b.East
+}
+
+// MapCluster группа объявлений в одной ячейке сетки карты
+type MapCluster struct {
+	Count int
+	// Centroid центр масс точек кластера
+	Centroid Coordinates
+	// Bounds прямоугольник, охватывающий точки кластера
+	Bounds BoundingBox
+	// ListingID объявление кластера из одной точки
+	ListingID *uuid.UUID
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoordinatesDistanceTo(t *testing.T) {
+	moscow := Coordinates{Lat: 55.7558, Lng: 37.6173}
+	petersburg := Coordinates{Lat: 59.9343, Lng: 30.3351}
+
+	assert.InDelta(t, 634000, moscow.DistanceTo(petersburg), 2000)
+	assert.InDelta(t, moscow.DistanceTo(petersburg), petersburg.DistanceTo(moscow), 1e-6)
+	assert.Zero(t, moscow.DistanceTo(moscow))
+}
+
+func TestLocationHasCoordinates(t *testing.T) {
+	assert.False(t, Location{}.HasCoordinates())
+	// Точки на экваторе и нулевом меридиане тоже считаются заданными
+	assert.True(t, Location{Area: Area{Coordinates: Coordinates{Lat: 0, Lng: 37.6}}}.HasCoordinates())
+}
+
+func TestBoundingBoxIsValid(t *testing.T) {
+	assert.True(t, BoundingBox{South: 55.5, North: 56, West: 37.3, East: 37.9}.IsValid())
+	assert.False(t, BoundingBox{South: 56, North: 55.5, West: 37.3, East: 37.9}.IsValid(), "юг севернее севера")
+	assert.False(t, BoundingBox{South: -91, North: 10, West: 0, East: 10}.IsValid())
+	assert.False(t, BoundingBox{South: 0, North: 10, West: 5, East: 5}.IsValid())
+
+	// Область вокруг антимеридиана: запад больше востока
+	chukotka := BoundingBox{South: 60, North: 70, West: 170, East: -170}
+	assert.True(t, chukotka.IsValid())
+	assert.True(t, chukotka.CrossesAntimeridian())
+}
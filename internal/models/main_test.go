@@ -0,0 +1,14 @@
+package models
+
+import (
+	"os"
+	"testing"
+
+	"github.com/yaroslavvasilenko/argon/config"
+)
+
+// TestMain загружает конфигурацию: разбор характеристик и фильтров опирается на реестр характеристик
+func TestMain(m *testing.M) {
+	config.LoadConfig()
+	os.Exit(m.Run())
+}
@@ -0,0 +1,100 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OrderStatus представляет состояние заказа
+type OrderStatus string
+
+const (
+	// OrderStatusPending заказ создан, товар зарезервирован, ожидается оплата
+	OrderStatusPending OrderStatus = "pending"
+	// OrderStatusPaid заказ оплачен
+	OrderStatusPaid OrderStatus = "paid"
+	// OrderStatusShipped продавец отправил товар
+	OrderStatusShipped OrderStatus = "shipped"
+	// OrderStatusCompleted покупатель получил товар
+	OrderStatusCompleted OrderStatus = "completed"
+	// OrderStatusCancelled заказ отменен, резерв снят, оплата возвращена
+	OrderStatusCancelled OrderStatus = "cancelled"
+)
+
+// orderStatusTransitions описывает допустимые переходы между состояниями заказа
+var orderStatusTransitions = map[OrderStatus][]OrderStatus{
+	OrderStatusPending:   {OrderStatusPaid, OrderStatusCancelled},
+	OrderStatusPaid:      {OrderStatusShipped, OrderStatusCancelled},
+	OrderStatusShipped:   {OrderStatusCompleted},
+	OrderStatusCompleted: {},
+	OrderStatusCancelled: {},
+}
+
+// CanTransitionTo проверяет, допустим ли переход из текущего состояния в указанное
+func (s OrderStatus) CanTransitionTo(next OrderStatus) bool {
+	for _, allowed := range orderStatusTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// IsOpen сообщает, что заказ еще не завершен и удерживает резерв товара
+func (s OrderStatus) IsOpen() bool {
+	return s == OrderStatusPending || s == OrderStatusPaid || s == OrderStatusShipped
+}
+
+// Order заказ на покупку объявления
+type Order struct {
+	ID        uuid.UUID `json:"id"`
+	ListingID uuid.UUID `json:"listing_id"`
+	// Buyer покупатель, оформивший заказ
+	Buyer  Actor       `json:"buyer"`
+	Status OrderStatus `json:"status"`
+	// Amount и Currency цена объявления на момент заказа
+	Amount   float64  `json:"amount"`
+	Currency Currency `json:"currency"`
+	// CommissionPercent суммарная комиссия действующих бустов объявления
+	CommissionPercent float64 `json:"commission_percent"`
+	CommissionAmount  float64 `json:"commission_amount"`
+	// PaymentID идентификатор платежа у платежного провайдера
+	PaymentID    string     `json:"payment_id"`
+	CancelReason string     `json:"cancel_reason,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	PaidAt       *time.Time `json:"paid_at,omitempty"`
+	ShippedAt    *time.Time `json:"shipped_at,omitempty"`
+	CompletedAt  *time.Time `json:"completed_at,omitempty"`
+	CancelledAt  *time.Time `json:"cancelled_at,omitempty"`
+}
+
+// IsStocked проверяет характеристику stocked: товар есть в наличии
+func IsStocked(characteristics map[string]interface{}) bool {
+	switch v := characteristics[CHAR_STOCKED].(type) {
+	case bool:
+		return v
+	case CheckboxValue:
+		return v.CheckboxValue
+	case map[string]interface{}:
+		stocked, _ := v["checkbox_value"].(bool)
+		return stocked
+	}
+	return false
+}
+
+// IsBuyable проверяет, что объявление можно купить через заказ:
+// у него действует буст upfront и товар есть в наличии
+func IsBuyable(boosts []Boost, characteristics map[string]interface{}) bool {
+	if !IsStocked(characteristics) {
+		return false
+	}
+
+	for _, boost := range boosts {
+		if boost.Type == BoostTypeUpfront && boost.Status == BoostStatusActive {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderStatus(t *testing.T) {
+	t.Run("Допустимые переходы", func(t *testing.T) {
+		assert.True(t, OrderStatusPending.CanTransitionTo(OrderStatusPaid))
+		assert.True(t, OrderStatusPending.CanTransitionTo(OrderStatusCancelled))
+		assert.True(t, OrderStatusPaid.CanTransitionTo(OrderStatusShipped))
+		assert.True(t, OrderStatusPaid.CanTransitionTo(OrderStatusCancelled))
+		assert.True(t, OrderStatusShipped.CanTransitionTo(OrderStatusCompleted))
+	})
+
+	t.Run("Недопустимые переходы", func(t *testing.T) {
+		assert.False(t, OrderStatusPending.CanTransitionTo(OrderStatusShipped), "нельзя отправить неоплаченный заказ")
+		assert.False(t, OrderStatusShipped.CanTransitionTo(OrderStatusCancelled), "отправленный заказ нельзя отменить")
+		assert.False(t, OrderStatusCompleted.CanTransitionTo(OrderStatusCancelled))
+		assert.False(t, OrderStatusCancelled.CanTransitionTo(OrderStatusPending))
+		assert.False(t, OrderStatus("unknown").CanTransitionTo(OrderStatusPaid))
+	})
+
+	t.Run("Открытые заказы", func(t *testing.T) {
+		assert.True(t, OrderStatusPending.IsOpen())
+		assert.True(t, OrderStatusShipped.IsOpen())
+		assert.False(t, OrderStatusCompleted.IsOpen())
+		assert.False(t, OrderStatusCancelled.IsOpen())
+	})
+}
+
+func TestIsBuyable(t *testing.T) {
+	upfront := []Boost{{Type: BoostTypeUpfront, Status: BoostStatusActive}}
+	stocked := map[string]interface{}{CHAR_STOCKED: CheckboxValue{CheckboxValue: true}}
+
+	assert.True(t, IsBuyable(upfront, stocked))
+	// Характеристики из JSONB приходят в виде map
+	assert.True(t, IsBuyable(upfront, map[string]interface{}{
+		CHAR_STOCKED: map[string]interface{}{"checkbox_value": true},
+	}))
+
+	assert.False(t, IsBuyable(upfront, map[string]interface{}{}), "товара нет в наличии")
+	assert.False(t, IsBuyable(upfront, map[string]interface{}{
+		CHAR_STOCKED: CheckboxValue{CheckboxValue: false},
+	}))
+	assert.False(t, IsBuyable([]Boost{{Type: BoostTypeHighlight, Status: BoostStatusActive}}, stocked), "нужен буст upfront")
+	assert.False(t, IsBuyable([]Boost{{Type: BoostTypeUpfront, Status: BoostStatusScheduled}}, stocked), "буст еще не действует")
+}
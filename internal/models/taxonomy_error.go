@@ -0,0 +1,29 @@
+package models
+
+import (
+	"strings"
+
+	"github.com/yaroslavvasilenko/argon/config"
+)
+
+// InvalidTaxonomyError изменение сделало бы таксономию категорий несогласованной.
+// Отдается клиенту с ответом 422 вместе с замечаниями, действующая таксономия не меняется
+type InvalidTaxonomyError struct {
+	Issues []config.TaxonomyIssue
+}
+
+func NewInvalidTaxonomyError(issues []config.TaxonomyIssue) *InvalidTaxonomyError {
+	return &InvalidTaxonomyError{
+		Issues: issues,
+	}
+}
+
+func (e *InvalidTaxonomyError) Error() string {
+	messages := make([]string, 0, len(e.Issues))
+	for _, issue := range e.Issues {
+		if issue.Level == config.TaxonomyError {
+			messages = append(messages, issue.File+": "+issue.Message)
+		}
+	}
+	return strings.Join(messages, "; ")
+}
@@ -0,0 +1,98 @@
+package models
+
+import "math"
+
+// unitScale связывает единицу измерения с базовой единицей ее величины
+type unitScale struct {
+	Base   Dimension
+	Factor float64
+}
+
+// unitScales множители перевода в базовую единицу: длина в метрах, площадь в квадратных
+// метрах, объем в литрах, масса в килограммах. Таблица повторяется в миграции
+// listing_characteristics_base_value, которая пересчитывает уже сохраненные значения
+var unitScales = map[Dimension]unitScale{
+	CM: {Base: M, Factor: 0.01},
+	M:  {Base: M, Factor: 1},
+	KM: {Base: M, Factor: 1000},
+
+	CM2: {Base: M2, Factor: 0.0001},
+	M2:  {Base: M2, Factor: 1},
+	KM2: {Base: M2, Factor: 1e6},
+
+	ML:  {Base: L, Factor: 0.001},
+	L:   {Base: L, Factor: 1},
+	CM3: {Base: L, Factor: 0.001},
+	M3:  {Base: L, Factor: 1000},
+	KM3: {Base: L, Factor: 1e12},
+
+	G:  {Base: KG, Factor: 0.001},
+	KG: {Base: KG, Factor: 1},
+	T:  {Base: KG, Factor: 1000},
+
+	MA: {Base: A, Factor: 0.001},
+	A:  {Base: A, Factor: 1},
+	W:  {Base: W, Factor: 1},
+	KW: {Base: W, Factor: 1000},
+	OM: {Base: OM, Factor: 1},
+}
+
+// unitPrecision знаков после запятой при переводе между единицами; отсекает хвосты
+// двоичного представления вроде 200.00000000000003
+const unitPrecision = 1e9
+
+// BaseUnit возвращает базовую единицу величины
+func (d Dimension) BaseUnit() (Dimension, bool) {
+	scale, ok := unitScales[d]
+	return scale.Base, ok
+}
+
+// ToBaseUnit переводит значение из единицы unit в базовую единицу ее величины
+func ToBaseUnit(value float64, unit Dimension) (float64, bool) {
+	scale, ok := unitScales[unit]
+	if !ok {
+		return 0, false
+	}
+	return roundUnit(value * scale.Factor), true
+}
+
+// FromBaseUnit переводит значение из базовой единицы в единицу unit
+func FromBaseUnit(value float64, unit Dimension) (float64, bool) {
+	scale, ok := unitScales[unit]
+	if !ok {
+		return 0, false
+	}
+	return roundUnit(value / scale.Factor), true
+}
+
+// ConvertUnit переводит значение между единицами одной величины
+func ConvertUnit(value float64, from, to Dimension) (float64, bool) {
+	fromScale, ok := unitScales[from]
+	if !ok {
+		return 0, false
+	}
+	toScale, ok := unitScales[to]
+	if !ok || fromScale.Base != toScale.Base {
+		return 0, false
+	}
+	return roundUnit(value * fromScale.Factor / toScale.Factor), true
+}
+
+// DimensionFilterFromBase строит фильтр величины в единице unit по границам в базовой единице.
+// Границы округляются наружу, чтобы диапазон покрывал все значения
+func DimensionFilterFromBase(min, max float64, unit Dimension) (DimensionFilter, bool) {
+	unitMin, ok := FromBaseUnit(min, unit)
+	if !ok {
+		return DimensionFilter{}, false
+	}
+	unitMax, _ := FromBaseUnit(max, unit)
+	return DimensionFilter{
+		Min:       int(math.Floor(unitMin)),
+		Max:       int(math.Ceil(unitMax)),
+		Dimension: string(unit),
+	}, true
+}
+
+func roundUnit(value float64) float64 {
+	return math.Round(value*unitPrecision) / unitPrecision
+}
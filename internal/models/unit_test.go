@@ -0,0 +1,51 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnitConversion(t *testing.T) {
+	t.Run("Перевод в базовую единицу", func(t *testing.T) {
+		base, ok := ToBaseUnit(200, CM)
+		require.True(t, ok)
+		assert.Equal(t, 2.0, base)
+
+		base, ok = ToBaseUnit(1500, G)
+		require.True(t, ok)
+		assert.Equal(t, 1.5, base)
+
+		_, ok = ToBaseUnit(1, "inch")
+		assert.False(t, ok)
+	})
+
+	t.Run("Перевод между единицами", func(t *testing.T) {
+		value, ok := ConvertUnit(2, M, CM)
+		require.True(t, ok)
+		assert.Equal(t, 200.0, value)
+
+		value, ok = ConvertUnit(1, M3, ML)
+		require.True(t, ok)
+		assert.Equal(t, 1e6, value)
+
+		_, ok = ConvertUnit(1, M, KG)
+		assert.False(t, ok, "разные величины не переводятся")
+	})
+
+	t.Run("Значение хранит базовую единицу", func(t *testing.T) {
+		amount := Amount{Value: 2, Dimension: M}.Normalized()
+		require.NotNil(t, amount.BaseValue)
+		assert.Equal(t, 2.0, *amount.BaseValue)
+
+		amount = Amount{Value: 2, Dimension: "inch"}.Normalized()
+		assert.Nil(t, amount.BaseValue)
+	})
+
+	t.Run("Диапазон из базовой единицы", func(t *testing.T) {
+		filter, ok := DimensionFilterFromBase(0.145, 0.1601, CM)
+		require.True(t, ok)
+		assert.Equal(t, DimensionFilter{Min: 14, Max: 17, Dimension: "cm"}, filter)
+	})
+}
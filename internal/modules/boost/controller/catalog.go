@@ -0,0 +1,92 @@
+package controller
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/yaroslavvasilenko/argon/internal/models"
+	"github.com/yaroslavvasilenko/argon/internal/modules/boost"
+)
+
+func (b *Boost) GetCatalog(c *fiber.Ctx) error {
+	resp, err := b.s.GetCatalog(c.UserContext())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(resp)
+}
+
+func (b *Boost) UpsertBoostType(c *fiber.Ctx) error {
+	req := boost.UpsertBoostTypeRequest{}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	req.Type = models.BoostType(c.Params("boost_type"))
+
+	resp, err := b.s.UpsertBoostType(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(resp)
+}
+
+func (b *Boost) CreateTariff(c *fiber.Ctx) error {
+	req := boost.TariffRequest{}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	resp, err := b.s.CreateTariff(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(resp)
+}
+
+func (b *Boost) UpdateTariff(c *fiber.Ctx) error {
+	req := boost.TariffRequest{}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	id, err := uuid.Parse(c.Params("tariff_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid tariff ID",
+		})
+	}
+	req.ID = id
+
+	resp, err := b.s.UpdateTariff(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(resp)
+}
+
+func (b *Boost) DeleteTariff(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("tariff_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid tariff ID",
+		})
+	}
+
+	if err := b.s.DeleteTariff(c.UserContext(), id); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -1,6 +1,8 @@
 package controller
 
 import (
+	"errors"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	"github.com/yaroslavvasilenko/argon/internal/modules/boost"
@@ -67,6 +69,12 @@ func (b *Boost) UpdateBoost(c *fiber.Ctx) error {
 
 	boost, err := b.s.UpsertBoost(c.UserContext(), req)
 	if err != nil {
+		var fiberErr *fiber.Error
+		if errors.As(err, &fiberErr) && fiberErr.Code == fiber.StatusBadRequest {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": fiberErr.Message,
+			})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to update boost",
 		})
@@ -10,16 +10,105 @@ type GetBoostRequest struct {
 }
 
 type GetBoostResponse struct {
-	AvailableBoosts []BoostResp `json:"boosts"`
+	AvailableBoosts  []BoostResp        `json:"boosts"`
 	EnableBoostTypes []models.BoostType `json:"enable_boost_types,omitempty"`
+	// Active, Scheduled и Expired бусты объявления по состоянию окна действия
+	Active    []BoostWindowResp `json:"active"`
+	Scheduled []BoostWindowResp `json:"scheduled"`
+	Expired   []BoostWindowResp `json:"expired"`
+	Ledger    LedgerResp        `json:"ledger"`
 }
 
+// BoostResp тип буста, доступный объявлению, с комиссией по действующему тарифу
 type BoostResp struct {
 	Type              models.BoostType `json:"type"`
 	CommissionPercent float64          `json:"commission_percents"`
+	// CommissionAmount комиссия в валюте объявления с учетом минимальной суммы
+	CommissionAmount float64         `json:"commission_amount"`
+	Currency         models.Currency `json:"currency,omitempty"`
+	TariffID         uuid.UUID       `json:"tariff_id"`
+}
+
+// BoostWindowResp купленный буст с окном действия; у бустов без срока EndsAt отсутствует
+type BoostWindowResp struct {
+	ID                uuid.UUID          `json:"id"`
+	Type              models.BoostType   `json:"type"`
+	Status            models.BoostStatus `json:"status"`
+	CommissionPercent float64            `json:"commission_percents"`
+	CommissionAmount  float64            `json:"commission_amount"`
+	Currency          models.Currency    `json:"currency,omitempty"`
+	StartsAt          int64              `json:"starts_at"`
+	EndsAt            *int64             `json:"ends_at,omitempty"`
+}
+
+// LedgerResp журнал комиссий объявления и итоги по валютам
+type LedgerResp struct {
+	Entries  []LedgerEntryResp   `json:"entries"`
+	Balances []LedgerBalanceResp `json:"balances"`
+}
+
+type LedgerEntryResp struct {
+	ID         uuid.UUID              `json:"id"`
+	PurchaseID uuid.UUID              `json:"purchase_id"`
+	Type       models.LedgerEntryType `json:"type"`
+	Amount     float64                `json:"amount"`
+	Currency   models.Currency        `json:"currency"`
+	CreatedAt  int64                  `json:"created_at"`
+}
+
+// LedgerBalanceResp итог по валюте: Owed — начислено, но еще не списано, Charged — списано
+type LedgerBalanceResp struct {
+	Currency models.Currency `json:"currency"`
+	Owed     float64         `json:"owed"`
+	Charged  float64         `json:"charged"`
 }
 
 type UpdateBoostRequest struct {
-	ListingID uuid.UUID `json:"listing_id"`
+	ListingID uuid.UUID          `json:"listing_id"`
 	Boosts    []models.BoostType `json:"enabled_boost_types"`
+	// StartsAt начало окна новых бустов в миллисекундах; по умолчанию — сейчас
+	StartsAt *int64 `json:"starts_at,omitempty"`
+	// DurationDays длительность окна новых бустов в днях
+	DurationDays int `json:"duration_days,omitempty"`
+}
+
+// CatalogResponse каталог бустов со всеми тарифами
+type CatalogResponse struct {
+	Types []CatalogTypeResp `json:"types"`
+}
+
+type CatalogTypeResp struct {
+	Type     models.BoostType `json:"type"`
+	Position int              `json:"position"`
+	IsActive bool             `json:"is_active"`
+	Tariffs  []TariffResp     `json:"tariffs"`
+}
+
+type TariffResp struct {
+	ID                uuid.UUID                   `json:"id"`
+	Type              models.BoostType            `json:"type"`
+	CategoryID        *string                     `json:"category_id,omitempty"`
+	CommissionPercent float64                     `json:"commission_percents"`
+	MinAmounts        map[models.Currency]float64 `json:"min_amounts"`
+	ValidFrom         *int64                      `json:"valid_from,omitempty"`
+	ValidTo           *int64                      `json:"valid_to,omitempty"`
+}
+
+// UpsertBoostTypeRequest создание или изменение типа буста в каталоге
+type UpsertBoostTypeRequest struct {
+	Type     models.BoostType `json:"-"`
+	Position int              `json:"position"`
+	// IsActive по умолчанию true; неактивный тип нельзя купить
+	IsActive *bool `json:"is_active,omitempty"`
+}
+
+// TariffRequest создание или замена тарифа; границы периода действия в миллисекундах
+type TariffRequest struct {
+	ID                uuid.UUID                   `json:"-"`
+	Type              models.BoostType            `json:"type"`
+	CategoryID        *string                     `json:"category_id,omitempty"`
+	CommissionPercent float64                     `json:"commission_percents"`
+	MinAmounts        map[models.Currency]float64 `json:"min_amounts,omitempty"`
+	ValidFrom         *int64                      `json:"valid_from,omitempty"`
+	ValidTo           *int64                      `json:"valid_to,omitempty"`
 }
@@ -0,0 +1,300 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/yaroslavvasilenko/argon/config"
+	"github.com/yaroslavvasilenko/argon/internal/models"
+	"github.com/yaroslavvasilenko/argon/internal/modules/boost"
+)
+
+// boostTypePattern допустимый идентификатор типа буста
+var boostTypePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,31}$`)
+
+// availableBoosts возвращает тарифы, по которым объявление может купить бусты в момент now,
+// а также цену и валюту объявления для расчета комиссии
+func (s *Boost) availableBoosts(ctx context.Context, listingID uuid.UUID, now time.Time) ([]models.BoostTariff, float64, models.Currency, error) {
+	price, currency, err := s.s.GetListingPrice(ctx, listingID)
+	if err != nil {
+		return nil, 0, "", err
+	}
+
+	categories, err := s.s.GetListingCategories(ctx, listingID)
+	if err != nil {
+		return nil, 0, "", err
+	}
+
+	types, err := s.s.GetCatalogTypes(ctx)
+	if err != nil {
+		return nil, 0, "", err
+	}
+
+	tariffs, err := s.s.GetTariffs(ctx)
+	if err != nil {
+		return nil, 0, "", err
+	}
+
+	return resolveTariffs(types, tariffs, categoryDepths(models.Categories(), categories), now), price, currency, nil
+}
+
+// categoryDepths сопоставляет категориям объявления и всем их предкам глубину в дереве.
+// Категория, которой нет в дереве, учитывается только сама по себе
+func categoryDepths(tree models.CategoryTree, categoryIDs []string) map[string]int {
+	depths := make(map[string]int, len(categoryIDs))
+	for _, id := range categoryIDs {
+		path := tree.Ancestors(id)
+		if len(path) == 0 {
+			path = []string{id}
+		}
+		for i, ancestor := range path {
+			if depths[ancestor] < i+1 {
+				depths[ancestor] = i + 1
+			}
+		}
+	}
+	return depths
+}
+
+// resolveTariffs подбирает для каждого активного типа буста тариф, действующий в момент now.
+// categories — категории объявления с предками и их глубиной в дереве. Тариф категории
+// приоритетнее тарифа по умолчанию, тариф более глубокой категории — тарифа ее предка,
+// среди равных выбирается начавший действовать позже. Результат упорядочен так же, как типы в каталоге
+func resolveTariffs(types []models.BoostCatalogType, tariffs []models.BoostTariff, categories map[string]int, now time.Time) []models.BoostTariff {
+	best := make(map[models.BoostType]models.BoostTariff)
+	for _, tariff := range tariffs {
+		if !tariff.IsValidAt(now) {
+			continue
+		}
+
+		if tariff.CategoryID != nil {
+			if _, ok := categories[*tariff.CategoryID]; !ok {
+				continue
+			}
+		}
+
+		current, ok := best[tariff.Type]
+		if !ok || tariffPrecedes(tariff, current, categories) {
+			best[tariff.Type] = tariff
+		}
+	}
+
+	resolved := make([]models.BoostTariff, 0, len(types))
+	for _, t := range types {
+		if !t.IsActive {
+			continue
+		}
+		if tariff, ok := best[t.Type]; ok {
+			resolved = append(resolved, tariff)
+		}
+	}
+
+	return resolved
+}
+
+// tariffPrecedes сообщает, что тариф a приоритетнее тарифа b того же типа
+func tariffPrecedes(a, b models.BoostTariff, categories map[string]int) bool {
+	if (a.CategoryID != nil) != (b.CategoryID != nil) {
+		return a.CategoryID != nil
+	}
+
+	if a.CategoryID != nil {
+		if aDepth, bDepth := categories[*a.CategoryID], categories[*b.CategoryID]; aDepth != bDepth {
+			return aDepth > bDepth
+		}
+	}
+
+	aFrom, bFrom := time.Time{}, time.Time{}
+	if a.ValidFrom != nil {
+		aFrom = *a.ValidFrom
+	}
+	if b.ValidFrom != nil {
+		bFrom = *b.ValidFrom
+	}
+	if !aFrom.Equal(bFrom) {
+		return aFrom.After(bFrom)
+	}
+
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.After(b.CreatedAt)
+	}
+
+	return a.ID.String() > b.ID.String()
+}
+
+// GetCatalog возвращает каталог бустов со всеми тарифами, включая недействующие
+func (s *Boost) GetCatalog(ctx context.Context) (boost.CatalogResponse, error) {
+	types, err := s.s.GetCatalogTypes(ctx)
+	if err != nil {
+		return boost.CatalogResponse{}, err
+	}
+
+	tariffs, err := s.s.GetTariffs(ctx)
+	if err != nil {
+		return boost.CatalogResponse{}, err
+	}
+
+	byType := make(map[models.BoostType][]boost.TariffResp, len(types))
+	for _, tariff := range tariffs {
+		byType[tariff.Type] = append(byType[tariff.Type], newTariffResp(tariff))
+	}
+
+	resp := boost.CatalogResponse{Types: make([]boost.CatalogTypeResp, 0, len(types))}
+	for _, t := range types {
+		typeResp := boost.CatalogTypeResp{
+			Type:     t.Type,
+			Position: t.Position,
+			IsActive: t.IsActive,
+			Tariffs:  byType[t.Type],
+		}
+		if typeResp.Tariffs == nil {
+			typeResp.Tariffs = []boost.TariffResp{}
+		}
+		resp.Types = append(resp.Types, typeResp)
+	}
+
+	return resp, nil
+}
+
+// UpsertBoostType добавляет тип буста в каталог или меняет его порядок и активность
+func (s *Boost) UpsertBoostType(ctx context.Context, req boost.UpsertBoostTypeRequest) (boost.CatalogResponse, error) {
+	if !boostTypePattern.MatchString(string(req.Type)) {
+		return boost.CatalogResponse{}, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("invalid boost type %q", req.Type))
+	}
+
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+
+	if err := s.s.UpsertCatalogType(ctx, models.BoostCatalogType{
+		Type:     req.Type,
+		Position: req.Position,
+		IsActive: isActive,
+	}); err != nil {
+		return boost.CatalogResponse{}, err
+	}
+
+	return s.GetCatalog(ctx)
+}
+
+// CreateTariff добавляет тариф в каталог
+func (s *Boost) CreateTariff(ctx context.Context, req boost.TariffRequest) (boost.TariffResp, error) {
+	now := time.Now()
+	tariff := models.BoostTariff{ID: uuid.New(), CreatedAt: now}
+
+	return s.saveTariff(ctx, tariff, req, now)
+}
+
+// UpdateTariff полностью заменяет условия существующего тарифа
+func (s *Boost) UpdateTariff(ctx context.Context, req boost.TariffRequest) (boost.TariffResp, error) {
+	tariff, err := s.s.GetTariff(ctx, req.ID)
+	if err != nil {
+		return boost.TariffResp{}, err
+	}
+
+	return s.saveTariff(ctx, tariff, req, time.Now())
+}
+
+// DeleteTariff удаляет тариф из каталога
+func (s *Boost) DeleteTariff(ctx context.Context, id uuid.UUID) error {
+	return s.s.DeleteTariff(ctx, id)
+}
+
+func (s *Boost) saveTariff(ctx context.Context, tariff models.BoostTariff, req boost.TariffRequest, now time.Time) (boost.TariffResp, error) {
+	if err := s.validateTariff(ctx, req); err != nil {
+		return boost.TariffResp{}, err
+	}
+
+	tariff.Type = req.Type
+	tariff.CategoryID = req.CategoryID
+	tariff.CommissionPercent = req.CommissionPercent
+	tariff.MinAmounts = req.MinAmounts
+	if tariff.MinAmounts == nil {
+		tariff.MinAmounts = map[models.Currency]float64{}
+	}
+	tariff.ValidFrom = millisToTime(req.ValidFrom)
+	tariff.ValidTo = millisToTime(req.ValidTo)
+	tariff.UpdatedAt = now
+
+	if err := s.s.SaveTariff(ctx, tariff); err != nil {
+		return boost.TariffResp{}, err
+	}
+
+	return newTariffResp(tariff), nil
+}
+
+func (s *Boost) validateTariff(ctx context.Context, req boost.TariffRequest) error {
+	types, err := s.s.GetCatalogTypes(ctx)
+	if err != nil {
+		return err
+	}
+
+	known := false
+	for _, t := range types {
+		if t.Type == req.Type {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("unknown boost type %s", req.Type))
+	}
+
+	if req.CommissionPercent < 0 || req.CommissionPercent >= 1 {
+		return fiber.NewError(fiber.StatusBadRequest, "commission_percents must be in range [0, 1)")
+	}
+
+	if req.CategoryID != nil {
+		if _, ok := config.GetConfig().Categories.CategoryIds[*req.CategoryID]; !ok {
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("unknown category %s", *req.CategoryID))
+		}
+	}
+
+	for currency, amount := range req.MinAmounts {
+		if err := currency.Validate(); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		if amount < 0 {
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("min amount for %s must not be negative", currency))
+		}
+	}
+
+	if req.ValidFrom != nil && req.ValidTo != nil && *req.ValidTo <= *req.ValidFrom {
+		return fiber.NewError(fiber.StatusBadRequest, "valid_to must be after valid_from")
+	}
+
+	return nil
+}
+
+func newTariffResp(tariff models.BoostTariff) boost.TariffResp {
+	return boost.TariffResp{
+		ID:                tariff.ID,
+		Type:              tariff.Type,
+		CategoryID:        tariff.CategoryID,
+		CommissionPercent: tariff.CommissionPercent,
+		MinAmounts:        tariff.MinAmounts,
+		ValidFrom:         timeToMillis(tariff.ValidFrom),
+		ValidTo:           timeToMillis(tariff.ValidTo),
+	}
+}
+
+func millisToTime(ms *int64) *time.Time {
+	if ms == nil {
+		return nil
+	}
+	t := time.UnixMilli(*ms).UTC()
+	return &t
+}
+
+func timeToMillis(t *time.Time) *int64 {
+	if t == nil {
+		return nil
+	}
+	ms := t.UnixMilli()
+	return &ms
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"time"
+)
+
+// boostSyncInterval период проверки окон действия бустов
+const boostSyncInterval = time.Minute
+
+// ExpireBoostsSync периодически активирует запланированные бусты и завершает истекшие
+func (s *Boost) ExpireBoostsSync(stopChan chan struct{}) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.logger.Errorf("Panic in ExpireBoostsSync: %v", r)
+		}
+	}()
+
+	s.logger.Infof("Starting boost expiration cron task")
+
+	for {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					s.logger.Errorf("Panic in boost expiration task: %v", r)
+				}
+			}()
+
+			ctx, cancel := context.WithTimeout(context.Background(), boostSyncInterval)
+			defer cancel()
+
+			activated, expired, err := s.SyncBoostWindows(ctx, time.Now())
+			if err != nil {
+				s.logger.Errorf("Failed to sync boost windows: %v", err)
+			} else if activated > 0 || expired > 0 {
+				s.logger.Infof("Boosts activated: %d, expired: %d", activated, expired)
+			}
+		}()
+
+		select {
+		case <-stopChan:
+			s.logger.Infof("Boost expiration cron task received stop signal")
+			return
+		case <-time.After(boostSyncInterval):
+		}
+	}
+}
+
+// SyncBoostWindows активирует бусты, окно которых началось, и завершает бусты, окно которых закончилось.
+// Активация выполняется первой, чтобы по короткому окну, пропущенному целиком, комиссия тоже была списана
+func (s *Boost) SyncBoostWindows(ctx context.Context, now time.Time) (int, int, error) {
+	activated, err := s.s.ActivateDueBoosts(ctx, now)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	expired, err := s.s.ExpireBoosts(ctx, now)
+	if err != nil {
+		return activated, 0, err
+	}
+
+	return activated, expired, nil
+}
@@ -2,14 +2,22 @@ package service
 
 import (
 	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
 
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 	"github.com/yaroslavvasilenko/argon/internal/core/logger"
-	"github.com/yaroslavvasilenko/argon/internal/modules/boost/storage"
 	"github.com/yaroslavvasilenko/argon/internal/models"
-	"github.com/google/uuid"
 	"github.com/yaroslavvasilenko/argon/internal/modules/boost"
+	"github.com/yaroslavvasilenko/argon/internal/modules/boost/storage"
 )
 
+// defaultBoostDuration длительность окна буста, если она не указана в запросе
+const defaultBoostDuration = 7 * 24 * time.Hour
+
 type Boost struct {
 	s      *storage.Boost
 	logger *logger.Glog
@@ -30,39 +38,271 @@ func (s *Boost) GetBoost(ctx context.Context, id uuid.UUID) (boost.GetBoostRespo
 		return boost.GetBoostResponse{}, err
 	}
 
-	resp := boost.GetBoostResponse{}
+	boostIDs := make([]uuid.UUID, 0, len(boosts))
+	for _, b := range boosts {
+		boostIDs = append(boostIDs, b.ID)
+	}
+
+	purchases, err := s.s.GetPurchasesByBoostIDs(ctx, boostIDs)
+	if err != nil {
+		return boost.GetBoostResponse{}, err
+	}
+
+	ledger, err := s.s.GetLedger(ctx, id)
+	if err != nil {
+		return boost.GetBoostResponse{}, err
+	}
+
+	resp := boost.GetBoostResponse{
+		Active:    []boost.BoostWindowResp{},
+		Scheduled: []boost.BoostWindowResp{},
+		Expired:   []boost.BoostWindowResp{},
+		Ledger:    newLedgerResp(ledger),
+	}
 
 	for _, b := range boosts {
-		resp.EnableBoostTypes = append(resp.EnableBoostTypes, b.Type)
+		window := boost.BoostWindowResp{
+			ID:                b.ID,
+			Type:              b.Type,
+			Status:            b.Status,
+			CommissionPercent: b.Commission,
+			StartsAt:          b.StartsAt.UnixMilli(),
+		}
+		if b.EndsAt != nil {
+			endsAt := b.EndsAt.UnixMilli()
+			window.EndsAt = &endsAt
+		}
+		if purchase, ok := purchases[b.ID]; ok {
+			window.CommissionAmount = purchase.CommissionAmount
+			window.Currency = purchase.Currency
+		}
+
+		switch b.Status {
+		case models.BoostStatusActive:
+			resp.Active = append(resp.Active, window)
+			resp.EnableBoostTypes = append(resp.EnableBoostTypes, b.Type)
+		case models.BoostStatusScheduled:
+			resp.Scheduled = append(resp.Scheduled, window)
+		default:
+			resp.Expired = append(resp.Expired, window)
+		}
+	}
+
+	// Доступные бусты выводятся в порядке каталога с комиссией в валюте объявления
+	tariffs, price, currency, err := s.availableBoosts(ctx, id, time.Now())
+	if err != nil {
+		return boost.GetBoostResponse{}, err
 	}
 
-	for boostType, commission := range models.GetBoostTypesWithCommissions() {
+	resp.AvailableBoosts = make([]boost.BoostResp, 0, len(tariffs))
+	for _, tariff := range tariffs {
 		resp.AvailableBoosts = append(resp.AvailableBoosts, boost.BoostResp{
-			Type:              boostType,
-			CommissionPercent: commission,
+			Type:              tariff.Type,
+			CommissionPercent: tariff.CommissionPercent,
+			CommissionAmount:  tariff.CommissionAmount(price, currency),
+			Currency:          currency,
+			TariffID:          tariff.ID,
 		})
 	}
 
-
-
 	return resp, nil
 }
 
+// UpsertBoost приводит набор действующих и запланированных бустов к переданному списку типов:
+// на недостающие типы оформляется покупка с окном действия, лишние запланированные бусты
+// отменяются с возвратом начисления, лишние действующие — завершаются досрочно
 func (s *Boost) UpsertBoost(ctx context.Context, req boost.UpdateBoostRequest) (boost.GetBoostResponse, error) {
-	boosts := make([]models.Boost, 0, len(req.Boosts))
-	
+	now := time.Now()
+
+	startsAt := now
+	if req.StartsAt != nil && time.UnixMilli(*req.StartsAt).After(now) {
+		startsAt = time.UnixMilli(*req.StartsAt)
+	}
+
+	if req.DurationDays < 0 {
+		return boost.GetBoostResponse{}, fiber.NewError(fiber.StatusBadRequest, "duration_days must be positive")
+	}
+	duration := defaultBoostDuration
+	if req.DurationDays > 0 {
+		duration = time.Duration(req.DurationDays) * 24 * time.Hour
+	}
+
+	available, price, currency, err := s.availableBoosts(ctx, req.ListingID, now)
+	if err != nil {
+		return boost.GetBoostResponse{}, err
+	}
+
+	tariffs := make(map[models.BoostType]models.BoostTariff, len(available))
+	for _, tariff := range available {
+		tariffs[tariff.Type] = tariff
+	}
+
+	current, err := s.s.GetBoosts(ctx, req.ListingID)
+	if err != nil {
+		return boost.GetBoostResponse{}, err
+	}
+
+	// Уже купленный буст остается в силе, даже если его тип с тех пор выведен из каталога:
+	// доступность проверяется только для новых покупок
+	bought := make(map[models.BoostType]struct{})
+	for _, b := range current {
+		if b.Status == models.BoostStatusActive || b.Status == models.BoostStatusScheduled {
+			bought[b.Type] = struct{}{}
+		}
+	}
+
+	requested := make(map[models.BoostType]struct{}, len(req.Boosts))
 	for _, t := range req.Boosts {
-		boosts = append(boosts, models.Boost{
-			ListingID: req.ListingID,
-			Type:  t,
-		})
+		_, available := tariffs[t]
+		_, kept := bought[t]
+		if !available && !kept {
+			return boost.GetBoostResponse{}, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("boost type %s is not available", t))
+		}
+		requested[t] = struct{}{}
+	}
+
+	changes := storage.BoostChanges{Now: now}
+	live := make(map[models.BoostType]struct{})
+	cancelledIDs := make([]uuid.UUID, 0)
+
+	for _, b := range current {
+		if b.Status != models.BoostStatusActive && b.Status != models.BoostStatusScheduled {
+			continue
+		}
+
+		if _, ok := requested[b.Type]; ok {
+			live[b.Type] = struct{}{}
+			continue
+		}
+
+		if b.Status == models.BoostStatusScheduled {
+			changes.Cancelled = append(changes.Cancelled, b.ID)
+			cancelledIDs = append(cancelledIDs, b.ID)
+		} else {
+			changes.Stopped = append(changes.Stopped, b.ID)
+		}
 	}
-	
-	
-	err := s.s.UpsertBoost(ctx, req.ListingID, boosts)
+
+	// Начисление по отмененным до начала действия бустам сторнируется
+	cancelledPurchases, err := s.s.GetPurchasesByBoostIDs(ctx, cancelledIDs)
 	if err != nil {
 		return boost.GetBoostResponse{}, err
 	}
-	
+	for _, id := range cancelledIDs {
+		purchase, ok := cancelledPurchases[id]
+		if !ok {
+			continue
+		}
+		changes.Ledger = append(changes.Ledger, newLedgerEntry(purchase, models.LedgerEntryReversal, now))
+	}
+
+	for _, t := range req.Boosts {
+		if _, ok := live[t]; ok {
+			continue
+		}
+		live[t] = struct{}{}
+
+		status := models.BoostStatusScheduled
+		if !startsAt.After(now) {
+			status = models.BoostStatusActive
+		}
+
+		tariff := tariffs[t]
+		endsAt := startsAt.Add(duration)
+		b := models.Boost{
+			ID:         uuid.New(),
+			ListingID:  req.ListingID,
+			Type:       t,
+			Commission: tariff.CommissionPercent,
+			StartsAt:   startsAt,
+			EndsAt:     &endsAt,
+			Status:     status,
+			CreatedAt:  now,
+		}
+		purchase := models.BoostPurchase{
+			ID:                uuid.New(),
+			BoostID:           b.ID,
+			ListingID:         req.ListingID,
+			Type:              t,
+			CommissionPercent: b.Commission,
+			ListingPrice:      price,
+			CommissionAmount:  tariff.CommissionAmount(price, currency),
+			Currency:          currency,
+			TariffID:          &tariff.ID,
+			CreatedAt:         now,
+		}
+
+		changes.Purchased = append(changes.Purchased, storage.PurchasedBoost{Boost: b, Purchase: purchase})
+		changes.Ledger = append(changes.Ledger, newLedgerEntry(purchase, models.LedgerEntryAccrual, now))
+
+		// Буст, действующий сразу, списывается в момент покупки; запланированный — при активации
+		if status == models.BoostStatusActive {
+			changes.Ledger = append(changes.Ledger, newLedgerEntry(purchase, models.LedgerEntryCharge, now))
+		}
+	}
+
+	if err := s.s.ApplyBoostChanges(ctx, changes); err != nil {
+		return boost.GetBoostResponse{}, err
+	}
+
 	return s.GetBoost(ctx, req.ListingID)
-}
\ No newline at end of file
+}
+
+func newLedgerEntry(purchase models.BoostPurchase, entryType models.LedgerEntryType, now time.Time) models.LedgerEntry {
+	return models.LedgerEntry{
+		ID:         uuid.New(),
+		ListingID:  purchase.ListingID,
+		PurchaseID: purchase.ID,
+		Type:       entryType,
+		Amount:     purchase.CommissionAmount,
+		Currency:   purchase.Currency,
+		CreatedAt:  now,
+	}
+}
+
+// newLedgerResp формирует журнал комиссий с итогами по каждой валюте
+func newLedgerResp(entries []models.LedgerEntry) boost.LedgerResp {
+	resp := boost.LedgerResp{
+		Entries:  make([]boost.LedgerEntryResp, 0, len(entries)),
+		Balances: []boost.LedgerBalanceResp{},
+	}
+
+	balances := make(map[models.Currency]*boost.LedgerBalanceResp)
+	for _, e := range entries {
+		resp.Entries = append(resp.Entries, boost.LedgerEntryResp{
+			ID:         e.ID,
+			PurchaseID: e.PurchaseID,
+			Type:       e.Type,
+			Amount:     e.Amount,
+			Currency:   e.Currency,
+			CreatedAt:  e.CreatedAt.UnixMilli(),
+		})
+
+		balance, ok := balances[e.Currency]
+		if !ok {
+			balance = &boost.LedgerBalanceResp{Currency: e.Currency}
+			balances[e.Currency] = balance
+		}
+
+		switch e.Type {
+		case models.LedgerEntryAccrual:
+			balance.Owed += e.Amount
+		case models.LedgerEntryCharge:
+			balance.Owed -= e.Amount
+			balance.Charged += e.Amount
+		case models.LedgerEntryReversal:
+			balance.Owed -= e.Amount
+		}
+	}
+
+	for _, balance := range balances {
+		balance.Owed = math.Round(balance.Owed*100) / 100
+		balance.Charged = math.Round(balance.Charged*100) / 100
+		resp.Balances = append(resp.Balances, *balance)
+	}
+	sort.Slice(resp.Balances, func(i, j int) bool {
+		return resp.Balances[i].Currency < resp.Balances[j].Currency
+	})
+
+	return resp
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yaroslavvasilenko/argon/config"
+	"github.com/yaroslavvasilenko/argon/internal/models"
+)
+
+func TestNewLedgerResp(t *testing.T) {
+	now := time.Now()
+	listingID := uuid.New()
+
+	active := models.BoostPurchase{ID: uuid.New(), ListingID: listingID, CommissionAmount: models.BoostTariff{CommissionPercent: 0.07}.CommissionAmount(1000, models.RUB), Currency: models.RUB}
+	scheduled := models.BoostPurchase{ID: uuid.New(), ListingID: listingID, CommissionAmount: models.BoostTariff{CommissionPercent: 0.12}.CommissionAmount(1000, models.RUB), Currency: models.RUB}
+	cancelled := models.BoostPurchase{ID: uuid.New(), ListingID: listingID, CommissionAmount: models.BoostTariff{CommissionPercent: 0.02}.CommissionAmount(50, models.USD), Currency: models.USD}
+
+	resp := newLedgerResp([]models.LedgerEntry{
+		newLedgerEntry(active, models.LedgerEntryAccrual, now),
+		newLedgerEntry(active, models.LedgerEntryCharge, now),
+		newLedgerEntry(scheduled, models.LedgerEntryAccrual, now),
+		newLedgerEntry(cancelled, models.LedgerEntryAccrual, now),
+		newLedgerEntry(cancelled, models.LedgerEntryReversal, now),
+	})
+
+	assert.Len(t, resp.Entries, 5)
+	require.Len(t, resp.Balances, 2)
+
+	// Итоги отсортированы по валюте
+	assert.Equal(t, models.RUB, resp.Balances[0].Currency)
+	assert.Equal(t, 120.0, resp.Balances[0].Owed, "запланированный буст начислен, но не списан")
+	assert.Equal(t, 70.0, resp.Balances[0].Charged)
+
+	assert.Equal(t, models.USD, resp.Balances[1].Currency)
+	assert.Equal(t, 0.0, resp.Balances[1].Owed, "отмененное начисление сторнировано")
+	assert.Equal(t, 0.0, resp.Balances[1].Charged)
+}
+
+func TestResolveTariffs(t *testing.T) {
+	now := time.Now()
+	yesterday := now.Add(-24 * time.Hour)
+	tomorrow := now.Add(24 * time.Hour)
+	phones := "smartphones"
+	cars := "cars"
+
+	types := []models.BoostCatalogType{
+		{Type: models.BoostTypeBase, Position: 1, IsActive: true},
+		{Type: models.BoostTypeHighlight, Position: 2, IsActive: true},
+		{Type: models.BoostTypeUpfront, Position: 3, IsActive: false},
+	}
+
+	baseDefault := models.BoostTariff{ID: uuid.New(), Type: models.BoostTypeBase, CommissionPercent: 0.02}
+	basePhones := models.BoostTariff{ID: uuid.New(), Type: models.BoostTypeBase, CategoryID: &phones, CommissionPercent: 0.03}
+	baseCars := models.BoostTariff{ID: uuid.New(), Type: models.BoostTypeBase, CategoryID: &cars, CommissionPercent: 0.05}
+	highlightDefault := models.BoostTariff{ID: uuid.New(), Type: models.BoostTypeHighlight, CommissionPercent: 0.07}
+	highlightPromo := models.BoostTariff{ID: uuid.New(), Type: models.BoostTypeHighlight, CommissionPercent: 0.05, ValidFrom: &yesterday, ValidTo: &tomorrow}
+	highlightFuture := models.BoostTariff{ID: uuid.New(), Type: models.BoostTypeHighlight, CommissionPercent: 0.01, ValidFrom: &tomorrow}
+	upfrontDefault := models.BoostTariff{ID: uuid.New(), Type: models.BoostTypeUpfront, CommissionPercent: 0.12}
+
+	// Порядок тарифов на входе не влияет на результат
+	tariffs := []models.BoostTariff{highlightFuture, upfrontDefault, highlightPromo, basePhones, baseCars, highlightDefault, baseDefault}
+
+	t.Run("Переопределение для категории и период действия", func(t *testing.T) {
+		resolved := resolveTariffs(types, tariffs, map[string]int{phones: 1}, now)
+
+		require.Len(t, resolved, 2, "неактивный тип буста недоступен")
+		assert.Equal(t, basePhones.ID, resolved[0].ID)
+		assert.Equal(t, highlightPromo.ID, resolved[1].ID, "действующая акция приоритетнее тарифа по умолчанию")
+	})
+
+	t.Run("Тарифы по умолчанию", func(t *testing.T) {
+		resolved := resolveTariffs(types, tariffs, nil, tomorrow.Add(time.Hour))
+
+		require.Len(t, resolved, 2)
+		assert.Equal(t, baseDefault.ID, resolved[0].ID)
+		assert.Equal(t, highlightFuture.ID, resolved[1].ID)
+	})
+
+	t.Run("Тариф категории действует и для подкатегорий", func(t *testing.T) {
+		tree := models.NewCategoryTree([]config.CategoryNode{{
+			ID:            "electronics",
+			Subcategories: []config.CategoryNode{{ID: phones}},
+		}})
+		electronics := "electronics"
+		baseElectronics := models.BoostTariff{ID: uuid.New(), Type: models.BoostTypeBase, CategoryID: &electronics, CommissionPercent: 0.04}
+
+		depths := categoryDepths(tree, []string{phones})
+		assert.Equal(t, map[string]int{electronics: 1, phones: 2}, depths)
+
+		resolved := resolveTariffs(types, []models.BoostTariff{baseDefault, baseElectronics}, depths, now)
+		require.Len(t, resolved, 1)
+		assert.Equal(t, baseElectronics.ID, resolved[0].ID, "тариф предка приоритетнее тарифа по умолчанию")
+
+		resolved = resolveTariffs(types, []models.BoostTariff{basePhones, baseElectronics, baseDefault}, depths, now)
+		require.Len(t, resolved, 1)
+		assert.Equal(t, basePhones.ID, resolved[0].ID, "тариф самой категории приоритетнее тарифа предка")
+	})
+}
@@ -0,0 +1,180 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/yaroslavvasilenko/argon/internal/models"
+)
+
+const tariffFields = "id, boost_type, category_id, commission_percent, min_amounts, valid_from, valid_to, created_at, updated_at"
+
+// GetCatalogTypes возвращает типы бустов каталога в порядке вывода
+func (s *Boost) GetCatalogTypes(ctx context.Context) ([]models.BoostCatalogType, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT boost_type, position, is_active, created_at, updated_at
+		FROM boost_types
+		ORDER BY position, boost_type
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	types := []models.BoostCatalogType{}
+	for rows.Next() {
+		var t models.BoostCatalogType
+		var boostType string
+		if err := rows.Scan(&boostType, &t.Position, &t.IsActive, &t.CreatedAt, &t.UpdatedAt); err != nil {
+			return nil, err
+		}
+		t.Type = models.BoostType(boostType)
+		types = append(types, t)
+	}
+
+	return types, rows.Err()
+}
+
+// UpsertCatalogType создает тип буста или обновляет его порядок и активность
+func (s *Boost) UpsertCatalogType(ctx context.Context, t models.BoostCatalogType) error {
+	_, err := s.pool.Exec(ctx, `
+		INSERT INTO boost_types (boost_type, position, is_active, created_at, updated_at)
+		VALUES ($1, $2, $3, NOW(), NOW())
+		ON CONFLICT (boost_type) DO UPDATE
+		SET position = EXCLUDED.position, is_active = EXCLUDED.is_active, updated_at = NOW()
+	`, string(t.Type), t.Position, t.IsActive)
+
+	return err
+}
+
+// GetTariffs возвращает все тарифы каталога в детерминированном порядке:
+// сначала тарифы по умолчанию, затем переопределения по категориям
+func (s *Boost) GetTariffs(ctx context.Context) ([]models.BoostTariff, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT `+tariffFields+`
+		FROM boost_tariffs
+		ORDER BY boost_type, category_id NULLS FIRST, valid_from NULLS FIRST, created_at, id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tariffs := []models.BoostTariff{}
+	for rows.Next() {
+		tariff, err := scanTariff(rows)
+		if err != nil {
+			return nil, err
+		}
+		tariffs = append(tariffs, tariff)
+	}
+
+	return tariffs, rows.Err()
+}
+
+// GetTariff возвращает тариф по идентификатору
+func (s *Boost) GetTariff(ctx context.Context, id uuid.UUID) (models.BoostTariff, error) {
+	row := s.pool.QueryRow(ctx, `SELECT `+tariffFields+` FROM boost_tariffs WHERE id = $1`, id)
+
+	tariff, err := scanTariff(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.BoostTariff{}, fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("tariff %s not found", id))
+	}
+
+	return tariff, err
+}
+
+// SaveTariff создает или полностью заменяет тариф
+func (s *Boost) SaveTariff(ctx context.Context, tariff models.BoostTariff) error {
+	minAmounts, err := json.Marshal(tariff.MinAmounts)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.pool.Exec(ctx, `
+		INSERT INTO boost_tariffs (`+tariffFields+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (id) DO UPDATE
+		SET boost_type = EXCLUDED.boost_type,
+			category_id = EXCLUDED.category_id,
+			commission_percent = EXCLUDED.commission_percent,
+			min_amounts = EXCLUDED.min_amounts,
+			valid_from = EXCLUDED.valid_from,
+			valid_to = EXCLUDED.valid_to,
+			updated_at = EXCLUDED.updated_at
+	`, tariff.ID, string(tariff.Type), tariff.CategoryID, tariff.CommissionPercent, minAmounts,
+		tariff.ValidFrom, tariff.ValidTo, tariff.CreatedAt, tariff.UpdatedAt)
+
+	return err
+}
+
+// DeleteTariff удаляет тариф; покупки по нему сохраняют зафиксированную комиссию
+func (s *Boost) DeleteTariff(ctx context.Context, id uuid.UUID) error {
+	tag, err := s.pool.Exec(ctx, `DELETE FROM boost_tariffs WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("tariff %s not found", id))
+	}
+
+	return nil
+}
+
+// GetListingCategories возвращает категории объявления для выбора тарифа
+func (s *Boost) GetListingCategories(ctx context.Context, listingID uuid.UUID) ([]string, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT category_id FROM listing_categories WHERE listing_id = $1 ORDER BY category_id
+	`, listingID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := []string{}
+	for rows.Next() {
+		var categoryID string
+		if err := rows.Scan(&categoryID); err != nil {
+			return nil, err
+		}
+		categories = append(categories, categoryID)
+	}
+
+	return categories, rows.Err()
+}
+
+func scanTariff(row pgx.Row) (models.BoostTariff, error) {
+	var tariff models.BoostTariff
+	var boostType string
+	var minAmounts []byte
+
+	if err := row.Scan(
+		&tariff.ID,
+		&boostType,
+		&tariff.CategoryID,
+		&tariff.CommissionPercent,
+		&minAmounts,
+		&tariff.ValidFrom,
+		&tariff.ValidTo,
+		&tariff.CreatedAt,
+		&tariff.UpdatedAt,
+	); err != nil {
+		return models.BoostTariff{}, err
+	}
+
+	tariff.Type = models.BoostType(boostType)
+	tariff.MinAmounts = map[models.Currency]float64{}
+	if len(minAmounts) > 0 {
+		if err := json.Unmarshal(minAmounts, &tariff.MinAmounts); err != nil {
+			return models.BoostTariff{}, err
+		}
+	}
+
+	return tariff, nil
+}
@@ -2,9 +2,16 @@ package storage
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
 
+	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rotisserie/eris"
 	"github.com/yaroslavvasilenko/argon/internal/models"
 	"gorm.io/gorm"
 )
@@ -18,29 +25,62 @@ func NewBoost(db *gorm.DB, pool *pgxpool.Pool) *Boost {
 	return &Boost{gorm: db, pool: pool}
 }
 
+const boostFields = "id, listing_id, boost_type, commission, starts_at, ends_at, status, created_at"
+
+// uniqueViolation код ошибки Postgres при нарушении уникального индекса
+const uniqueViolation = "23505"
+
+// GetBoosts возвращает все бусты объявления, включая запланированные и завершенные
 func (s *Boost) GetBoosts(ctx context.Context, id uuid.UUID) ([]models.Boost, error) {
 	query := `
-		SELECT listing_id, boost_type, commission
+		SELECT ` + boostFields + `
 		FROM listing_boosts
 		WHERE listing_id = $1
+		ORDER BY starts_at, created_at
+	`
+
+	return s.queryBoosts(ctx, query, id)
+}
+
+// GetActiveBoosts возвращает действующие бусты объявления
+func (s *Boost) GetActiveBoosts(ctx context.Context, id uuid.UUID) ([]models.Boost, error) {
+	query := `
+		SELECT ` + boostFields + `
+		FROM listing_boosts
+		WHERE listing_id = $1 AND status = $2
+		ORDER BY starts_at, created_at
 	`
 
-	rows, err := s.pool.Query(ctx, query, id)
+	return s.queryBoosts(ctx, query, id, string(models.BoostStatusActive))
+}
+
+func (s *Boost) queryBoosts(ctx context.Context, query string, args ...interface{}) ([]models.Boost, error) {
+	rows, err := s.pool.Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var boosts []models.Boost
+	boosts := []models.Boost{}
 	for rows.Next() {
 		var boost models.Boost
-		var boostTypeStr string
+		var boostTypeStr, statusStr string
 
-		if err := rows.Scan(&boost.ListingID, &boostTypeStr, &boost.Commission); err != nil {
+		if err := rows.Scan(
+			&boost.ID,
+			&boost.ListingID,
+			&boostTypeStr,
+			&boost.Commission,
+			&boost.StartsAt,
+			&boost.EndsAt,
+			&statusStr,
+			&boost.CreatedAt,
+		); err != nil {
 			return nil, err
 		}
 
 		boost.Type = models.BoostType(boostTypeStr)
+		boost.Status = models.BoostStatus(statusStr)
 		boosts = append(boosts, boost)
 	}
 
@@ -48,66 +88,222 @@ func (s *Boost) GetBoosts(ctx context.Context, id uuid.UUID) ([]models.Boost, er
 		return nil, err
 	}
 
-	if len(boosts) == 0 {
-		return []models.Boost{}, nil
+	return boosts, nil
+}
+
+// GetListingPrice возвращает цену и валюту объявления, от которых считается комиссия
+func (s *Boost) GetListingPrice(ctx context.Context, listingID uuid.UUID) (float64, models.Currency, error) {
+	var price float64
+	var currency string
+
+	err := s.pool.QueryRow(ctx, `
+		SELECT price, currency FROM listings WHERE id = $1 AND deleted_at IS NULL
+	`, listingID).Scan(&price, &currency)
+	if err != nil {
+		return 0, "", eris.Wrapf(err, "failed to get price of listing %s", listingID)
 	}
 
-	return boosts, nil
+	return price, models.Currency(currency), nil
 }
 
-// UpsertBoost обновляет или добавляет бусты для объявления и удаляет те, которые не были переданы
-func (s *Boost) UpsertBoost(ctx context.Context, listingID uuid.UUID, boosts []models.Boost) error {
-	// Получаем комиссии для типов бустов
-	commissions := models.GetBoostTypesWithCommissions()
+// BoostChanges набор изменений бустов объявления, применяемых в одной транзакции
+type BoostChanges struct {
+	// Purchased новые бусты вместе с записями о покупке
+	Purchased []PurchasedBoost
+	// Cancelled запланированные бусты, которые отменяются до начала действия
+	Cancelled []uuid.UUID
+	// Stopped действующие бусты, окно которых завершается досрочно
+	Stopped []uuid.UUID
+	// Ledger записи журнала комиссий
+	Ledger []models.LedgerEntry
+	// Now момент применения изменений
+	Now time.Time
+}
 
-	// Начинаем транзакцию
+// PurchasedBoost новый буст и запись о его покупке
+type PurchasedBoost struct {
+	Boost    models.Boost
+	Purchase models.BoostPurchase
+}
+
+// ApplyBoostChanges сохраняет покупки, отмены и записи журнала комиссий. Если те же бусты
+// уже купил или отменил параллельный запрос, ничего не сохраняет и возвращает 409
+func (s *Boost) ApplyBoostChanges(ctx context.Context, changes BoostChanges) error {
 	tx, err := s.pool.Begin(ctx)
 	if err != nil {
 		return err
 	}
-	// Используем отложенную функцию для отката транзакции в случае ошибки
-	defer func() {
+	defer tx.Rollback(ctx)
+
+	if len(changes.Cancelled) > 0 {
+		_, err = tx.Exec(ctx, `
+			UPDATE listing_boosts SET status = $2 WHERE id = ANY($1) AND status = $3
+		`, changes.Cancelled, string(models.BoostStatusCancelled), string(models.BoostStatusScheduled))
 		if err != nil {
-			_ = tx.Rollback(ctx)
+			return err
 		}
-	}()
-
-	// Удаляем все существующие бусты для данного объявления
-	_, err = tx.Exec(ctx, "DELETE FROM listing_boosts WHERE listing_id = $1", listingID)
-	if err != nil {
-		return err
 	}
 
-	// Если передан пустой список бустов, просто завершаем транзакцию
-	if len(boosts) == 0 {
-		return tx.Commit(ctx)
+	if len(changes.Stopped) > 0 {
+		_, err = tx.Exec(ctx, `
+			UPDATE listing_boosts SET status = $2, ends_at = $3 WHERE id = ANY($1) AND status = $4
+		`, changes.Stopped, string(models.BoostStatusExpired), changes.Now, string(models.BoostStatusActive))
+		if err != nil {
+			return err
+		}
 	}
 
-	// Вместо использования batch, будем выполнять запросы напрямую
-	insertQuery := `
-		INSERT INTO listing_boosts (listing_id, boost_type, commission)
-		VALUES ($1, $2, $3)
-	`
-
-	// Добавляем каждый буст отдельным запросом
-	for _, boost := range boosts {
-		// Убеждаемся, что ID объявления совпадает с переданным
-		boost.ListingID = listingID
+	for _, p := range changes.Purchased {
+		_, err = tx.Exec(ctx, `
+			INSERT INTO listing_boosts (id, listing_id, boost_type, commission, starts_at, ends_at, status, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		`, p.Boost.ID, p.Boost.ListingID, string(p.Boost.Type), p.Boost.Commission,
+			p.Boost.StartsAt, p.Boost.EndsAt, string(p.Boost.Status), p.Boost.CreatedAt)
+		// Тот же буст уже купил параллельный запрос
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return fiber.NewError(fiber.StatusConflict, fmt.Sprintf("boost %s of listing %s is already bought", p.Boost.Type, p.Boost.ListingID))
+		}
+		if err != nil {
+			return err
+		}
 
-		// Получаем комиссию для данного типа буста
-		commission, exists := commissions[boost.Type]
-		if !exists {
-			// Если комиссия не найдена, используем значение по умолчанию или пропускаем
-			continue
+		_, err = tx.Exec(ctx, `
+			INSERT INTO boost_purchases (
+				id, boost_id, listing_id, boost_type, commission_percent,
+				listing_price, commission_amount, currency, tariff_id, created_at
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		`, p.Purchase.ID, p.Purchase.BoostID, p.Purchase.ListingID, string(p.Purchase.Type), p.Purchase.CommissionPercent,
+			p.Purchase.ListingPrice, p.Purchase.CommissionAmount, string(p.Purchase.Currency), p.Purchase.TariffID, p.Purchase.CreatedAt)
+		if err != nil {
+			return err
 		}
+	}
 
-		// Выполняем запрос на вставку
-		_, err = tx.Exec(ctx, insertQuery, boost.ListingID, boost.Type, commission)
+	for _, entry := range changes.Ledger {
+		// Ту же запись журнала, например сторно отмененного буста, уже сделал параллельный запрос
+		err = insertLedgerEntry(ctx, tx, entry)
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return fiber.NewError(fiber.StatusConflict, fmt.Sprintf("boosts of listing %s were changed concurrently", entry.ListingID))
+		}
 		if err != nil {
 			return err
 		}
 	}
 
-	// Завершаем транзакцию
 	return tx.Commit(ctx)
 }
+
+func insertLedgerEntry(ctx context.Context, tx pgx.Tx, entry models.LedgerEntry) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO boost_ledger (id, listing_id, purchase_id, entry_type, amount, currency, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, entry.ID, entry.ListingID, entry.PurchaseID, string(entry.Type), entry.Amount, string(entry.Currency), entry.CreatedAt)
+
+	return err
+}
+
+// GetPurchasesByBoostIDs возвращает записи о покупке указанных бустов
+func (s *Boost) GetPurchasesByBoostIDs(ctx context.Context, boostIDs []uuid.UUID) (map[uuid.UUID]models.BoostPurchase, error) {
+	purchases := make(map[uuid.UUID]models.BoostPurchase, len(boostIDs))
+	if len(boostIDs) == 0 {
+		return purchases, nil
+	}
+
+	rows, err := s.pool.Query(ctx, `
+		SELECT id, boost_id, listing_id, boost_type, commission_percent,
+			listing_price, commission_amount, currency, tariff_id, created_at
+		FROM boost_purchases
+		WHERE boost_id = ANY($1)
+	`, boostIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p models.BoostPurchase
+		var boostTypeStr, currencyStr string
+		if err := rows.Scan(&p.ID, &p.BoostID, &p.ListingID, &boostTypeStr, &p.CommissionPercent,
+			&p.ListingPrice, &p.CommissionAmount, &currencyStr, &p.TariffID, &p.CreatedAt); err != nil {
+			return nil, err
+		}
+		p.Type = models.BoostType(boostTypeStr)
+		p.Currency = models.Currency(currencyStr)
+		purchases[p.BoostID] = p
+	}
+
+	return purchases, rows.Err()
+}
+
+// GetLedger возвращает журнал комиссий объявления в хронологическом порядке
+func (s *Boost) GetLedger(ctx context.Context, listingID uuid.UUID) ([]models.LedgerEntry, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT id, listing_id, purchase_id, entry_type, amount, currency, created_at
+		FROM boost_ledger
+		WHERE listing_id = $1
+		ORDER BY created_at, id
+	`, listingID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []models.LedgerEntry{}
+	for rows.Next() {
+		var e models.LedgerEntry
+		var typeStr, currencyStr string
+		if err := rows.Scan(&e.ID, &e.ListingID, &e.PurchaseID, &typeStr, &e.Amount, &currencyStr, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		e.Type = models.LedgerEntryType(typeStr)
+		e.Currency = models.Currency(currencyStr)
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
+// ActivateDueBoosts переводит запланированные бусты, окно которых началось, в действующие
+// и списывает по ним комиссию. Возвращает количество активированных бустов
+func (s *Boost) ActivateDueBoosts(ctx context.Context, now time.Time) (int, error) {
+	// Смена статуса и списание выполняются одним запросом, чтобы буст не активировался без списания.
+	// Бусты без записи о покупке активируются без списания, поэтому считаются по activated
+	var activated int
+	err := s.pool.QueryRow(ctx, `
+		WITH activated AS (
+			UPDATE listing_boosts
+			SET status = $2
+			WHERE status = $3 AND starts_at <= $1
+			RETURNING id
+		), charged AS (
+			INSERT INTO boost_ledger (id, listing_id, purchase_id, entry_type, amount, currency, created_at)
+			SELECT gen_random_uuid(), p.listing_id, p.id, $4, p.commission_amount, p.currency, $1
+			FROM boost_purchases p
+			JOIN activated a ON a.id = p.boost_id
+			ON CONFLICT (purchase_id, entry_type) DO NOTHING
+		)
+		SELECT count(*) FROM activated
+	`, now, string(models.BoostStatusActive), string(models.BoostStatusScheduled), string(models.LedgerEntryCharge)).Scan(&activated)
+	if err != nil {
+		return 0, err
+	}
+
+	return activated, nil
+}
+
+// ExpireBoosts завершает действующие бусты, окно которых закончилось.
+// Возвращает количество завершенных бустов
+func (s *Boost) ExpireBoosts(ctx context.Context, now time.Time) (int, error) {
+	tag, err := s.pool.Exec(ctx, `
+		UPDATE listing_boosts
+		SET status = $2
+		WHERE status = $3 AND ends_at <= $1
+	`, now, string(models.BoostStatusExpired), string(models.BoostStatusActive))
+	if err != nil {
+		return 0, err
+	}
+
+	return int(tag.RowsAffected()), nil
+}
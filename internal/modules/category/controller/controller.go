@@ -0,0 +1,159 @@
+package controller
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/yaroslavvasilenko/argon/config"
+	"github.com/yaroslavvasilenko/argon/internal/modules/category"
+	"github.com/yaroslavvasilenko/argon/internal/modules/category/service"
+)
+
+type Category struct {
+	s *service.Category
+}
+
+func NewCategory(s *service.Category) *Category {
+	return &Category{s: s}
+}
+
+// ReloadCategories импортирует таксономию из файлов директории categories. Если в файлах
+// есть ошибки, отвечает 422 со списком замечаний, а действующая таксономия не меняется
+func (h *Category) ReloadCategories(c *fiber.Ctx) error {
+	resp, err := h.s.ReloadCategories(c.UserContext())
+	if err != nil {
+		return err
+	}
+
+	return importResponse(c, resp)
+}
+
+// ImportCategories заменяет таксономию содержимым файлов из тела запроса
+func (h *Category) ImportCategories(c *fiber.Ctx) error {
+	files := config.TaxonomyFiles{}
+
+	if err := c.BodyParser(&files); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	resp, err := h.s.Import(c.UserContext(), files)
+	if err != nil {
+		return err
+	}
+
+	return importResponse(c, resp)
+}
+
+func importResponse(c *fiber.Ctx, resp category.ImportResponse) error {
+	if !resp.Applied {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(resp)
+	}
+	return c.JSON(resp)
+}
+
+// ExportCategories выгружает таксономию в формате файлов директории categories
+func (h *Category) ExportCategories(c *fiber.Ctx) error {
+	files, err := h.s.Export(c.UserContext())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(files)
+}
+
+func (h *Category) GetCategory(c *fiber.Ctx) error {
+	resp, err := h.s.GetCategory(c.UserContext(), c.Params("category_id"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(resp)
+}
+
+func (h *Category) CreateCategory(c *fiber.Ctx) error {
+	req := category.CategoryRequest{}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	resp, err := h.s.CreateCategory(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(resp)
+}
+
+func (h *Category) UpdateCategory(c *fiber.Ctx) error {
+	req := category.CategoryRequest{}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	req.ID = c.Params("category_id")
+
+	resp, err := h.s.UpdateCategory(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(resp)
+}
+
+func (h *Category) MoveCategory(c *fiber.Ctx) error {
+	req := category.MoveCategoryRequest{}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	req.ID = c.Params("category_id")
+
+	resp, err := h.s.MoveCategory(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(resp)
+}
+
+func (h *Category) DeleteCategory(c *fiber.Ctx) error {
+	return h.s.DeleteCategory(c.UserContext(), c.Params("category_id"))
+}
+
+func (h *Category) GetCharacteristic(c *fiber.Ctx) error {
+	resp, err := h.s.GetCharacteristic(c.UserContext(), c.Params("role"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(resp)
+}
+
+func (h *Category) SaveCharacteristic(c *fiber.Ctx) error {
+	req := category.CharacteristicRequest{}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	req.Role = c.Params("role")
+
+	resp, err := h.s.SaveCharacteristic(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(resp)
+}
+
+func (h *Category) DeleteCharacteristic(c *fiber.Ctx) error {
+	return h.s.DeleteCharacteristic(c.UserContext(), c.Params("role"))
+}
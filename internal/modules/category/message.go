@@ -0,0 +1,63 @@
+package category
+
+import (
+	"github.com/yaroslavvasilenko/argon/config"
+)
+
+// CategoryRequest создание или изменение категории
+type CategoryRequest struct {
+	ID string `json:"id"`
+	// ParentID родитель новой категории; для изменения категории не используется, перенос — через MoveCategoryRequest
+	ParentID *string `json:"parent_id,omitempty"`
+	// Position место среди соседей новой категории; по умолчанию — в конце
+	Position *int `json:"position,omitempty"`
+	// Names названия по языкам
+	Names map[string]string `json:"names"`
+	// Characteristics характеристики уровня категории в порядке вывода
+	Characteristics []config.CharacteristicNode `json:"characteristics"`
+}
+
+// MoveCategoryRequest перенос категории к другому родителю или на другое место среди соседей
+type MoveCategoryRequest struct {
+	ID string `json:"-"`
+	// ParentID новый родитель; без значения категория становится корневой
+	ParentID *string `json:"parent_id"`
+	Position int     `json:"position"`
+}
+
+// CategoryResponse категория в админке
+type CategoryResponse struct {
+	ID              string                      `json:"id"`
+	ParentID        *string                     `json:"parent_id"`
+	Position        int                         `json:"position"`
+	Names           map[string]string           `json:"names"`
+	Characteristics []config.CharacteristicNode `json:"characteristics"`
+	// Subcategories идентификаторы подкатегорий по порядку
+	Subcategories []string `json:"subcategories"`
+}
+
+// CharacteristicRequest создание или изменение характеристики реестра
+type CharacteristicRequest struct {
+	config.CharacteristicDefinition
+	// Position место в реестре; по умолчанию — в конце для новой характеристики и прежнее для существующей
+	Position *int `json:"position,omitempty"`
+	// Names названия по языкам
+	Names map[string]string `json:"names"`
+	// OptionNames переводы опций по языкам
+	OptionNames map[string]map[string]string `json:"option_names"`
+}
+
+// CharacteristicResponse характеристика реестра в админке
+type CharacteristicResponse struct {
+	config.CharacteristicDefinition
+	Position    int                          `json:"position"`
+	Names       map[string]string            `json:"names"`
+	OptionNames map[string]map[string]string `json:"option_names"`
+}
+
+// ImportResponse результат импорта таксономии из файлов
+type ImportResponse struct {
+	// Applied таксономия применена; при ошибках действует предыдущая версия
+	Applied bool                   `json:"applied"`
+	Issues  []config.TaxonomyIssue `json:"issues"`
+}
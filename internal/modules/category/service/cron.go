@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"time"
+)
+
+// categoryRefreshInterval период, за который изменения таксономии из другого экземпляра сервиса доходят до кэша
+const categoryRefreshInterval = time.Minute
+
+// RefreshCategoriesSync периодически перечитывает таксономию категорий из базы в кэш
+func (s *Category) RefreshCategoriesSync(stopChan chan struct{}) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.logger.Errorf("Panic in RefreshCategoriesSync: %v", r)
+		}
+	}()
+
+	s.logger.Infof("Starting category refresh cron task")
+
+	for {
+		select {
+		case <-stopChan:
+			s.logger.Infof("Category refresh cron task received stop signal")
+			return
+		case <-time.After(categoryRefreshInterval):
+		}
+
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					s.logger.Errorf("Panic in category refresh task: %v", r)
+				}
+			}()
+
+			ctx, cancel := context.WithTimeout(context.Background(), categoryRefreshInterval)
+			defer cancel()
+
+			if err := s.Refresh(ctx); err != nil {
+				s.logger.Errorf("Failed to refresh categories: %v", err)
+			}
+		}()
+	}
+}
@@ -0,0 +1,310 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yaroslavvasilenko/argon/config"
+	"github.com/yaroslavvasilenko/argon/internal/core/logger"
+	"github.com/yaroslavvasilenko/argon/internal/models"
+	"github.com/yaroslavvasilenko/argon/internal/modules/category"
+	"github.com/yaroslavvasilenko/argon/internal/modules/category/storage"
+)
+
+// Category управляет таксономией категорий в базе. Приложение читает таксономию
+// из кэша config.GetConfig().Categories, который сервис обновляет после каждого изменения
+type Category struct {
+	s      *storage.Category
+	logger *logger.Glog
+}
+
+func NewCategory(s *storage.Category, logger *logger.Glog) *Category {
+	return &Category{
+		s:      s,
+		logger: logger,
+	}
+}
+
+// Init импортирует файлы директории categories в пустую базу и загружает таксономию из базы в кэш
+func (s *Category) Init(ctx context.Context) error {
+	empty, err := s.s.IsEmpty(ctx)
+	if err != nil {
+		return err
+	}
+
+	if empty {
+		resp, err := s.ReloadCategories(ctx)
+		if err != nil {
+			return err
+		}
+		if !resp.Applied {
+			return fmt.Errorf("seeding category taxonomy: %w", config.ErrInvalidTaxonomy)
+		}
+		return nil
+	}
+
+	return s.Refresh(ctx)
+}
+
+// Refresh перечитывает таксономию из базы в кэш. Нужен, чтобы изменения, сделанные
+// через другой экземпляр сервиса, дошли и до этого
+func (s *Category) Refresh(ctx context.Context) error {
+	taxonomy, err := s.s.Load(ctx)
+	if err != nil {
+		return err
+	}
+
+	if report := taxonomy.Validate(); report.HasErrors() {
+		return fmt.Errorf("loading category taxonomy: %w", config.ErrInvalidTaxonomy)
+	}
+
+	config.SetCategories(taxonomy.Config())
+	return nil
+}
+
+// ReloadCategories импортирует в базу файлы директории categories
+func (s *Category) ReloadCategories(ctx context.Context) (category.ImportResponse, error) {
+	dir, err := config.TaxonomyDir()
+	if err != nil {
+		return category.ImportResponse{}, err
+	}
+
+	files, err := config.ReadTaxonomyFiles(dir)
+	if err != nil {
+		return category.ImportResponse{}, err
+	}
+
+	return s.Import(ctx, files)
+}
+
+// Import заменяет таксономию в базе содержимым файлов. Таксономия с битыми ссылками не применяется
+func (s *Category) Import(ctx context.Context, files config.TaxonomyFiles) (category.ImportResponse, error) {
+	taxonomy, report := config.ParseTaxonomy(files)
+	if !report.HasErrors() {
+		var err error
+		taxonomy, report, err = s.s.Replace(ctx, taxonomy)
+		if err != nil && !errors.Is(err, config.ErrInvalidTaxonomy) {
+			return category.ImportResponse{}, err
+		}
+	}
+
+	issues := report.Issues
+	if issues == nil {
+		issues = []config.TaxonomyIssue{}
+	}
+	if report.HasErrors() {
+		s.logger.Errorf("category taxonomy import rejected: %d issues", len(issues))
+		return category.ImportResponse{Applied: false, Issues: issues}, nil
+	}
+
+	config.SetCategories(taxonomy.Config())
+	s.logger.Infof("category taxonomy imported: %d issues", len(issues))
+
+	return category.ImportResponse{Applied: true, Issues: issues}, nil
+}
+
+// Export выгружает таксономию из базы в формате файлов директории categories
+func (s *Category) Export(ctx context.Context) (config.TaxonomyFiles, error) {
+	taxonomy, err := s.s.Load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return taxonomy.Files()
+}
+
+// GetCategory возвращает категорию с характеристиками уровня и названиями
+func (s *Category) GetCategory(ctx context.Context, id string) (category.CategoryResponse, error) {
+	taxonomy, err := s.s.Load(ctx)
+	if err != nil {
+		return category.CategoryResponse{}, err
+	}
+
+	return newCategoryResponse(taxonomy, id)
+}
+
+// CreateCategory добавляет категорию в дерево
+func (s *Category) CreateCategory(ctx context.Context, req category.CategoryRequest) (category.CategoryResponse, error) {
+	if err := checkNames(req.Names); err != nil {
+		return category.CategoryResponse{}, err
+	}
+
+	taxonomy, err := s.apply(s.s.CreateCategory(ctx, req))
+	if err != nil {
+		return category.CategoryResponse{}, err
+	}
+
+	return newCategoryResponse(taxonomy, req.ID)
+}
+
+// UpdateCategory заменяет характеристики уровня и названия категории
+func (s *Category) UpdateCategory(ctx context.Context, req category.CategoryRequest) (category.CategoryResponse, error) {
+	if err := checkNames(req.Names); err != nil {
+		return category.CategoryResponse{}, err
+	}
+
+	taxonomy, err := s.apply(s.s.UpdateCategory(ctx, req))
+	if err != nil {
+		return category.CategoryResponse{}, err
+	}
+
+	return newCategoryResponse(taxonomy, req.ID)
+}
+
+// MoveCategory переносит категорию в дереве
+func (s *Category) MoveCategory(ctx context.Context, req category.MoveCategoryRequest) (category.CategoryResponse, error) {
+	taxonomy, err := s.apply(s.s.MoveCategory(ctx, req))
+	if err != nil {
+		return category.CategoryResponse{}, err
+	}
+
+	return newCategoryResponse(taxonomy, req.ID)
+}
+
+// DeleteCategory удаляет категорию без подкатегорий и объявлений
+func (s *Category) DeleteCategory(ctx context.Context, id string) error {
+	_, err := s.apply(s.s.DeleteCategory(ctx, id))
+	return err
+}
+
+// GetCharacteristic возвращает характеристику реестра с переводами
+func (s *Category) GetCharacteristic(ctx context.Context, role string) (category.CharacteristicResponse, error) {
+	taxonomy, err := s.s.Load(ctx)
+	if err != nil {
+		return category.CharacteristicResponse{}, err
+	}
+
+	return newCharacteristicResponse(taxonomy, role)
+}
+
+// SaveCharacteristic создает или заменяет характеристику реестра
+func (s *Category) SaveCharacteristic(ctx context.Context, req category.CharacteristicRequest) (category.CharacteristicResponse, error) {
+	if err := checkNames(req.Names); err != nil {
+		return category.CharacteristicResponse{}, err
+	}
+	for lang := range req.OptionNames {
+		if !isTaxonomyLanguage(lang) {
+			return category.CharacteristicResponse{}, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("unsupported language %q", lang))
+		}
+	}
+	if req.Kind == "" {
+		return category.CharacteristicResponse{}, fiber.NewError(fiber.StatusBadRequest, "kind is required")
+	}
+
+	taxonomy, err := s.apply(s.s.SaveCharacteristic(ctx, req))
+	if err != nil {
+		return category.CharacteristicResponse{}, err
+	}
+
+	return newCharacteristicResponse(taxonomy, req.Role)
+}
+
+// DeleteCharacteristic удаляет характеристику, которую не использует ни одна категория
+func (s *Category) DeleteCharacteristic(ctx context.Context, role string) error {
+	_, err := s.apply(s.s.DeleteCharacteristic(ctx, role))
+	return err
+}
+
+// apply обновляет кэш после изменения таксономии или превращает замечания в ошибку ответа
+func (s *Category) apply(taxonomy config.Taxonomy, report config.TaxonomyReport, err error) (config.Taxonomy, error) {
+	if errors.Is(err, config.ErrInvalidTaxonomy) {
+		return config.Taxonomy{}, models.NewInvalidTaxonomyError(report.Issues)
+	}
+	if err != nil {
+		return config.Taxonomy{}, err
+	}
+
+	config.SetCategories(taxonomy.Config())
+	return taxonomy, nil
+}
+
+// checkNames проверяет, что названия заданы только для поддерживаемых языков
+func checkNames(names map[string]string) error {
+	for lang := range names {
+		if !isTaxonomyLanguage(lang) {
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("unsupported language %q", lang))
+		}
+	}
+	return nil
+}
+
+func isTaxonomyLanguage(lang string) bool {
+	for _, l := range config.TaxonomyLanguages {
+		if l == lang {
+			return true
+		}
+	}
+	return false
+}
+
+func newCategoryResponse(taxonomy config.Taxonomy, id string) (category.CategoryResponse, error) {
+	var find func(nodes []config.CategoryNode, parentID *string) (category.CategoryResponse, bool)
+	find = func(nodes []config.CategoryNode, parentID *string) (category.CategoryResponse, bool) {
+		for i, node := range nodes {
+			if node.ID == id {
+				resp := category.CategoryResponse{
+					ID:              node.ID,
+					ParentID:        parentID,
+					Position:        i,
+					Names:           make(map[string]string),
+					Characteristics: node.Characteristics,
+					Subcategories:   make([]string, 0, len(node.Subcategories)),
+				}
+				if resp.Characteristics == nil {
+					resp.Characteristics = []config.CharacteristicNode{}
+				}
+				for _, sub := range node.Subcategories {
+					resp.Subcategories = append(resp.Subcategories, sub.ID)
+				}
+				return resp, true
+			}
+
+			nodeID := node.ID
+			if resp, ok := find(node.Subcategories, &nodeID); ok {
+				return resp, true
+			}
+		}
+		return category.CategoryResponse{}, false
+	}
+
+	resp, ok := find(taxonomy.Categories, nil)
+	if !ok {
+		return category.CategoryResponse{}, fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("category %s not found", id))
+	}
+
+	for lang, translations := range taxonomy.Translations {
+		if name, ok := translations.Categories[id]; ok {
+			resp.Names[lang] = name
+		}
+	}
+
+	return resp, nil
+}
+
+func newCharacteristicResponse(taxonomy config.Taxonomy, role string) (category.CharacteristicResponse, error) {
+	for i, def := range taxonomy.Characteristics {
+		if def.Role != role {
+			continue
+		}
+
+		resp := category.CharacteristicResponse{
+			CharacteristicDefinition: def,
+			Position:                 i,
+			Names:                    make(map[string]string),
+			OptionNames:              make(map[string]map[string]string),
+		}
+		for lang, translations := range taxonomy.Translations {
+			if name, ok := translations.Characteristics[role]; ok {
+				resp.Names[lang] = name
+			}
+			if options, ok := translations.Options[role]; ok {
+				resp.OptionNames[lang] = options
+			}
+		}
+		return resp, nil
+	}
+
+	return category.CharacteristicResponse{}, fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("characteristic %s not found", role))
+}
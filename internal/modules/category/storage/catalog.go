@@ -0,0 +1,302 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/yaroslavvasilenko/argon/config"
+	"github.com/yaroslavvasilenko/argon/internal/modules/category"
+)
+
+// CreateCategory добавляет категорию с характеристиками уровня и названиями
+func (s *Category) CreateCategory(ctx context.Context, req category.CategoryRequest) (config.Taxonomy, config.TaxonomyReport, error) {
+	return s.apply(ctx, func(tx pgx.Tx) error {
+		if _, err := findCategory(ctx, tx, req.ID); err == nil {
+			return fiber.NewError(fiber.StatusConflict, fmt.Sprintf("category %s already exists", req.ID))
+		} else if !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+		if err := checkParent(ctx, tx, req.ID, req.ParentID); err != nil {
+			return err
+		}
+
+		_, err := tx.Exec(ctx, `INSERT INTO categories (id, parent_id) VALUES ($1, $2)`, req.ID, req.ParentID)
+		if err != nil {
+			return err
+		}
+		if err := placeCategory(ctx, tx, req.ID, req.ParentID, req.Position); err != nil {
+			return err
+		}
+		if err := saveCategoryCharacteristics(ctx, tx, req.ID, req.Characteristics); err != nil {
+			return err
+		}
+		return saveCategoryNames(ctx, tx, req.ID, req.Names)
+	})
+}
+
+// UpdateCategory заменяет характеристики уровня и названия категории. Если задана позиция,
+// категория переставляется среди соседей
+func (s *Category) UpdateCategory(ctx context.Context, req category.CategoryRequest) (config.Taxonomy, config.TaxonomyReport, error) {
+	return s.apply(ctx, func(tx pgx.Tx) error {
+		parentID, err := getCategory(ctx, tx, req.ID)
+		if err != nil {
+			return err
+		}
+
+		if req.Position != nil {
+			if err := placeCategory(ctx, tx, req.ID, parentID, req.Position); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.Exec(ctx, `UPDATE categories SET updated_at = NOW() WHERE id = $1`, req.ID); err != nil {
+			return err
+		}
+		if err := saveCategoryCharacteristics(ctx, tx, req.ID, req.Characteristics); err != nil {
+			return err
+		}
+		return saveCategoryNames(ctx, tx, req.ID, req.Names)
+	})
+}
+
+// MoveCategory переносит категорию вместе с подкатегориями к другому родителю
+// или на другое место среди соседей
+func (s *Category) MoveCategory(ctx context.Context, req category.MoveCategoryRequest) (config.Taxonomy, config.TaxonomyReport, error) {
+	return s.apply(ctx, func(tx pgx.Tx) error {
+		if _, err := getCategory(ctx, tx, req.ID); err != nil {
+			return err
+		}
+		if err := checkParent(ctx, tx, req.ID, req.ParentID); err != nil {
+			return err
+		}
+
+		position := req.Position
+		return placeCategory(ctx, tx, req.ID, req.ParentID, &position)
+	})
+}
+
+// DeleteCategory удаляет категорию без подкатегорий и объявлений
+func (s *Category) DeleteCategory(ctx context.Context, id string) (config.Taxonomy, config.TaxonomyReport, error) {
+	return s.apply(ctx, func(tx pgx.Tx) error {
+		if _, err := getCategory(ctx, tx, id); err != nil {
+			return err
+		}
+
+		var hasSubcategories, hasListings bool
+		err := tx.QueryRow(ctx, `
+			SELECT EXISTS (SELECT 1 FROM categories WHERE parent_id = $1),
+				EXISTS (SELECT 1 FROM listing_categories WHERE category_id = $1)
+		`, id).Scan(&hasSubcategories, &hasListings)
+		if err != nil {
+			return err
+		}
+		if hasSubcategories {
+			return fiber.NewError(fiber.StatusConflict, fmt.Sprintf("category %s has subcategories", id))
+		}
+		if hasListings {
+			return fiber.NewError(fiber.StatusConflict, fmt.Sprintf("category %s has listings", id))
+		}
+
+		_, err = tx.Exec(ctx, `DELETE FROM categories WHERE id = $1`, id)
+		return err
+	})
+}
+
+// SaveCharacteristic создает или заменяет характеристику реестра вместе с переводами
+func (s *Category) SaveCharacteristic(ctx context.Context, req category.CharacteristicRequest) (config.Taxonomy, config.TaxonomyReport, error) {
+	return s.apply(ctx, func(tx pgx.Tx) error {
+		position := req.Position
+		if position == nil {
+			var current int
+			err := tx.QueryRow(ctx, `SELECT position FROM characteristic_definitions WHERE role = $1`, req.Role).Scan(&current)
+			if err == nil {
+				position = &current
+			} else if !errors.Is(err, pgx.ErrNoRows) {
+				return err
+			}
+		}
+
+		if err := saveDefinition(ctx, tx, req.CharacteristicDefinition, 0); err != nil {
+			return err
+		}
+		if err := placeDefinition(ctx, tx, req.Role, position); err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(ctx, `DELETE FROM characteristic_translations WHERE role = $1`, req.Role); err != nil {
+			return err
+		}
+		for lang, name := range req.Names {
+			if err := saveCharacteristicName(ctx, tx, req.Role, lang, name); err != nil {
+				return err
+			}
+		}
+
+		if _, err := tx.Exec(ctx, `DELETE FROM characteristic_option_translations WHERE role = $1`, req.Role); err != nil {
+			return err
+		}
+		for lang, options := range req.OptionNames {
+			for option, label := range options {
+				if err := saveOptionLabel(ctx, tx, req.Role, option, lang, label); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+}
+
+// DeleteCharacteristic удаляет характеристику реестра, которую не использует ни одна категория
+func (s *Category) DeleteCharacteristic(ctx context.Context, role string) (config.Taxonomy, config.TaxonomyReport, error) {
+	return s.apply(ctx, func(tx pgx.Tx) error {
+		var categoryID string
+		err := tx.QueryRow(ctx, `
+			SELECT category_id FROM category_characteristics WHERE role = $1 ORDER BY category_id LIMIT 1
+		`, role).Scan(&categoryID)
+		if err == nil {
+			return fiber.NewError(fiber.StatusConflict, fmt.Sprintf("characteristic %s is used by category %s", role, categoryID))
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+
+		tag, err := tx.Exec(ctx, `DELETE FROM characteristic_definitions WHERE role = $1`, role)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("characteristic %s not found", role))
+		}
+
+		_, err = tx.Exec(ctx, `DELETE FROM characteristic_translations WHERE role = $1`, role)
+		return err
+	})
+}
+
+// findCategory возвращает родителя категории или pgx.ErrNoRows
+func findCategory(ctx context.Context, tx pgx.Tx, id string) (*string, error) {
+	var parentID *string
+	err := tx.QueryRow(ctx, `SELECT parent_id FROM categories WHERE id = $1`, id).Scan(&parentID)
+	return parentID, err
+}
+
+// getCategory возвращает родителя существующей категории
+func getCategory(ctx context.Context, tx pgx.Tx, id string) (*string, error) {
+	parentID, err := findCategory(ctx, tx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("category %s not found", id))
+	}
+	return parentID, err
+}
+
+// checkParent проверяет, что родитель существует и не лежит в поддереве категории
+func checkParent(ctx context.Context, tx pgx.Tx, id string, parentID *string) error {
+	if parentID == nil {
+		return nil
+	}
+
+	if _, err := findCategory(ctx, tx, *parentID); errors.Is(err, pgx.ErrNoRows) {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("parent category %s not found", *parentID))
+	} else if err != nil {
+		return err
+	}
+
+	var cycle bool
+	err := tx.QueryRow(ctx, `
+		WITH RECURSIVE subtree AS (
+			SELECT id FROM categories WHERE id = $1
+			UNION ALL
+			SELECT c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+		)
+		SELECT EXISTS (SELECT 1 FROM subtree WHERE id = $2)
+	`, id, *parentID).Scan(&cycle)
+	if err != nil {
+		return err
+	}
+	if cycle || *parentID == id {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("category %s cannot be moved under its own subcategory %s", id, *parentID))
+	}
+
+	return nil
+}
+
+// placeCategory ставит категорию под родителя на позицию среди соседей и перенумеровывает их.
+// Без позиции категория встает в конец
+func placeCategory(ctx context.Context, tx pgx.Tx, id string, parentID *string, position *int) error {
+	rows, err := tx.Query(ctx, `
+		SELECT id FROM categories
+		WHERE parent_id IS NOT DISTINCT FROM $1 AND id <> $2
+		ORDER BY position, id
+	`, parentID, id)
+	if err != nil {
+		return err
+	}
+	siblings, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return err
+	}
+
+	for i, sibling := range insertAt(siblings, id, position) {
+		_, err := tx.Exec(ctx, `
+			UPDATE categories SET parent_id = $2, position = $3, updated_at = NOW() WHERE id = $1
+		`, sibling, parentID, i)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// placeDefinition ставит характеристику на позицию в реестре и перенумеровывает остальные
+func placeDefinition(ctx context.Context, tx pgx.Tx, role string, position *int) error {
+	rows, err := tx.Query(ctx, `
+		SELECT role FROM characteristic_definitions WHERE role <> $1 ORDER BY position, role
+	`, role)
+	if err != nil {
+		return err
+	}
+	roles, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return err
+	}
+
+	for i, r := range insertAt(roles, role, position) {
+		if _, err := tx.Exec(ctx, `UPDATE characteristic_definitions SET position = $2 WHERE role = $1`, r, i); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// insertAt вставляет value на позицию position; позиция за пределами списка или без значения — в конец
+func insertAt(values []string, value string, position *int) []string {
+	i := len(values)
+	if position != nil && *position >= 0 && *position < len(values) {
+		i = *position
+	}
+
+	result := make([]string, 0, len(values)+1)
+	result = append(result, values[:i]...)
+	result = append(result, value)
+	return append(result, values[i:]...)
+}
+
+// saveCategoryNames заменяет названия категории
+func saveCategoryNames(ctx context.Context, tx pgx.Tx, id string, names map[string]string) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM category_translations WHERE category_id = $1`, id); err != nil {
+		return err
+	}
+
+	for lang, name := range names {
+		if err := saveCategoryName(ctx, tx, id, lang, name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,434 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/yaroslavvasilenko/argon/config"
+)
+
+// taxonomyLockKey ключ блокировки, под которой изменения таксономии выполняются по одному:
+// каждое изменение проверяется поверх предыдущего
+const taxonomyLockKey = 4700
+
+// foreignKeyViolation код ошибки Postgres для нарушенного внешнего ключа
+const foreignKeyViolation = "23503"
+
+type Category struct {
+	pool *pgxpool.Pool
+}
+
+func NewCategory(pool *pgxpool.Pool) *Category {
+	return &Category{pool: pool}
+}
+
+// querier общие методы пула и транзакции
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+// IsEmpty проверяет, что в базе еще нет ни одной категории
+func (s *Category) IsEmpty(ctx context.Context) (bool, error) {
+	var empty bool
+	err := s.pool.QueryRow(ctx, `SELECT NOT EXISTS (SELECT 1 FROM categories)`).Scan(&empty)
+	return empty, err
+}
+
+// Load возвращает таксономию из базы
+func (s *Category) Load(ctx context.Context) (config.Taxonomy, error) {
+	return loadTaxonomy(ctx, s.pool)
+}
+
+// apply выполняет изменение в транзакции и фиксирует его, только если таксономия после
+// изменения согласована. Иначе возвращает отчет и config.ErrInvalidTaxonomy
+func (s *Category) apply(ctx context.Context, change func(tx pgx.Tx) error) (config.Taxonomy, config.TaxonomyReport, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return config.Taxonomy{}, config.TaxonomyReport{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, taxonomyLockKey); err != nil {
+		return config.Taxonomy{}, config.TaxonomyReport{}, err
+	}
+
+	if err := change(tx); err != nil {
+		// ссылка на характеристику, которой нет в реестре
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+			return config.Taxonomy{}, config.TaxonomyReport{}, fiber.NewError(fiber.StatusBadRequest, pgErr.Detail)
+		}
+		return config.Taxonomy{}, config.TaxonomyReport{}, err
+	}
+
+	taxonomy, err := loadTaxonomy(ctx, tx)
+	if err != nil {
+		return config.Taxonomy{}, config.TaxonomyReport{}, err
+	}
+
+	report := taxonomy.Validate()
+	if report.HasErrors() {
+		return taxonomy, report, config.ErrInvalidTaxonomy
+	}
+
+	if err := rebuildClosure(ctx, tx); err != nil {
+		return config.Taxonomy{}, config.TaxonomyReport{}, err
+	}
+
+	return taxonomy, report, tx.Commit(ctx)
+}
+
+// rebuildClosure перестраивает замыкание дерева категорий, по которому поиск находит
+// объявления подкатегорий. Циклы в дереве исключает проверка при переносе категории
+func rebuildClosure(ctx context.Context, tx pgx.Tx) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM category_closure`); err != nil {
+		return err
+	}
+
+	_, err := tx.Exec(ctx, `
+		INSERT INTO category_closure (ancestor_id, descendant_id, depth)
+		WITH RECURSIVE tree AS (
+			SELECT id AS ancestor_id, id AS descendant_id, 0 AS depth FROM categories
+			UNION ALL
+			SELECT tree.ancestor_id, c.id, tree.depth + 1
+			FROM tree
+			JOIN categories c ON c.parent_id = tree.descendant_id
+		)
+		SELECT ancestor_id, descendant_id, depth FROM tree
+	`)
+	return err
+}
+
+// Replace заменяет всю таксономию в базе, например при импорте файлов. Как и DeleteCategory,
+// не удаляет категории, в которых есть объявления
+func (s *Category) Replace(ctx context.Context, taxonomy config.Taxonomy) (config.Taxonomy, config.TaxonomyReport, error) {
+	return s.apply(ctx, func(tx pgx.Tx) error {
+		ids := make([]string, 0)
+		for id := range taxonomy.CategoryCharacteristics() {
+			ids = append(ids, id)
+		}
+
+		var dropped []string
+		err := tx.QueryRow(ctx, `
+			SELECT COALESCE(array_agg(DISTINCT lc.category_id ORDER BY lc.category_id), '{}')
+			FROM listing_categories lc
+			JOIN categories c ON c.id = lc.category_id
+			WHERE NOT (lc.category_id = ANY($1))
+		`, ids).Scan(&dropped)
+		if err != nil {
+			return err
+		}
+		if len(dropped) > 0 {
+			return fiber.NewError(fiber.StatusConflict, fmt.Sprintf("categories %s have listings", strings.Join(dropped, ", ")))
+		}
+
+		_, err = tx.Exec(ctx, `
+			TRUNCATE TABLE characteristic_option_translations, characteristic_translations,
+				category_translations, category_characteristics, category_closure, categories,
+				characteristic_definitions
+		`)
+		if err != nil {
+			return err
+		}
+
+		for i, def := range taxonomy.Characteristics {
+			if err := saveDefinition(ctx, tx, def, i); err != nil {
+				return err
+			}
+		}
+
+		var insert func(nodes []config.CategoryNode, parentID *string) error
+		insert = func(nodes []config.CategoryNode, parentID *string) error {
+			for i, node := range nodes {
+				_, err := tx.Exec(ctx, `
+					INSERT INTO categories (id, parent_id, position) VALUES ($1, $2, $3)
+				`, node.ID, parentID, i)
+				if err != nil {
+					return err
+				}
+				if err := saveCategoryCharacteristics(ctx, tx, node.ID, node.Characteristics); err != nil {
+					return err
+				}
+				id := node.ID
+				if err := insert(node.Subcategories, &id); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		if err := insert(taxonomy.Categories, nil); err != nil {
+			return err
+		}
+
+		for lang, translations := range taxonomy.Translations {
+			for id, name := range translations.Categories {
+				if err := saveCategoryName(ctx, tx, id, lang, name); err != nil {
+					return err
+				}
+			}
+			for role, name := range translations.Characteristics {
+				if err := saveCharacteristicName(ctx, tx, role, lang, name); err != nil {
+					return err
+				}
+			}
+			for role, options := range translations.Options {
+				for option, label := range options {
+					if err := saveOptionLabel(ctx, tx, role, option, lang, label); err != nil {
+						return err
+					}
+				}
+			}
+		}
+
+		return nil
+	})
+}
+
+// loadTaxonomy читает дерево, реестр и переводы в порядке, заданном позициями
+func loadTaxonomy(ctx context.Context, q querier) (config.Taxonomy, error) {
+	taxonomy := config.Taxonomy{
+		Translations: make(map[string]config.TaxonomyTranslations, len(config.TaxonomyLanguages)),
+	}
+	for _, lang := range config.TaxonomyLanguages {
+		taxonomy.Translations[lang] = config.TaxonomyTranslations{
+			Categories:      make(map[string]string),
+			Characteristics: make(map[string]string),
+			Options:         make(map[string]map[string]string),
+		}
+	}
+
+	// Реестр характеристик
+	rows, err := q.Query(ctx, `
+		SELECT role, kind, options, units, default_unit, min_value, max_value, max_length, filter_mode
+		FROM characteristic_definitions
+		ORDER BY position, role
+	`)
+	if err != nil {
+		return config.Taxonomy{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var def config.CharacteristicDefinition
+		if err := rows.Scan(&def.Role, &def.Kind, &def.Options, &def.Units, &def.DefaultUnit,
+			&def.Min, &def.Max, &def.MaxLength, &def.Filter); err != nil {
+			return config.Taxonomy{}, err
+		}
+		def.Options = nilIfEmpty(def.Options)
+		def.Units = nilIfEmpty(def.Units)
+		taxonomy.Characteristics = append(taxonomy.Characteristics, def)
+	}
+	if err := rows.Err(); err != nil {
+		return config.Taxonomy{}, err
+	}
+
+	// Характеристики уровней
+	characteristics := make(map[string][]config.CharacteristicNode)
+	rows, err = q.Query(ctx, `
+		SELECT category_id, role, options, required, sort_order, min_value, max_value, unit
+		FROM category_characteristics
+		ORDER BY category_id, position, role
+	`)
+	if err != nil {
+		return config.Taxonomy{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var categoryID string
+		var node config.CharacteristicNode
+		if err := rows.Scan(&categoryID, &node.Role, &node.Options, &node.Required, &node.Order,
+			&node.Min, &node.Max, &node.Unit); err != nil {
+			return config.Taxonomy{}, err
+		}
+		node.Options = nilIfEmpty(node.Options)
+		characteristics[categoryID] = append(characteristics[categoryID], node)
+	}
+	if err := rows.Err(); err != nil {
+		return config.Taxonomy{}, err
+	}
+
+	// Дерево категорий
+	children := make(map[string][]string)
+	rows, err = q.Query(ctx, `SELECT id, COALESCE(parent_id, '') FROM categories ORDER BY position, id`)
+	if err != nil {
+		return config.Taxonomy{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, parentID string
+		if err := rows.Scan(&id, &parentID); err != nil {
+			return config.Taxonomy{}, err
+		}
+		children[parentID] = append(children[parentID], id)
+	}
+	if err := rows.Err(); err != nil {
+		return config.Taxonomy{}, err
+	}
+
+	var build func(parentID string) []config.CategoryNode
+	build = func(parentID string) []config.CategoryNode {
+		var nodes []config.CategoryNode
+		for _, id := range children[parentID] {
+			nodes = append(nodes, config.CategoryNode{
+				ID:              id,
+				Characteristics: characteristics[id],
+				Subcategories:   build(id),
+			})
+		}
+		return nodes
+	}
+	taxonomy.Categories = build("")
+
+	// Переводы
+	rows, err = q.Query(ctx, `SELECT category_id, lang, name FROM category_translations`)
+	if err != nil {
+		return config.Taxonomy{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, lang, name string
+		if err := rows.Scan(&id, &lang, &name); err != nil {
+			return config.Taxonomy{}, err
+		}
+		if translations, ok := taxonomy.Translations[lang]; ok {
+			translations.Categories[id] = name
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return config.Taxonomy{}, err
+	}
+
+	rows, err = q.Query(ctx, `SELECT role, lang, name FROM characteristic_translations`)
+	if err != nil {
+		return config.Taxonomy{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var role, lang, name string
+		if err := rows.Scan(&role, &lang, &name); err != nil {
+			return config.Taxonomy{}, err
+		}
+		if translations, ok := taxonomy.Translations[lang]; ok {
+			translations.Characteristics[role] = name
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return config.Taxonomy{}, err
+	}
+
+	rows, err = q.Query(ctx, `SELECT role, option, lang, label FROM characteristic_option_translations`)
+	if err != nil {
+		return config.Taxonomy{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var role, option, lang, label string
+		if err := rows.Scan(&role, &option, &lang, &label); err != nil {
+			return config.Taxonomy{}, err
+		}
+		if translations, ok := taxonomy.Translations[lang]; ok {
+			if translations.Options[role] == nil {
+				translations.Options[role] = make(map[string]string)
+			}
+			translations.Options[role][option] = label
+		}
+	}
+
+	return taxonomy, rows.Err()
+}
+
+func nilIfEmpty(values []string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+	return values
+}
+
+func emptyIfNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+func saveDefinition(ctx context.Context, tx pgx.Tx, def config.CharacteristicDefinition, position int) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO characteristic_definitions
+			(role, kind, options, units, default_unit, min_value, max_value, max_length, filter_mode, position)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (role) DO UPDATE
+		SET kind = EXCLUDED.kind,
+			options = EXCLUDED.options,
+			units = EXCLUDED.units,
+			default_unit = EXCLUDED.default_unit,
+			min_value = EXCLUDED.min_value,
+			max_value = EXCLUDED.max_value,
+			max_length = EXCLUDED.max_length,
+			filter_mode = EXCLUDED.filter_mode,
+			position = EXCLUDED.position,
+			updated_at = NOW()
+	`, def.Role, def.Kind, emptyIfNil(def.Options), emptyIfNil(def.Units), def.DefaultUnit,
+		def.Min, def.Max, def.MaxLength, def.Filter, position)
+
+	return err
+}
+
+// saveCategoryCharacteristics заменяет характеристики уровня категории
+func saveCategoryCharacteristics(ctx context.Context, tx pgx.Tx, categoryID string, characteristics []config.CharacteristicNode) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM category_characteristics WHERE category_id = $1`, categoryID); err != nil {
+		return err
+	}
+
+	for i, node := range characteristics {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO category_characteristics
+				(category_id, role, position, options, required, sort_order, min_value, max_value, unit)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		`, categoryID, node.Role, i, emptyIfNil(node.Options), node.Required, node.Order, node.Min, node.Max, node.Unit)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func saveCategoryName(ctx context.Context, tx pgx.Tx, categoryID, lang, name string) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO category_translations (category_id, lang, name) VALUES ($1, $2, $3)
+		ON CONFLICT (category_id, lang) DO UPDATE SET name = EXCLUDED.name
+	`, categoryID, lang, name)
+
+	return err
+}
+
+func saveCharacteristicName(ctx context.Context, tx pgx.Tx, role, lang, name string) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO characteristic_translations (role, lang, name) VALUES ($1, $2, $3)
+		ON CONFLICT (role, lang) DO UPDATE SET name = EXCLUDED.name
+	`, role, lang, name)
+
+	return err
+}
+
+func saveOptionLabel(ctx context.Context, tx pgx.Tx, role, option, lang, label string) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO characteristic_option_translations (role, option, lang, label) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (role, option, lang) DO UPDATE SET label = EXCLUDED.label
+	`, role, option, lang, label)
+
+	return err
+}
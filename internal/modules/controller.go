@@ -2,10 +2,12 @@ package modules
 
 import (
 	bcontroller "github.com/yaroslavvasilenko/argon/internal/modules/boost/controller"
+	catcontroller "github.com/yaroslavvasilenko/argon/internal/modules/category/controller"
 	ccontroller "github.com/yaroslavvasilenko/argon/internal/modules/currency/controller"
 	icontroller "github.com/yaroslavvasilenko/argon/internal/modules/image/controller"
 	lcontroller "github.com/yaroslavvasilenko/argon/internal/modules/listing/controller"
 	loccontroller "github.com/yaroslavvasilenko/argon/internal/modules/location/controller"
+	ocontroller "github.com/yaroslavvasilenko/argon/internal/modules/order/controller"
 )
 
 type Controllers struct {
@@ -14,14 +16,18 @@ type Controllers struct {
 	Location *loccontroller.Location
 	Boost    *bcontroller.Boost
 	Image    *icontroller.Image
+	Order    *ocontroller.Order
+	Category *catcontroller.Category
 }
 
 func NewControllers(services *Services) *Controllers {
 	return &Controllers{
-		Listing:  lcontroller.NewListing(services.listing),
+		Listing:  lcontroller.NewListing(services.Listing),
 		Currency: ccontroller.NewCurrency(services.currency),
 		Location: loccontroller.NewLocation(services.location),
-		Boost:    bcontroller.NewBoost(services.boost),
+		Boost:    bcontroller.NewBoost(services.Boost),
 		Image:    icontroller.NewImage(services.Image),
+		Order:    ocontroller.NewOrder(services.Order),
+		Category: catcontroller.NewCategory(services.Category),
 	}
 }
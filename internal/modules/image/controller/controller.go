@@ -1,12 +1,16 @@
 package controller
 
 import (
+	"errors"
 	"github.com/yaroslavvasilenko/argon/internal/core/parser"
 	"net/http"
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/yaroslavvasilenko/argon/internal/modules/image"
 	"github.com/yaroslavvasilenko/argon/internal/modules/image/service"
+	"github.com/yaroslavvasilenko/argon/internal/modules/image/storage"
 )
 
 type Image struct {
@@ -91,6 +95,11 @@ func (h *Image) GetImage(c *fiber.Ctx) error {
 
 	image, err := h.s.GetImage(c.UserContext(), req.Id)
 	if err != nil {
+		if errors.Is(err, storage.ErrFileNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Image not found",
+			})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to get image",
 		})
@@ -103,3 +112,44 @@ func (h *Image) GetImage(c *fiber.Ctx) error {
 	// Send the image stream to the client
 	return c.SendStream(image)
 }
+
+func (h *Image) GetDuplicates(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("image_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid image ID",
+		})
+	}
+
+	resp, err := h.s.GetDuplicates(c.UserContext(), id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(resp)
+}
+
+func (h *Image) ModerateImage(c *fiber.Ctx) error {
+	req := image.ModerateImageRequest{}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	id, err := uuid.Parse(c.Params("image_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid image ID",
+		})
+	}
+	req.ImageID = id
+
+	resp, err := h.s.ModerateImage(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(resp)
+}
@@ -0,0 +1,31 @@
+package image
+
+import "github.com/google/uuid"
+
+// DuplicateImage изображение, перцептивно совпадающее с искомым
+type DuplicateImage struct {
+	ImageID       uuid.UUID   `json:"image_id"`
+	PHashDistance int         `json:"phash_distance"`
+	DHashDistance int         `json:"dhash_distance"`
+	ListingIDs    []uuid.UUID `json:"listing_ids"`
+}
+
+type GetDuplicatesResponse struct {
+	ImageID    uuid.UUID        `json:"image_id"`
+	PHash      string           `json:"phash"`
+	DHash      string           `json:"dhash"`
+	Duplicates []DuplicateImage `json:"duplicates"`
+}
+
+type ModerateImageRequest struct {
+	ImageID uuid.UUID `json:"-"`
+	IsNSFW  bool      `json:"is_nsfw"`
+	Reason  string    `json:"reason"`
+}
+
+type ModerationResponse struct {
+	ImageID    uuid.UUID   `json:"image_id"`
+	IsNSFW     bool        `json:"is_nsfw"`
+	Reason     string      `json:"reason"`
+	ListingIDs []uuid.UUID `json:"listing_ids"`
+}
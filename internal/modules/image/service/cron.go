@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/rotisserie/eris"
+	"github.com/yaroslavvasilenko/argon/internal/models"
 )
 
 func (s *Image) DeleteImageSync(stopChan chan struct{}) {
@@ -55,34 +56,49 @@ func (s *Image) DeleteImageSync(stopChan chan struct{}) {
 }
 
 
-// DeleteImage удаляет изображения, которые не связаны с объявлениями более часа
-func (s *Image) DeleteImage(ctx context.Context) (string, error) {
+// imageRetention время, в течение которого непривязанные изображения хранятся до удаления
+const imageRetention = time.Hour
 
-	// Получаем текущее время
-	now := time.Now()
+// DeleteImage удаляет изображения, которые не привязаны к объявлениям дольше imageRetention,
+// а также файлы в хранилище, о которых нет записей в базе данных
+func (s *Image) DeleteImage(ctx context.Context) (string, error) {
+	olderThan := time.Now().Add(-imageRetention)
 
-	// Получаем список несвязанных изображений из базы данных
-	unlinkedImages, err := s.s.GetUnlinkedImages(ctx, now.Add(-1 * time.Hour))
+	// Получаем загруженные, но не привязанные, и отвязанные изображения
+	statuses := []models.ImageStatus{models.ImageStatusUploaded, models.ImageStatusDetached}
+	expiredLinks, err := s.s.GetExpiredImageLinks(ctx, statuses, olderThan)
 	if err != nil {
 		return "", eris.Wrapf(err, "Failed to get unlinked images: %v", err)
 	}
 
-	var deletedCount int
 	var errors []string
 
-	// Удаляем каждое несвязанное изображение
-	for _, imageName := range unlinkedImages {
-		err := s.s.DeleteFile(ctx, imageName)
-		if err != nil {
-			errors = append(errors, fmt.Sprintf("Error deleting image %s: %v", imageName, err))
-			continue
-		}
+	expiredNames := make([]string, 0, len(expiredLinks))
+	for _, link := range expiredLinks {
+		expiredNames = append(expiredNames, link.NameImage)
+	}
 
-		deletedCount++
+	// Переводим записи в состояние deleted, повторно проверяя их состояние: изображение могли
+	// привязать к объявлению после выборки, и тогда его файлы удалять нельзя
+	deletedNames, err := s.s.MarkImageFilesDeleted(ctx, expiredNames, statuses, olderThan)
+	if err != nil {
+		errors = append(errors, err.Error())
 	}
 
+	// Удаляем файлы только помеченных записей. Файл, который не удалось удалить, останется
+	// без живой записи и будет удален вместе с файлами-сиротами
+	for _, name := range deletedNames {
+		if err := s.s.DeleteFile(ctx, name); err != nil {
+			errors = append(errors, fmt.Sprintf("Error deleting image %s: %v", name, err))
+		}
+	}
+
+	// Ищем файлы-сироты в хранилище, о которых нет живых записей в базе
+	orphanCount, orphanErrors := s.deleteOrphanFiles(ctx, olderThan)
+	errors = append(errors, orphanErrors...)
+
 	// Формируем отчет о выполнении
-	result := fmt.Sprintf("Deleted %d unused images", deletedCount)
+	result := fmt.Sprintf("Deleted %d unused images, %d orphan files", len(deletedNames), orphanCount)
 	if len(errors) > 0 {
 		result += fmt.Sprintf(". Errors encountered: %d", len(errors))
 		return result, eris.New(strings.Join(errors, "; "))
@@ -91,4 +107,38 @@ func (s *Image) DeleteImage(ctx context.Context) (string, error) {
 	return result, nil
 }
 
+// deleteOrphanFiles удаляет из хранилища файлы старше olderThan, для которых нет записей в image_links
+func (s *Image) deleteOrphanFiles(ctx context.Context, olderThan time.Time) (int, []string) {
+	files, err := s.s.ListFiles(ctx)
+	if err != nil {
+		return 0, []string{err.Error()}
+	}
+
+	liveNames, err := s.s.GetLiveImageNames(ctx)
+	if err != nil {
+		return 0, []string{err.Error()}
+	}
 
+	var deletedCount int
+	var errors []string
+
+	for _, file := range files {
+		if _, ok := liveNames[file.Name]; ok {
+			continue
+		}
+
+		// Свежие файлы пропускаем: запись о них может появиться чуть позже загрузки
+		if file.LastModified.After(olderThan) {
+			continue
+		}
+
+		if err := s.s.DeleteFile(ctx, file.Name); err != nil {
+			errors = append(errors, fmt.Sprintf("Error deleting orphan file %s: %v", file.Name, err))
+			continue
+		}
+
+		deletedCount++
+	}
+
+	return deletedCount, errors
+}
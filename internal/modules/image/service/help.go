@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/google/uuid"
 	"github.com/rotisserie/eris"
 )
 
@@ -21,15 +22,15 @@ func (s *Image) GetImageID(ctx context.Context, url string) (string, error) {
 	}
 
 	// Извлекаем UUID из имени файла (UUID-size.webp)
-	uuidParts := strings.Split(imageFileName, "-")
-	if len(uuidParts) < 2 {
+	sizeIndex := strings.LastIndex(imageFileName, "-")
+	if sizeIndex == -1 {
 		return "", eris.New("invalid filename format: missing size suffix")
 	}
 
 	// Проверка формата UUID
-	uuidStr := uuidParts[0]
-	if len(uuidStr) != 36 { // Стандартная длина UUID в строковом представлении
-		return "", eris.New("invalid UUID length in filename")
+	uuidStr := imageFileName[:sizeIndex]
+	if _, err := uuid.Parse(uuidStr); err != nil {
+		return "", eris.Wrapf(err, "invalid UUID in filename %s", imageFileName)
 	}
 
 	// Возвращаем базовый UUID изображения
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/yaroslavvasilenko/argon/internal/models"
+	"github.com/yaroslavvasilenko/argon/internal/modules/image/storage"
+)
+
+// ListingImagesPlan проверенное изменение набора изображений объявления.
+// Записывается методом Apply в транзакции объявления, поэтому ошибки в изображениях
+// не оставляют объявление сохраненным наполовину
+type ListingImagesPlan struct {
+	s         *storage.Image
+	listingID uuid.UUID
+	images    []models.ListingImage
+	attach    []uuid.UUID
+	detach    []uuid.UUID
+}
+
+// PlanListingImages проверяет переданный список изображений объявления, ничего не записывая:
+// новые изображения будут привязаны, отсутствующие в списке — отвязаны,
+// порядок, обложка и подписи сохранятся так, как переданы в запросе
+func (s *Image) PlanListingImages(ctx context.Context, listingID uuid.UUID, inputs []models.ListingImageInput) (*ListingImagesPlan, error) {
+	images, err := s.buildListingImages(ctx, inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	currentIDs, err := s.s.GetListingImageIDs(ctx, listingID)
+	if err != nil {
+		return nil, err
+	}
+
+	current := make(map[uuid.UUID]struct{}, len(currentIDs))
+	for _, id := range currentIDs {
+		current[id] = struct{}{}
+	}
+
+	plan := &ListingImagesPlan{
+		s:         s.s,
+		listingID: listingID,
+		images:    images,
+		attach:    make([]uuid.UUID, 0, len(images)),
+		detach:    make([]uuid.UUID, 0),
+	}
+
+	wanted := make(map[uuid.UUID]struct{}, len(images))
+	for _, image := range images {
+		wanted[image.ID] = struct{}{}
+		if _, ok := current[image.ID]; !ok {
+			plan.attach = append(plan.attach, image.ID)
+		}
+	}
+
+	for _, id := range currentIDs {
+		if _, ok := wanted[id]; !ok {
+			plan.detach = append(plan.detach, id)
+		}
+	}
+
+	if err := s.checkTransition(ctx, plan.attach, models.ImageStatusAttached); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkTransition(ctx, plan.detach, models.ImageStatusDetached); err != nil {
+		return nil, err
+	}
+
+	return plan, nil
+}
+
+// Apply записывает изменение изображений в транзакции tx
+func (p *ListingImagesPlan) Apply(ctx context.Context, tx pgx.Tx) error {
+	return p.s.ApplyListingImages(ctx, tx, p.listingID, p.images, p.attach, p.detach)
+}
+
+// checkTransition проверяет, что все изображения существуют и могут перейти в состояние status
+func (s *Image) checkTransition(ctx context.Context, imageIDs []uuid.UUID, status models.ImageStatus) error {
+	if len(imageIDs) == 0 {
+		return nil
+	}
+
+	links, err := s.s.GetImageLinks(ctx, imageIDs)
+	if err != nil {
+		return err
+	}
+
+	found := make(map[uuid.UUID]struct{}, len(imageIDs))
+	for _, link := range links {
+		found[link.ImageID] = struct{}{}
+
+		if !link.Status.CanTransitionTo(status) {
+			return fiber.NewError(fiber.StatusBadRequest,
+				fmt.Sprintf("image %s cannot be moved from %s to %s", link.ImageID, link.Status, status))
+		}
+	}
+
+	for _, id := range imageIDs {
+		if _, ok := found[id]; !ok {
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("image %s not found", id))
+		}
+	}
+
+	return nil
+}
+
+// buildListingImages превращает изображения из запроса в упорядоченные записи без повторов.
+// Если обложка не указана, ей становится первое изображение
+func (s *Image) buildListingImages(ctx context.Context, inputs []models.ListingImageInput) ([]models.ListingImage, error) {
+	seen := make(map[uuid.UUID]struct{}, len(inputs))
+	images := make([]models.ListingImage, 0, len(inputs))
+	hasCover := false
+
+	for _, input := range inputs {
+		id := input.ID
+		if id == uuid.Nil {
+			idStr, err := s.GetImageID(ctx, input.URL)
+			if err != nil {
+				return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("invalid image url %s: %v", input.URL, err))
+			}
+			id = uuid.MustParse(idStr)
+		}
+
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+
+		if input.IsCover {
+			if hasCover {
+				return nil, fiber.NewError(fiber.StatusBadRequest, "only one image can be the cover")
+			}
+			hasCover = true
+		}
+
+		images = append(images, models.ListingImage{
+			ID:       id,
+			Position: len(images),
+			IsCover:  input.IsCover,
+			Alt:      input.Alt,
+		})
+	}
+
+	if !hasCover && len(images) > 0 {
+		images[0].IsCover = true
+	}
+
+	return images, nil
+}
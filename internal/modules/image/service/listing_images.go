@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/yaroslavvasilenko/argon/internal/models"
+)
+
+// GetListingImages возвращает изображения объявления в порядке показа с URL всех вариантов
+func (s *Image) GetListingImages(ctx context.Context, listingID uuid.UUID) ([]models.ListingImage, error) {
+	images, err := s.s.GetListingImages(ctx, listingID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range images {
+		images[i].Variants = models.ImageVariants{
+			Full:      ImageURL(images[i].Variants.Full),
+			Thumbnail: ImageURL(images[i].Variants.Thumbnail),
+		}
+	}
+
+	return images, nil
+}
+
+// PlanImagesOrder проверяет новый порядок изображений объявления и возвращает запись, которая
+// выполняется в транзакции объявления. Список должен содержать каждое изображение ровно один раз
+func (s *Image) PlanImagesOrder(ctx context.Context, listingID uuid.UUID, imageIDs []uuid.UUID) (func(context.Context, pgx.Tx) error, error) {
+	currentIDs, err := s.s.GetListingImageIDs(ctx, listingID)
+	if err != nil {
+		return nil, err
+	}
+
+	current := make(map[uuid.UUID]struct{}, len(currentIDs))
+	for _, id := range currentIDs {
+		current[id] = struct{}{}
+	}
+
+	if len(imageIDs) != len(currentIDs) {
+		return nil, fiber.NewError(fiber.StatusBadRequest,
+			fmt.Sprintf("expected %d images, got %d", len(currentIDs), len(imageIDs)))
+	}
+
+	seen := make(map[uuid.UUID]struct{}, len(imageIDs))
+	for _, id := range imageIDs {
+		if _, ok := current[id]; !ok {
+			return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("image %s does not belong to listing", id))
+		}
+		if _, ok := seen[id]; ok {
+			return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("image %s is listed twice", id))
+		}
+		seen[id] = struct{}{}
+	}
+
+	return func(ctx context.Context, tx pgx.Tx) error {
+		return s.s.UpdateListingImagesOrder(ctx, tx, listingID, imageIDs)
+	}, nil
+}
+
+// PlanListingCover проверяет, что изображение принадлежит объявлению, и возвращает запись
+// новой обложки, которая выполняется в транзакции объявления
+func (s *Image) PlanListingCover(ctx context.Context, listingID, imageID uuid.UUID) (func(context.Context, pgx.Tx) error, error) {
+	currentIDs, err := s.s.GetListingImageIDs(ctx, listingID)
+	if err != nil {
+		return nil, err
+	}
+
+	found := false
+	for _, id := range currentIDs {
+		if id == imageID {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("image %s does not belong to listing", imageID))
+	}
+
+	return func(ctx context.Context, tx pgx.Tx) error {
+		return s.s.SetListingCover(ctx, tx, listingID, imageID)
+	}, nil
+}
+
+// ImageURL возвращает публичный URL файла изображения; для пустого имени возвращает пустую строку
+func ImageURL(imageName string) string {
+	if imageName == "" {
+		return ""
+	}
+
+	return createUrlForImage(imageName)
+}
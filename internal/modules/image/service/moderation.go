@@ -0,0 +1,206 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	goimage "image"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yaroslavvasilenko/argon/config"
+	"github.com/yaroslavvasilenko/argon/internal/core/imagehash"
+	"github.com/yaroslavvasilenko/argon/internal/core/logger"
+	"github.com/yaroslavvasilenko/argon/internal/models"
+	"github.com/yaroslavvasilenko/argon/internal/modules/image"
+)
+
+// defaultHashDistance расстояние Хэмминга, в пределах которого изображения считаются дубликатами
+const defaultHashDistance = 8
+
+// ModerationSample данные изображения, передаваемые на модерацию
+type ModerationSample struct {
+	ImageID uuid.UUID
+	Pixels  goimage.Image
+	PHash   uint64
+	DHash   uint64
+}
+
+// ModerationVerdict решение модерации по изображению
+type ModerationVerdict struct {
+	IsNSFW bool
+	Reason string
+}
+
+// Moderator проверяет загруженное изображение
+type Moderator interface {
+	Moderate(ctx context.Context, sample ModerationSample) (ModerationVerdict, error)
+}
+
+// RulesModerator локальная модерация по правилам: совпадение с запрещенными хешами
+// и доля пикселей телесного цвета
+type RulesModerator struct {
+	blockedHashes []uint64
+	hashDistance  int
+	skinRatio     float64
+}
+
+// NewRulesModerator создает модерацию по правилам из конфигурации; некорректные хеши пропускаются
+func NewRulesModerator(cfg config.Config, lg *logger.Glog) *RulesModerator {
+	m := &RulesModerator{
+		hashDistance: cfg.Moderation.HashDistance,
+		skinRatio:    cfg.Moderation.SkinRatio,
+	}
+	if m.hashDistance <= 0 {
+		m.hashDistance = defaultHashDistance
+	}
+
+	for _, s := range cfg.Moderation.BlockedHashes {
+		hash, err := imagehash.Parse(s)
+		if err != nil {
+			lg.Warnf("skipping invalid blocked image hash %q: %v", s, err)
+			continue
+		}
+		m.blockedHashes = append(m.blockedHashes, hash)
+	}
+
+	return m
+}
+
+func (m *RulesModerator) Moderate(ctx context.Context, sample ModerationSample) (ModerationVerdict, error) {
+	for _, blocked := range m.blockedHashes {
+		if distance := imagehash.Distance(sample.PHash, blocked); distance <= m.hashDistance {
+			return ModerationVerdict{
+				IsNSFW: true,
+				Reason: fmt.Sprintf("matches blocked image %s (distance %d)", imagehash.Format(blocked), distance),
+			}, nil
+		}
+	}
+
+	// Нулевой порог отключает проверку телесного цвета
+	if m.skinRatio > 0 && sample.Pixels != nil {
+		if ratio := skinPixelRatio(sample.Pixels); ratio >= m.skinRatio {
+			return ModerationVerdict{
+				IsNSFW: true,
+				Reason: fmt.Sprintf("skin tone ratio %.2f exceeds %.2f", ratio, m.skinRatio),
+			}, nil
+		}
+	}
+
+	return ModerationVerdict{}, nil
+}
+
+// skinPixelRatio возвращает долю пикселей, попадающих в диапазон телесного цвета RGB (правило Kovac)
+func skinPixelRatio(img goimage.Image) float64 {
+	bounds := img.Bounds()
+	total := bounds.Dx() * bounds.Dy()
+	if total == 0 {
+		return 0
+	}
+
+	var skin int
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r32, g32, b32, _ := img.At(x, y).RGBA()
+			r, g, b := int(r32>>8), int(g32>>8), int(b32>>8)
+
+			maxC := max(r, g, b)
+			minC := min(r, g, b)
+			if r > 95 && g > 40 && b > 20 && maxC-minC > 15 && abs(r-g) > 15 && r > g && r > b {
+				skin++
+			}
+		}
+	}
+
+	return float64(skin) / float64(total)
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// GetDuplicates возвращает изображения, перцептивно совпадающие с указанным
+func (s *Image) GetDuplicates(ctx context.Context, imageID uuid.UUID) (image.GetDuplicatesResponse, error) {
+	img, err := s.s.GetImage(ctx, imageID)
+	if err != nil {
+		return image.GetDuplicatesResponse{}, err
+	}
+
+	similar, err := s.s.FindSimilarImages(ctx, img, s.duplicateDistance)
+	if err != nil {
+		return image.GetDuplicatesResponse{}, err
+	}
+
+	resp := image.GetDuplicatesResponse{
+		ImageID:    img.ID,
+		PHash:      imagehash.Format(uint64(img.PHash)),
+		DHash:      imagehash.Format(uint64(img.DHash)),
+		Duplicates: make([]image.DuplicateImage, 0, len(similar)),
+	}
+	for _, item := range similar {
+		resp.Duplicates = append(resp.Duplicates, image.DuplicateImage{
+			ImageID:       item.ImageID,
+			PHashDistance: item.PHashDistance,
+			DHashDistance: item.DHashDistance,
+			ListingIDs:    item.ListingIDs,
+		})
+	}
+
+	return resp, nil
+}
+
+// ModerateImage вручную помечает изображение и пересчитывает флаг NSFW объявлений, где оно используется
+func (s *Image) ModerateImage(ctx context.Context, req image.ModerateImageRequest) (image.ModerationResponse, error) {
+	if err := s.s.UpdateImageModeration(ctx, req.ImageID, req.IsNSFW, req.Reason); err != nil {
+		return image.ModerationResponse{}, err
+	}
+
+	listingIDs, err := s.s.GetImageListingIDs(ctx, req.ImageID)
+	if err != nil {
+		return image.ModerationResponse{}, err
+	}
+
+	if err := s.s.RefreshListingsNSFW(ctx, listingIDs); err != nil {
+		return image.ModerationResponse{}, err
+	}
+
+	return image.ModerationResponse{
+		ImageID:    req.ImageID,
+		IsNSFW:     req.IsNSFW,
+		Reason:     req.Reason,
+		ListingIDs: listingIDs,
+	}, nil
+}
+
+// moderate вычисляет перцептивные хеши изображения и прогоняет его через модерацию
+func (s *Image) moderate(ctx context.Context, imageID uuid.UUID, pixels goimage.Image) (models.Image, error) {
+	pHash := imagehash.PHash(pixels)
+	dHash := imagehash.DHash(pixels)
+
+	verdict, err := s.moderator.Moderate(ctx, ModerationSample{
+		ImageID: imageID,
+		Pixels:  pixels,
+		PHash:   pHash,
+		DHash:   dHash,
+	})
+	if err != nil {
+		return models.Image{}, err
+	}
+
+	timeNow := time.Now()
+	img := models.Image{
+		ID:               imageID,
+		PHash:            int64(pHash),
+		DHash:            int64(dHash),
+		IsNSFW:           verdict.IsNSFW,
+		ModerationReason: verdict.Reason,
+		ModeratedAt:      &timeNow,
+	}
+	if verdict.IsNSFW {
+		s.log.Warnf("image %s flagged by moderation: %s", imageID, verdict.Reason)
+	}
+
+	return img, nil
+}
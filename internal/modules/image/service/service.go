@@ -15,17 +15,26 @@ import (
 	"github.com/rotisserie/eris"
 	"github.com/yaroslavvasilenko/argon/internal/core/logger"
 	"github.com/yaroslavvasilenko/argon/internal/modules/image/storage"
+	"golang.org/x/image/webp"
 )
 
 type Image struct {
-	s        *storage.Image
-	log      *logger.Glog
+	s         *storage.Image
+	moderator Moderator
+	log       *logger.Glog
+	// duplicateDistance расстояние Хэмминга, в пределах которого изображения считаются дубликатами
+	duplicateDistance int
 }
 
-func NewImage(s *storage.Image, logger *logger.Glog) *Image {
+func NewImage(s *storage.Image, moderator Moderator, cfg config.Config, logger *logger.Glog) *Image {
 	srv := &Image{
-		s:        s,
-		log:      logger,
+		s:                 s,
+		moderator:         moderator,
+		log:               logger,
+		duplicateDistance: cfg.Moderation.HashDistance,
+	}
+	if srv.duplicateDistance <= 0 {
+		srv.duplicateDistance = defaultHashDistance
 	}
 
 	return srv
@@ -86,8 +95,11 @@ func (s *Image) SaveImage(ctx context.Context, file multipart.File, name, conten
 		return nil, eris.Wrap(err, "failed to export 400px image to WebP")
 	}
 
+	// Оба размера изображения используют общий идентификатор
+	imageID := uuid.New()
+
 	// Upload image MinIO 400px
-	imageName400px, err := s.s.UploadImage(ctx, s.getFileName(ctx, uuid.New().String(), "400px"), bytes.NewReader(webpBytes400))
+	imageName400px, err := s.s.UploadImage(ctx, s.getFileName(ctx, imageID.String(), storage.VariantFull), bytes.NewReader(webpBytes400))
 	if err != nil {
 		return nil, eris.Wrap(err, "failed to upload 400px image")
 	}
@@ -99,11 +111,29 @@ func (s *Image) SaveImage(ctx context.Context, file multipart.File, name, conten
 	}
 
 	// Upload image MinIO 200px
-	imageName200px, err := s.s.UploadImage(ctx, s.getFileName(ctx, uuid.New().String(), "200px"), bytes.NewReader(webpBytes200))
+	imageName200px, err := s.s.UploadImage(ctx, s.getFileName(ctx, imageID.String(), storage.VariantThumbnail), bytes.NewReader(webpBytes200))
 	if err != nil {
 		return nil, eris.Wrap(err, "failed to upload 200px image")
 	}
 
+	// Перцептивные хеши и модерацию считаем по уменьшенной копии: результат не зависит от размера
+	pixels, err := webp.Decode(bytes.NewReader(webpBytes200))
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to decode 200px image for hashing")
+	}
+
+	imageMeta, err := s.moderate(ctx, imageID, pixels)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to moderate image")
+	}
+	imageMeta.Width = im400.Width()
+	imageMeta.Height = im400.Height()
+
+	// Регистрируем изображение в состоянии uploaded, чтобы непривязанные файлы были удалены по крону
+	if err := s.s.CreateUploadedImage(ctx, imageMeta, []string{imageName400px, imageName200px}); err != nil {
+		return nil, eris.Wrap(err, "failed to register uploaded image")
+	}
+
 	return []string{createUrlForImage(imageName400px), createUrlForImage(imageName200px)}, nil
 }
 
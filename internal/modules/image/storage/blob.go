@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/rotisserie/eris"
+	"github.com/yaroslavvasilenko/argon/config"
+)
+
+// Поддерживаемые бэкенды файлового хранилища
+const (
+	BlobBackendMinio      = "minio"
+	BlobBackendFileSystem = "fs"
+	BlobBackendMemory     = "memory"
+)
+
+// ErrFileNotFound возвращается, если файл отсутствует в хранилище
+var ErrFileNotFound = errors.New("file not found")
+
+// Blob описывает файловое хранилище изображений
+type Blob interface {
+	// Put сохраняет файл под указанным именем, перезаписывая существующий
+	Put(ctx context.Context, name string, r io.Reader) error
+	// Get возвращает содержимое файла или ErrFileNotFound
+	Get(ctx context.Context, name string) (io.ReadCloser, error)
+	// Delete удаляет файл; удаление отсутствующего файла не является ошибкой
+	Delete(ctx context.Context, name string) error
+	// List возвращает все файлы хранилища
+	List(ctx context.Context) ([]StoredFile, error)
+}
+
+// StoredFile описывает объект в хранилище
+type StoredFile struct {
+	Name         string
+	LastModified time.Time
+}
+
+// NewBlob создает файловое хранилище, выбранное в конфигурации
+func NewBlob(ctx context.Context, cfg config.Config) (Blob, error) {
+	switch cfg.Storage.Backend {
+	case BlobBackendMinio, "":
+		return NewMinio(ctx, cfg)
+	case BlobBackendFileSystem:
+		return NewFileSystem(cfg.Storage.Path)
+	case BlobBackendMemory:
+		return NewMemory(), nil
+	default:
+		return nil, eris.Errorf("unknown storage backend %q", cfg.Storage.Backend)
+	}
+}
@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rotisserie/eris"
+)
+
+// tempFilePrefix префикс временных файлов незавершенных загрузок
+const tempFilePrefix = ".upload-"
+
+// FileSystem хранит файлы в директории локальной файловой системы
+type FileSystem struct {
+	root string
+}
+
+// NewFileSystem создает хранилище в указанной директории, создавая её при необходимости
+func NewFileSystem(root string) (*FileSystem, error) {
+	if root == "" {
+		return nil, eris.New("storage path is required for fs backend")
+	}
+
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, eris.Wrapf(err, "creating storage directory %s failed", root)
+	}
+
+	return &FileSystem{root: root}, nil
+}
+
+// Put сохраняет файл на диск; запись идет во временный файл, чтобы читатели не увидели его частично
+func (f *FileSystem) Put(ctx context.Context, name string, r io.Reader) error {
+	path, err := f.path(name)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(f.root, tempFilePrefix+"*")
+	if err != nil {
+		return eris.Wrapf(err, "creating temp file for %s failed", name)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return eris.Wrapf(err, "writing file %s failed", name)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return eris.Wrapf(err, "closing file %s failed", name)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return eris.Wrapf(err, "saving file %s failed", name)
+	}
+
+	return nil
+}
+
+// Get открывает файл на диске
+func (f *FileSystem) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	path, err := f.path(name)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, eris.Wrapf(ErrFileNotFound, "file %s not found in %s", name, f.root)
+		}
+		return nil, eris.Wrapf(err, "opening file %s failed", name)
+	}
+
+	return file, nil
+}
+
+// Delete удаляет файл с диска
+func (f *FileSystem) Delete(ctx context.Context, name string) error {
+	path, err := f.path(name)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return eris.Wrapf(err, "deleting file %s failed", name)
+	}
+
+	return nil
+}
+
+// List возвращает все файлы в директории хранилища
+func (f *FileSystem) List(ctx context.Context) ([]StoredFile, error) {
+	entries, err := os.ReadDir(f.root)
+	if err != nil {
+		return nil, eris.Wrapf(err, "reading storage directory %s failed", f.root)
+	}
+
+	files := make([]StoredFile, 0, len(entries))
+	for _, entry := range entries {
+		// Пропускаем поддиректории и незавершенные загрузки
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), tempFilePrefix) {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		files = append(files, StoredFile{
+			Name:         entry.Name(),
+			LastModified: info.ModTime(),
+		})
+	}
+
+	return files, nil
+}
+
+// path возвращает путь к файлу, не позволяя выйти за пределы директории хранилища
+func (f *FileSystem) path(name string) (string, error) {
+	if name == "" || name == "." || name == ".." || filepath.Base(name) != name {
+		return "", eris.Errorf("invalid file name %q", name)
+	}
+
+	return filepath.Join(f.root, name), nil
+}
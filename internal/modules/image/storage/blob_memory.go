@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rotisserie/eris"
+)
+
+type memoryFile struct {
+	data         []byte
+	lastModified time.Time
+}
+
+// Memory хранит файлы в памяти процесса; предназначено для разработки и тестов
+type Memory struct {
+	mu    sync.RWMutex
+	files map[string]memoryFile
+}
+
+// NewMemory создает пустое хранилище в памяти
+func NewMemory() *Memory {
+	return &Memory{files: make(map[string]memoryFile)}
+}
+
+// Put сохраняет копию содержимого файла
+func (m *Memory) Put(ctx context.Context, name string, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return eris.Wrapf(err, "reading file %s failed", name)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.files[name] = memoryFile{data: data, lastModified: time.Now()}
+
+	return nil
+}
+
+// Get возвращает содержимое файла
+func (m *Memory) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	file, ok := m.files[name]
+	if !ok {
+		return nil, eris.Wrapf(ErrFileNotFound, "file %s not found in memory storage", name)
+	}
+
+	return io.NopCloser(bytes.NewReader(file.data)), nil
+}
+
+// Delete удаляет файл
+func (m *Memory) Delete(ctx context.Context, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.files, name)
+
+	return nil
+}
+
+// List возвращает все файлы, отсортированные по имени
+func (m *Memory) List(ctx context.Context) ([]StoredFile, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	files := make([]StoredFile, 0, len(m.files))
+	for name, file := range m.files {
+		files = append(files, StoredFile{Name: name, LastModified: file.lastModified})
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+
+	return files, nil
+}
@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"log/slog"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/rotisserie/eris"
+	"github.com/yaroslavvasilenko/argon/config"
+)
+
+// Minio представляет клиент для работы с хранилищем MinIO
+type Minio struct {
+	client     *minio.Client
+	bucketName string
+}
+
+// NewMinio создает новый клиент MinIO
+func NewMinio(ctx context.Context, cfg config.Config) (*Minio, error) {
+	// Инициализация клиента MinIO
+	client, err := minio.New(cfg.Minio.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.Minio.User, cfg.Minio.Password, ""),
+		Secure: false, // Используем HTTP вместо HTTPS
+	})
+	if err != nil {
+		return nil, eris.Wrapf(err, "creating client for %s failed", cfg.Minio.Endpoint)
+	}
+
+	// Проверяем существование бакета, если нет - создаем
+	exists, err := client.BucketExists(ctx, cfg.Minio.Bucket)
+	if err != nil {
+		return nil, eris.Wrapf(err, "checking bucket %s failed", cfg.Minio.Bucket)
+	}
+
+	if !exists {
+		err = client.MakeBucket(ctx, cfg.Minio.Bucket, minio.MakeBucketOptions{})
+		if err != nil {
+			return nil, eris.Wrapf(err, "creating bucket %s failed", cfg.Minio.Bucket)
+		}
+		slog.Info("Bucket created", "bucket", cfg.Minio.Bucket)
+	}
+
+	return &Minio{
+		client:     client,
+		bucketName: cfg.Minio.Bucket,
+	}, nil
+}
+
+// Put загружает файл в MinIO
+func (m *Minio) Put(ctx context.Context, name string, r io.Reader) error {
+	_, err := m.client.PutObject(ctx, m.bucketName, name, r, -1, minio.PutObjectOptions{})
+	if err != nil {
+		return eris.Wrapf(err, "uploading file %s failed", name)
+	}
+
+	return nil
+}
+
+// Get получает файл из MinIO
+func (m *Minio) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	// GetObject не проверяет существование объекта, поэтому сначала запрашиваем его метаданные
+	_, err := m.client.StatObject(ctx, m.bucketName, name, minio.StatObjectOptions{})
+	if err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, eris.Wrapf(ErrFileNotFound, "object %s not found, in bucket %s", name, m.bucketName)
+		}
+		return nil, eris.Wrapf(err, "checking object %s in bucket %s failed", name, m.bucketName)
+	}
+
+	obj, err := m.client.GetObject(ctx, m.bucketName, name, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, eris.Wrapf(err, "object %s not found, in bucket %s", name, m.bucketName)
+	}
+
+	return obj, nil
+}
+
+// Delete удаляет файл из MinIO
+func (m *Minio) Delete(ctx context.Context, name string) error {
+	err := m.client.RemoveObject(ctx, m.bucketName, name, minio.RemoveObjectOptions{})
+	if err != nil {
+		return eris.Wrapf(err, "object %s not found, in bucket %s", name, m.bucketName)
+	}
+
+	return nil
+}
+
+// List возвращает список всех объектов в бакете MinIO
+func (m *Minio) List(ctx context.Context) ([]StoredFile, error) {
+	files := make([]StoredFile, 0)
+	for obj := range m.client.ListObjects(ctx, m.bucketName, minio.ListObjectsOptions{Recursive: true}) {
+		if obj.Err != nil {
+			return nil, eris.Wrapf(obj.Err, "listing objects in bucket %s failed", m.bucketName)
+		}
+
+		files = append(files, StoredFile{
+			Name:         obj.Key,
+			LastModified: obj.LastModified,
+		})
+	}
+
+	return files, nil
+}
@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlobBackends(t *testing.T) {
+	fs, err := NewFileSystem(t.TempDir())
+	require.NoError(t, err)
+
+	backends := map[string]Blob{
+		BlobBackendFileSystem: fs,
+		BlobBackendMemory:     NewMemory(),
+	}
+
+	for name, blob := range backends {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			require.NoError(t, blob.Put(ctx, "a-400px.webp", strings.NewReader("first")))
+			require.NoError(t, blob.Put(ctx, "b-200px.webp", strings.NewReader("second")))
+
+			// Повторная загрузка перезаписывает файл
+			require.NoError(t, blob.Put(ctx, "a-400px.webp", strings.NewReader("updated")))
+
+			r, err := blob.Get(ctx, "a-400px.webp")
+			require.NoError(t, err)
+			data, err := io.ReadAll(r)
+			require.NoError(t, err)
+			require.NoError(t, r.Close())
+			assert.Equal(t, "updated", string(data))
+
+			files, err := blob.List(ctx)
+			require.NoError(t, err)
+			names := make([]string, 0, len(files))
+			for _, f := range files {
+				names = append(names, f.Name)
+			}
+			assert.ElementsMatch(t, []string{"a-400px.webp", "b-200px.webp"}, names)
+
+			require.NoError(t, blob.Delete(ctx, "a-400px.webp"))
+			// Удаление отсутствующего файла не является ошибкой
+			require.NoError(t, blob.Delete(ctx, "a-400px.webp"))
+
+			_, err = blob.Get(ctx, "a-400px.webp")
+			assert.True(t, errors.Is(err, ErrFileNotFound), "ожидалась ErrFileNotFound, получено %v", err)
+		})
+	}
+
+	t.Run("fs: имя файла не может выходить за пределы директории", func(t *testing.T) {
+		err := fs.Put(context.Background(), "../escape.webp", strings.NewReader("x"))
+		assert.Error(t, err)
+	})
+}
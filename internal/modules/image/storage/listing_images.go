@@ -0,0 +1,152 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rotisserie/eris"
+	"github.com/yaroslavvasilenko/argon/internal/models"
+)
+
+// Суффиксы вариантов изображения в именах файлов (UUID-400px.webp, UUID-200px.webp)
+const (
+	VariantFull      = "400px"
+	VariantThumbnail = "200px"
+)
+
+// GetListingImages возвращает изображения объявления в порядке показа.
+// В Variants возвращаются имена файлов, URL формирует сервисный слой
+func (m *Image) GetListingImages(ctx context.Context, listingID uuid.UUID) ([]models.ListingImage, error) {
+	query := `
+		SELECT
+			li.image_id,
+			li.position,
+			li.is_cover,
+			li.alt,
+			COALESCE(i.width, 0),
+			COALESCE(i.height, 0),
+			COALESCE(MAX(il.image_name) FILTER (WHERE il.image_name LIKE '%-' || $2 || '.webp'), ''),
+			COALESCE(MAX(il.image_name) FILTER (WHERE il.image_name LIKE '%-' || $3 || '.webp'), '')
+		FROM listing_images li
+		JOIN image_links il ON il.image_id = li.image_id
+		LEFT JOIN images i ON i.id = li.image_id
+		WHERE li.listing_id = $1
+		GROUP BY li.image_id, li.position, li.is_cover, li.alt, i.width, i.height
+		ORDER BY li.position
+	`
+
+	rows, err := m.pool.Query(ctx, query, listingID, VariantFull, VariantThumbnail)
+	if err != nil {
+		return nil, eris.Wrapf(err, "failed to get images of listing %s", listingID)
+	}
+	defer rows.Close()
+
+	images := make([]models.ListingImage, 0)
+	for rows.Next() {
+		var image models.ListingImage
+		if err := rows.Scan(
+			&image.ID,
+			&image.Position,
+			&image.IsCover,
+			&image.Alt,
+			&image.Width,
+			&image.Height,
+			&image.Variants.Full,
+			&image.Variants.Thumbnail,
+		); err != nil {
+			return nil, eris.Wrap(err, "failed to scan listing image")
+		}
+		images = append(images, image)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, eris.Wrap(err, "failed to iterate listing images")
+	}
+
+	return images, nil
+}
+
+// ApplyListingImages в транзакции объявления привязывает attach, отвязывает detach
+// и заменяет набор изображений объявления вместе с порядком, обложкой и подписями
+func (m *Image) ApplyListingImages(ctx context.Context, tx pgx.Tx, listingID uuid.UUID, images []models.ListingImage, attach, detach []uuid.UUID) error {
+	if err := moveImages(ctx, tx, attach, &listingID, models.ImageStatusAttached); err != nil {
+		return err
+	}
+
+	if err := moveImages(ctx, tx, detach, nil, models.ImageStatusDetached); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM listing_images WHERE listing_id = $1`, listingID); err != nil {
+		return eris.Wrapf(err, "failed to clear images of listing %s", listingID)
+	}
+
+	for _, image := range images {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO listing_images (listing_id, image_id, position, is_cover, alt)
+			VALUES ($1, $2, $3, $4, $5)
+		`, listingID, image.ID, image.Position, image.IsCover, image.Alt)
+		if err != nil {
+			return eris.Wrapf(err, "failed to save image %s of listing %s", image.ID, listingID)
+		}
+	}
+
+	// Флаг NSFW объявления зависит от набора привязанных изображений
+	if _, err := tx.Exec(ctx, refreshNSFWQuery, []uuid.UUID{listingID}, string(models.ImageStatusAttached)); err != nil {
+		return eris.Wrapf(err, "failed to refresh nsfw flag of listing %s", listingID)
+	}
+
+	return nil
+}
+
+// moveImages переводит все файлы изображений в новое состояние внутри транзакции
+func moveImages(ctx context.Context, tx pgx.Tx, imageIDs []uuid.UUID, listingID *uuid.UUID, status models.ImageStatus) error {
+	if len(imageIDs) == 0 {
+		return nil
+	}
+
+	_, err := tx.Exec(ctx, `
+		UPDATE image_links
+		SET listing_id = $2, status = $3, updated_at = NOW()
+		WHERE image_id = ANY($1)
+	`, imageIDs, listingID, string(status))
+	if err != nil {
+		return eris.Wrapf(err, "failed to move images to status %s", status)
+	}
+
+	return nil
+}
+
+// UpdateListingImagesOrder в транзакции объявления переставляет изображения в порядке imageIDs
+func (m *Image) UpdateListingImagesOrder(ctx context.Context, tx pgx.Tx, listingID uuid.UUID, imageIDs []uuid.UUID) error {
+	// Уникальность позиций проверяется в конце транзакции, поэтому перестановка выполняется одним запросом
+	_, err := tx.Exec(ctx, `
+		UPDATE listing_images li
+		SET position = (o.ord - 1)::int
+		FROM unnest($2::uuid[]) WITH ORDINALITY AS o(image_id, ord)
+		WHERE li.listing_id = $1 AND li.image_id = o.image_id
+	`, listingID, imageIDs)
+	if err != nil {
+		return eris.Wrapf(err, "failed to reorder images of listing %s", listingID)
+	}
+
+	return nil
+}
+
+// SetListingCover в транзакции объявления делает изображение обложкой
+func (m *Image) SetListingCover(ctx context.Context, tx pgx.Tx, listingID, imageID uuid.UUID) error {
+	// Сначала снимаем текущую обложку: уникальный индекс не допускает двух обложек даже внутри запроса
+	if _, err := tx.Exec(ctx, `
+		UPDATE listing_images SET is_cover = FALSE WHERE listing_id = $1 AND is_cover
+	`, listingID); err != nil {
+		return eris.Wrapf(err, "failed to reset cover of listing %s", listingID)
+	}
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE listing_images SET is_cover = TRUE WHERE listing_id = $1 AND image_id = $2
+	`, listingID, imageID); err != nil {
+		return eris.Wrapf(err, "failed to set cover of listing %s", listingID)
+	}
+
+	return nil
+}
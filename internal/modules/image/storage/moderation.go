@@ -0,0 +1,152 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/rotisserie/eris"
+	"github.com/yaroslavvasilenko/argon/internal/models"
+	"gorm.io/gorm"
+)
+
+// SimilarImage изображение, перцептивно похожее на искомое
+type SimilarImage struct {
+	ImageID       uuid.UUID
+	PHashDistance int
+	DHashDistance int
+	// ListingIDs объявления, к которым сейчас привязано изображение
+	ListingIDs []uuid.UUID
+}
+
+// similarImagesLimit максимальное количество похожих изображений в ответе
+const similarImagesLimit = 50
+
+// GetImage возвращает метаданные изображения
+func (m *Image) GetImage(ctx context.Context, imageID uuid.UUID) (models.Image, error) {
+	var image models.Image
+	err := m.gorm.WithContext(ctx).Where("id = ?", imageID).First(&image).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.Image{}, fiber.NewError(fiber.StatusNotFound, "image not found")
+		}
+		return models.Image{}, eris.Wrapf(err, "failed to get image %s", imageID)
+	}
+
+	return image, nil
+}
+
+// FindSimilarImages ищет неудаленные изображения, у которых расстояние Хэмминга
+// по pHash или dHash не превышает maxDistance
+func (m *Image) FindSimilarImages(ctx context.Context, image models.Image, maxDistance int) ([]SimilarImage, error) {
+	query := `
+		WITH distances AS (
+			SELECT
+				i.id,
+				bit_count((i.phash # $2::bigint)::bit(64))::int AS phash_distance,
+				bit_count((i.dhash # $3::bigint)::bit(64))::int AS dhash_distance
+			FROM images i
+			WHERE i.id <> $1
+		)
+		SELECT
+			d.id,
+			d.phash_distance,
+			d.dhash_distance,
+			COALESCE(
+				array_agg(DISTINCT il.listing_id) FILTER (WHERE il.status = $5 AND il.listing_id IS NOT NULL),
+				'{}'
+			) AS listing_ids
+		FROM distances d
+		JOIN image_links il ON il.image_id = d.id
+		WHERE (d.phash_distance <= $4 OR d.dhash_distance <= $4)
+			AND il.status <> $6
+		GROUP BY d.id, d.phash_distance, d.dhash_distance
+		ORDER BY d.phash_distance, d.dhash_distance, d.id
+		LIMIT $7
+	`
+
+	rows, err := m.pool.Query(ctx, query,
+		image.ID, image.PHash, image.DHash, maxDistance,
+		string(models.ImageStatusAttached), string(models.ImageStatusDeleted), similarImagesLimit)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to find similar images")
+	}
+	defer rows.Close()
+
+	similar := make([]SimilarImage, 0)
+	for rows.Next() {
+		var item SimilarImage
+		if err := rows.Scan(&item.ImageID, &item.PHashDistance, &item.DHashDistance, &item.ListingIDs); err != nil {
+			return nil, eris.Wrap(err, "failed to scan similar image")
+		}
+		similar = append(similar, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, eris.Wrap(err, "failed to iterate similar images")
+	}
+
+	return similar, nil
+}
+
+// UpdateImageModeration сохраняет решение модерации по изображению
+func (m *Image) UpdateImageModeration(ctx context.Context, imageID uuid.UUID, isNSFW bool, reason string) error {
+	timeNow := time.Now()
+
+	result := m.gorm.WithContext(ctx).Model(&models.Image{}).
+		Where("id = ?", imageID).
+		Updates(map[string]interface{}{
+			"is_nsfw":           isNSFW,
+			"moderation_reason": reason,
+			"moderated_at":      timeNow,
+			"updated_at":        timeNow,
+		})
+	if result.Error != nil {
+		return eris.Wrapf(result.Error, "failed to update moderation of image %s", imageID)
+	}
+	if result.RowsAffected == 0 {
+		return fiber.NewError(fiber.StatusNotFound, "image not found")
+	}
+
+	return nil
+}
+
+// GetImageListingIDs возвращает объявления, к которым привязано изображение
+func (m *Image) GetImageListingIDs(ctx context.Context, imageID uuid.UUID) ([]uuid.UUID, error) {
+	var listingIDs []uuid.UUID
+	result := m.gorm.WithContext(ctx).Model(&models.ImageLink{}).
+		Where("image_id = ? AND status = ? AND listing_id IS NOT NULL", imageID, models.ImageStatusAttached).
+		Distinct().
+		Pluck("listing_id", &listingIDs)
+	if result.Error != nil {
+		return nil, eris.Wrapf(result.Error, "failed to get listings of image %s", imageID)
+	}
+
+	return listingIDs, nil
+}
+
+// refreshNSFWQuery пересчитывает флаг NSFW объявлений $1 по изображениям в состоянии $2
+const refreshNSFWQuery = `
+	UPDATE listings l
+	SET is_nsfw = EXISTS (
+		SELECT 1
+		FROM image_links il
+		JOIN images i ON i.id = il.image_id
+		WHERE il.listing_id = l.id AND il.status = $2 AND i.is_nsfw
+	)
+	WHERE l.id = ANY($1)
+`
+
+// RefreshListingsNSFW пересчитывает флаг NSFW объявлений по их привязанным изображениям
+func (m *Image) RefreshListingsNSFW(ctx context.Context, listingIDs []uuid.UUID) error {
+	if len(listingIDs) == 0 {
+		return nil
+	}
+
+	if _, err := m.pool.Exec(ctx, refreshNSFWQuery, listingIDs, string(models.ImageStatusAttached)); err != nil {
+		return eris.Wrap(err, "failed to refresh listings nsfw flag")
+	}
+
+	return nil
+}
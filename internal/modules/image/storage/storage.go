@@ -2,130 +2,143 @@ package storage
 
 import (
 	"context"
-	"log/slog"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
-	"github.com/minio/minio-go/v7"
-	"github.com/minio/minio-go/v7/pkg/credentials"
 	"github.com/rotisserie/eris"
-	"github.com/yaroslavvasilenko/argon/config"
 	"github.com/yaroslavvasilenko/argon/internal/models"
 	"gorm.io/gorm"
 )
 
-// Minio представляет клиент для работы с хранилищем MinIO
-type Minio struct {
-	client     *minio.Client
-	bucketName string
+type Image struct {
+	gorm *gorm.DB
+	pool *pgxpool.Pool
+	blob Blob
 }
 
-// NewMinio создает новый клиент MinIO
-func NewMinio(ctx context.Context, cfg config.Config) (*Minio, error) {
-	// Инициализация клиента MinIO
-	client, err := minio.New(cfg.Minio.Endpoint, &minio.Options{
-		Creds:  credentials.NewStaticV4(cfg.Minio.User, cfg.Minio.Password, ""),
-		Secure: false, // Используем HTTP вместо HTTPS
-	})
-	if err != nil {
-		return nil, eris.Wrapf(err, "creating client for %s failed", cfg.Minio.Endpoint)
-	}
+func NewImage(db *gorm.DB, pool *pgxpool.Pool, blob Blob) *Image {
+	return &Image{gorm: db, pool: pool, blob: blob}
+}
 
-	// Проверяем существование бакета, если нет - создаем
-	exists, err := client.BucketExists(ctx, cfg.Minio.Bucket)
-	if err != nil {
-		return nil, eris.Wrapf(err, "checking bucket %s failed", cfg.Minio.Bucket)
+// CreateUploadedImage сохраняет метаданные изображения и создает записи о его файлах в состоянии uploaded
+func (m *Image) CreateUploadedImage(ctx context.Context, image models.Image, imageNames []string) error {
+	timeNow := time.Now()
+	image.CreatedAt = timeNow
+	image.UpdatedAt = timeNow
+
+	imageLinks := make([]models.ImageLink, 0, len(imageNames))
+	for _, name := range imageNames {
+		imageLinks = append(imageLinks, models.ImageLink{
+			ImageID:   image.ID,
+			NameImage: name,
+			Status:    models.ImageStatusUploaded,
+			CreatedAt: timeNow,
+			UpdatedAt: timeNow,
+		})
 	}
 
-	if !exists {
-		err = client.MakeBucket(ctx, cfg.Minio.Bucket, minio.MakeBucketOptions{})
-		if err != nil {
-			return nil, eris.Wrapf(err, "creating bucket %s failed", cfg.Minio.Bucket)
+	err := m.gorm.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&image).Error; err != nil {
+			return err
 		}
-		slog.Info("Bucket created", "bucket", cfg.Minio.Bucket)
+		return tx.Create(&imageLinks).Error
+	})
+	if err != nil {
+		return eris.Wrapf(err, "failed to create image %s", image.ID)
 	}
 
-	return &Minio{
-		client:     client,
-		bucketName: cfg.Minio.Bucket,
-	}, nil
+	return nil
 }
 
-type Image struct {
-	gorm  *gorm.DB
-	pool  *pgxpool.Pool
-	minio *Minio
-}
+// GetImageLinks возвращает записи о файлах для указанных изображений
+func (m *Image) GetImageLinks(ctx context.Context, imageIDs []uuid.UUID) ([]models.ImageLink, error) {
+	var imageLinks []models.ImageLink
+	if len(imageIDs) == 0 {
+		return imageLinks, nil
+	}
 
-func NewImage(db *gorm.DB, pool *pgxpool.Pool, minio *Minio) *Image {
-	return &Image{gorm: db, pool: pool, minio: minio}
+	result := m.gorm.WithContext(ctx).Where("image_id IN ?", imageIDs).Find(&imageLinks)
+	if result.Error != nil {
+		return nil, eris.Wrap(result.Error, "failed to get image links")
+	}
+
+	return imageLinks, nil
 }
 
-// LinkImageToListing создает запись о связи изображения с объявлением
-func (m *Image) LinkImageToListing(ctx context.Context, imageName string, listingID string) error {
-	// Преобразуем строковый ID в UUID
-	listingUUID, err := uuid.Parse(listingID)
-	if err != nil {
-		return eris.Wrapf(err, "invalid listing ID: %s", listingID)
+// GetListingImageIDs возвращает идентификаторы изображений, привязанных к объявлению
+func (m *Image) GetListingImageIDs(ctx context.Context, listingID uuid.UUID) ([]uuid.UUID, error) {
+	var imageIDs []uuid.UUID
+	result := m.gorm.WithContext(ctx).Model(&models.ImageLink{}).
+		Where("listing_id = ? AND status = ?", listingID, models.ImageStatusAttached).
+		Distinct().
+		Pluck("image_id", &imageIDs)
+	if result.Error != nil {
+		return nil, eris.Wrapf(result.Error, "failed to get images of listing %s", listingID)
 	}
 
-	// Создаем запись о связи
-	imageLink := models.ImageLink{
-		ListingID: listingUUID,
-		NameImage:  imageName,
-		Linked:    true,
-		UpdatedAt: time.Now(),
-	}
+	return imageIDs, nil
+}
 
-	// Сохраняем в базу данных
-	result := m.gorm.WithContext(ctx).Create(&imageLink)
+// GetExpiredImageLinks возвращает файлы в указанных состояниях, которые не менялись с момента olderThan
+func (m *Image) GetExpiredImageLinks(ctx context.Context, statuses []models.ImageStatus, olderThan time.Time) ([]models.ImageLink, error) {
+	var imageLinks []models.ImageLink
+	result := m.gorm.WithContext(ctx).
+		Where("status IN ? AND updated_at < ?", statuses, olderThan).
+		Find(&imageLinks)
 	if result.Error != nil {
-		return eris.Wrapf(result.Error, "failed to create image link for %s", imageName)
+		return nil, eris.Wrap(result.Error, "failed to get expired image links")
 	}
 
-	return nil
+	return imageLinks, nil
 }
 
-// UnlinkImageFromListing помечает изображение как не связанное с объявлением
-func (m *Image) UnlinkImageFromListing(ctx context.Context, imageName string, listingID string) error {
-	// Преобразуем строковый ID в UUID
-	listingUUID, err := uuid.Parse(listingID)
-	if err != nil {
-		return eris.Wrapf(err, "invalid listing ID: %s", listingID)
+// MarkImageFilesDeleted помечает файлы изображений как удаленные, если они все еще находятся
+// в одном из состояний statuses и не менялись с момента olderThan. Возвращает имена файлов,
+// которые были помечены: только их можно удалять из хранилища
+func (m *Image) MarkImageFilesDeleted(ctx context.Context, imageNames []string, statuses []models.ImageStatus, olderThan time.Time) ([]string, error) {
+	if len(imageNames) == 0 {
+		return nil, nil
 	}
 
-	// Обновляем запись о связи
-	result := m.gorm.WithContext(ctx).Model(&models.ImageLink{}).Where(
-		"listing_id = ? AND name_image = ?", listingUUID, imageName,
-	).Updates(map[string]interface{}{
-		"linked":     false,
-		"updated_at": time.Now(),
-	})
+	statusNames := make([]string, 0, len(statuses))
+	for _, status := range statuses {
+		statusNames = append(statusNames, string(status))
+	}
 
-	if result.Error != nil {
-		return eris.Wrapf(result.Error, "failed to unlink image %s", imageName)
+	rows, err := m.pool.Query(ctx, `
+		UPDATE image_links
+		SET status = $1, updated_at = NOW()
+		WHERE image_name = ANY($2) AND status = ANY($3) AND updated_at < $4
+		RETURNING image_name
+	`, string(models.ImageStatusDeleted), imageNames, statusNames, olderThan)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to mark image files as deleted")
 	}
 
-	return nil
-}
+	deleted, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to mark image files as deleted")
+	}
 
-// GetUnlinkedImages возвращает список изображений, которые не связаны с объявлениями и старше указанного времени
-func (m *Image) GetUnlinkedImages(ctx context.Context, olderThan time.Time) ([]string, error) {
-	var imageLinks []models.ImageLink
-	result := m.gorm.WithContext(ctx).Model(&models.ImageLink{}).Where(
-		"linked = false AND updated_at < ?", olderThan,
-	).Select("name_image").Find(&imageLinks)
+	return deleted, nil
+}
 
+// GetLiveImageNames возвращает имена всех файлов, которые еще не удалены
+func (m *Image) GetLiveImageNames(ctx context.Context) (map[string]struct{}, error) {
+	var imageNames []string
+	result := m.gorm.WithContext(ctx).Model(&models.ImageLink{}).
+		Where("status <> ?", models.ImageStatusDeleted).
+		Pluck("image_name", &imageNames)
 	if result.Error != nil {
-		return nil, eris.Wrapf(result.Error, "failed to get unlinked images")
+		return nil, eris.Wrap(result.Error, "failed to get live image names")
 	}
 
-	// Преобразуем результат в список имен файлов
-	imageNames := make([]string, len(imageLinks))
-	for i, link := range imageLinks {
-		imageNames[i] = link.NameImage
+	names := make(map[string]struct{}, len(imageNames))
+	for _, name := range imageNames {
+		names[name] = struct{}{}
 	}
 
-	return imageNames, nil
+	return names, nil
 }
@@ -3,38 +3,28 @@ package storage
 import (
 	"context"
 	"io"
-
-	"github.com/minio/minio-go/v7"
-	"github.com/rotisserie/eris"
 )
 
-// UploadImage загружает изображение в MinIO
+// UploadImage загружает изображение в файловое хранилище
 func (m *Image) UploadImage(ctx context.Context, fileName string, file io.Reader) (string, error) {
-	// Загружаем файл в MinIO
-	_, err := m.minio.client.PutObject(ctx, m.minio.bucketName, fileName, file, -1, minio.PutObjectOptions{})
-	if err != nil {
-		return "", eris.Wrapf(err, "uploading file %s failed", fileName)
+	if err := m.blob.Put(ctx, fileName, file); err != nil {
+		return "", err
 	}
 
 	return fileName, nil
 }
 
-// DeleteFile удаляет файл из MinIO
+// DeleteFile удаляет файл из файлового хранилища
 func (m *Image) DeleteFile(ctx context.Context, objectName string) error {
-	err := m.minio.client.RemoveObject(ctx, m.minio.bucketName, objectName, minio.RemoveObjectOptions{})
-	if err != nil {
-		return eris.Wrapf(err, "object %s not found, in bucket %s", objectName, m.minio.bucketName)
-	}
-
-	return nil
+	return m.blob.Delete(ctx, objectName)
 }
 
-// GetFile получает файл из MinIO
+// GetFile получает файл из файлового хранилища
 func (m *Image) GetFile(ctx context.Context, objectName string) (io.ReadCloser, error) {
-	obj, err := m.minio.client.GetObject(ctx, m.minio.bucketName, objectName, minio.GetObjectOptions{})
-	if err != nil {
-		return nil, eris.Wrapf(err, "object %s not found, in bucket %s", objectName, m.minio.bucketName)
-	}
+	return m.blob.Get(ctx, objectName)
+}
 
-	return obj, nil
+// ListFiles возвращает список всех файлов в файловом хранилище
+func (m *Image) ListFiles(ctx context.Context) ([]StoredFile, error) {
+	return m.blob.List(ctx)
 }
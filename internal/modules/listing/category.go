@@ -0,0 +1,141 @@
+package listing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/yaroslavvasilenko/argon/config"
+	"github.com/yaroslavvasilenko/argon/internal/core/parser"
+	"github.com/yaroslavvasilenko/argon/internal/models"
+)
+
+// CategoryCrumb звено хлебных крошек категории
+type CategoryCrumb struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+}
+
+// CategoryLocalizer переводит категории на язык запроса и строит их URL и хлебные крошки.
+// Создается на запрос, чтобы переводы разбирались один раз
+type CategoryLocalizer struct {
+	tree  models.CategoryTree
+	names map[string]string
+	slugs map[string]string
+	paths map[string]string
+}
+
+// NewCategoryLocalizer создает локализатор для языка из контекста
+func NewCategoryLocalizer(ctx context.Context) (CategoryLocalizer, error) {
+	categories := config.GetConfig().Categories
+
+	var langData string
+	switch lang := parser.GetLang(ctx); lang {
+	case models.LanguageRu:
+		langData = categories.LangCategories.Ru
+	case models.LanguageEn:
+		langData = categories.LangCategories.En
+	case models.LanguageEs:
+		langData = categories.LangCategories.Es
+	default:
+		return CategoryLocalizer{}, errors.New("не поддерживаемый язык: " + string(lang))
+	}
+
+	l := CategoryLocalizer{
+		tree:  models.NewCategoryTree(categories.Data.Categories),
+		slugs: make(map[string]string),
+		paths: make(map[string]string),
+	}
+	if err := json.Unmarshal([]byte(langData), &l.names); err != nil {
+		return CategoryLocalizer{}, errors.New("ошибка при разборе локализаций: " + err.Error())
+	}
+
+	l.buildSlugs(categories.Data.Categories, "")
+	return l, nil
+}
+
+// buildSlugs строит слаги из переведенных названий. Слаг уникален среди соседей:
+// при совпадении к нему добавляется идентификатор категории
+func (l CategoryLocalizer) buildSlugs(nodes []config.CategoryNode, parentPath string) {
+	taken := make(map[string]bool, len(nodes))
+	for _, node := range nodes {
+		slug := models.Slugify(l.Name(node.ID))
+		if slug == "" {
+			slug = strings.ReplaceAll(node.ID, "_", "-")
+		}
+		if taken[slug] {
+			slug += "-" + strings.ReplaceAll(node.ID, "_", "-")
+		}
+		taken[slug] = true
+
+		path := slug
+		if parentPath != "" {
+			path = parentPath + "/" + slug
+		}
+		l.slugs[node.ID] = slug
+		l.paths[node.ID] = path
+
+		l.buildSlugs(node.Subcategories, path)
+	}
+}
+
+// Name возвращает название категории; без перевода — ее идентификатор
+func (l CategoryLocalizer) Name(id string) string {
+	if name, ok := l.names[id]; ok && name != "" {
+		return name
+	}
+	return id
+}
+
+// Category возвращает категорию со слагом, полным путем и хлебными крошками.
+// Для категории не из дерева заполняются только идентификатор и название
+func (l CategoryLocalizer) Category(id string) Category {
+	category := Category{ID: id, Name: l.Name(id)}
+	if !l.tree.Has(id) {
+		return category
+	}
+
+	category.Slug = l.slugs[id]
+	category.Path = l.paths[id]
+	for _, ancestor := range l.tree.Ancestors(id) {
+		category.Breadcrumb = append(category.Breadcrumb, CategoryCrumb{
+			ID:   ancestor,
+			Name: l.Name(ancestor),
+			Slug: l.slugs[ancestor],
+		})
+	}
+	return category
+}
+
+// Deepest возвращает самую глубокую из категорий объявления
+func (l CategoryLocalizer) Deepest(ids []string) string {
+	if deepest := l.tree.Deepest(ids); deepest != "" {
+		return deepest
+	}
+	if len(ids) > 0 {
+		return ids[0]
+	}
+	return ""
+}
+
+// FindByPath возвращает категорию по полному пути из слагов на языке локализатора
+func (l CategoryLocalizer) FindByPath(path string) (string, bool) {
+	path = strings.Trim(path, "/")
+	for id, p := range l.paths {
+		if p == path {
+			return id, true
+		}
+	}
+	return "", false
+}
+
+// IDs возвращает идентификаторы всех категорий дерева
+func (l CategoryLocalizer) IDs() []string {
+	ids := make([]string, 0, len(l.paths))
+	for id := range l.paths {
+		ids = append(ids, id)
+	}
+	return ids
+}
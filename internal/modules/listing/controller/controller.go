@@ -1,9 +1,15 @@
 package controller
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	"github.com/yaroslavvasilenko/argon/internal/core/parser"
+	"github.com/yaroslavvasilenko/argon/internal/models"
 	"github.com/yaroslavvasilenko/argon/internal/modules/listing"
 	"github.com/yaroslavvasilenko/argon/internal/modules/listing/service"
 )
@@ -32,10 +38,15 @@ func (h *Listing) CreateListing(c *fiber.Ctx) error {
 
 	listing, err := h.s.CreateListing(c.UserContext(), r)
 	if err != nil {
+		// Ошибки значений полей отдаются клиенту как есть, со списком полей
+		var validationFailed *models.ValidationFailedError
+		if errors.As(err, &validationFailed) {
+			return err
+		}
 		return fiber.NewError(fiber.StatusInternalServerError, "error creating listing: "+err.Error())
 	}
 
-	return c.JSON(listing)
+	return writeListing(c, listing)
 }
 
 func (h *Listing) GetListing(c *fiber.Ctx) error {
@@ -46,7 +57,7 @@ func (h *Listing) GetListing(c *fiber.Ctx) error {
 		return err
 	}
 
-	return c.JSON(listing)
+	return writeListing(c, listing)
 }
 
 func (h *Listing) DeleteListing(c *fiber.Ctx) error {
@@ -82,12 +93,77 @@ func (h *Listing) UpdateListing(c *fiber.Ctx) error {
 	// Устанавливаем ID из параметра URL в запрос
 	r.ID = listingID
 
-	listing, err := h.s.UpdateListing(c.UserContext(), r)
+	// Версия из If-Match важнее версии в теле запроса
+	if ifMatch := c.Get(fiber.HeaderIfMatch); ifMatch != "" {
+		version, err := listing.ParseETag(ifMatch)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		r.Version = version
+	}
+
+	resp, err := h.s.UpdateListing(c.UserContext(), r)
+	if err != nil {
+		return err
+	}
+
+	return writeListing(c, resp)
+}
+
+func (h *Listing) ReorderImages(c *fiber.Ctx) error {
+	listingID, err := uuid.Parse(c.Params("listing_id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Неверный формат ID листинга")
+	}
+
+	r := listing.ReorderImagesRequest{}
+	if err := c.BodyParser(&r); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Ошибка при разборе тела запроса: "+err.Error())
+	}
+	r.ListingID = listingID
+
+	// Версия из If-Match важнее версии в теле запроса
+	if ifMatch := c.Get(fiber.HeaderIfMatch); ifMatch != "" {
+		version, err := listing.ParseETag(ifMatch)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		r.Version = version
+	}
+
+	resp, err := h.s.ReorderImages(c.UserContext(), r)
 	if err != nil {
 		return err
 	}
 
-	return c.JSON(listing)
+	return writeListingImages(c, resp)
+}
+
+func (h *Listing) SetCoverImage(c *fiber.Ctx) error {
+	listingID, err := uuid.Parse(c.Params("listing_id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Неверный формат ID листинга")
+	}
+
+	imageID, err := uuid.Parse(c.Params("image_id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Неверный формат ID изображения")
+	}
+
+	// Без If-Match обложка меняется поверх текущего состояния
+	var version int
+	if ifMatch := c.Get(fiber.HeaderIfMatch); ifMatch != "" {
+		if version, err = listing.ParseETag(ifMatch); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+	}
+
+	resp, err := h.s.SetCoverImage(c.UserContext(), listingID, imageID, version)
+	if err != nil {
+		return err
+	}
+
+	return writeListingImages(c, resp)
 }
 
 func (h *Listing) SearchListings(c *fiber.Ctx) error {
@@ -105,6 +181,25 @@ func (h *Listing) SearchListings(c *fiber.Ctx) error {
 	return c.JSON(listings)
 }
 
+func (h *Listing) SearchMap(c *fiber.Ctx) error {
+	req := listing.SearchMapRequest{}
+	if err := parser.BodyParser(c, &req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	resp, err := h.s.SearchMap(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(resp)
+}
+
 func (h *Listing) GetCategories(c *fiber.Ctx) error {
 	resp, err := h.s.GetCategories(c.UserContext())
 	if err != nil {
@@ -114,6 +209,21 @@ func (h *Listing) GetCategories(c *fiber.Ctx) error {
 	return c.JSON(resp)
 }
 
+// GetCategoryByPath возвращает категорию по пути из слагов на языке запроса
+func (h *Listing) GetCategoryByPath(c *fiber.Ctx) error {
+	path := c.Query("path")
+	if path == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "path parameter is required")
+	}
+
+	resp, err := h.s.GetCategoryByPath(c.UserContext(), path)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(resp)
+}
+
 func (h *Listing) SearchListingsParams(c *fiber.Ctx) error {
 	qID := c.Query("qid")
 	if qID == "" {
@@ -157,11 +267,44 @@ func (h *Listing) GetFiltersForCategory(c *fiber.Ctx) error {
 		})
 	}
 
+	units, err := parseUnits(c.Query("units"))
+	if err != nil {
+		return err
+	}
+
 	// Вызываем сервис для получения фильтров
-	filters, err := h.s.GetFiltersForCategory(c.UserContext(), categoryId)
+	filters, err := h.s.GetFiltersForCategory(c.UserContext(), categoryId, c.QueryBool("exact_category"), units)
 	if err != nil {
 		return err
 	}
 
 	return c.JSON(filters)
 }
+
+// parseUnits разбирает единицы диапазонов величин вида "height:m,weight:g"
+func parseUnits(raw string) (map[string]models.Dimension, error) {
+	units := make(map[string]models.Dimension)
+	if raw == "" {
+		return units, nil
+	}
+	for _, pair := range strings.Split(raw, ",") {
+		role, unit, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if !ok || role == "" || unit == "" {
+			return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("invalid units item %q, expected role:unit", pair))
+		}
+		units[role] = models.Dimension(unit)
+	}
+	return units, nil
+}
+
+// writeListing отдает объявление вместе с тегом его версии
+func writeListing(c *fiber.Ctx, resp listing.FullListingResponse) error {
+	c.Set(fiber.HeaderETag, listing.ETag(resp.Version))
+	return c.JSON(resp)
+}
+
+// writeListingImages отдает изображения объявления с ETag новой версии объявления
+func writeListingImages(c *fiber.Ctx, resp listing.ListingImagesResponse) error {
+	c.Set(fiber.HeaderETag, listing.ETag(resp.Version))
+	return c.JSON(resp)
+}
@@ -0,0 +1,41 @@
+package controller
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+func (h *Listing) GetRevisions(c *fiber.Ctx) error {
+	listingID, err := uuid.Parse(c.Params("listing_id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Неверный формат ID листинга")
+	}
+
+	resp, err := h.s.GetRevisions(c.UserContext(), listingID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(resp)
+}
+
+func (h *Listing) RestoreRevision(c *fiber.Ctx) error {
+	listingID, err := uuid.Parse(c.Params("listing_id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Неверный формат ID листинга")
+	}
+
+	revision, err := strconv.Atoi(c.Params("revision"))
+	if err != nil || revision <= 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "Неверный номер ревизии")
+	}
+
+	resp, err := h.s.RestoreRevision(c.UserContext(), listingID, revision)
+	if err != nil {
+		return err
+	}
+
+	return writeListing(c, resp)
+}
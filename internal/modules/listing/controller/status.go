@@ -0,0 +1,79 @@
+package controller
+
+import (
+	"context"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/yaroslavvasilenko/argon/internal/modules/listing"
+)
+
+func (h *Listing) PublishListing(c *fiber.Ctx) error {
+	return h.statusAction(c, h.s.PublishListing)
+}
+
+func (h *Listing) PauseListing(c *fiber.Ctx) error {
+	return h.statusAction(c, h.s.PauseListing)
+}
+
+func (h *Listing) MarkListingSold(c *fiber.Ctx) error {
+	return h.statusAction(c, h.s.MarkListingSold)
+}
+
+func (h *Listing) RenewListing(c *fiber.Ctx) error {
+	return h.statusAction(c, h.s.RenewListing)
+}
+
+func (h *Listing) ApproveListing(c *fiber.Ctx) error {
+	return h.statusAction(c, h.s.ApproveListing)
+}
+
+func (h *Listing) RejectListing(c *fiber.Ctx) error {
+	listingID, err := uuid.Parse(c.Params("listing_id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Неверный формат ID листинга")
+	}
+
+	req := listing.RejectListingRequest{}
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Ошибка при разборе тела запроса: "+err.Error())
+	}
+	req.ID = listingID
+
+	validate := validator.New()
+	if err := validate.Struct(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	resp, err := h.s.RejectListing(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return writeListing(c, resp)
+}
+
+func (h *Listing) GetModerationQueue(c *fiber.Ctx) error {
+	resp, err := h.s.GetModerationQueue(c.UserContext(), c.QueryInt("limit"), c.QueryInt("offset"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(resp)
+}
+
+// statusAction выполняет переход объявления из параметра listing_id в новое состояние
+func (h *Listing) statusAction(c *fiber.Ctx, action func(context.Context, uuid.UUID) (listing.FullListingResponse, error)) error {
+	listingID, err := uuid.Parse(c.Params("listing_id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Неверный формат ID листинга")
+	}
+
+	resp, err := action(c.UserContext(), listingID)
+	if err != nil {
+		return err
+	}
+
+	return writeListing(c, resp)
+}
@@ -0,0 +1,30 @@
+package listing
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var errInvalidETag = errors.New("If-Match must contain a listing ETag")
+
+// ETag сильный тег версии объявления для заголовков ETag и If-Match
+func ETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// ParseETag извлекает версию объявления из значения If-Match. Слабые теги не подходят
+// для условной записи, поэтому принимается только значение, выданное в ETag
+func ParseETag(value string) (int, error) {
+	value = strings.TrimSpace(value)
+	if len(value) < 2 || value[0] != '"' || value[len(value)-1] != '"' {
+		return 0, errInvalidETag
+	}
+
+	version, err := strconv.Atoi(value[1 : len(value)-1])
+	if err != nil || version <= 0 {
+		return 0, errInvalidETag
+	}
+
+	return version, nil
+}
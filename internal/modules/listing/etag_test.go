@@ -0,0 +1,23 @@
+package listing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseETag(t *testing.T) {
+	version, err := ParseETag(ETag(7))
+	require.NoError(t, err)
+	assert.Equal(t, 7, version)
+
+	version, err = ParseETag(` "3" `)
+	require.NoError(t, err)
+	assert.Equal(t, 3, version)
+
+	for _, value := range []string{"", "3", `W/"3"`, `"abc"`, `"0"`, `"-1"`, "*", `"3", "4"`} {
+		_, err := ParseETag(value)
+		assert.Error(t, err, value)
+	}
+}
@@ -2,12 +2,8 @@ package listing
 
 import (
 	"context"
-	"encoding/json"
-	"errors"
 
 	"github.com/google/uuid"
-	"github.com/yaroslavvasilenko/argon/config"
-	"github.com/yaroslavvasilenko/argon/internal/core/parser"
 	"github.com/yaroslavvasilenko/argon/internal/models"
 )
 
@@ -26,6 +22,13 @@ type Category struct {
 	ID    string  `json:"id"`
 	Name  string  `json:"name"`
 	Image *string `json:"image,omitempty"`
+	// Slug часть URL категории на языке запроса, Path — слаги от корня дерева через /
+	Slug string `json:"slug,omitempty"`
+	Path string `json:"path,omitempty"`
+	// Breadcrumb путь от корня дерева до категории включительно
+	Breadcrumb []CategoryCrumb `json:"breadcrumb,omitempty"`
+	// ListingCount число опубликованных объявлений в категории вместе с подкатегориями
+	ListingCount *int `json:"listing_count,omitempty"`
 }
 
 type CreateListingRequest struct {
@@ -35,8 +38,10 @@ type CreateListingRequest struct {
 	Currency        models.Currency            `json:"currency,omitempty" validate:"required,oneof=USD EUR RUB ARS"`
 	Location        *models.Location           `json:"location,omitempty"`
 	Categories      []string                   `json:"categories,omitempty" validate:"required,categories_validation"`
-	Characteristics models.CharacteristicValue `json:"characteristics,omitempty" validate:"characteristics_value"`
-	Images          []string                   `json:"images" validate:"omitempty"`
+	Characteristics models.CharacteristicValue `json:"characteristics,omitempty"`
+	Images          []models.ListingImageInput `json:"images" validate:"omitempty"`
+	// Draft сохраняет объявление черновиком, не отправляя его на публикацию
+	Draft bool `json:"draft,omitempty"`
 }
 
 type CreateListingResponse struct {
@@ -49,7 +54,7 @@ type CreateListingResponse struct {
 	Categories      []Category                 `json:"categories"`
 	Characteristics models.CharacteristicValue `json:"characteristics,omitempty"`
 	Boosts          []BoostResp                `json:"boosts,omitempty"`
-	Images          []string                   `json:"images"`
+	Images          []models.ListingImage      `json:"images"`
 }
 
 type BoostResp struct {
@@ -65,16 +70,24 @@ type UpdateListingRequest struct {
 	Currency        models.Currency            `json:"currency,omitempty" validate:"required,oneof=USD EUR RUB"`
 	Location        models.Location            `json:"location,omitempty"`
 	Categories      []string                   `json:"categories,omitempty" validate:"categories_validation"`
-	Characteristics models.CharacteristicValue `json:"characteristics,omitempty" validate:"characteristics_value"`
+	Characteristics models.CharacteristicValue `json:"characteristics,omitempty"`
 	Boosts          []BoostResp                `json:"boosts,omitempty"`
-	Images          []string                   `json:"images"`
+	Images          []models.ListingImageInput `json:"images"`
+	// Version версия, которую видел клиент; вместо нее можно передать заголовок If-Match
+	Version int `json:"version,omitempty"`
 }
 
 type FullListingResponse struct {
-	ID                  uuid.UUID                  `json:"id"`
-	Title               string                     `json:"title"`
-	Description         string                     `json:"description"`
+	ID uuid.UUID `json:"id"`
+	// Title и Description на языке запроса, если перевод готов, иначе на языке автора
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	// Language язык Title и Description
+	Language models.Localization `json:"language"`
+	// OriginalTitle, OriginalDescription и OriginalLanguage текст автора без перевода
+	OriginalTitle       string                     `json:"original_title"`
 	OriginalDescription string                     `json:"original_description"`
+	OriginalLanguage    models.Localization        `json:"original_language"`
 	Price               float64                    `json:"price"`
 	Currency            models.Currency            `json:"currency"`
 	OriginalPrice       float64                    `json:"original_price"`
@@ -83,55 +96,78 @@ type FullListingResponse struct {
 	Seller              models.Seller              `json:"seller"`
 	Categories          []Category                 `json:"categories"`
 	Characteristics     models.CharacteristicValue `json:"characteristics"`
-	Images              []string                   `json:"images"`
+	Images              []models.ListingImage      `json:"images"`
 	CreatedAt           int64                      `json:"created_at"`
 	UpdatedAt           int64                      `json:"updated_at"`
 	Boosts              []BoostResp                `json:"boosts,omitempty"`
 	IsEditable          bool                       `json:"is_editable"`
 	IsBuyable           bool                       `json:"is_buyable"`
 	IsNSFW              bool                       `json:"is_nsfw"`
+	Status              models.ListingStatus       `json:"status"`
+	PublishedAt         *int64                     `json:"published_at,omitempty"`
+	ExpiresAt           *int64                     `json:"expires_at,omitempty"`
+	// ModerationReason причина, по которой модератор вернул объявление в черновики
+	ModerationReason string `json:"moderation_reason,omitempty"`
+	// PriceDropped цена ниже самой высокой цены объявления за последние 30 дней
+	PriceDropped  bool     `json:"price_dropped"`
+	PreviousPrice *float64 `json:"previous_price,omitempty"`
+	// Version текущая версия объявления, она же передается в ETag
+	Version int `json:"version"`
+}
+
+// RevisionResponse ревизия из истории изменений объявления
+type RevisionResponse struct {
+	Revision     int                           `json:"revision"`
+	Actor        string                        `json:"actor"`
+	Changes      map[string]models.FieldChange `json:"changes"`
+	Snapshot     models.ListingSnapshot        `json:"snapshot"`
+	RestoredFrom *int                          `json:"restored_from,omitempty"`
+	CreatedAt    int64                         `json:"created_at"`
+}
+
+type RevisionsResponse struct {
+	Items []RevisionResponse `json:"items"`
+}
+
+// RejectListingRequest отклонение объявления модератором
+type RejectListingRequest struct {
+	ID     uuid.UUID `json:"-"`
+	Reason string    `json:"reason" validate:"required"`
+}
+
+// ModerationQueueResponse объявления, ожидающие модерации
+type ModerationQueueResponse struct {
+	Items []FullListingResponse `json:"items"`
+}
+
+type ReorderImagesRequest struct {
+	ListingID uuid.UUID   `json:"-"`
+	ImageIDs  []uuid.UUID `json:"image_ids" validate:"required"`
+	// Version версия, которую видел клиент; вместо нее можно передать заголовок If-Match.
+	// Без версии порядок меняется поверх текущего состояния
+	Version int `json:"version,omitempty"`
+}
+
+type ListingImagesResponse struct {
+	Images []models.ListingImage `json:"images"`
+	// Version версия объявления после изменения изображений
+	Version int `json:"version"`
 }
 
 type GetFiltersForCategoryResponse struct {
 	Filters models.Filters `json:"filter_params"`
 }
 
-// getCategoriesWithLocalizedNames получает локализованные названия категорий
+// GetCategoriesWithLocalizedNames возвращает категории с названиями, слагами и хлебными крошками на языке запроса
 func GetCategoriesWithLocalizedNames(ctx context.Context, categoryIDs []string) ([]Category, error) {
-	// Получаем язык из контекста
-	lang := parser.GetLang(ctx)
-
-	// Загружаем локализации
-	var translations map[string]string
-	var langData string
-
-	switch lang {
-	case models.LanguageRu:
-		langData = config.GetConfig().Categories.LangCategories.Ru
-	case models.LanguageEn:
-		langData = config.GetConfig().Categories.LangCategories.En
-	case models.LanguageEs:
-		langData = config.GetConfig().Categories.LangCategories.Es
-	default:
-		return nil, errors.New("не поддерживаемый язык: " + string(lang))
-	}
-
-	// Распаковываем локализации
-	if err := json.Unmarshal([]byte(langData), &translations); err != nil {
-		return nil, errors.New("ошибка при разборе локализаций: " + err.Error())
+	localizer, err := NewCategoryLocalizer(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	// Создаем массив категорий с локализованными названиями
 	categories := make([]Category, 0, len(categoryIDs))
 	for _, categoryID := range categoryIDs {
-		name, ok := translations[categoryID]
-		if !ok {
-			name = categoryID // Если перевод не найден, используем ID как имя
-		}
-		categories = append(categories, Category{
-			ID:   categoryID,
-			Name: name,
-		})
+		categories = append(categories, localizer.Category(categoryID))
 	}
 
 	return categories, nil
@@ -165,5 +201,6 @@ type Option struct {
 }
 
 type GetCharacteristicsForCategoryResponse struct {
-	Option models.CharacteristicParam `json:"characteristic_params"`
+	// Option характеристики в порядке схемы категории
+	Option []models.CharacteristicParamItem `json:"characteristic_params"`
 }
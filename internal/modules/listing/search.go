@@ -2,6 +2,7 @@ package listing
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/yaroslavvasilenko/argon/internal/models"
@@ -17,6 +18,40 @@ type SearchListingsRequest struct {
 	Location   models.Location     `json:"location,omitempty"`
 	Filters    models.FilterParams `json:"filters,omitempty"`
 	SortOrder  string              `json:"sort_order,omitempty"`
+	// ExactCategory ищет только в самой категории, без подкатегорий
+	ExactCategory bool `json:"exact_category,omitempty"`
+}
+
+// SearchMapRequest поиск объявлений в видимой области карты
+type SearchMapRequest struct {
+	Query string `json:"query"`
+	// SearchID параметры сохраненного поиска: категория и фильтры берутся из него
+	SearchID   string              `json:"qid,omitempty"`
+	CategoryID string              `json:"category_id,omitempty"`
+	Filters    models.FilterParams `json:"filters,omitempty"`
+	Viewport   models.BoundingBox  `json:"viewport"`
+	// ExactCategory ищет только в самой категории, без подкатегорий
+	ExactCategory bool `json:"exact_category,omitempty"`
+	// Zoom масштаб карты в терминах веб-тайлов: 0 — весь мир, 20 — здания
+	Zoom int `json:"zoom" validate:"min=0,max=22"`
+}
+
+// SearchMapResponse на мелком масштабе содержит кластеры, на крупном — объявления
+type SearchMapResponse struct {
+	Clustered bool              `json:"clustered"`
+	Clusters  []MapCluster      `json:"clusters"`
+	Items     []ListingResponse `json:"items"`
+	// Truncated сообщает, что в области больше объявлений, чем вернулось в items
+	Truncated bool `json:"truncated"`
+}
+
+// MapCluster группа объявлений в ячейке сетки карты
+type MapCluster struct {
+	Count    int                `json:"count"`
+	Centroid models.Coordinates `json:"centroid"`
+	Bounds   models.BoundingBox `json:"bounds"`
+	// ItemID объявление, если оно в кластере одно
+	ItemID *uuid.UUID `json:"item_id,omitempty"`
 }
 
 type SearchListingsResponse struct {
@@ -36,9 +71,15 @@ type ListingResponse struct {
 	Description      string          `json:"description"`
 	Location         models.Location `json:"location"`
 	Category         Category        `json:"category"`
-	Images           []string        `json:"images"`
+	CoverThumbnail   string          `json:"cover_thumbnail"`
 	IsHighlighted    bool            `json:"is_highlighted"`
 	IsBuyable        bool            `json:"is_buyable"`
+	IsPromoted       bool            `json:"is_promoted"`
+	// Distance расстояние от покупателя в метрах, если в запросе указана локация
+	Distance *float64 `json:"distance,omitempty"`
+	// PriceDropped цена ниже самой высокой цены объявления за последние 30 дней
+	PriceDropped  bool     `json:"price_dropped"`
+	PreviousPrice *float64 `json:"previous_price,omitempty"`
 }
 
 // CreateSearchListingsResponse создает ответ на запрос поиска объявлений
@@ -51,32 +92,22 @@ func CreateSearchListingsResponse(
 ) (SearchListingsResponse, error) {
 	results := make([]ListingResponse, 0, len(listings))
 
+	localizer, err := NewCategoryLocalizer(ctx)
+	if err != nil {
+		return SearchListingsResponse{}, err
+	}
+
 	for _, listingResult := range listings {
 		listing := listingResult.Listing
 
 		// Подготавливаем данные для ответа
 		var categoryInfo Category
 		var isHighlighted bool
-		var isBuyable bool
 		var location models.Location
 
-		// Обрабатываем категории
+		// Показываем самую глубокую категорию объявления с хлебными крошками
 		if len(listingResult.Categories) > 0 {
-			// Получаем локализованное название категории
-			categoryID := listingResult.Categories[0].ID[0]
-			categoryName := ""
-			categoryNames, err := GetCategoriesWithLocalizedNames(ctx, []string{categoryID})
-			if err != nil {
-				return SearchListingsResponse{}, err
-			}
-
-			if len(categoryNames) != 0 {
-				categoryName = categoryNames[0].Name
-			}
-			categoryInfo = Category{
-				ID:   categoryID,
-				Name: categoryName,
-			}
+			categoryInfo = localizer.Category(localizer.Deepest(listingResult.Categories[0].ID))
 		}
 
 		// Обрабатываем локацию
@@ -89,16 +120,8 @@ func CreateSearchListingsResponse(
 			if boost.Type == models.BoostTypeHighlight {
 				isHighlighted = true
 			}
-			// TODO: сделать логику для определения IsBuyable на основе бустов
-			if boost.Type == models.BoostTypeUpfront {
-				isBuyable = true
-
-			}
 		}
 
-		// TODO: обработка изображений
-		// В будущем здесь будет код для получения изображений
-
 		// Создаем модель ответа в конце, после сбора всех данных
 		response := ListingResponse{
 			ItemID:           listing.ID,
@@ -110,10 +133,14 @@ func CreateSearchListingsResponse(
 			Description:      listing.Description,
 			Location:         location,
 			Category:         categoryInfo,
-			Images:           []string{},
+			CoverThumbnail:   listingResult.CoverThumbnail,
 			IsHighlighted:    isHighlighted,
-			IsBuyable:        isBuyable,
-			// Можно добавить характеристики и изображения, если они нужны в ответе
+			IsBuyable:        models.IsBuyable(listingResult.Boosts, listingResult.Characteristics),
+			IsPromoted:       listingResult.IsPromoted,
+			Distance:         listingResult.Distance,
+			PriceDropped:     listingResult.PreviousPrice != nil,
+			PreviousPrice:    listingResult.PreviousPrice,
+			// Можно добавить характеристики, если они нужны в ответе
 		}
 
 		results = append(results, response)
@@ -137,13 +164,25 @@ const (
 type SearchCursor struct {
 	Block     SearchBlock
 	LastIndex *uuid.UUID
+	// Score ранг объявления-курсора при сортировке по релевантности
+	// или его расстояние до покупателя при сортировке по расстоянию
+	Score *float64
+	// RankedAt момент, от которого считается свежесть; фиксируется на первой странице,
+	// чтобы ранги не менялись при переходе между страницами
+	RankedAt *time.Time
+	// Page номер страницы, следующей за курсором; по нему выбираются продвигаемые объявления
+	Page int
+	// PromotedOffset число уже показанных продвигаемых объявлений, если страница перед курсором
+	// была дополнена ими после конца органической выдачи; заменяет смещение по номеру страницы
+	PromotedOffset *int
 }
 
 type SearchID struct {
-	CategoryID string
-	Filters    models.FilterParams
-	SortOrder  string
-	Location   models.Location
+	CategoryID    string
+	ExactCategory bool
+	Filters       models.FilterParams
+	SortOrder     string
+	Location      models.Location
 }
 
 type GetSearchParamsResponse struct {
@@ -151,4 +190,6 @@ type GetSearchParamsResponse struct {
 	Location  *models.Location     `json:"location,omitempty"`
 	Filters   *models.FilterParams `json:"filters,omitempty"`
 	SortOrder *string              `json:"sort_order,omitempty"`
+	// ExactCategory поиск без подкатегорий
+	ExactCategory bool `json:"exact_category,omitempty"`
 }
@@ -2,81 +2,47 @@ package service
 
 import (
 	"context"
-	"encoding/json"
 
-	"github.com/yaroslavvasilenko/argon/config"
-	"github.com/yaroslavvasilenko/argon/internal/core/parser"
+	"github.com/gofiber/fiber/v2"
 	"github.com/yaroslavvasilenko/argon/internal/models"
 	"github.com/yaroslavvasilenko/argon/internal/modules/listing"
 )
 
-// GetCategoryById получает информацию о категории по её ID
+// GetCategoryById получает информацию о категории по её ID: название, слаг и хлебные крошки
+// на языке запроса. Для категории не из дерева возвращается пустая категория
 func (s *Listing) GetCategoryById(ctx context.Context, categoryID string) (listing.Category, error) {
-	if categoryID == "" {
+	if categoryID == "" || !models.Categories().Has(categoryID) {
 		return listing.Category{}, nil
 	}
 
-	// Получаем конфигурацию
-	cfg := config.GetConfig()
-
-	// Ищем категорию по ID в дереве категорий
-	var findCategory func(nodes []config.CategoryNode, id string) *config.CategoryNode
-	findCategory = func(nodes []config.CategoryNode, id string) *config.CategoryNode {
-		for i := range nodes {
-			if nodes[i].ID == id {
-				return &nodes[i]
-			}
-			if len(nodes[i].Subcategories) > 0 {
-				if found := findCategory(nodes[i].Subcategories, id); found != nil {
-					return found
-				}
-			}
-		}
-		return nil
+	localizer, err := listing.NewCategoryLocalizer(ctx)
+	if err != nil {
+		return listing.Category{}, err
 	}
 
-	// Ищем категорию в дереве
-	categoryNode := findCategory(cfg.Categories.Data.Categories, categoryID)
-	if categoryNode == nil {
-		return listing.Category{}, nil
-	}
+	return localizer.Category(categoryID), nil
+}
 
-	// Создаем объект категории
-	category := listing.Category{
-		ID:   categoryID,
-		Name: categoryID, // По умолчанию используем ID как имя
+// GetCategoryByPath находит категорию по пути из слагов на языке запроса, например
+// "electronics/smartphones". Возвращает категорию с хлебными крошками и числом объявлений
+func (s *Listing) GetCategoryByPath(ctx context.Context, path string) (listing.Category, error) {
+	localizer, err := listing.NewCategoryLocalizer(ctx)
+	if err != nil {
+		return listing.Category{}, err
 	}
 
-	// Получаем перевод категории из конфигурации
-	lang := models.Localization(parser.GetLang(ctx))
-	switch lang {
-	case models.LanguageRu:
-		var categories map[string]string
-		if err := json.Unmarshal([]byte(cfg.Categories.LangCategories.Ru), &categories); err == nil {
-			if name, ok := categories[categoryID]; ok && name != "" {
-				category.Name = name
-			}
-		}
-
-	case models.LanguageEn:
-		var categories map[string]string
-		if err := json.Unmarshal([]byte(cfg.Categories.LangCategories.En), &categories); err == nil {
-			if name, ok := categories[categoryID]; ok && name != "" {
-				category.Name = name
-			}
-		}
-
-	case models.LanguageEs:
-		var categories map[string]string
-		if err := json.Unmarshal([]byte(cfg.Categories.LangCategories.Es), &categories); err == nil {
-			if name, ok := categories[categoryID]; ok && name != "" {
-				category.Name = name
-			}
-		}
+	categoryID, ok := localizer.FindByPath(path)
+	if !ok {
+		return listing.Category{}, fiber.NewError(fiber.StatusNotFound, "category not found")
 	}
 
-	// Если перевод не найден, продолжаем использовать ID как имя
-	// В будущем можно добавить поле Name в структуру CategoryNode
+	counts, err := s.s.CountPublishedListings(ctx, []string{categoryID})
+	if err != nil {
+		return listing.Category{}, err
+	}
 
+	category := localizer.Category(categoryID)
+	count := counts[categoryID]
+	category.ListingCount = &count
 	return category, nil
 }
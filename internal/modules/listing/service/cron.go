@@ -0,0 +1,47 @@
+package service
+
+import (
+	"context"
+	"time"
+)
+
+// listingExpireInterval период проверки сроков публикации объявлений
+const listingExpireInterval = 10 * time.Minute
+
+// ExpireListingsSync периодически снимает с публикации объявления с истекшим сроком
+func (s *Listing) ExpireListingsSync(stopChan chan struct{}) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.logger.Errorf("Panic in ExpireListingsSync: %v", r)
+		}
+	}()
+
+	s.logger.Infof("Starting listing expiration cron task")
+
+	for {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					s.logger.Errorf("Panic in listing expiration task: %v", r)
+				}
+			}()
+
+			ctx, cancel := context.WithTimeout(context.Background(), listingExpireInterval)
+			defer cancel()
+
+			expired, err := s.s.ExpireListings(ctx, time.Now())
+			if err != nil {
+				s.logger.Errorf("Failed to expire listings: %v", err)
+			} else if expired > 0 {
+				s.logger.Infof("Listings expired: %d", expired)
+			}
+		}()
+
+		select {
+		case <-stopChan:
+			s.logger.Infof("Listing expiration cron task received stop signal")
+			return
+		case <-time.After(listingExpireInterval):
+		}
+	}
+}
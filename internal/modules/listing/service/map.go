@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"math"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yaroslavvasilenko/argon/config"
+	"github.com/yaroslavvasilenko/argon/internal/core/parser"
+	iservice "github.com/yaroslavvasilenko/argon/internal/modules/image/service"
+	"github.com/yaroslavvasilenko/argon/internal/modules/listing"
+	"github.com/yaroslavvasilenko/argon/internal/modules/listing/storage"
+)
+
+const (
+	defaultClusterMaxZoom = 13
+	defaultGridCells      = 4
+	defaultMapListings    = 300
+)
+
+// mapSettings параметры поиска по карте из конфигурации со значениями по умолчанию
+type mapSettings struct {
+	clusterMaxZoom int
+	gridCells      int
+	maxListings    int
+}
+
+func newMapSettings(cfg config.Config) mapSettings {
+	settings := mapSettings{
+		clusterMaxZoom: cfg.Map.ClusterMaxZoom,
+		gridCells:      cfg.Map.GridCells,
+		maxListings:    cfg.Map.MaxListings,
+	}
+	if settings.clusterMaxZoom <= 0 {
+		settings.clusterMaxZoom = defaultClusterMaxZoom
+	}
+	if settings.gridCells <= 0 {
+		settings.gridCells = defaultGridCells
+	}
+	if settings.maxListings <= 0 {
+		settings.maxListings = defaultMapListings
+	}
+	return settings
+}
+
+// cellSize сторона ячейки сетки кластеров в градусах: тайл масштаба zoom
+// занимает 360 / 2^zoom градусов долготы и делится на gridCells ячеек
+func (m mapSettings) cellSize(zoom int) float64 {
+	return 360 / (math.Exp2(float64(zoom)) * float64(m.gridCells))
+}
+
+// SearchMap ищет объявления в видимой области карты. До масштаба clusterMaxZoom
+// объявления объединяются в кластеры по сетке, на более крупном возвращаются сами объявления
+func (s *Listing) SearchMap(ctx context.Context, req listing.SearchMapRequest) (listing.SearchMapResponse, error) {
+	if !req.Viewport.IsValid() {
+		return listing.SearchMapResponse{}, fiber.NewError(fiber.StatusBadRequest, "invalid viewport")
+	}
+
+	if req.SearchID != "" {
+		search, err := s.cache.GetSearchInfo(req.SearchID)
+		if err != nil {
+			return listing.SearchMapResponse{}, err
+		}
+
+		if search != nil {
+			req.Filters = search.Filters
+			req.CategoryID = search.CategoryID
+			req.ExactCategory = search.ExactCategory
+		}
+	}
+
+	filters, err := req.Filters.ToFilters()
+	if err != nil {
+		return listing.SearchMapResponse{}, err
+	}
+
+	category := storage.CategoryScope{ID: req.CategoryID, Exact: req.ExactCategory}
+	settings := newMapSettings(config.GetConfig())
+	resp := listing.SearchMapResponse{
+		Clusters: []listing.MapCluster{},
+		Items:    []listing.ListingResponse{},
+	}
+
+	if req.Zoom <= settings.clusterMaxZoom {
+		clusters, err := s.s.SearchMapClusters(ctx, req.Query, category, filters, req.Viewport, settings.cellSize(req.Zoom))
+		if err != nil {
+			return listing.SearchMapResponse{}, err
+		}
+
+		resp.Clustered = true
+		for _, cluster := range clusters {
+			resp.Clusters = append(resp.Clusters, listing.MapCluster{
+				Count:    cluster.Count,
+				Centroid: cluster.Centroid,
+				Bounds:   cluster.Bounds,
+				ItemID:   cluster.ListingID,
+			})
+		}
+
+		return resp, nil
+	}
+
+	// Лишнее объявление показывает, что в области есть еще
+	results, err := s.s.SearchMapListings(ctx, req.Query, category, filters, req.Viewport, settings.maxListings+1)
+	if err != nil {
+		return listing.SearchMapResponse{}, err
+	}
+	if len(results) > settings.maxListings {
+		results = results[:settings.maxListings]
+		resp.Truncated = true
+	}
+
+	if err := s.previousPrices(ctx, results); err != nil {
+		return listing.SearchMapResponse{}, err
+	}
+
+	for i := range results {
+		results[i].CoverThumbnail = iservice.ImageURL(results[i].CoverThumbnail)
+	}
+
+	// Заголовки и описания отдаются на языке запроса, если перевод уже готов
+	if err := s.translations.LocalizeResults(ctx, results, parser.GetLang(ctx)); err != nil {
+		return listing.SearchMapResponse{}, err
+	}
+
+	items, err := listing.CreateSearchListingsResponse(ctx, results, nil, nil, "")
+	if err != nil {
+		return listing.SearchMapResponse{}, err
+	}
+	resp.Items = items.Results
+
+	return resp, nil
+}
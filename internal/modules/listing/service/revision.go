@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yaroslavvasilenko/argon/internal/core/parser"
+	"github.com/yaroslavvasilenko/argon/internal/models"
+	"github.com/yaroslavvasilenko/argon/internal/modules/listing"
+	"github.com/yaroslavvasilenko/argon/internal/modules/listing/storage"
+)
+
+// GetRevisions возвращает историю изменений объявления, начиная с последней ревизии
+func (s *Listing) GetRevisions(ctx context.Context, listingID uuid.UUID) (listing.RevisionsResponse, error) {
+	if _, err := s.s.GetListing(ctx, listingID.String()); err != nil {
+		return listing.RevisionsResponse{}, err
+	}
+
+	revisions, err := s.s.GetRevisions(ctx, listingID)
+	if err != nil {
+		return listing.RevisionsResponse{}, err
+	}
+
+	resp := listing.RevisionsResponse{Items: make([]listing.RevisionResponse, 0, len(revisions))}
+	for _, revision := range revisions {
+		resp.Items = append(resp.Items, listing.RevisionResponse{
+			Revision:     revision.Revision,
+			Actor:        revision.Actor,
+			Changes:      revision.Changes,
+			Snapshot:     revision.Snapshot,
+			RestoredFrom: revision.RestoredFrom,
+			CreatedAt:    revision.CreatedAt.UnixMilli(),
+		})
+	}
+
+	return resp, nil
+}
+
+// RestoreRevision возвращает объявлению содержимое ревизии number. История не переписывается:
+// восстановление добавляет новую ревизию со ссылкой на исходную
+func (s *Listing) RestoreRevision(ctx context.Context, listingID uuid.UUID, number int) (listing.FullListingResponse, error) {
+	revision, err := s.s.GetRevision(ctx, listingID, number)
+	if err != nil {
+		return listing.FullListingResponse{}, err
+	}
+
+	// Правила категорий могли измениться после ревизии, поэтому она проверяется как новое изменение
+	characteristics, err := snapshotCharacteristics(revision.Snapshot)
+	if err != nil {
+		return listing.FullListingResponse{}, err
+	}
+	if err := validateCharacteristics(ctx, revision.Snapshot.Categories, characteristics); err != nil {
+		return listing.FullListingResponse{}, err
+	}
+
+	current, err := s.s.GetFullListing(ctx, listingID.String())
+	if err != nil {
+		return listing.FullListingResponse{}, err
+	}
+
+	now := time.Now()
+	snapshot := revision.Snapshot
+	revisions, err := s.newRevisions(ctx, current, snapshot, &number, now)
+	if err != nil {
+		return listing.FullListingResponse{}, err
+	}
+
+	var location models.Location
+	if snapshot.Location != nil {
+		location = *snapshot.Location
+		location.ListingID = listingID
+	}
+
+	err = s.s.UpdateFullListing(ctx, models.Listing{
+		ID:          listingID,
+		Title:       snapshot.Title,
+		Description: snapshot.Description,
+		Price:       snapshot.Price,
+		Currency:    snapshot.Currency,
+		UpdatedAt:   now,
+		Version:     current.Listing.Version,
+	}, snapshot.Categories, location, snapshot.Characteristics, nil, revisions...)
+	if errors.Is(err, storage.ErrStaleVersion) {
+		return listing.FullListingResponse{}, s.versionConflict(ctx, listingID)
+	}
+	if err != nil {
+		return listing.FullListingResponse{}, err
+	}
+
+	s.translations.Enqueue(listingID)
+
+	return s.GetListing(ctx, listingID.String())
+}
+
+// newRevisions готовит ревизии для перехода объявления из состояния current в next.
+// Если истории еще нет, первой сохраняется исходное состояние, чтобы к нему можно было вернуться.
+// Изменение без отличий от текущего состояния ревизию не создает
+func (s *Listing) newRevisions(ctx context.Context, current storage.FullListing, next models.ListingSnapshot, restoredFrom *int, now time.Time) ([]models.ListingRevision, error) {
+	prev := snapshotOf(current)
+	changes := models.DiffSnapshots(prev, next)
+	if len(changes) == 0 && restoredFrom == nil {
+		return nil, nil
+	}
+
+	hasHistory, err := s.s.HasRevisions(ctx, current.Listing.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	revisions := make([]models.ListingRevision, 0, 2)
+	if !hasHistory {
+		revisions = append(revisions, models.ListingRevision{
+			ID:        uuid.New(),
+			ListingID: current.Listing.ID,
+			Snapshot:  prev,
+			Changes:   map[string]models.FieldChange{},
+			CreatedAt: current.Listing.UpdatedAt,
+		})
+	}
+
+	revisions = append(revisions, models.ListingRevision{
+		ID:           uuid.New(),
+		ListingID:    current.Listing.ID,
+		Actor:        string(parser.GetActor(ctx)),
+		Snapshot:     next,
+		Changes:      changes,
+		RestoredFrom: restoredFrom,
+		CreatedAt:    now,
+	})
+
+	return revisions, nil
+}
+
+// initialRevision первая ревизия нового объявления: все заполненные поля считаются измененными
+func initialRevision(ctx context.Context, listingID uuid.UUID, snapshot models.ListingSnapshot, now time.Time) models.ListingRevision {
+	return models.ListingRevision{
+		ID:        uuid.New(),
+		ListingID: listingID,
+		Actor:     string(parser.GetActor(ctx)),
+		Snapshot:  snapshot,
+		Changes:   models.DiffSnapshots(models.ListingSnapshot{}, snapshot),
+		CreatedAt: now,
+	}
+}
+
+// snapshotOf редактируемое содержимое объявления из базы
+func snapshotOf(full storage.FullListing) models.ListingSnapshot {
+	snapshot := models.ListingSnapshot{
+		Title:           full.Listing.Title,
+		Description:     full.Listing.Description,
+		Price:           full.Listing.Price,
+		Currency:        full.Listing.Currency,
+		Categories:      full.Categories.ID,
+		Characteristics: full.Characteristics,
+	}
+	if full.Location.ID != "" {
+		location := full.Location
+		snapshot.Location = &location
+	}
+
+	return snapshot
+}
+
+// newSnapshot содержимое объявления из запроса на создание или изменение
+func newSnapshot(title, description string, price float64, currency models.Currency, categories []string, location *models.Location, characteristics map[string]interface{}) models.ListingSnapshot {
+	snapshot := models.ListingSnapshot{
+		Title:           title,
+		Description:     description,
+		Price:           price,
+		Currency:        currency,
+		Categories:      categories,
+		Characteristics: characteristics,
+	}
+	if location != nil && location.ID != "" {
+		snapshot.Location = location
+	}
+
+	return snapshot
+}
+
+// snapshotCharacteristics разбирает характеристики ревизии в типизированные значения по ролям
+func snapshotCharacteristics(snapshot models.ListingSnapshot) (models.CharacteristicValue, error) {
+	data, err := json.Marshal(models.CharacteristicValue(snapshot.Characteristics))
+	if err != nil {
+		return nil, err
+	}
+
+	var characteristics models.CharacteristicValue
+	if err := json.Unmarshal(data, &characteristics); err != nil {
+		return nil, err
+	}
+
+	return characteristics, nil
+}
+
+// previousPrices находит для результатов поиска более высокую цену из истории
+func (s *Listing) previousPrices(ctx context.Context, results []models.ListingResult) error {
+	ids := make([]uuid.UUID, 0, len(results))
+	for _, result := range results {
+		ids = append(ids, result.Listing.ID)
+	}
+
+	prices, err := s.s.GetPreviousPrices(ctx, ids, time.Now().Add(-models.PriceDropWindow))
+	if err != nil {
+		return err
+	}
+
+	for i := range results {
+		if price, ok := prices[results[i].Listing.ID]; ok {
+			results[i].PreviousPrice = &price
+		}
+	}
+
+	return nil
+}
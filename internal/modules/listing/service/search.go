@@ -4,12 +4,16 @@ import (
 	"context"
 	"errors"
 	"math"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
 
+	"github.com/yaroslavvasilenko/argon/internal/core/parser"
 	"github.com/yaroslavvasilenko/argon/internal/models"
+	iservice "github.com/yaroslavvasilenko/argon/internal/modules/image/service"
 	"github.com/yaroslavvasilenko/argon/internal/modules/listing"
+	"github.com/yaroslavvasilenko/argon/internal/modules/listing/storage"
 )
 
 func (s *Listing) SearchListings(ctx context.Context, req listing.SearchListingsRequest) (listing.SearchListingsResponse, error) {
@@ -26,10 +30,13 @@ func (s *Listing) SearchListings(ctx context.Context, req listing.SearchListings
 			req.SortOrder = search.SortOrder
 			req.Filters = search.Filters
 			req.CategoryID = search.CategoryID
+			req.ExactCategory = search.ExactCategory
 			req.Location = search.Location
 		}
 	}
 
+	category := storage.CategoryScope{ID: req.CategoryID, Exact: req.ExactCategory}
+
 	if req.Cursor != "" {
 		cursor, err = s.cache.GetCursor(req.Cursor)
 		if err != nil {
@@ -37,9 +44,25 @@ func (s *Listing) SearchListings(ctx context.Context, req listing.SearchListings
 		}
 	}
 
+	// Момент ранжирования фиксируется на первой странице и переходит в следующие курсоры
+	if cursor.RankedAt == nil {
+		rankedAt := time.Now().UTC().Truncate(time.Second)
+		cursor.RankedAt = &rankedAt
+	}
+
+	// Номер страницы для продвигаемых мест: при движении назад выдается страница перед курсором
+	page := cursor.Page
+	if req.Limit < 0 {
+		page--
+	}
+
 	resp := listing.SearchListingsResponse{}
 	var searchTitle, searchDescription bool
 	var listingAnchor *models.Listing
+	var organic []models.ListingResult
+	// nextPromoted смещение продвигаемых объявлений для следующей страницы, если они не поместились на текущую
+	var nextPromoted *int
+	organicLimit := req.Limit
 
 	// Используем абсолютное значение для емкости слайса, чтобы избежать ошибки при отрицательном значении req.Limit
 	listingsRes := make([]models.ListingResult, 0, int(math.Abs(float64(req.Limit))))
@@ -49,8 +72,23 @@ func (s *Listing) SearchListings(ctx context.Context, req listing.SearchListings
 		if err != nil {
 			return listing.SearchListingsResponse{}, err
 		}
-		var listings []models.ListingResult
-		listingAnchor, listings, err = s.s.SearchListingsByTitle(ctx, req.Query, req.Limit, cursor.LastIndex, req.SortOrder, req.CategoryID, filters, req.Location)
+
+		// Продвигаемые объявления занимают первые места страницы, остальное — органическая выдача
+		promotedOffset, promotedCount := s.s.PromotedWindow(req.Limit, page)
+		if cursor.PromotedOffset != nil && req.Limit > 0 {
+			promotedOffset = *cursor.PromotedOffset
+		}
+		promoted, err := s.s.SearchPromotedListings(ctx, req.Query, promotedOffset, promotedCount, category, filters, req.Location)
+		if err != nil {
+			return listing.SearchListingsResponse{}, err
+		}
+		if organicLimit > 0 {
+			organicLimit -= len(promoted)
+		} else {
+			organicLimit += len(promoted)
+		}
+
+		listingAnchor, organic, err = s.s.SearchListingsByTitle(ctx, req.Query, organicLimit, cursor, req.SortOrder, category, filters, req.Location)
 		if err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				return listing.SearchListingsResponse{}, fiber.NewError(fiber.StatusNotFound, err.Error())
@@ -58,8 +96,25 @@ func (s *Listing) SearchListings(ctx context.Context, req listing.SearchListings
 			return listing.SearchListingsResponse{}, err
 		}
 
-		listingsRes = append(listingsRes, listings...)
+		listingsRes = append(listingsRes, promoted...)
+		listingsRes = append(listingsRes, organic...)
 		searchTitle = true
+
+		// Органическая выдача исключает все продвигаемые объявления, поэтому после ее конца
+		// страница дополняется оставшимися продвигаемыми, иначе они не попали бы в выдачу
+		if free := organicLimit - len(organic); req.Limit > 0 && free > 0 {
+			offset := promotedOffset + len(promoted)
+			rest, err := s.s.SearchPromotedListings(ctx, req.Query, offset, free+1, category, filters, req.Location)
+			if err != nil {
+				return listing.SearchListingsResponse{}, err
+			}
+			if len(rest) > free {
+				rest = rest[:free]
+				next := offset + free
+				nextPromoted = &next
+			}
+			listingsRes = append(listingsRes, rest...)
+		}
 	}
 
 	if cursor.Block == listing.DescriptionBlock || len(listingsRes) < req.Limit {
@@ -81,11 +136,28 @@ func (s *Listing) SearchListings(ctx context.Context, req listing.SearchListings
 		// searchDescription = true
 	}
 
-	if len(listingsRes) > 0 && len(listingsRes) == int(math.Abs(float64(req.Limit))) {
-		lastListing := listingsRes[len(listingsRes)-1]
-
+	// Курсоры строятся только по органической выдаче: продвигаемые места определяются номером страницы
+	// или смещением, если продвигаемые объявления не поместились на страницу после конца органики
+	organicFull := len(organic) > 0 && len(organic) == int(math.Abs(float64(organicLimit)))
+	if organicFull || nextPromoted != nil {
 		newCursor := listing.SearchCursor{
-			LastIndex: &lastListing.Listing.ID,
+			LastIndex:      cursor.LastIndex,
+			Score:          cursor.Score,
+			RankedAt:       cursor.RankedAt,
+			Page:           page + 1,
+			PromotedOffset: nextPromoted,
+		}
+		if len(organic) > 0 {
+			lastListing := organic[len(organic)-1]
+			newCursor.LastIndex = &lastListing.Listing.ID
+
+			// При сортировке по расстоянию курсор задается расстоянием, иначе рангом объявления
+			if req.SortOrder == models.SORT_DISTANCE {
+				newCursor.Score = lastListing.Distance
+			} else {
+				score := lastListing.RankScore
+				newCursor.Score = &score
+			}
 		}
 
 		if searchDescription {
@@ -104,6 +176,9 @@ func (s *Listing) SearchListings(ctx context.Context, req listing.SearchListings
 
 		newCursor := listing.SearchCursor{
 			LastIndex: &firstListing.ID,
+			Score:     cursor.Score,
+			RankedAt:  cursor.RankedAt,
+			Page:      cursor.Page,
 		}
 
 		if searchTitle {
@@ -117,11 +192,36 @@ func (s *Listing) SearchListings(ctx context.Context, req listing.SearchListings
 		resp.CursorBefore = &cursor
 	}
 
+	if err := s.previousPrices(ctx, listingsRes); err != nil {
+		return listing.SearchListingsResponse{}, err
+	}
+
+	// Миниатюры обложек хранятся как имена файлов, в ответе нужны URL
+	for i := range listingsRes {
+		listingsRes[i].CoverThumbnail = iservice.ImageURL(listingsRes[i].CoverThumbnail)
+	}
+
+	// При сортировке по расстоянию его считает база, для остальных выдач — по координатам
+	if req.Location.HasCoordinates() {
+		for i := range listingsRes {
+			if listingsRes[i].Distance == nil && listingsRes[i].Location.HasCoordinates() {
+				distance := req.Location.Area.Coordinates.DistanceTo(listingsRes[i].Location.Area.Coordinates)
+				listingsRes[i].Distance = &distance
+			}
+		}
+	}
+
+	// Заголовки и описания отдаются на языке запроса, если перевод уже готов
+	if err := s.translations.LocalizeResults(ctx, listingsRes, parser.GetLang(ctx)); err != nil {
+		return listing.SearchListingsResponse{}, err
+	}
+
 	searchId := listing.SearchID{
-		CategoryID: req.CategoryID,
-		Filters:    req.Filters,
-		SortOrder:  req.SortOrder,
-		Location: req.Location,
+		CategoryID:    req.CategoryID,
+		ExactCategory: req.ExactCategory,
+		Filters:       req.Filters,
+		SortOrder:     req.SortOrder,
+		Location:      req.Location,
 	}
 
 	resp.SearchID = s.cache.StoreSearchInfo(searchId)
@@ -141,6 +241,7 @@ func (s *Listing) GetSearchParams(ctx context.Context, qID string) (listing.GetS
 	}
 
 	resp := listing.GetSearchParamsResponse{
+		ExactCategory: search.ExactCategory,
 	}
 
 	if search.SortOrder != "" {
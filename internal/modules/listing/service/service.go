@@ -3,9 +3,9 @@ package service
 import (
 	"context"
 
-	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"time"
 
 	"github.com/google/uuid"
@@ -15,32 +15,34 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/yaroslavvasilenko/argon/internal/core/logger"
 	"github.com/yaroslavvasilenko/argon/internal/core/parser"
+	"github.com/yaroslavvasilenko/argon/internal/core/validator"
 	"github.com/yaroslavvasilenko/argon/internal/models"
 	iservice "github.com/yaroslavvasilenko/argon/internal/modules/image/service"
-	istorage "github.com/yaroslavvasilenko/argon/internal/modules/image/storage"
 	"github.com/yaroslavvasilenko/argon/internal/modules/listing"
 	"github.com/yaroslavvasilenko/argon/internal/modules/listing/storage"
 	"github.com/yaroslavvasilenko/argon/internal/modules/location/service"
+	tservice "github.com/yaroslavvasilenko/argon/internal/modules/translation/service"
 	"gorm.io/gorm"
 )
 
 type Listing struct {
-	s  *storage.Listing
-	is *istorage.Image
-	ss *iservice.Image
+	s            *storage.Listing
+	images       *iservice.Image
+	translations *tservice.Translation
 
 	logger   *logger.Glog
 	cache    *storage.Cache
 	location *service.Location
 }
 
-func NewListing(s *storage.Listing, i *istorage.Image, pool *pgxpool.Pool, logger *logger.Glog, locationService *service.Location) *Listing {
+func NewListing(s *storage.Listing, imageService *iservice.Image, translationService *tservice.Translation, pool *pgxpool.Pool, logger *logger.Glog, locationService *service.Location) *Listing {
 	srv := &Listing{
-		s:        s,
-		is:       i,
-		cache:    storage.NewCache(pool),
-		logger:   logger,
-		location: locationService,
+		s:            s,
+		images:       imageService,
+		translations: translationService,
+		cache:        storage.NewCache(pool),
+		logger:       logger,
+		location:     locationService,
 	}
 
 	return srv
@@ -54,34 +56,46 @@ func (s *Listing) CreateListing(ctx context.Context, p listing.CreateListingRequ
 	ID := uuid.New()
 	timeNow := time.Now()
 
-	// Создаем объявление с переданными ID категорий
-	err := s.s.CreateListing(ctx, models.Listing{
-		ID:          ID,
-		Title:       p.Title,
-		Description: p.Description,
-		Price:       p.Price,
-		Currency:    p.Currency,
-		Images:      p.Images,
-		CreatedAt:   timeNow,
-		UpdatedAt:   timeNow,
-	}, p.Categories, *p.Location, p.Characteristics)
+	newListing := models.Listing{
+		ID:              ID,
+		Title:           p.Title,
+		Description:     p.Description,
+		Price:           p.Price,
+		Currency:        p.Currency,
+		CreatedAt:       timeNow,
+		UpdatedAt:       timeNow,
+		Status:          models.ListingStatusDraft,
+		StatusChangedAt: timeNow,
+		// Текст объявления написан на языке запроса автора
+		OriginalLang: parser.GetLang(ctx),
+	}
+
+	// Без флага черновика объявление сразу отправляется на публикацию
+	if !p.Draft {
+		storage.NewLifecycle(config.GetConfig()).Submit(&newListing, timeNow)
+	}
+
+	if err := validateCharacteristics(ctx, p.Categories, p.Characteristics); err != nil {
+		return listing.FullListingResponse{}, err
+	}
+
+	// Изображения проверяются до записи и привязываются в транзакции создания объявления
+	images, err := s.images.PlanListingImages(ctx, ID, p.Images)
 	if err != nil {
 		return listing.FullListingResponse{}, err
 	}
 
-	// Привязываем изображения к объявлению
-	for _, imageURL := range p.Images {
-		imageID, err := s.ss.GetImageFileName(ctx, imageURL)
-		if err != nil {
-			return listing.FullListingResponse{}, err
-		}
+	snapshot := newSnapshot(p.Title, p.Description, p.Price, p.Currency, p.Categories, p.Location, p.Characteristics)
 
-		err = s.is.LinkImageToListing(ctx, ID.String(), imageID)
-		if err != nil {
-			return listing.FullListingResponse{}, err
-		}
+	// Создаем объявление с переданными ID категорий
+	err = s.s.CreateListing(ctx, newListing, p.Categories, *p.Location, p.Characteristics,
+		images.Apply, initialRevision(ctx, ID, snapshot, timeNow))
+	if err != nil {
+		return listing.FullListingResponse{}, err
 	}
 
+	s.translations.Enqueue(ID)
+
 	resp, err := s.GetListing(ctx, ID.String())
 	if err != nil {
 		return listing.FullListingResponse{}, err
@@ -114,28 +128,72 @@ func (s *Listing) GetListing(ctx context.Context, pID string) (listing.FullListi
 		return listing.FullListingResponse{}, err
 	}
 
+	images, err := s.images.GetListingImages(ctx, fullListing.Listing.ID)
+	if err != nil {
+		return listing.FullListingResponse{}, err
+	}
+
+	previousPrices, err := s.s.GetPreviousPrices(ctx, []uuid.UUID{fullListing.Listing.ID}, time.Now().Add(-models.PriceDropWindow))
+	if err != nil {
+		return listing.FullListingResponse{}, err
+	}
+	var previousPrice *float64
+	if price, ok := previousPrices[fullListing.Listing.ID]; ok {
+		previousPrice = &price
+	}
+
+	// Заголовок и описание отдаются на языке запроса, если перевод уже готов
+	text, err := s.translations.Localize(ctx, fullListing.Listing, parser.GetLang(ctx))
+	if err != nil {
+		return listing.FullListingResponse{}, err
+	}
+
 	resp := listing.FullListingResponse{
-		ID:               fullListing.Listing.ID,
-		Title:            fullListing.Listing.Title,
-		Description:      fullListing.Listing.Description,
-		Price:            fullListing.Listing.Price,
-		Currency:         fullListing.Listing.Currency,
-		OriginalPrice:    fullListing.Listing.Price,
-		OriginalCurrency: fullListing.Listing.Currency,
-		Location:         fullListing.Location,
-		Categories:       categories,
-		Characteristics:  fullListing.Characteristics,
-		Images:           fullListing.Listing.Images,
-		Boosts:           boosts,
-		CreatedAt:        fullListing.Listing.CreatedAt.UnixMilli(),
-		UpdatedAt:        fullListing.Listing.UpdatedAt.UnixMilli(),
+		ID:                  fullListing.Listing.ID,
+		Title:               text.Title,
+		Description:         text.Description,
+		Language:            text.Lang,
+		OriginalTitle:       fullListing.Listing.Title,
+		OriginalDescription: fullListing.Listing.Description,
+		OriginalLanguage:    fullListing.Listing.OriginalLang,
+		Price:               fullListing.Listing.Price,
+		Currency:            fullListing.Listing.Currency,
+		OriginalPrice:       fullListing.Listing.Price,
+		OriginalCurrency:    fullListing.Listing.Currency,
+		Location:            fullListing.Location,
+		Categories:          categories,
+		Characteristics:     fullListing.Characteristics,
+		Images:              images,
+		Boosts:              boosts,
+		IsBuyable:           models.IsBuyable(fullListing.Boosts, fullListing.Characteristics),
+		IsNSFW:              fullListing.Listing.IsNSFW,
+		CreatedAt:           fullListing.Listing.CreatedAt.UnixMilli(),
+		UpdatedAt:           fullListing.Listing.UpdatedAt.UnixMilli(),
+		Status:              fullListing.Listing.Status,
+		PublishedAt:         timeToMillis(fullListing.Listing.PublishedAt),
+		ExpiresAt:           timeToMillis(fullListing.Listing.ExpiresAt),
+		ModerationReason:    fullListing.Listing.ModerationReason,
+		PriceDropped:        previousPrice != nil,
+		PreviousPrice:       previousPrice,
+		Version:             fullListing.Listing.Version,
 	}
 
 	return resp, nil
 }
 
 func (s *Listing) DeleteListing(ctx context.Context, pID string) error {
-	err := s.s.DeleteListing(ctx, pID)
+	listingID, err := uuid.Parse(pID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Некорректный ID объявления")
+	}
+
+	// Изображения удаленного объявления отвязываются вместе с ним, после чего их удалит крон
+	images, err := s.images.PlanListingImages(ctx, listingID, nil)
+	if err != nil {
+		return err
+	}
+
+	err = s.s.DeleteListing(ctx, listingID, images.Apply)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return fiber.NewError(fiber.StatusNotFound, err.Error())
@@ -150,84 +208,169 @@ func (s *Listing) UpdateListing(ctx context.Context, p listing.UpdateListingRequ
 	// Устанавливаем ListingID в Location
 	p.Location.ListingID = p.ID
 
-	err := s.s.UpdateFullListing(ctx, models.Listing{
-		ID:          p.ID,
-		Title:       p.Title,
-		Description: p.Description,
-		Price:       p.Price,
-		Currency:    p.Currency,
-		Images:      p.Images,
-		UpdatedAt:   time.Now(),
-	}, p.Categories, p.Location, p.Characteristics)
+	if p.Version <= 0 {
+		return listing.FullListingResponse{}, fiber.NewError(fiber.StatusPreconditionRequired,
+			"If-Match header or version field is required")
+	}
+
+	if err := validateCharacteristics(ctx, p.Categories, p.Characteristics); err != nil {
+		return listing.FullListingResponse{}, err
+	}
+
+	current, err := s.s.GetFullListing(ctx, p.ID.String())
+	if err != nil {
+		return listing.FullListingResponse{}, err
+	}
+	if current.Listing.Version != p.Version {
+		return listing.FullListingResponse{}, s.versionConflict(ctx, p.ID)
+	}
+
+	// Новые изображения привязываются, а удаленные отвязываются вместе с изменением объявления
+	images, err := s.images.PlanListingImages(ctx, p.ID, p.Images)
 	if err != nil {
 		return listing.FullListingResponse{}, err
 	}
 
+	// Изменение сохраняется в истории вместе с отличиями от текущего состояния
+	now := time.Now()
+	snapshot := newSnapshot(p.Title, p.Description, p.Price, p.Currency, p.Categories, &p.Location, p.Characteristics)
+	revisions, err := s.newRevisions(ctx, current, snapshot, nil, now)
+	if err != nil {
+		return listing.FullListingResponse{}, err
+	}
+
+	// Новый текст написан на языке запроса автора; без изменений текста язык остается прежним
+	textChanged := p.Title != current.Listing.Title || p.Description != current.Listing.Description
+	var originalLang models.Localization
+	if textChanged {
+		originalLang = parser.GetLang(ctx)
+	}
+
+	err = s.s.UpdateFullListing(ctx, models.Listing{
+		ID:           p.ID,
+		Title:        p.Title,
+		Description:  p.Description,
+		Price:        p.Price,
+		Currency:     p.Currency,
+		UpdatedAt:    now,
+		Version:      p.Version,
+		OriginalLang: originalLang,
+	}, p.Categories, p.Location, p.Characteristics, images.Apply, revisions...)
+	if errors.Is(err, storage.ErrStaleVersion) {
+		return listing.FullListingResponse{}, s.versionConflict(ctx, p.ID)
+	}
+	if err != nil {
+		return listing.FullListingResponse{}, err
+	}
+
+	// Переводы прежнего текста больше не отдаются, новые готовятся в фоне
+	if textChanged {
+		s.translations.Enqueue(p.ID)
+	}
+
 	return s.GetListing(ctx, p.ID.String())
 }
 
-func (s *Listing) GetCategories(ctx context.Context) (listing.ResponseGetCategories, error) {
-	// Получаем базовую структуру категорий
-	// Преобразуем структуру из конфига в формат для API
-	configCategories := config.GetConfig().Categories.Data.Categories
-	categories := make([]listing.CategoryNode, 0, len(configCategories))
+// validateCharacteristics проверяет характеристики по правилам категорий объявления:
+// обязательность, опции и границы. Ошибки отдаются по полям на языке запроса
+func validateCharacteristics(ctx context.Context, categories []string, characteristics models.CharacteristicValue) error {
+	schema := models.ResolveCategories(categories)
+	errs := validator.CheckCharacteristics(schema, characteristics, parser.GetLang(ctx))
+	if len(errs) > 0 {
+		return models.NewValidationFailedError(errs)
+	}
+	return nil
+}
 
-	// Преобразуем формат категорий из TOML в формат API
-	for _, cat := range configCategories {
-		categories = append(categories, convertCategoryNodeToAPI(cat))
+// versionConflict ошибка записи поверх устаревшей версии с текущим состоянием объявления
+func (s *Listing) versionConflict(ctx context.Context, listingID uuid.UUID) error {
+	current, err := s.GetListing(ctx, listingID.String())
+	if err != nil {
+		return err
 	}
 
-	// Получаем язык из контекста
-	lang := parser.GetLang(ctx)
-	// Загружаем локализации
-	var translations map[string]string
-	var langData string
+	return models.NewConflictError(
+		fmt.Sprintf("listing %s was modified, current version is %d", listingID, current.Version), current)
+}
 
-	switch lang {
-	case models.LanguageRu:
-		langData = config.GetConfig().Categories.LangCategories.Ru
-	case models.LanguageEn:
-		langData = config.GetConfig().Categories.LangCategories.En
-	case models.LanguageEs:
-		langData = config.GetConfig().Categories.LangCategories.Es
+// ReorderImages задает новый порядок изображений объявления и повышает его версию
+func (s *Listing) ReorderImages(ctx context.Context, req listing.ReorderImagesRequest) (listing.ListingImagesResponse, error) {
+	if _, err := s.s.GetListing(ctx, req.ListingID.String()); err != nil {
+		return listing.ListingImagesResponse{}, err
+	}
+
+	order, err := s.images.PlanImagesOrder(ctx, req.ListingID, req.ImageIDs)
+	if err != nil {
+		return listing.ListingImagesResponse{}, err
 	}
 
-	// Распаковываем локализации
-	if err := json.Unmarshal([]byte(langData), &translations); err != nil {
-		return listing.ResponseGetCategories{}, errors.New("ошибка при разборе локализаций: " + err.Error())
+	return s.updateImages(ctx, req.ListingID, req.Version, order)
+}
+
+// SetCoverImage делает изображение обложкой объявления и повышает его версию
+func (s *Listing) SetCoverImage(ctx context.Context, listingID, imageID uuid.UUID, version int) (listing.ListingImagesResponse, error) {
+	if _, err := s.s.GetListing(ctx, listingID.String()); err != nil {
+		return listing.ListingImagesResponse{}, err
 	}
 
-	// Рекурсивно применяем локализации
-	applyTranslations(&categories, translations)
+	cover, err := s.images.PlanListingCover(ctx, listingID, imageID)
+	if err != nil {
+		return listing.ListingImagesResponse{}, err
+	}
 
-	return listing.ResponseGetCategories{Categories: categories}, nil
+	return s.updateImages(ctx, listingID, version, cover)
 }
 
-// applyTranslations рекурсивно применяет переводы к категориям
-func applyTranslations(nodes *[]listing.CategoryNode, translations map[string]string) {
-	for i := range *nodes {
-		node := &(*nodes)[i]
-		if name, ok := translations[node.Category.ID]; ok {
-			node.Category.Name = name
-		}
-		if len(node.Subcategories) > 0 {
-			applyTranslations(&node.Subcategories, translations)
-		}
+// updateImages записывает изменение изображений вместе с новой версией объявления.
+// Если клиент передал версию, изменение поверх более новой версии отклоняется
+func (s *Listing) updateImages(ctx context.Context, listingID uuid.UUID, version int, images storage.TxStep) (listing.ListingImagesResponse, error) {
+	newVersion, err := s.s.UpdateListingImages(ctx, listingID, version, images)
+	if errors.Is(err, storage.ErrStaleVersion) {
+		return listing.ListingImagesResponse{}, s.versionConflict(ctx, listingID)
+	}
+	if err != nil {
+		return listing.ListingImagesResponse{}, err
 	}
+
+	result, err := s.images.GetListingImages(ctx, listingID)
+	if err != nil {
+		return listing.ListingImagesResponse{}, err
+	}
+
+	return listing.ListingImagesResponse{Images: result, Version: newVersion}, nil
 }
 
-func (s *Listing) GetCharacteristicsForCategory(ctx context.Context, categoryIds []string) (listing.GetCharacteristicsForCategoryResponse, error) {
-	// Получаем характеристики и переводы
-	_, characteristicKeys, translations, err := s.getCategoryCharacteristics(ctx, categoryIds)
+// GetCategories возвращает дерево категорий на языке запроса со слагами, хлебными крошками
+// и числом опубликованных объявлений вместе с подкатегориями
+func (s *Listing) GetCategories(ctx context.Context) (listing.ResponseGetCategories, error) {
+	localizer, err := listing.NewCategoryLocalizer(ctx)
 	if err != nil {
-		return listing.GetCharacteristicsForCategoryResponse{}, err
+		return listing.ResponseGetCategories{}, err
 	}
 
-	// Создаем массив характеристик в порядке их ключей
-	result := make(models.CharacteristicParam)
-	for _, key := range characteristicKeys {
+	counts, err := s.s.CountPublishedListings(ctx, localizer.IDs())
+	if err != nil {
+		return listing.ResponseGetCategories{}, err
+	}
+
+	return listing.ResponseGetCategories{
+		Categories: convertCategoryNodes(config.GetConfig().Categories.Data.Categories, localizer, counts),
+	}, nil
+}
+
+// GetCharacteristicsForCategory возвращает действующую схему характеристик для пути категорий
+// с унаследованными от предков характеристиками, в порядке схемы
+func (s *Listing) GetCharacteristicsForCategory(ctx context.Context, categoryIds []string) (listing.GetCharacteristicsForCategoryResponse, error) {
+	schema := models.ResolveCategories(categoryIds)
+
+	result := make([]models.CharacteristicParamItem, 0, len(schema.Characteristics))
+	for _, rule := range schema.Characteristics {
 		// Создаем параметр в зависимости от типа характеристики
-		result[key] = s.createParamForCharacteristic(ctx, key, translations)
+		result = append(result, models.CharacteristicParamItem{
+			Role:     rule.Definition.Role,
+			Param:    s.createParamForCharacteristic(ctx, rule),
+			Required: rule.Required,
+		})
 	}
 
 	return listing.GetCharacteristicsForCategoryResponse{
@@ -235,10 +378,23 @@ func (s *Listing) GetCharacteristicsForCategory(ctx context.Context, categoryIds
 	}, nil
 }
 
-// GetFiltersForCategory возвращает фильтры для указанной категории
-func (s *Listing) GetFiltersForCategory(ctx context.Context, categoryId string) (listing.GetFiltersForCategoryResponse, error) {
+// GetFiltersForCategory возвращает фильтры для указанной категории вместе с подкатегориями,
+// а при exact — только для самой категории, так же как поиск. Диапазоны величин
+// отдаются в единицах из units, а для остальных ролей — в единице по умолчанию
+func (s *Listing) GetFiltersForCategory(ctx context.Context, categoryId string, exact bool, units map[string]models.Dimension) (listing.GetFiltersForCategoryResponse, error) {
+	registry := models.Characteristics()
+	for role, unit := range units {
+		def, ok := registry.Get(role)
+		if !ok || def.Kind != models.KindAmount {
+			return listing.GetFiltersForCategoryResponse{}, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("characteristic %q has no units", role))
+		}
+		if !def.AllowsUnit(unit) {
+			return listing.GetFiltersForCategoryResponse{}, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("unit %q is not allowed for %q", unit, role))
+		}
+	}
+
 	// Получаем значения характеристик из БД
-	charValues, err := s.s.GetCategoryFilters(ctx, categoryId)
+	charValues, err := s.s.GetCategoryFilters(ctx, storage.CategoryScope{ID: categoryId, Exact: exact}, units)
 	if err != nil {
 		return listing.GetFiltersForCategoryResponse{}, fmt.Errorf("error getting characteristic values: %w", err)
 	}
@@ -286,6 +442,23 @@ func (s *Listing) filterEmptyValues(filters models.Filters) models.Filters {
 				result[key] = f
 			}
 
+		case models.IntegerRangeFilter:
+			// Добавляем диапазон только если значения в категории различаются
+			if f.Min != nil && f.Max != nil && *f.Min < *f.Max {
+				result[key] = f
+			}
+
+		case models.DateRangeFilter:
+			// Даты в DateLayout сравниваются как строки
+			if f.From != "" && f.To != "" && f.From < f.To {
+				result[key] = f
+			}
+
+		case models.TextFilter:
+			if f != "" {
+				result[key] = f
+			}
+
 		default:
 			// Для неизвестных типов фильтров просто копируем
 			result[key] = filter
@@ -295,84 +468,11 @@ func (s *Listing) filterEmptyValues(filters models.Filters) models.Filters {
 	return result
 }
 
-// getCategoryCharacteristics получает характеристики для указанных категорий
-func (s *Listing) getCategoryCharacteristics(ctx context.Context, categoryIds []string) (map[string][]string, []string, map[string]string, error) {
-	// Получаем язык из контекста
-	lang := string(parser.GetLang(ctx))
-
-	// Собираем характеристики категорий из структуры категорий в конфиге
-	categoryCharacteristics := make(map[string][]string)
-
-	// Собираем характеристики из категорий в конфиге
-	for _, cat := range config.GetConfig().Categories.Data.Categories {
-		charRoles := extractCharacteristicRoles(cat)
-		categoryCharacteristics[cat.ID] = charRoles
-	}
-
-	// Загружаем переводы характеристик в зависимости от языка
-	var characteristicsTranslations map[string]string
-	var translationsJson string
-
-	// Выбираем нужный язык перевода
-	switch lang {
-	case string(models.LanguageRu):
-		translationsJson = config.GetConfig().Categories.LangCharacteristics.Ru
-	case string(models.LanguageEn):
-		translationsJson = config.GetConfig().Categories.LangCharacteristics.En
-	case string(models.LanguageEs):
-		translationsJson = config.GetConfig().Categories.LangCharacteristics.Es
-	default:
-		translationsJson = config.GetConfig().Categories.LangCharacteristics.En
-	}
-
-	// Парсим переводы
-	if err := json.Unmarshal([]byte(translationsJson), &characteristicsTranslations); err != nil {
-		return nil, nil, nil, errors.New("ошибка при разборе переводов характеристик: " + err.Error())
-	}
-
-	// Создаем мапу для отслеживания уже добавленных характеристик
-	characteristicsSet := make(map[string]bool)
-	var characteristicKeys []string
-
-	// Проходим по категориям в порядке их иерархии
-	// Поскольку categoryIds уже содержит путь от корня до категории,
-	// мы просто проходим по нему в том же порядке
-	for _, categoryId := range categoryIds {
-		// Получаем характеристики для текущей категории
-		if chars, ok := categoryCharacteristics[categoryId]; ok {
-			// Добавляем характеристики для текущей категории, сохраняя порядок
-			for _, char := range chars {
-				if !characteristicsSet[char] {
-					characteristicsSet[char] = true
-					characteristicKeys = append(characteristicKeys, char)
-				}
-			}
-		}
-	}
-
-	return categoryCharacteristics, characteristicKeys, characteristicsTranslations, nil
-}
-
-// extractCharacteristicRoles извлекает роли характеристик из категории
-func extractCharacteristicRoles(category config.CategoryNode) []string {
-	roles := make([]string, 0, len(category.Characteristics))
-
-	// Добавляем роли характеристик текущей категории
-	for _, char := range category.Characteristics {
-		roles = append(roles, char.Role)
-	}
-
-	return roles
-}
-
 // createParamForCharacteristic создает параметр нужного типа для характеристики
-func (s *Listing) createParamForCharacteristic(ctx context.Context, characteristicKey string, translations map[string]string) interface{} {
-	// Получаем тип параметра из мапы
-	paramType, exists := models.CharacteristicParamMap[characteristicKey]
-	if !exists {
-		// Если тип не определен, возвращаем nil
-		return nil
-	}
+// с опциями из схемы категории
+func (s *Listing) createParamForCharacteristic(ctx context.Context, rule models.CharacteristicRule) interface{} {
+	def := rule.Definition
+	characteristicKey := def.Role
 
 	// Получаем язык из контекста
 	lang := ctx.Value(models.KeyLanguage)
@@ -392,96 +492,89 @@ func (s *Listing) createParamForCharacteristic(ctx context.Context, characterist
 	}
 
 	// В зависимости от типа параметра создаем соответствующую структуру
-	switch paramType.(type) {
-	case models.ColorParam:
+	switch def.Kind {
+	case models.KindColor:
 		// Для цвета просто возвращаем пустую структуру
 		return models.ColorParam{}
 
-	case models.StringParam:
+	case models.KindDropdown:
 		// Для строковых параметров (выпадающих списков) загружаем опции
-		stringParam := models.StringParam{
-			Options: make([]models.DropdownOption, 0),
+		return models.StringParam{
+			Options: translatedOptions(def, langOptions[characteristicKey]),
 		}
 
-		// Получаем опции для данной характеристики
-		if optionValues, ok := config.Categories.CategoryOptions[characteristicKey]; ok && len(optionValues) > 0 {
-			for _, value := range optionValues {
-				// По умолчанию используем значение как метку
-				label := value
-
-				// Если есть переводы для этой характеристики
-				if optionTranslations, ok := langOptions[characteristicKey]; ok {
-					// Если есть перевод для этого значения
-					if translation, ok := optionTranslations[value]; ok {
-						label = translation
-					}
-				}
-
-				stringParam.Options = append(stringParam.Options, models.DropdownOption{
-					Value: value,
-					Label: label,
-				})
-			}
+	case models.KindMultiSelect:
+		return models.MultiSelectParam{
+			Options:  translatedOptions(def, langOptions[characteristicKey]),
+			Multiple: true,
 		}
 
-		return stringParam
-
-	case models.CheckboxParam:
+	case models.KindCheckbox:
 		// Для чекбокса просто возвращаем пустую структуру
 		return models.CheckboxParam{}
 
-	case models.AmountParam:
-		// Для числовых параметров добавляем соответствующую единицу измерения
-		dimension := s.getDefaultDimensionForCharacteristic(characteristicKey)
+	case models.KindAmount:
+		// Для числовых параметров добавляем единицу измерения по умолчанию и границы категории в ней
 		return models.AmountParam{
-			Dimension: dimension[0],
+			Dimension: def.DefaultUnit,
+			Min:       rule.Min,
+			Max:       rule.Max,
 		}
 
-	default:
-		// Для неизвестных типов возвращаем nil
-		return nil
-	}
-}
-
-// getDefaultDimensionForCharacteristic возвращает дефолтную единицу измерения для числовой характеристики
-func (s *Listing) getDefaultDimensionForCharacteristic(characteristicKey string) []models.Dimension {
-	switch characteristicKey {
-	case models.CHAR_HEIGHT, models.CHAR_WIDTH, models.CHAR_DEPTH:
-		// Для линейных размеров (дефолт - сантиметры)
-		return []models.Dimension{models.Dimension(models.CM)}
-
-	case models.CHAR_AREA:
-		// Для площади (дефолт - квадратные метры)
-		return []models.Dimension{models.Dimension(models.M2)}
+	case models.KindInteger:
+		param := models.IntegerParam{}
+		if rule.Min != nil {
+			min := int64(math.Ceil(*rule.Min))
+			param.Min = &min
+		}
+		if rule.Max != nil {
+			max := int64(math.Floor(*rule.Max))
+			param.Max = &max
+		}
+		return param
 
-	case models.CHAR_VOLUME:
-		// Для объема (дефолт - литры)
-		return []models.Dimension{models.Dimension(models.L)}
+	case models.KindText:
+		return models.TextParam{MaxLength: def.MaxLength}
 
-	case models.CHAR_WEIGHT:
-		// Для веса (дефолт - килограммы)
-		return []models.Dimension{models.Dimension(models.KG)}
+	case models.KindDate:
+		return models.DateParam{Format: models.DateParamFormat}
 
 	default:
-		return []models.Dimension{models.Dimension("")}
+		// Для неизвестных типов возвращаем nil
+		return nil
 	}
 }
 
-// convertCategoryNodeToAPI преобразует структуру категории из конфига в формат API
-func convertCategoryNodeToAPI(configNode config.CategoryNode) listing.CategoryNode {
-	node := listing.CategoryNode{
-		Category: listing.Category{
-			ID: configNode.ID,
-		},
+// translatedOptions опции характеристики из реестра с метками на языке запроса.
+// Без перевода меткой служит само значение
+func translatedOptions(def models.CharacteristicDefinition, translations map[string]string) []models.DropdownOption {
+	options := make([]models.DropdownOption, 0, len(def.Options))
+	for _, value := range def.Options {
+		label := value
+		if translation, ok := translations[value]; ok {
+			label = translation
+		}
+		options = append(options, models.DropdownOption{
+			Value: value,
+			Label: label,
+		})
 	}
+	return options
+}
 
-	// Преобразуем подкатегории рекурсивно
-	if len(configNode.Subcategories) > 0 {
-		node.Subcategories = make([]listing.CategoryNode, 0, len(configNode.Subcategories))
-		for _, subCat := range configNode.Subcategories {
-			node.Subcategories = append(node.Subcategories, convertCategoryNodeToAPI(subCat))
+// convertCategoryNodes преобразует дерево категорий из конфига в формат API
+func convertCategoryNodes(configNodes []config.CategoryNode, localizer listing.CategoryLocalizer, counts map[string]int) []listing.CategoryNode {
+	nodes := make([]listing.CategoryNode, 0, len(configNodes))
+	for _, configNode := range configNodes {
+		category := localizer.Category(configNode.ID)
+		count := counts[configNode.ID]
+		category.ListingCount = &count
+
+		node := listing.CategoryNode{Category: category}
+		if len(configNode.Subcategories) > 0 {
+			node.Subcategories = convertCategoryNodes(configNode.Subcategories, localizer, counts)
 		}
+		nodes = append(nodes, node)
 	}
-
-	return node
+	return nodes
 }
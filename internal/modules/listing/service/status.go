@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/yaroslavvasilenko/argon/config"
+	"github.com/yaroslavvasilenko/argon/internal/models"
+	"github.com/yaroslavvasilenko/argon/internal/modules/listing"
+	"github.com/yaroslavvasilenko/argon/internal/modules/listing/storage"
+)
+
+const (
+	defaultModerationLimit = 20
+	maxModerationLimit     = 100
+)
+
+// PublishListing отправляет черновик на публикацию или возвращает снятое объявление в поиск.
+// Если включена модерация, черновик сначала попадает в очередь модератора
+func (s *Listing) PublishListing(ctx context.Context, id uuid.UUID) (listing.FullListingResponse, error) {
+	current, err := s.s.GetListing(ctx, id.String())
+	if err != nil {
+		return listing.FullListingResponse{}, err
+	}
+
+	next := models.ListingStatusPublished
+	if current.Status == models.ListingStatusDraft {
+		next = storage.NewLifecycle(config.GetConfig()).SubmitStatus()
+	}
+
+	return s.changeStatus(ctx, current, storage.StatusChange{To: next})
+}
+
+// PauseListing временно снимает объявление с публикации
+func (s *Listing) PauseListing(ctx context.Context, id uuid.UUID) (listing.FullListingResponse, error) {
+	current, err := s.s.GetListing(ctx, id.String())
+	if err != nil {
+		return listing.FullListingResponse{}, err
+	}
+
+	return s.changeStatus(ctx, current, storage.StatusChange{To: models.ListingStatusPaused})
+}
+
+// MarkListingSold отмечает товар проданным; из этого состояния объявление не возвращается
+func (s *Listing) MarkListingSold(ctx context.Context, id uuid.UUID) (listing.FullListingResponse, error) {
+	current, err := s.s.GetListing(ctx, id.String())
+	if err != nil {
+		return listing.FullListingResponse{}, err
+	}
+
+	return s.changeStatus(ctx, current, storage.StatusChange{To: models.ListingStatusSold})
+}
+
+// RenewListing продлевает срок публикации: опубликованное объявление получает новый срок,
+// истекшее публикуется снова
+func (s *Listing) RenewListing(ctx context.Context, id uuid.UUID) (listing.FullListingResponse, error) {
+	current, err := s.s.GetListing(ctx, id.String())
+	if err != nil {
+		return listing.FullListingResponse{}, err
+	}
+
+	if current.Status != models.ListingStatusPublished && current.Status != models.ListingStatusExpired {
+		return listing.FullListingResponse{}, fiber.NewError(fiber.StatusConflict,
+			fmt.Sprintf("listing in status %s cannot be renewed", current.Status))
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(storage.NewLifecycle(config.GetConfig()).TTL)
+	change := storage.StatusChange{
+		From:      current.Status,
+		To:        models.ListingStatusPublished,
+		At:        now,
+		ExpiresAt: &expiresAt,
+	}
+	if current.Status == models.ListingStatusExpired {
+		change.PublishedAt = &now
+	}
+
+	if err := s.s.ChangeStatus(ctx, id, change); err != nil {
+		return listing.FullListingResponse{}, err
+	}
+
+	return s.GetListing(ctx, id.String())
+}
+
+// ApproveListing публикует объявление из очереди модерации
+func (s *Listing) ApproveListing(ctx context.Context, id uuid.UUID) (listing.FullListingResponse, error) {
+	current, err := s.s.GetListing(ctx, id.String())
+	if err != nil {
+		return listing.FullListingResponse{}, err
+	}
+	if current.Status != models.ListingStatusPendingReview {
+		return listing.FullListingResponse{}, fiber.NewError(fiber.StatusConflict,
+			fmt.Sprintf("listing %s is not pending review", id))
+	}
+
+	return s.changeStatus(ctx, current, storage.StatusChange{
+		To:       models.ListingStatusPublished,
+		Decision: models.ModerationApproved,
+	})
+}
+
+// RejectListing возвращает объявление из очереди модерации в черновики с указанием причины
+func (s *Listing) RejectListing(ctx context.Context, req listing.RejectListingRequest) (listing.FullListingResponse, error) {
+	current, err := s.s.GetListing(ctx, req.ID.String())
+	if err != nil {
+		return listing.FullListingResponse{}, err
+	}
+	if current.Status != models.ListingStatusPendingReview {
+		return listing.FullListingResponse{}, fiber.NewError(fiber.StatusConflict,
+			fmt.Sprintf("listing %s is not pending review", req.ID))
+	}
+
+	return s.changeStatus(ctx, current, storage.StatusChange{
+		To:       models.ListingStatusDraft,
+		Reason:   req.Reason,
+		Decision: models.ModerationRejected,
+	})
+}
+
+// GetModerationQueue возвращает страницу очереди модерации, начиная с давно ожидающих объявлений
+func (s *Listing) GetModerationQueue(ctx context.Context, limit, offset int) (listing.ModerationQueueResponse, error) {
+	if limit <= 0 {
+		limit = defaultModerationLimit
+	}
+	if limit > maxModerationLimit {
+		limit = maxModerationLimit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	ids, err := s.s.GetModerationQueue(ctx, limit, offset)
+	if err != nil {
+		return listing.ModerationQueueResponse{}, err
+	}
+
+	resp := listing.ModerationQueueResponse{Items: make([]listing.FullListingResponse, 0, len(ids))}
+	for _, id := range ids {
+		item, err := s.GetListing(ctx, id.String())
+		if err != nil {
+			return listing.ModerationQueueResponse{}, err
+		}
+		resp.Items = append(resp.Items, item)
+	}
+
+	return resp, nil
+}
+
+// changeStatus проверяет допустимость перехода и сохраняет новое состояние объявления
+func (s *Listing) changeStatus(ctx context.Context, current models.Listing, change storage.StatusChange) (listing.FullListingResponse, error) {
+	if !current.Status.CanTransitionTo(change.To) {
+		return listing.FullListingResponse{}, fiber.NewError(fiber.StatusConflict,
+			fmt.Sprintf("cannot change listing status from %s to %s", current.Status, change.To))
+	}
+
+	change.From = current.Status
+	change.At = time.Now()
+	storage.NewLifecycle(config.GetConfig()).Publication(&change)
+
+	if err := s.s.ChangeStatus(ctx, current.ID, change); err != nil {
+		return listing.FullListingResponse{}, err
+	}
+
+	return s.GetListing(ctx, current.ID.String())
+}
+
+func timeToMillis(t *time.Time) *int64 {
+	if t == nil {
+		return nil
+	}
+	ms := t.UnixMilli()
+	return &ms
+}
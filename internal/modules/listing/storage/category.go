@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// CategoryScope категория поиска. По умолчанию в выдачу попадают объявления категории
+// и всех ее подкатегорий, Exact оставляет только объявления самой категории
+type CategoryScope struct {
+	ID    string
+	Exact bool
+}
+
+// condition возвращает SQL условие по категории. Подкатегории берутся из замыкания
+// дерева category_closure, которое перестраивается при каждом изменении таксономии
+func (c CategoryScope) condition() string {
+	if c.ID == "" {
+		return ""
+	}
+
+	id := strings.ReplaceAll(c.ID, "'", "''")
+	if c.Exact {
+		return `
+			AND EXISTS (
+				SELECT 1 FROM listing_categories lc
+				WHERE lc.listing_id = l.id AND lc.category_id = '` + id + `'
+			)`
+	}
+	return `
+			AND EXISTS (
+				SELECT 1 FROM listing_categories lc
+				JOIN category_closure cc ON cc.descendant_id = lc.category_id
+				WHERE lc.listing_id = l.id AND cc.ancestor_id = '` + id + `'
+			)`
+}
+
+// CountPublishedListings считает видимые в поиске объявления категорий вместе с подкатегориями.
+// Объявление засчитывается категории один раз, даже если привязано к нескольким категориям ветки
+func (s *Listing) CountPublishedListings(ctx context.Context, categoryIDs []string) (map[string]int, error) {
+	counts := make(map[string]int, len(categoryIDs))
+	if len(categoryIDs) == 0 {
+		return counts, nil
+	}
+
+	rows, err := s.pool.Query(ctx, `
+		SELECT cc.ancestor_id, COUNT(DISTINCT l.id)
+		FROM category_closure cc
+		JOIN listing_categories lc ON lc.category_id = cc.descendant_id
+		JOIN listings l ON l.id = lc.listing_id
+		WHERE cc.ancestor_id = ANY($1)
+		AND `+visibleCondition+`
+		GROUP BY cc.ancestor_id`, categoryIDs)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка подсчета объявлений по категориям: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			id    string
+			count int
+		)
+		if err := rows.Scan(&id, &count); err != nil {
+			return nil, fmt.Errorf("ошибка чтения числа объявлений: %w", err)
+		}
+		counts[id] = count
+	}
+	return counts, rows.Err()
+}
@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"strings"
+
+	"github.com/yaroslavvasilenko/argon/internal/models"
+)
+
+// facetCases ветки CASE запроса фильтров категории для характеристик из реестра.
+// Для опций собираются уникальные значения, для флагов — встречающиеся значения,
+// для диапазонов — минимум и максимум; величины агрегируются в базовой единице.
+// Текстовые характеристики агрегатов не имеют. Агрегаты считаются по объявлениям
+// из category_listings, которые выбирает запрос фильтров
+func facetCases(registry models.CharacteristicRegistry) string {
+	var cases strings.Builder
+
+	if roles := registry.RolesByFilter(models.FilterOptions); len(roles) > 0 {
+		cases.WriteString(`
+					-- Для опций возвращаем массив уникальных значений
+					WHEN key IN (` + quoteRoles(roles) + `) THEN (
+						SELECT jsonb_agg(DISTINCT value)
+						FROM (
+							-- Обработка массивов
+							SELECT jsonb_array_elements_text(lch.characteristics->key) AS value
+							FROM listing_characteristics lch
+							JOIN category_listings fl ON fl.id = lch.listing_id
+							WHERE lch.characteristics ? key
+							AND jsonb_typeof(lch.characteristics->key) = 'array'
+							UNION ALL
+							-- Обработка скалярных значений
+							SELECT lch.characteristics->>key AS value
+							FROM listing_characteristics lch
+							JOIN category_listings fl ON fl.id = lch.listing_id
+							WHERE lch.characteristics ? key
+							AND jsonb_typeof(lch.characteristics->key) != 'array'
+						) subq
+						WHERE value IS NOT NULL
+					)`)
+	}
+
+	if roles := registry.RolesByFilter(models.FilterFlag); len(roles) > 0 {
+		cases.WriteString(`
+					-- Для булевых значений (например, "в наличии")
+					WHEN key IN (` + quoteRoles(roles) + `) THEN (
+						SELECT jsonb_agg(DISTINCT (lch.characteristics->key->>'checkbox_value')::boolean)
+						FROM listing_characteristics lch
+						JOIN category_listings fl ON fl.id = lch.listing_id
+						WHERE lch.characteristics ? key
+					)`)
+	}
+
+	if roles := registry.RolesByKind(models.KindAmount); len(roles) > 0 {
+		cases.WriteString(`
+					-- Для размерных характеристик (высота, ширина и т.д.) границы в базовой единице
+					WHEN key IN (` + quoteRoles(roles) + `) THEN (
+						SELECT jsonb_build_object(
+							'min', MIN((lch.characteristics->key->>'base_value')::float),
+							'max', MAX((lch.characteristics->key->>'base_value')::float)
+						)
+						FROM listing_characteristics lch
+						JOIN category_listings fl ON fl.id = lch.listing_id
+						WHERE lch.characteristics->key ? 'base_value'
+					)`)
+	}
+
+	if roles := registry.RolesByKind(models.KindInteger); len(roles) > 0 {
+		cases.WriteString(`
+					-- Для целых значений (год, пробег)
+					WHEN key IN (` + quoteRoles(roles) + `) THEN (
+						SELECT jsonb_build_object(
+							'min', MIN((lch.characteristics->>key)::bigint),
+							'max', MAX((lch.characteristics->>key)::bigint)
+						)
+						FROM listing_characteristics lch
+						JOIN category_listings fl ON fl.id = lch.listing_id
+						WHERE lch.characteristics ? key
+					)`)
+	}
+
+	if roles := registry.RolesByKind(models.KindDate); len(roles) > 0 {
+		cases.WriteString(`
+					-- Для дат возвращаем самую раннюю и самую позднюю
+					WHEN key IN (` + quoteRoles(roles) + `) THEN (
+						SELECT jsonb_build_object(
+							'from', MIN((lch.characteristics->>key)::date),
+							'to', MAX((lch.characteristics->>key)::date)
+						)
+						FROM listing_characteristics lch
+						JOIN category_listings fl ON fl.id = lch.listing_id
+						WHERE lch.characteristics ? key
+					)`)
+	}
+
+	return cases.String()
+}
+
+// quoteRoles перечисляет роли характеристик строковыми литералами SQL
+func quoteRoles(roles []string) string {
+	quoted := make([]string, 0, len(roles))
+	for _, role := range roles {
+		quoted = append(quoted, quoteLiteral(role))
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// quoteLiteral превращает значение из запроса в строковый литерал SQL
+func quoteLiteral(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yaroslavvasilenko/argon/config"
+	"github.com/yaroslavvasilenko/argon/internal/models"
+)
+
+func TestFacetCases(t *testing.T) {
+	registry := models.NewCharacteristicRegistry([]config.CharacteristicDefinition{
+		{Role: "brand", Kind: "dropdown"},
+		{Role: "color", Kind: "color"},
+		{Role: "stocked", Kind: "checkbox"},
+		{Role: "mileage", Kind: "amount", Units: []string{"km"}},
+		{Role: "serial", Kind: "dropdown", Filter: "none"},
+		{Role: "year", Kind: "integer"},
+		{Role: "available_from", Kind: "date"},
+		{Role: "model", Kind: "text"},
+	})
+
+	cases := facetCases(registry)
+	assert.Contains(t, cases, "WHEN key IN ('brand', 'color') THEN")
+	assert.Contains(t, cases, "WHEN key IN ('stocked') THEN")
+	assert.Contains(t, cases, "WHEN key IN ('mileage') THEN")
+	assert.Contains(t, cases, "WHEN key IN ('year') THEN")
+	assert.Contains(t, cases, "WHEN key IN ('available_from') THEN")
+	assert.NotContains(t, cases, "serial")
+	assert.NotContains(t, cases, "model", "у текстовых характеристик нет агрегатов")
+	assert.NotContains(t, cases, "lc.category_id", "агрегаты считаются по объявлениям категории вместе с подкатегориями")
+
+	assert.Empty(t, facetCases(models.CharacteristicRegistry{}))
+}
+
+func TestCharacteristicConditions(t *testing.T) {
+	stocked := true
+	filters := models.Filters{
+		"brand":   models.DropdownFilter{"apple", "samsung"},
+		"stocked": models.CheckboxFilter(&stocked),
+		"height":  models.DimensionFilter{Min: 10, Max: 20, Dimension: "cm"},
+	}
+
+	assert.Equal(t, []string{"(lch.characteristics -> 'brand' ? 'apple' OR lch.characteristics -> 'brand' ? 'samsung')"},
+		characteristicConditions(models.CharacteristicDefinition{Role: "brand", Kind: models.KindDropdown}, filters))
+	assert.Equal(t, []string{"(lch.characteristics ->> 'stocked')::boolean = true"},
+		characteristicConditions(models.CharacteristicDefinition{Role: "stocked", Kind: models.KindCheckbox}, filters))
+	assert.Len(t, characteristicConditions(models.CharacteristicDefinition{Role: "height", Kind: models.KindAmount}, filters), 2)
+	assert.Empty(t, characteristicConditions(models.CharacteristicDefinition{Role: "color", Kind: models.KindColor}, filters))
+}
+
+func TestDimensionConditions(t *testing.T) {
+	height := models.CharacteristicDefinition{Role: "height", Kind: models.KindAmount, DefaultUnit: "cm"}
+
+	assert.Equal(t, []string{
+		"(lch.characteristics -> 'height' ->> 'base_value')::float >= 2",
+		"(lch.characteristics -> 'height' ->> 'base_value')::float <= 3",
+	}, characteristicConditions(height, models.Filters{
+		"height": models.DimensionFilter{Min: 200, Max: 300, Dimension: "cm"},
+	}))
+	assert.Equal(t, []string{"(lch.characteristics -> 'height' ->> 'base_value')::float >= 1000"},
+		characteristicConditions(height, models.Filters{"height": models.DimensionFilter{Min: 1, Dimension: "km"}}))
+	assert.Equal(t, []string{"(lch.characteristics -> 'height' ->> 'base_value')::float <= 0.5"},
+		characteristicConditions(height, models.Filters{"height": models.DimensionFilter{Max: 50}}),
+		"без единицы используется единица по умолчанию")
+}
+
+func TestNewKindConditions(t *testing.T) {
+	minYear := int64(2010)
+	filters := models.Filters{
+		"year":           models.IntegerRangeFilter{Min: &minYear},
+		"amenities":      models.DropdownFilter{"parking", "kids' room"},
+		"model":          models.TextFilter("50% o'clock"),
+		"available_from": models.DateRangeFilter{From: "2025-06-01", To: "bad"},
+	}
+
+	assert.Equal(t, []string{"(lch.characteristics ->> 'year')::bigint >= 2010"},
+		characteristicConditions(models.CharacteristicDefinition{Role: "year", Kind: models.KindInteger}, filters))
+	assert.Equal(t, []string{"(lch.characteristics -> 'amenities' ? 'parking' OR lch.characteristics -> 'amenities' ? 'kids'' room')"},
+		characteristicConditions(models.CharacteristicDefinition{Role: "amenities", Kind: models.KindMultiSelect}, filters))
+	assert.Equal(t, []string{`lch.characteristics ->> 'model' ILIKE '%50\% o''clock%'`},
+		characteristicConditions(models.CharacteristicDefinition{Role: "model", Kind: models.KindText}, filters))
+	assert.Equal(t, []string{"(lch.characteristics ->> 'available_from')::date >= '2025-06-01'"},
+		characteristicConditions(models.CharacteristicDefinition{Role: "available_from", Kind: models.KindDate}, filters),
+		"некорректная граница не попадает в запрос")
+}
@@ -1,6 +1,8 @@
 package storage
 
 import (
+	"encoding/json"
+
 	"github.com/jackc/pgx/v5"
 	"github.com/yaroslavvasilenko/argon/internal/models"
 )
@@ -8,23 +10,55 @@ import (
 const (
 	itemTable     = "listings"
 	listingFields = "l.id, l.title, l.original_description, l.price, l.currency, l.views_count, l.created_at, l.updated_at, l.deleted_at"
+	// pointParam точка локации из параметров $4 (широта) и $5 (долгота) запросов вставки в locations
+	pointParam = "ST_SetSRID(ST_MakePoint($5::float8, $4::float8), 4326)::geography"
 )
 
+// listingDest возвращает адреса полей объявления в порядке listingFields
+func listingDest(listing *models.Listing) []interface{} {
+	return []interface{}{
+		&listing.ID,
+		&listing.Title,
+		&listing.Description,
+		&listing.Price,
+		&listing.Currency,
+		&listing.ViewsCount,
+		&listing.CreatedAt,
+		&listing.UpdatedAt,
+		&listing.DeletedAt,
+	}
+}
+
+// addressJSON сериализует адрес локации для колонки locations.address; пустой адрес сохраняется как NULL
+func addressJSON(address *models.Address) []byte {
+	if address == nil {
+		return nil
+	}
+
+	// Address состоит только из строк, поэтому сериализация не может завершиться ошибкой
+	data, _ := json.Marshal(address)
+	return data
+}
+
+// parseAddress разбирает колонку locations.address
+func parseAddress(data []byte) (*models.Address, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	var address models.Address
+	if err := json.Unmarshal(data, &address); err != nil {
+		return nil, err
+	}
+
+	return &address, nil
+}
+
 func (s *Listing) scanListings(rows pgx.Rows) ([]models.Listing, error) {
 	var listings []models.Listing
 	for rows.Next() {
 		var listing models.Listing
-		if err := rows.Scan(
-			&listing.ID,
-			&listing.Title,
-			&listing.Description,
-			&listing.Price,
-			&listing.Currency,
-			&listing.ViewsCount,
-			&listing.CreatedAt,
-			&listing.UpdatedAt,
-			&listing.DeletedAt,
-		); err != nil {
+		if err := rows.Scan(listingDest(&listing)...); err != nil {
 			return nil, err
 		}
 		listings = append(listings, listing)
@@ -34,3 +68,23 @@ func (s *Listing) scanListings(rows pgx.Rows) ([]models.Listing, error) {
 	}
 	return listings, nil
 }
+
+// scanRankedListings читает объявления вместе со значением ключа сортировки из последней колонки:
+// рангом rank_score или расстоянием distance
+func (s *Listing) scanRankedListings(rows pgx.Rows) ([]models.Listing, []float64, error) {
+	var listings []models.Listing
+	var scores []float64
+	for rows.Next() {
+		var listing models.Listing
+		var score float64
+		if err := rows.Scan(append(listingDest(&listing), &score)...); err != nil {
+			return nil, nil, err
+		}
+		listings = append(listings, listing)
+		scores = append(scores, score)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	return listings, scores, nil
+}
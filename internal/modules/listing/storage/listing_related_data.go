@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 
+	"github.com/google/uuid"
 	"github.com/yaroslavvasilenko/argon/internal/models"
 )
 
@@ -32,11 +33,11 @@ func (s *Listing) getListingWithRelatedData(ctx context.Context, listing models.
 		result.SetCategories([]models.Category{category})
 	}
 
-	// Получаем бусты объявления
+	// Получаем действующие бусты объявления
 	var boosts []models.Boost
 	if err := s.gorm.Table("listing_boosts").
-		Select("listing_id, boost_type, commission").
-		Where("listing_id = ?", listing.ID).
+		Select("id, listing_id, boost_type, commission, starts_at, ends_at, status").
+		Where("listing_id = ? AND status = ?", listing.ID, models.BoostStatusActive).
 		Find(&boosts).Error; err != nil {
 		return models.ListingResult{}, err
 	}
@@ -63,13 +64,15 @@ func (s *Listing) getListingWithRelatedData(ctx context.Context, listing models.
 	// Создаем переменные для хранения координат и радиуса
 	var latitude, longitude float64
 	var radius int
+	var address []byte
+	var serviceArea bool
 
 	// Получаем данные из БД напрямую в локальные переменные
 	var location models.Location
 	if err := s.gorm.Table("locations").
-		Select("id, listing_id, name, latitude, longitude, radius").
+		Select("id, listing_id, name, ST_Y(geog::geometry), ST_X(geog::geometry), radius, address, is_service_area").
 		Where("listing_id = ?", listing.ID).
-		Row().Scan(&location.ID, &location.ListingID, &location.Name, &latitude, &longitude, &radius); err != nil {
+		Row().Scan(&location.ID, &location.ListingID, &location.Name, &latitude, &longitude, &radius, &address, &serviceArea); err != nil {
 		// Если местоположение не найдено, просто продолжаем без него
 		// Это не критическая ошибка
 	} else {
@@ -79,10 +82,151 @@ func (s *Listing) getListingWithRelatedData(ctx context.Context, listing models.
 				Lat: latitude,
 				Lng: longitude,
 			},
-			Radius: radius,
+			Radius:      radius,
+			ServiceArea: serviceArea,
+		}
+		if location.Address, err = parseAddress(address); err != nil {
+			return models.ListingResult{}, err
 		}
 		result.SetLocation(location)
 	}
 
+	// Получаем миниатюру обложки объявления
+	var coverThumbnail string
+	if err := s.gorm.Table("listing_images li").
+		Select("il.image_name").
+		Joins("JOIN image_links il ON il.image_id = li.image_id").
+		Where("li.listing_id = ? AND li.is_cover AND il.image_name LIKE ?", listing.ID, "%-200px.webp").
+		Limit(1).
+		Row().Scan(&coverThumbnail); err != nil {
+		// Объявление может быть без изображений
+	} else {
+		result.SetCoverThumbnail(coverThumbnail)
+	}
+
 	return result, nil
 }
+
+// getListingsWithRelatedData получает те же данные, что getListingWithRelatedData, сразу для
+// списка объявлений: по одному запросу на каждый вид связанных данных вместо запросов на объявление
+func (s *Listing) getListingsWithRelatedData(ctx context.Context, listings []models.Listing) ([]models.ListingResult, error) {
+	results := make([]models.ListingResult, len(listings))
+	byID := make(map[uuid.UUID]*models.ListingResult, len(listings))
+	ids := make([]uuid.UUID, 0, len(listings))
+	for i, listing := range listings {
+		results[i] = models.NewListingResult(listing)
+		byID[listing.ID] = &results[i]
+		ids = append(ids, listing.ID)
+	}
+	if len(ids) == 0 {
+		return results, nil
+	}
+
+	// Категории объявлений, по одной записи с массивом идентификаторов на объявление
+	var categoryRows []struct {
+		ListingID  uuid.UUID
+		CategoryID string
+	}
+	if err := s.gorm.WithContext(ctx).Table("listing_categories").
+		Select("listing_id, category_id").
+		Where("listing_id IN ?", ids).
+		Find(&categoryRows).Error; err != nil {
+		return nil, err
+	}
+	categoryIDs := make(map[uuid.UUID][]string, len(ids))
+	for _, row := range categoryRows {
+		categoryIDs[row.ListingID] = append(categoryIDs[row.ListingID], row.CategoryID)
+	}
+	for id, categories := range categoryIDs {
+		byID[id].SetCategories([]models.Category{{ID: categories, ListingID: id.String()}})
+	}
+
+	// Действующие бусты
+	var boosts []models.Boost
+	if err := s.gorm.WithContext(ctx).Table("listing_boosts").
+		Select("id, listing_id, boost_type, commission, starts_at, ends_at, status").
+		Where("listing_id IN ? AND status = ?", ids, models.BoostStatusActive).
+		Find(&boosts).Error; err != nil {
+		return nil, err
+	}
+	for _, boost := range boosts {
+		result := byID[boost.ListingID]
+		result.SetBoosts(append(result.Boosts, boost))
+	}
+
+	// Характеристики
+	var characteristicRows []struct {
+		ListingID       uuid.UUID
+		Characteristics []byte
+	}
+	if err := s.gorm.WithContext(ctx).Table("listing_characteristics").
+		Select("listing_id, characteristics").
+		Where("listing_id IN ?", ids).
+		Find(&characteristicRows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range characteristicRows {
+		if len(row.Characteristics) == 0 {
+			continue
+		}
+		var characteristics map[string]interface{}
+		if err := json.Unmarshal(row.Characteristics, &characteristics); err != nil {
+			return nil, err
+		}
+		byID[row.ListingID].SetCharacteristics(characteristics)
+	}
+
+	// Местоположения
+	var locationRows []struct {
+		ID            string
+		ListingID     uuid.UUID
+		Name          string
+		Lat           float64
+		Lng           float64
+		Radius        int
+		Address       []byte
+		IsServiceArea bool
+	}
+	if err := s.gorm.WithContext(ctx).Table("locations").
+		Select("id, listing_id, name, ST_Y(geog::geometry) AS lat, ST_X(geog::geometry) AS lng, radius, address, is_service_area").
+		Where("listing_id IN ?", ids).
+		Find(&locationRows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range locationRows {
+		address, err := parseAddress(row.Address)
+		if err != nil {
+			return nil, err
+		}
+		byID[row.ListingID].SetLocation(models.Location{
+			ID:        row.ID,
+			ListingID: row.ListingID,
+			Name:      row.Name,
+			Area: models.Area{
+				Coordinates: models.Coordinates{Lat: row.Lat, Lng: row.Lng},
+				Radius:      row.Radius,
+				ServiceArea: row.IsServiceArea,
+			},
+			Address: address,
+		})
+	}
+
+	// Миниатюры обложек
+	var coverRows []struct {
+		ListingID uuid.UUID
+		ImageName string
+	}
+	if err := s.gorm.WithContext(ctx).Table("listing_images li").
+		Select("DISTINCT ON (li.listing_id) li.listing_id, il.image_name").
+		Joins("JOIN image_links il ON il.image_id = li.image_id").
+		Where("li.listing_id IN ? AND li.is_cover AND il.image_name LIKE ?", ids, "%-200px.webp").
+		Order("li.listing_id").
+		Find(&coverRows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range coverRows {
+		byID[row.ListingID].SetCoverThumbnail(row.ImageName)
+	}
+
+	return results, nil
+}
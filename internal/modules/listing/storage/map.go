@@ -0,0 +1,132 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/yaroslavvasilenko/argon/internal/models"
+)
+
+// SearchMapListings возвращает объявления с локацией внутри области карты viewport,
+// отобранные по тем же условиям, что и поисковая выдача. Новые объявления идут первыми
+func (s *Listing) SearchMapListings(ctx context.Context, query string, category CategoryScope, filters models.Filters, viewport models.BoundingBox, limit int) ([]models.ListingResult, error) {
+	if limit <= 0 {
+		return []models.ListingResult{}, nil
+	}
+
+	searchType := determineSearchType(query)
+	args := searchArgs(createSearchQuery(query, searchType), searchType)
+
+	conditions := buildFilterConditions(category, filters, models.Location{}) + viewportCondition(viewport)
+	sqlQuery := buildBaseQuery(searchType, listingFields, conditions) +
+		fmt.Sprintf(`ORDER BY l.created_at DESC, l.id DESC LIMIT $%d`, len(args)+1)
+
+	rows, err := s.pool.Query(ctx, sqlQuery, append(args, limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	listings, err := s.scanListings(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	// На карте сотни объявлений, поэтому связанные данные загружаются пачкой
+	return s.getListingsWithRelatedData(ctx, listings)
+}
+
+// SearchMapClusters группирует подходящие объявления внутри области карты по ячейкам
+// квадратной сетки со стороной cellSize градусов. Для каждой ячейки возвращается число
+// объявлений, центр масс и охватывающий прямоугольник
+func (s *Listing) SearchMapClusters(ctx context.Context, query string, category CategoryScope, filters models.Filters, viewport models.BoundingBox, cellSize float64) ([]models.MapCluster, error) {
+	searchType := determineSearchType(query)
+	args := searchArgs(createSearchQuery(query, searchType), searchType)
+
+	conditions := buildFilterConditions(category, filters, models.Location{}) + viewportCondition(viewport)
+	baseQuery := buildBaseQuery(searchType, "l.id", conditions)
+
+	rows, err := s.pool.Query(ctx, buildClusterQuery(baseQuery, viewport, cellSize), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	clusters := []models.MapCluster{}
+	for rows.Next() {
+		var cluster models.MapCluster
+		var listingID *uuid.UUID
+		if err := rows.Scan(
+			&cluster.Count,
+			&cluster.Centroid.Lat,
+			&cluster.Centroid.Lng,
+			&cluster.Bounds.South,
+			&cluster.Bounds.North,
+			&cluster.Bounds.West,
+			&cluster.Bounds.East,
+			&listingID,
+		); err != nil {
+			return nil, err
+		}
+		cluster.ListingID = listingID
+		clusters = append(clusters, cluster)
+	}
+
+	return clusters, rows.Err()
+}
+
+// buildClusterQuery формирует запрос кластеров: точки найденных объявлений внутри области
+// группируются по номеру ячейки сетки. Крупные кластеры идут первыми
+func buildClusterQuery(baseQuery string, viewport models.BoundingBox, cellSize float64) string {
+	cell := formatFloat(cellSize)
+
+	return `
+		WITH points AS (
+			SELECT found.id, loc.geog::geometry AS geom
+			FROM (` + baseQuery + `) found
+			JOIN locations loc ON loc.listing_id = found.id
+			WHERE ` + envelopeMatch(viewport) + `
+		)
+		SELECT
+			count(*)::int,
+			ST_Y(ST_Centroid(ST_Collect(geom))),
+			ST_X(ST_Centroid(ST_Collect(geom))),
+			min(ST_Y(geom)), max(ST_Y(geom)),
+			min(ST_X(geom)), max(ST_X(geom)),
+			CASE WHEN count(*) = 1 THEN (array_agg(id))[1] END
+		FROM points
+		GROUP BY floor(ST_X(geom) / ` + cell + `), floor(ST_Y(geom) / ` + cell + `)
+		ORDER BY count(*) DESC, min(ST_Y(geom)), min(ST_X(geom))
+	`
+}
+
+// viewportCondition условие поиска: у объявления есть локация внутри области карты
+func viewportCondition(viewport models.BoundingBox) string {
+	return `
+			AND EXISTS (
+				SELECT 1 FROM locations loc
+				WHERE loc.listing_id = l.id
+				AND ` + envelopeMatch(viewport) + `
+			)`
+}
+
+// envelopeMatch сравнивает точку локации loc с прямоугольником в градусах и использует
+// индекс idx_locations_geom. Область через антимеридиан делится на две части
+func envelopeMatch(viewport models.BoundingBox) string {
+	if !viewport.CrossesAntimeridian() {
+		return "loc.geog::geometry && " + envelopeExpr(viewport.West, viewport.South, viewport.East, viewport.North)
+	}
+
+	parts := []string{
+		"loc.geog::geometry && " + envelopeExpr(viewport.West, viewport.South, 180, viewport.North),
+		"loc.geog::geometry && " + envelopeExpr(-180, viewport.South, viewport.East, viewport.North),
+	}
+	return "(" + strings.Join(parts, " OR ") + ")"
+}
+
+func envelopeExpr(west, south, east, north float64) string {
+	return fmt.Sprintf("ST_MakeEnvelope(%s, %s, %s, %s, 4326)",
+		formatFloat(west), formatFloat(south), formatFloat(east), formatFloat(north))
+}
@@ -0,0 +1,32 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yaroslavvasilenko/argon/internal/models"
+)
+
+func TestViewportCondition(t *testing.T) {
+	moscow := models.BoundingBox{South: 55.5, North: 56, West: 37.3, East: 37.9}
+	assert.Contains(t, viewportCondition(moscow), "loc.geog::geometry && ST_MakeEnvelope(37.3, 55.5, 37.9, 56, 4326)")
+
+	// Область через антимеридиан делится на две части по обе стороны от 180°
+	chukotka := models.BoundingBox{South: 60, North: 70, West: 170, East: -170}
+	condition := viewportCondition(chukotka)
+	assert.Contains(t, condition, "ST_MakeEnvelope(170, 60, 180, 70, 4326)")
+	assert.Contains(t, condition, "ST_MakeEnvelope(-180, 60, -170, 70, 4326)")
+}
+
+func TestBuildClusterQuery(t *testing.T) {
+	viewport := models.BoundingBox{South: 55.5, North: 56, West: 37.3, East: 37.9}
+	sql := buildClusterQuery(buildBaseQuery(BrowseSearch, "l.id", viewportCondition(viewport)), viewport, 0.25)
+	// Сравниваем запрос без учета отступов и переносов строк
+	sql = strings.Join(strings.Fields(sql), " ")
+
+	assert.Contains(t, sql, "GROUP BY floor(ST_X(geom) / 0.25), floor(ST_Y(geom) / 0.25)")
+	assert.Contains(t, sql, "ST_Centroid(ST_Collect(geom))")
+	assert.Contains(t, sql, "FROM ( SELECT l.id FROM listings l", "поисковый запрос вложен как подзапрос")
+	assert.Contains(t, sql, ") found JOIN locations loc ON loc.listing_id = found.id")
+}
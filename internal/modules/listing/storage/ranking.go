@@ -0,0 +1,323 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yaroslavvasilenko/argon/config"
+	"github.com/yaroslavvasilenko/argon/internal/models"
+)
+
+const (
+	defaultRelevanceWeight   = 1.0
+	defaultBoostWeight       = 0.3
+	defaultFreshnessWeight   = 0.2
+	defaultFreshnessHalfLife = 7 * 24 * time.Hour
+
+	// rankedTimeLayout формат момента ранжирования в SQL
+	rankedTimeLayout = "2006-01-02 15:04:05"
+)
+
+// Ranking параметры ранжирования поисковой выдачи: ранг объявления складывается
+// из текстовой релевантности, уровня действующего буста и свежести
+type Ranking struct {
+	RelevanceWeight   float64
+	BoostWeight       float64
+	FreshnessWeight   float64
+	FreshnessHalfLife time.Duration
+	// BoostTiers уровень каждого типа буста
+	BoostTiers map[models.BoostType]int
+	// PromotedSlots число продвигаемых мест в начале каждой страницы
+	PromotedSlots int
+	// PromotedBoostTypes типы бустов, дающие право на продвигаемое место
+	PromotedBoostTypes []models.BoostType
+}
+
+// NewRanking создает параметры ранжирования из конфигурации, подставляя значения по умолчанию
+func NewRanking(cfg config.Config) Ranking {
+	r := Ranking{
+		RelevanceWeight:   cfg.Ranking.RelevanceWeight,
+		BoostWeight:       cfg.Ranking.BoostWeight,
+		FreshnessWeight:   cfg.Ranking.FreshnessWeight,
+		FreshnessHalfLife: time.Duration(cfg.Ranking.FreshnessHalfLifeHours * float64(time.Hour)),
+		BoostTiers:        make(map[models.BoostType]int, len(cfg.Ranking.BoostTiers)),
+		PromotedSlots:     cfg.Ranking.PromotedSlots,
+	}
+
+	if r.RelevanceWeight == 0 && r.BoostWeight == 0 && r.FreshnessWeight == 0 {
+		r.RelevanceWeight = defaultRelevanceWeight
+		r.BoostWeight = defaultBoostWeight
+		r.FreshnessWeight = defaultFreshnessWeight
+	}
+
+	if r.FreshnessHalfLife <= 0 {
+		r.FreshnessHalfLife = defaultFreshnessHalfLife
+	}
+
+	for boostType, tier := range cfg.Ranking.BoostTiers {
+		r.BoostTiers[models.BoostType(boostType)] = tier
+	}
+	if len(r.BoostTiers) == 0 {
+		r.BoostTiers = map[models.BoostType]int{
+			models.BoostTypeBase:      1,
+			models.BoostTypeHighlight: 2,
+			models.BoostTypeUpfront:   3,
+		}
+	}
+
+	for _, boostType := range cfg.Ranking.PromotedBoostTypes {
+		r.PromotedBoostTypes = append(r.PromotedBoostTypes, models.BoostType(boostType))
+	}
+
+	return r
+}
+
+// promotionEnabled сообщает, выделяются ли на странице продвигаемые места
+func (r Ranking) promotionEnabled() bool {
+	return r.PromotedSlots > 0 && len(r.PromotedBoostTypes) > 0
+}
+
+// promotedWindow возвращает смещение и число продвигаемых объявлений для страницы.
+// Смещение зависит только от номера страницы, поэтому продвигаемые места не сдвигаются
+// при пагинации. Хотя бы одно место на странице всегда остается за органической выдачей
+func (r Ranking) promotedWindow(limit, page int) (offset, count int) {
+	if !r.promotionEnabled() || page < 0 {
+		return 0, 0
+	}
+
+	if limit < 0 {
+		limit = -limit
+	}
+
+	slots := r.PromotedSlots
+	if slots > limit-1 {
+		slots = limit - 1
+	}
+	if slots <= 0 {
+		return 0, 0
+	}
+
+	return page * slots, slots
+}
+
+// boostTierExpr возвращает SQL выражение уровня самого старшего действующего буста объявления
+func (r Ranking) boostTierExpr() string {
+	types := make([]string, 0, len(r.BoostTiers))
+	for boostType := range r.BoostTiers {
+		types = append(types, string(boostType))
+	}
+	sort.Strings(types)
+
+	var cases strings.Builder
+	for _, boostType := range types {
+		fmt.Fprintf(&cases, " WHEN '%s' THEN %d", boostType, r.BoostTiers[models.BoostType(boostType)])
+	}
+
+	return `COALESCE((
+				SELECT MAX(CASE lb.boost_type` + cases.String() + ` ELSE 0 END)
+				FROM listing_boosts lb
+				WHERE lb.listing_id = l.id AND lb.status = '` + string(models.BoostStatusActive) + `'
+			), 0)`
+}
+
+// maxBoostTier возвращает наибольший уровень буста для нормировки вклада буста в ранг
+func (r Ranking) maxBoostTier() int {
+	maxTier := 0
+	for _, tier := range r.BoostTiers {
+		if tier > maxTier {
+			maxTier = tier
+		}
+	}
+	return maxTier
+}
+
+// promotedCondition возвращает SQL условие наличия буста, дающего право на продвигаемое место
+func (r Ranking) promotedCondition() string {
+	types := make([]string, 0, len(r.PromotedBoostTypes))
+	for _, boostType := range r.PromotedBoostTypes {
+		types = append(types, "'"+string(boostType)+"'")
+	}
+
+	return `EXISTS (
+				SELECT 1 FROM listing_boosts lb
+				WHERE lb.listing_id = l.id
+				AND lb.status = '` + string(models.BoostStatusActive) + `'
+				AND lb.boost_type IN (` + strings.Join(types, ", ") + `)
+			)`
+}
+
+// organicConditions возвращает условие, исключающее из органической выдачи
+// продвигаемые объявления, чтобы они не повторялись на страницах
+func (r Ranking) organicConditions() string {
+	if !r.promotionEnabled() {
+		return ""
+	}
+
+	return `
+			AND NOT ` + r.promotedCondition()
+}
+
+// scoreExpr возвращает SQL выражение ранга объявления относительно момента rankedAt
+func (r Ranking) scoreExpr(searchType SearchType, rankedAt time.Time) string {
+	boost := "0"
+	if maxTier := r.maxBoostTier(); maxTier > 0 {
+		boost = fmt.Sprintf("%s::float8 / %d", r.boostTierExpr(), maxTier)
+	}
+
+	// Вклад свежести уменьшается вдвое за каждый период полураспада
+	freshness := fmt.Sprintf(
+		"power(0.5, GREATEST(EXTRACT(EPOCH FROM ('%s'::timestamp - l.created_at))::float8, 0) / %s)",
+		rankedAt.UTC().Format(rankedTimeLayout), formatFloat(r.FreshnessHalfLife.Seconds()))
+
+	return fmt.Sprintf(`(
+				%s * COALESCE(%s, 0)::float8 +
+				%s * %s +
+				%s * %s
+			)::float8`,
+		formatFloat(r.RelevanceWeight), relevanceExpr(searchType),
+		formatFloat(r.BoostWeight), boost,
+		formatFloat(r.FreshnessWeight), freshness)
+}
+
+// relevanceExpr возвращает SQL выражение текстовой релевантности для типа поиска
+func relevanceExpr(searchType SearchType) string {
+	switch searchType {
+	case FullTextSearch:
+		return "ts_rank(lsr.title_vector, to_tsquery('russian', $1))"
+	case CombinedSearch:
+		return `(
+					0.6 * COALESCE(similarity(l.title, $1), 0) +
+					0.4 * COALESCE(word_similarity($1, l.title), 0) +
+					0.4 * COALESCE(ts_rank(lsr.title_vector, to_tsquery('russian', $2)), 0)
+				)`
+	case BrowseSearch:
+		// Без поискового запроса порядок определяют только бусты и свежесть
+		return "0"
+	default:
+		return "similarity(l.title, $1)"
+	}
+}
+
+// buildRankedQuery формирует запрос страницы, упорядоченной по рангу объявления.
+// Курсор задается рангом и идентификатором объявления, поэтому страницы
+// не пересекаются даже при равных рангах
+func buildRankedQuery(baseQuery string, args []interface{}, limit int, cursor *models.Listing, cursorScore *float64) (string, []interface{}) {
+	limitParam := fmt.Sprintf("$%d", len(args)+1)
+
+	if limit > 0 {
+		// Положительный лимит: объявления, следующие за курсором, без самого курсора
+		cond := "TRUE"
+		if cursor != nil {
+			cond = rankedCursorCondition(cursor, cursorScore, "<")
+		}
+
+		return `
+			WITH ranked AS (
+				` + baseQuery + `
+			)
+			SELECT ` + listingFields + `, l.rank_score FROM ranked l
+			WHERE ` + cond + `
+			ORDER BY l.rank_score DESC, l.id DESC
+			LIMIT ` + limitParam + `
+			`, append(args, limit)
+	}
+
+	// Отрицательный лимит: объявления, предшествующие курсору, включая его.
+	// Выбираем их в обратном порядке и переворачиваем результат
+	cond := "TRUE"
+	if cursor != nil {
+		cond = rankedCursorCondition(cursor, cursorScore, ">=")
+	}
+
+	return `
+			WITH ranked AS (
+				` + baseQuery + `
+			), reversed AS (
+				SELECT * FROM ranked l
+				WHERE ` + cond + `
+				ORDER BY l.rank_score ASC, l.id ASC
+				LIMIT ` + limitParam + `
+			)
+			SELECT ` + listingFields + `, l.rank_score FROM reversed l
+			ORDER BY l.rank_score DESC, l.id DESC
+			`, append(args, -limit)
+}
+
+// rankedCursorCondition сравнивает пару (ранг, id) объявления с курсором.
+// Если ранг курсора не сохранен, он вычисляется заново в рамках того же запроса
+func rankedCursorCondition(cursor *models.Listing, cursorScore *float64, operator string) string {
+	score := fmt.Sprintf("(SELECT c.rank_score FROM ranked c WHERE c.id = '%s')", cursor.ID)
+	if cursorScore != nil {
+		score = formatFloat(*cursorScore) + "::float8"
+	}
+
+	return fmt.Sprintf("(l.rank_score, l.id) %s (%s, '%s'::uuid)", operator, score, cursor.ID)
+}
+
+// formatFloat форматирует число без потери точности, чтобы ранг курсора совпадал с рангом в базе
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// PromotedWindow возвращает смещение и число продвигаемых объявлений для страницы page
+func (s *Listing) PromotedWindow(limit, page int) (offset, count int) {
+	return s.ranking.promotedWindow(limit, page)
+}
+
+// SearchPromotedListings возвращает count продвигаемых объявлений, начиная со смещения offset.
+// Продвигаемые объявления подбираются по тем же условиям поиска и упорядочены
+// по уровню буста и релевантности, так что каждое из них попадает ровно на одну страницу
+func (s *Listing) SearchPromotedListings(ctx context.Context, query string, offset, count int, category CategoryScope, filters models.Filters, location models.Location) ([]models.ListingResult, error) {
+	if count <= 0 || !s.ranking.promotionEnabled() {
+		return []models.ListingResult{}, nil
+	}
+
+	searchType := determineSearchType(query)
+	searchQuery := createSearchQuery(query, searchType)
+	args := searchArgs(searchQuery, searchType)
+
+	columns := listingFields + `,
+				COALESCE(` + relevanceExpr(searchType) + `, 0)::float8 AS rank_score,
+				` + s.ranking.boostTierExpr() + ` AS boost_tier`
+	conditions := buildFilterConditions(category, filters, location) + `
+			AND ` + s.ranking.promotedCondition()
+	baseQuery := buildBaseQuery(searchType, columns, conditions)
+
+	sqlQuery := `
+			WITH promoted AS (
+				` + baseQuery + `
+			)
+			SELECT ` + listingFields + `, l.rank_score FROM promoted l
+			ORDER BY l.boost_tier DESC, l.rank_score DESC, l.created_at DESC, l.id DESC
+			OFFSET ` + fmt.Sprintf("$%d", len(args)+1) + `
+			LIMIT ` + fmt.Sprintf("$%d", len(args)+2) + `
+			`
+
+	rows, err := s.pool.Query(ctx, sqlQuery, append(args, offset, count)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	listings, scores, err := s.scanRankedListings(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]models.ListingResult, 0, len(listings))
+	for i, listing := range listings {
+		result, err := s.getListingWithRelatedData(ctx, listing)
+		if err != nil {
+			return nil, err
+		}
+		result.RankScore = scores[i]
+		result.IsPromoted = true
+		results = append(results, result)
+	}
+
+	return results, nil
+}
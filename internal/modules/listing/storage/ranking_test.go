@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/yaroslavvasilenko/argon/config"
+	"github.com/yaroslavvasilenko/argon/internal/models"
+)
+
+func TestPromotedWindow(t *testing.T) {
+	r := Ranking{PromotedSlots: 2, PromotedBoostTypes: []models.BoostType{models.BoostTypeUpfront}}
+
+	t.Run("Смещение зависит только от номера страницы", func(t *testing.T) {
+		offset, count := r.promotedWindow(10, 0)
+		assert.Equal(t, 0, offset)
+		assert.Equal(t, 2, count)
+
+		offset, count = r.promotedWindow(10, 3)
+		assert.Equal(t, 6, offset)
+		assert.Equal(t, 2, count)
+
+		// Для движения назад используется модуль лимита
+		offset, count = r.promotedWindow(-10, 1)
+		assert.Equal(t, 2, offset)
+		assert.Equal(t, 2, count)
+	})
+
+	t.Run("Органической выдаче остается хотя бы одно место", func(t *testing.T) {
+		_, count := r.promotedWindow(2, 0)
+		assert.Equal(t, 1, count)
+
+		_, count = r.promotedWindow(1, 0)
+		assert.Equal(t, 0, count)
+	})
+
+	t.Run("Продвижение отключено", func(t *testing.T) {
+		_, count := r.promotedWindow(10, -1)
+		assert.Equal(t, 0, count)
+
+		_, count = Ranking{PromotedSlots: 2}.promotedWindow(10, 0)
+		assert.Equal(t, 0, count)
+	})
+}
+
+func TestNewRankingDefaults(t *testing.T) {
+	r := NewRanking(config.Config{})
+
+	assert.Equal(t, defaultRelevanceWeight, r.RelevanceWeight)
+	assert.Equal(t, defaultBoostWeight, r.BoostWeight)
+	assert.Equal(t, defaultFreshnessWeight, r.FreshnessWeight)
+	assert.Equal(t, defaultFreshnessHalfLife, r.FreshnessHalfLife)
+	assert.Equal(t, 3, r.maxBoostTier())
+	assert.False(t, r.promotionEnabled())
+	assert.Empty(t, r.organicConditions())
+}
+
+func TestBuildRankedQuery(t *testing.T) {
+	cursor := &models.Listing{ID: uuid.New()}
+	args := []interface{}{"iphone"}
+
+	t.Run("Курсор с сохраненным рангом", func(t *testing.T) {
+		score := 0.123456789012345
+		sql, queryArgs := buildRankedQuery("SELECT 1", args, 5, cursor, &score)
+
+		assert.Contains(t, sql, "(l.rank_score, l.id) < (0.123456789012345::float8, '"+cursor.ID.String()+"'::uuid)")
+		assert.Contains(t, sql, "LIMIT $2")
+		assert.Equal(t, []interface{}{"iphone", 5}, queryArgs)
+	})
+
+	t.Run("Ранг курсора вычисляется в запросе", func(t *testing.T) {
+		sql, queryArgs := buildRankedQuery("SELECT 1", nil, -5, cursor, nil)
+
+		assert.Contains(t, sql, "(l.rank_score, l.id) >= ((SELECT c.rank_score FROM ranked c WHERE c.id = '"+cursor.ID.String()+"')")
+		assert.Contains(t, sql, "LIMIT $1")
+		assert.True(t, strings.Index(sql, "ASC") < strings.Index(sql, "DESC"), "выборка назад должна переворачиваться")
+		assert.Equal(t, []interface{}{5}, queryArgs)
+	})
+}
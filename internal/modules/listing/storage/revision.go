@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/yaroslavvasilenko/argon/internal/models"
+)
+
+const revisionFields = "id, listing_id, revision, actor, snapshot, changes, restored_from, created_at"
+
+// insertRevisions добавляет ревизии в историю объявления в транзакции изменения.
+// Номер ревизии следует за последним сохраненным
+func insertRevisions(ctx context.Context, tx pgx.Tx, revisions []models.ListingRevision) error {
+	for _, revision := range revisions {
+		snapshot, err := json.Marshal(revision.Snapshot)
+		if err != nil {
+			return err
+		}
+		changes, err := json.Marshal(revision.Changes)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, `
+			INSERT INTO listing_revisions (id, listing_id, revision, actor, snapshot, changes, price, currency, restored_from, created_at)
+			SELECT $1, $2, COALESCE(MAX(revision), 0) + 1, $3, $4, $5, $6, $7, $8, $9
+			FROM listing_revisions WHERE listing_id = $2
+		`, revision.ID, revision.ListingID, revision.Actor, snapshot, changes,
+			revision.Snapshot.Price, string(revision.Snapshot.Currency), revision.RestoredFrom, revision.CreatedAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// HasRevisions сообщает, есть ли у объявления история изменений
+func (s *Listing) HasRevisions(ctx context.Context, listingID uuid.UUID) (bool, error) {
+	var exists bool
+	err := s.pool.QueryRow(ctx, `
+		SELECT EXISTS(SELECT 1 FROM listing_revisions WHERE listing_id = $1)
+	`, listingID).Scan(&exists)
+
+	return exists, err
+}
+
+// GetRevisions возвращает историю изменений объявления, начиная с последней ревизии
+func (s *Listing) GetRevisions(ctx context.Context, listingID uuid.UUID) ([]models.ListingRevision, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT `+revisionFields+` FROM listing_revisions
+		WHERE listing_id = $1
+		ORDER BY revision DESC
+	`, listingID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []models.ListingRevision{}
+	for rows.Next() {
+		revision, err := scanRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+
+	return revisions, rows.Err()
+}
+
+// GetRevision возвращает ревизию объявления по номеру
+func (s *Listing) GetRevision(ctx context.Context, listingID uuid.UUID, number int) (models.ListingRevision, error) {
+	row := s.pool.QueryRow(ctx, `
+		SELECT `+revisionFields+` FROM listing_revisions
+		WHERE listing_id = $1 AND revision = $2
+	`, listingID, number)
+
+	revision, err := scanRevision(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.ListingRevision{}, fiber.NewError(fiber.StatusNotFound,
+			fmt.Sprintf("revision %d of listing %s not found", number, listingID))
+	}
+
+	return revision, err
+}
+
+// GetPreviousPrices возвращает для объявлений, подешевевших с момента since, самую высокую
+// цену из истории в той же валюте. Объявления без снижения цены в результат не попадают
+func (s *Listing) GetPreviousPrices(ctx context.Context, listingIDs []uuid.UUID, since time.Time) (map[uuid.UUID]float64, error) {
+	prices := make(map[uuid.UUID]float64)
+	if len(listingIDs) == 0 {
+		return prices, nil
+	}
+
+	rows, err := s.pool.Query(ctx, `
+		SELECT l.id, MAX(r.price)
+		FROM listings l
+		JOIN listing_revisions r ON r.listing_id = l.id
+		WHERE l.id = ANY($1) AND r.created_at >= $2 AND r.currency = l.currency
+		GROUP BY l.id, l.price
+		HAVING MAX(r.price) > l.price
+	`, listingIDs, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id uuid.UUID
+		var price float64
+		if err := rows.Scan(&id, &price); err != nil {
+			return nil, err
+		}
+		prices[id] = price
+	}
+
+	return prices, rows.Err()
+}
+
+func scanRevision(row pgx.Row) (models.ListingRevision, error) {
+	var revision models.ListingRevision
+	var snapshot, changes []byte
+
+	if err := row.Scan(
+		&revision.ID,
+		&revision.ListingID,
+		&revision.Revision,
+		&revision.Actor,
+		&snapshot,
+		&changes,
+		&revision.RestoredFrom,
+		&revision.CreatedAt,
+	); err != nil {
+		return models.ListingRevision{}, err
+	}
+
+	if err := json.Unmarshal(snapshot, &revision.Snapshot); err != nil {
+		return models.ListingRevision{}, err
+	}
+	if err := json.Unmarshal(changes, &revision.Changes); err != nil {
+		return models.ListingRevision{}, err
+	}
+
+	return revision, nil
+}
@@ -4,16 +4,18 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/google/uuid"
 	"github.com/yaroslavvasilenko/argon/internal/models"
+	"github.com/yaroslavvasilenko/argon/internal/modules/listing"
 	"gorm.io/gorm"
 )
 
-func (s *Listing) SearchListingsByTitle(ctx context.Context, query string, limit int, cursorID *uuid.UUID, sort, categoryID string, filters models.Filters, location models.Location) (*models.Listing, []models.ListingResult, error) {
+func (s *Listing) SearchListingsByTitle(ctx context.Context, query string, limit int, searchCursor listing.SearchCursor, sort string, category CategoryScope, filters models.Filters, location models.Location) (*models.Listing, []models.ListingResult, error) {
 	// Если limit == 0, возвращаем пустой результат
 	if limit == 0 {
 		return nil, []models.ListingResult{}, nil
@@ -24,10 +26,10 @@ func (s *Listing) SearchListingsByTitle(ctx context.Context, query string, limit
 	}
 
 	var cursor *models.Listing
-	if cursorID != nil {
+	if searchCursor.LastIndex != nil {
 		var cursorListing models.Listing
 		if err := s.gorm.Table(itemTable).WithContext(ctx).
-			Where("id = ? AND deleted_at IS NULL", cursorID).
+			Where("id = ? AND deleted_at IS NULL", searchCursor.LastIndex).
 			First(&cursorListing).Error; err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				return nil, nil, fiber.NewError(fiber.StatusNotFound, "Курсор не найден")
@@ -39,12 +41,40 @@ func (s *Listing) SearchListingsByTitle(ctx context.Context, query string, limit
 
 	// Определяем тип поиска на основе запроса
 	searchType := determineSearchType(query)
-	baseQuery := buildBaseQuery(searchType, categoryID, filters, location)
-
-	orderExpr := getSortExpression(sort, searchType)
 	searchQuery := createSearchQuery(query, searchType)
+	args := searchArgs(searchQuery, searchType)
+
+	// Продвигаемые объявления выводятся на отдельных местах и не повторяются в органической выдаче
+	conditions := buildFilterConditions(category, filters, location) + s.ranking.organicConditions()
+
+	var sqlQuery string
+	var queryArgs []interface{}
+
+	orderExpr := getSortExpression(sort)
+	byDistance := sort == models.SORT_DISTANCE
+	ranked := orderExpr == "" && !byDistance
+	if byDistance {
+		if !location.HasCoordinates() {
+			return nil, nil, fiber.NewError(fiber.StatusBadRequest, "sort by distance requires location")
+		}
 
-	sqlQuery, queryArgs := buildSQLQuery(baseQuery, orderExpr, searchQuery, limit, cursor, searchType)
+		baseQuery := buildBaseQuery(searchType, listingFields, conditions)
+		sqlQuery, queryArgs = buildDistanceQuery(baseQuery, args, limit, cursor, searchCursor.Score, location.Area.Coordinates)
+	} else if ranked {
+		// Сортировка по релевантности учитывает бусты и свежесть объявления
+		rankedAt := time.Now()
+		if searchCursor.RankedAt != nil {
+			rankedAt = *searchCursor.RankedAt
+		}
+
+		columns := listingFields + `,
+				` + s.ranking.scoreExpr(searchType, rankedAt) + ` AS rank_score`
+		baseQuery := buildBaseQuery(searchType, columns, conditions)
+		sqlQuery, queryArgs = buildRankedQuery(baseQuery, args, limit, cursor, searchCursor.Score)
+	} else {
+		baseQuery := buildBaseQuery(searchType, listingFields, conditions)
+		sqlQuery, queryArgs = buildSQLQuery(baseQuery, orderExpr, args, limit, cursor)
+	}
 
 	rows, err := s.pool.Query(ctx, sqlQuery, queryArgs...)
 	if err != nil {
@@ -52,18 +82,32 @@ func (s *Listing) SearchListingsByTitle(ctx context.Context, query string, limit
 	}
 	defer rows.Close()
 
-	listings, err := s.scanListings(rows)
+	var listings []models.Listing
+	var scores []float64
+	if ranked || byDistance {
+		listings, scores, err = s.scanRankedListings(rows)
+	} else {
+		listings, err = s.scanListings(rows)
+	}
 	if err != nil {
 		return nil, nil, err
 	}
 
 	// Получаем полные данные для каждого объявления
 	listingResults := make([]models.ListingResult, 0, len(listings))
-	for _, listing := range listings {
+	for i, listing := range listings {
 		result, err := s.getListingWithRelatedData(ctx, listing)
 		if err != nil {
 			return nil, nil, err
 		}
+		if ranked {
+			result.RankScore = scores[i]
+		}
+		// Объявления без локации стоят в конце выдачи с бесконечным расстоянием
+		if byDistance && !math.IsInf(scores[i], 1) {
+			distance := scores[i]
+			result.Distance = &distance
+		}
 		listingResults = append(listingResults, result)
 	}
 
@@ -80,39 +124,35 @@ const (
 	FullTextSearch
 	// Комбинированный поиск (нечеткий + полнотекстовый)
 	CombinedSearch
+	// Просмотр без поискового запроса, только по фильтрам
+	BrowseSearch
 )
 
-// buildBaseQuery создает базовый SQL запрос в зависимости от типа поиска
-func buildBaseQuery(searchType SearchType, categoryID string, filters models.Filters, location models.Location) string {
-	var categoryFilter string
-	if categoryID != "" {
-		categoryFilter = `
-			AND EXISTS (
-				SELECT 1 FROM listing_categories lc 
-				WHERE lc.listing_id = l.id AND lc.category_id = '` + categoryID + `'
-			)`
-	}
-	
+// buildFilterConditions создает SQL условия фильтрации по категории, локации и характеристикам
+func buildFilterConditions(category CategoryScope, filters models.Filters, location models.Location) string {
+	categoryFilter := category.condition()
+
 	// Добавляем фильтр по локации, если указаны координаты
 	var locationFilter string
-	if location.Area.Coordinates.Lat != 0 && location.Area.Coordinates.Lng != 0 && location.Area.Radius > 0 {
-		// Используем функцию ST_DWithin для поиска в радиусе
-		// Преобразуем координаты в географические точки и вычисляем расстояние в метрах
+	if location.HasCoordinates() && location.Area.Radius > 0 {
+		// Объявление подходит, если оно в радиусе покупателя или покупатель в зоне обслуживания,
+		// которую продавец включил для объявления. Оба условия используют GiST-индексы по geog
+		// и service_area; у объявлений без зоны service_area пуст и второе условие ложно
+		point := pointExpr(location.Area.Coordinates)
 		locationFilter = fmt.Sprintf(`
 			AND EXISTS (
 				SELECT 1 FROM locations loc
 				WHERE loc.listing_id = l.id
-				AND ST_DWithin(
-					ST_SetSRID(ST_MakePoint(loc.longitude, loc.latitude), 4326)::geography,
-					ST_SetSRID(ST_MakePoint(%f, %f), 4326)::geography,
-					%d
+				AND (
+					ST_DWithin(loc.geog, %s, %d)
+					OR ST_Covers(loc.service_area, %s)
 				)
-			)`, 
-			location.Area.Coordinates.Lng, location.Area.Coordinates.Lat, location.Area.Radius)
+			)`,
+			point, location.Area.Radius, point)
 	}
 
 	// Добавляем фильтр по характеристикам, если они указаны
-	
+
 	var filtersFilter string
 	if len(filters) > 0 {
 		filtersFilter = `
@@ -122,8 +162,9 @@ func buildBaseQuery(searchType SearchType, categoryID string, filters models.Fil
 				AND (`
 
 		filterConditions := []string{}
+		registry := models.Characteristics()
 
-		for key, _ := range filters {
+		for key := range filters {
 			if priceFilter, ok := filters.GetPriceFilter(key); ok {
 				// Проверяем, что фильтр цены не пустой (Min и Max не равны 0 одновременно)
 				if priceFilter.Min > 0 {
@@ -132,53 +173,15 @@ func buildBaseQuery(searchType SearchType, categoryID string, filters models.Fil
 				if priceFilter.Max > 0 {
 					filterConditions = append(filterConditions, fmt.Sprintf("l.price <= %d", priceFilter.Max))
 				}
+				continue
 			}
-		
-
-		// Обрабатываем фильтр цвета
-			if colorFilter, ok := filters.GetColorFilter(key); ok && len(colorFilter.Options) > 0 {
-				colorConditions := []string{}
-				for _, color := range colorFilter.Options {
-					// Проверяем, содержит ли массив цветов заданный цвет
-					colorConditions = append(colorConditions, fmt.Sprintf(
-						"lch.characteristics -> '%s' ? '%s'", key, color))
-				}
-				filterConditions = append(filterConditions, "("+strings.Join(colorConditions, " OR ")+")")
-			}
-		
-
-		// Обрабатываем фильтр выпадающего списка
-			if dropdownFilter, ok := filters.GetDropdownFilter(key); ok && len(dropdownFilter) > 0 {
-				dropdownConditions := []string{}
-				for _, option := range dropdownFilter {
-					// Проверяем, содержит ли массив разрешений экрана заданное разрешение
-					dropdownConditions = append(dropdownConditions, fmt.Sprintf(
-						"lch.characteristics -> '%s' ? '%s'", key, option))
-				}
-
-				filterConditions = append(filterConditions, "("+strings.Join(dropdownConditions, " OR ")+")")
-			}
-		
 
-		// Обрабатываем фильтр чекбокса
-			if checkboxFilter, ok := filters.GetCheckboxFilter(key); ok && checkboxFilter != nil {
-				filterConditions = append(filterConditions, fmt.Sprintf(
-					"(lch.characteristics ->> '%s')::boolean = %t", key, *checkboxFilter))
-			}
-		
-
-		// Обрабатываем фильтр размеров
-			if dimensionFilter, ok := filters.GetDimensionFilter(key); ok {
-				// Проверяем, что фильтр размеров не пустой (Min и Max не равны 0 одновременно)
-				if dimensionFilter.Min > 0 {
-					filterConditions = append(filterConditions, fmt.Sprintf(
-						"(lch.characteristics ->> '%s')::float >= %f", key, float64(dimensionFilter.Min)))
-				}
-				if dimensionFilter.Max > 0 {
-					filterConditions = append(filterConditions, fmt.Sprintf(
-						"(lch.characteristics ->> '%s')::float <= %f", key, float64(dimensionFilter.Max)))
-				}
+			// Условие строится по типу характеристики из реестра
+			def, ok := registry.Get(key)
+			if !ok || !def.Filterable() {
+				continue
 			}
+			filterConditions = append(filterConditions, characteristicConditions(def, filters)...)
 		}
 
 		// Если есть условия фильтрации, добавляем их в запрос
@@ -192,46 +195,148 @@ func buildBaseQuery(searchType SearchType, categoryID string, filters models.Fil
 		filtersFilter += ")"
 	}
 
+	return categoryFilter + locationFilter + filtersFilter
+}
+
+// characteristicConditions условия фильтра по характеристике def: совпадение с одной из опций,
+// значение флага, диапазон или подстрока
+func characteristicConditions(def models.CharacteristicDefinition, filters models.Filters) []string {
+	key := def.Role
+
+	var options []string
+	switch def.Kind {
+	case models.KindColor:
+		if colorFilter, ok := filters.GetColorFilter(key); ok {
+			options = colorFilter.Options
+		}
+	case models.KindDropdown, models.KindMultiSelect:
+		// Для множественного выбора значение хранится массивом, ? проверяет его элементы
+		if dropdownFilter, ok := filters.GetDropdownFilter(key); ok {
+			options = dropdownFilter
+		}
+	case models.KindCheckbox:
+		if checkboxFilter, ok := filters.GetCheckboxFilter(key); ok && checkboxFilter != nil {
+			return []string{fmt.Sprintf(
+				"(lch.characteristics ->> '%s')::boolean = %t", key, *checkboxFilter)}
+		}
+		return nil
+	case models.KindAmount:
+		dimensionFilter, ok := filters.GetDimensionFilter(key)
+		if !ok {
+			return nil
+		}
+		// Границы переводятся в базовую единицу и сравниваются с base_value,
+		// поэтому 2 m и 200 cm совпадают. Без единицы границы заданы в единице по умолчанию
+		unit := models.Dimension(dimensionFilter.Dimension)
+		if unit == "" {
+			unit = def.DefaultUnit
+		}
+		conditions := []string{}
+		if dimensionFilter.Min > 0 {
+			if min, ok := models.ToBaseUnit(float64(dimensionFilter.Min), unit); ok {
+				conditions = append(conditions, fmt.Sprintf(
+					"(lch.characteristics -> '%s' ->> 'base_value')::float >= %s", key, strconv.FormatFloat(min, 'g', -1, 64)))
+			}
+		}
+		if dimensionFilter.Max > 0 {
+			if max, ok := models.ToBaseUnit(float64(dimensionFilter.Max), unit); ok {
+				conditions = append(conditions, fmt.Sprintf(
+					"(lch.characteristics -> '%s' ->> 'base_value')::float <= %s", key, strconv.FormatFloat(max, 'g', -1, 64)))
+			}
+		}
+		return conditions
+	case models.KindInteger:
+		intFilter, ok := filters.GetIntegerRangeFilter(key)
+		if !ok {
+			return nil
+		}
+		conditions := []string{}
+		if intFilter.Min != nil {
+			conditions = append(conditions, fmt.Sprintf(
+				"(lch.characteristics ->> '%s')::bigint >= %d", key, *intFilter.Min))
+		}
+		if intFilter.Max != nil {
+			conditions = append(conditions, fmt.Sprintf(
+				"(lch.characteristics ->> '%s')::bigint <= %d", key, *intFilter.Max))
+		}
+		return conditions
+	case models.KindDate:
+		dateFilter, ok := filters.GetDateRangeFilter(key)
+		if !ok {
+			return nil
+		}
+		// Границы подставляются в запрос только после разбора как даты
+		conditions := []string{}
+		if from, err := time.Parse(models.DateLayout, dateFilter.From); err == nil {
+			conditions = append(conditions, fmt.Sprintf(
+				"(lch.characteristics ->> '%s')::date >= '%s'", key, from.Format(models.DateLayout)))
+		}
+		if to, err := time.Parse(models.DateLayout, dateFilter.To); err == nil {
+			conditions = append(conditions, fmt.Sprintf(
+				"(lch.characteristics ->> '%s')::date <= '%s'", key, to.Format(models.DateLayout)))
+		}
+		return conditions
+	case models.KindText:
+		textFilter, ok := filters.GetTextFilter(key)
+		if !ok || strings.TrimSpace(string(textFilter)) == "" {
+			return nil
+		}
+		return []string{fmt.Sprintf(
+			"lch.characteristics ->> '%s' ILIKE '%%%s%%'", key, likePattern(strings.TrimSpace(string(textFilter))))}
+	}
+
+	if len(options) == 0 {
+		return nil
+	}
+
+	optionConditions := make([]string, 0, len(options))
+	for _, option := range options {
+		// Проверяем, содержит ли значение характеристики выбранную опцию
+		optionConditions = append(optionConditions, fmt.Sprintf(
+			"lch.characteristics -> '%s' ? %s", key, quoteLiteral(option)))
+	}
+	return []string{"(" + strings.Join(optionConditions, " OR ") + ")"}
+}
+
+// likePattern экранирует строку для подстановки в шаблон ILIKE внутри строкового литерала SQL
+func likePattern(value string) string {
+	value = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+	return strings.ReplaceAll(value, "'", "''")
+}
+
+// visibleCondition условие видимости объявления в поиске: оно опубликовано и срок публикации
+// не истек, даже если крон еще не перевел его в expired
+const visibleCondition = `l.deleted_at IS NULL
+			AND l.status = 'published'
+			AND (l.expires_at IS NULL OR l.expires_at > NOW())`
+
+// buildBaseQuery создает базовый SQL запрос в зависимости от типа поиска.
+// columns задает список выбираемых полей, conditions — дополнительные условия фильтрации
+func buildBaseQuery(searchType SearchType, columns, conditions string) string {
 	switch searchType {
-	case FuzzySearch:
-		// Запрос с использованием триграмм (pg_trgm) для нечеткого поиска
+	case BrowseSearch:
+		// Без поискового запроса отбираем объявления только по фильтрам
 		return `
-			SELECT ` + listingFields + `
+			SELECT ` + columns + `
 			FROM ` + itemTable + ` l
-			WHERE l.deleted_at IS NULL
-			AND (
-				/* Используем оператор % для поиска с опечатками */
-				l.title % $1 OR
-				/* similarity возвращает значение от 0 до 1, где 1 означает полное совпадение */
-				similarity(l.title, $1) > 0.3 OR
-				/* word_similarity сравнивает слова, а не символы */
-				word_similarity($1, l.title) > 0.4
-			)` + categoryFilter + locationFilter + filtersFilter + `
+			WHERE ` + visibleCondition + conditions + `
 		`
 	case FullTextSearch:
 		// Стандартный поиск с использованием полнотекстового индекса
 		return `
-			SELECT ` + listingFields + `
+			SELECT ` + columns + `
 			FROM ` + itemTable + ` l
 			JOIN listings_search_ru lsr ON l.id = lsr.listing_id
-			WHERE l.deleted_at IS NULL
-			AND to_tsquery('russian', $1) @@ lsr.title_vector` + categoryFilter + locationFilter + filtersFilter + `
+			WHERE ` + visibleCondition + `
+			AND to_tsquery('russian', $1) @@ lsr.title_vector` + conditions + `
 		`
 	case CombinedSearch:
-		// Комбинированный поиск, использующий оба метода с ранжированием результатов
+		// Комбинированный поиск, использующий оба метода; релевантность вычисляется при ранжировании
 		return `
-			SELECT ` + listingFields + `,
-				/* Вычисляем комбинированную релевантность */
-				(
-					/* Вес для нечеткого поиска (0.6) */
-					0.6 * COALESCE(similarity(l.title, $1), 0) +
-					0.4 * COALESCE(word_similarity($1, l.title), 0) +
-					/* Вес для полнотекстового поиска (0.4) */
-					0.4 * COALESCE(ts_rank(lsr.title_vector, to_tsquery('russian', $2)), 0)
-				) AS combined_rank
+			SELECT ` + columns + `
 			FROM ` + itemTable + ` l
 			LEFT JOIN listings_search_ru lsr ON l.id = lsr.listing_id
-			WHERE l.deleted_at IS NULL
+			WHERE ` + visibleCondition + `
 			AND (
 				/* Нечеткий поиск */
 				l.title % $1 OR
@@ -239,104 +344,74 @@ func buildBaseQuery(searchType SearchType, categoryID string, filters models.Fil
 				word_similarity($1, l.title) > 0.4 OR
 				/* Полнотекстовый поиск */
 				to_tsquery('russian', $2) @@ lsr.title_vector
-			)` + categoryFilter + locationFilter + filtersFilter + `
+			)` + conditions + `
 		`
 	default:
-		// По умолчанию используем нечеткий поиск
+		// Запрос с использованием триграмм (pg_trgm) для нечеткого поиска
 		return `
-			SELECT ` + listingFields + `
+			SELECT ` + columns + `
 			FROM ` + itemTable + ` l
-			WHERE l.deleted_at IS NULL
+			WHERE ` + visibleCondition + `
 			AND (
+				/* Используем оператор % для поиска с опечатками */
 				l.title % $1 OR
+				/* similarity возвращает значение от 0 до 1, где 1 означает полное совпадение */
 				similarity(l.title, $1) > 0.3 OR
+				/* word_similarity сравнивает слова, а не символы */
 				word_similarity($1, l.title) > 0.4
-			)` + categoryFilter + locationFilter + filtersFilter + `
+			)` + conditions + `
 		`
 	}
 }
 
+// searchArgs возвращает параметры поискового запроса для типа поиска
+func searchArgs(searchQuery string, searchType SearchType) []interface{} {
+	switch searchType {
+	case BrowseSearch:
+		return []interface{}{}
+	case CombinedSearch:
+		// Для комбинированного поиска используем два параметра:
+		// - оригинальный запрос для нечеткого поиска
+		// - обработанный запрос для полнотекстового поиска
+		return []interface{}{searchQuery, prepareTsQuery(searchQuery)}
+	default:
+		// Для нечеткого или полнотекстового поиска используем один параметр
+		return []interface{}{searchQuery}
+	}
+}
+
 // buildSQLQuery формирует итоговый SQL запрос и набор аргументов для выполнения запроса с пагинацией.
 // Параметры:
 //
 //	baseQuery  - базовый SQL запрос без условий сортировки и пагинации
 //	orderExpr  - выражение сортировки, определяющее порядок возвращаемых записей
-//	searchQuery- поисковый запрос, используемый в полном текстовом поиске
+//	args       - параметры поискового запроса, подставляемые в baseQuery
 //	limit      - лимит на количество возвращаемых записей (положительный для следующей страницы, отрицательный для предыдущей)
 //	cursor     - объект, представляющий запись-курсор для пагинации
-//	searchType - тип поиска (нечеткий, полнотекстовый или комбинированный)
 //
 // Функция возвращает сформированный SQL запрос и срез аргументов для подстановки в запрос.
-func buildSQLQuery(baseQuery, orderExpr, searchQuery string, limit int, cursor *models.Listing, searchType SearchType) (string, []interface{}) {
-	var args []interface{}
-
-	// Если запрос пустой, не добавляем условия поиска по названию
-	if searchQuery == "" {
-		// Для пустого запроса используем только параметр для LIMIT
-		args = []interface{}{}
-		
-		// Для пустого запроса изменяем базовый запрос, убирая условие поиска по названию
-		baseQuery = `
-			SELECT ` + listingFields + `
-			FROM ` + itemTable + ` l
-			WHERE l.deleted_at IS NULL
-		`
-		
-		// Для пустого запроса используем простую сортировку по дате создания
-		orderExpr = "l.created_at DESC"
-	} else {
-		var tsQueryVersion string
-		switch searchType {
-		case FuzzySearch, FullTextSearch:
-			// Для нечеткого или полнотекстового поиска используем один параметр
-			args = []interface{}{searchQuery}
-		case CombinedSearch:
-			// Для комбинированного поиска используем два параметра:
-			// - оригинальный запрос для нечеткого поиска
-			// - обработанный запрос для полнотекстового поиска
-			
-			// Используем prepareTsQuery для очистки запроса от специальных символов
-			tsQueryVersion = prepareTsQuery(searchQuery)
-			args = []interface{}{searchQuery, tsQueryVersion}
-		}
-	}
+func buildSQLQuery(baseQuery, orderExpr string, args []interface{}, limit int, cursor *models.Listing) (string, []interface{}) {
+	// Номер параметра для LIMIT следует за параметрами поискового запроса
+	limitParam := fmt.Sprintf("$%d", len(args)+1)
 
 	if cursor == nil {
 		// Если курсор не задан, выборка начинается с начала набора результатов или с конца, в зависимости от знака лимита.
 		if limit > 0 {
 			// Лимит положительный: выбираем первые limit записей, сортируя результат по заданному orderExpr
-			
-			// Определяем номер параметра для LIMIT в зависимости от типа поиска и наличия запроса
-			limitParam := "$1"
-			if searchQuery != "" {
-				limitParam = "$2"
-				if searchType == CombinedSearch {
-					limitParam = "$3"
-				}
-			}
-			
 			return baseQuery + `
 				ORDER BY ` + orderExpr + `
 				LIMIT ` + limitParam + `
 			`, append(args, limit)
-		} else {
-			// Лимит отрицательный: выбираем последние -limit записей.
-			// Для этого выполняем следующие шаги:
-			// 1. Сортируем базовый запрос в обратном порядке (reverse orderExpr).
-			// 2. Ограничиваем выборку до -limit записей.
-			// 3. Внешний запрос переворачивает результат для восстановления исходного порядка.
-			reverseExpr := getReverseOrderExpression(orderExpr)
-			
-			// Определяем номер параметра для LIMIT в зависимости от типа поиска и наличия запроса
-			limitParam := "$1"
-			if searchQuery != "" {
-				limitParam = "$2"
-				if searchType == CombinedSearch {
-					limitParam = "$3"
-				}
-			}
-			
-			sql := `
+		}
+
+		// Лимит отрицательный: выбираем последние -limit записей.
+		// Для этого выполняем следующие шаги:
+		// 1. Сортируем базовый запрос в обратном порядке (reverse orderExpr).
+		// 2. Ограничиваем выборку до -limit записей.
+		// 3. Внешний запрос переворачивает результат для восстановления исходного порядка.
+		reverseExpr := getReverseOrderExpression(orderExpr)
+
+		sql := `
 			WITH reversed AS (
 				` + baseQuery + `
 				ORDER BY ` + reverseExpr + `
@@ -345,48 +420,31 @@ func buildSQLQuery(baseQuery, orderExpr, searchQuery string, limit int, cursor *
 			SELECT ` + listingFields + ` FROM reversed l
 			ORDER BY ` + orderExpr + `
 			`
-			return sql, append(args, -limit)
-		}
-	} else {
-		// Если курсор задан, значит выборка должна быть смещена относительно курсора для пагинации.
-		if limit > 0 {
-			// Положительный лимит: выбираем записи, следующие за курсором (без включения самой записи-курсор).
-			// Функция getCursorCondition формирует условие, исключающее курсор из результата.
-			cond := getCursorCondition(orderExpr, cursor, false)
-			
-			// Определяем номер параметра для LIMIT в зависимости от типа поиска и наличия запроса
-			limitParam := "$1"
-			if searchQuery != "" {
-				limitParam = "$2"
-				if searchType == CombinedSearch {
-					limitParam = "$3"
-				}
-			}
-			
-			return baseQuery + `
+		return sql, append(args, -limit)
+	}
+
+	// Если курсор задан, значит выборка должна быть смещена относительно курсора для пагинации.
+	if limit > 0 {
+		// Положительный лимит: выбираем записи, следующие за курсором (без включения самой записи-курсор).
+		// Функция getCursorCondition формирует условие, исключающее курсор из результата.
+		cond := getCursorCondition(orderExpr, cursor, false)
+
+		return baseQuery + `
 				AND ` + cond + `
 				ORDER BY ` + orderExpr + `
 				LIMIT ` + limitParam + `
 			`, append(args, limit)
-		} else {
-			// Лимит отрицательный: выбираем записи, предшествующие курсору, включая его.
-			// В этом случае:
-			// 1. Используем обратное сортировочное выражение для формирования условия.
-			// 2. Формируем условие, включающее курсор (inclusive = true).
-			// 3. Для корректного порядка результатов затем переворачиваем выборку обратно.
-			reverseExpr := getReverseOrderExpression(orderExpr)
-			cond := getCursorCondition(reverseExpr, cursor, true)
-			
-			// Определяем номер параметра для LIMIT в зависимости от типа поиска и наличия запроса
-			limitParam := "$1"
-			if searchQuery != "" {
-				limitParam = "$2"
-				if searchType == CombinedSearch {
-					limitParam = "$3"
-				}
-			}
-			
-			sql := `
+	}
+
+	// Лимит отрицательный: выбираем записи, предшествующие курсору, включая его.
+	// В этом случае:
+	// 1. Используем обратное сортировочное выражение для формирования условия.
+	// 2. Формируем условие, включающее курсор (inclusive = true).
+	// 3. Для корректного порядка результатов затем переворачиваем выборку обратно.
+	reverseExpr := getReverseOrderExpression(orderExpr)
+	cond := getCursorCondition(reverseExpr, cursor, true)
+
+	sql := `
 			WITH reversed AS (
 				` + baseQuery + `
 				AND ` + cond + `
@@ -396,12 +454,12 @@ func buildSQLQuery(baseQuery, orderExpr, searchQuery string, limit int, cursor *
 			SELECT ` + listingFields + ` FROM reversed l
 			ORDER BY ` + orderExpr + `
 			`
-			return sql, append(args, -limit)
-		}
-	}
+	return sql, append(args, -limit)
 }
 
-func getSortExpression(sort string, searchType SearchType) string {
+// getSortExpression возвращает выражение сортировки. Для сортировки по релевантности
+// возвращается пустая строка: такая выдача упорядочивается по рангу (см. buildRankedQuery)
+func getSortExpression(sort string) string {
 	var orderExpr string
 	switch sort {
 	case models.SORT_PRICE_ASC:
@@ -410,38 +468,91 @@ func getSortExpression(sort string, searchType SearchType) string {
 		orderExpr = "l.price DESC"
 	case models.SORT_NEWEST:
 		orderExpr = "l.created_at DESC"
-	case models.SORT_RELEVANCE:
-		// Выбираем выражение для сортировки по релевантности в зависимости от типа поиска
-		switch searchType {
-		case FuzzySearch:
-			// Для нечеткого поиска используем similarity
-			orderExpr = "similarity(l.title, $1) DESC"
-		case FullTextSearch:
-			// Для полнотекстового поиска используем ts_rank
-			orderExpr = "ts_rank(lsr.title_vector, to_tsquery('russian', $1)) DESC"
-		case CombinedSearch:
-			// Для комбинированного поиска используем combined_rank
-			orderExpr = "combined_rank DESC"
-		default:
-			orderExpr = "similarity(l.title, $1) DESC"
-		}
 	}
 
 	return orderExpr
 }
 
-// getReverseOrderExpression возвращает обратный порядок сортировки
-func getReverseOrderExpression(orderExpr string) string {
-	// Специальная обработка для сортировки по релевантности
-	if strings.Contains(orderExpr, "similarity") {
-		if strings.Contains(orderExpr, "DESC") {
-			return strings.Replace(orderExpr, "DESC", "ASC", 1)
-		} else if strings.Contains(orderExpr, "ASC") {
-			return strings.Replace(orderExpr, "ASC", "DESC", 1)
-		}
+// pointExpr точка на поверхности Земли для сравнения с locations.geog
+func pointExpr(c models.Coordinates) string {
+	return fmt.Sprintf("ST_SetSRID(ST_MakePoint(%s, %s), 4326)::geography", formatFloat(c.Lng), formatFloat(c.Lat))
+}
+
+// distanceExpr расстояние от точки до локации loc по сфере в метрах. Оператор <-> дает
+// то же расстояние, что ST_Distance(..., false), но упорядочивание по нему идет через
+// GiST-индекс idx_locations_geog (KNN), а не вычислением для каждой строки
+func distanceExpr(c models.Coordinates) string {
+	return "(loc.geog <-> " + pointExpr(c) + ")"
+}
+
+// buildDistanceQuery формирует запрос выдачи, упорядоченной по расстоянию до покупателя.
+// Объявления с локацией отбираются в порядке KNN-индекса, объявления без локации идут
+// в конце выдачи с бесконечным расстоянием. Пагинация идет по паре (расстояние, id),
+// как и в выдаче по релевантности
+func buildDistanceQuery(baseQuery string, args []interface{}, limit int, cursor *models.Listing, cursorDistance *float64, point models.Coordinates) (string, []interface{}) {
+	limitParam := fmt.Sprintf("$%d", len(args)+1)
+	distance := distanceExpr(point)
+
+	operator, direction := ">", "ASC"
+	if limit < 0 {
+		operator, direction = "<=", "DESC"
+	}
+
+	located, unlocated := "TRUE", "TRUE"
+	if cursor != nil {
+		bound := distanceCursorBound(cursor, cursorDistance, point)
+		located = fmt.Sprintf("(%s, l.id) %s (%s, '%s'::uuid)", distance, operator, bound, cursor.ID)
+		unlocated = fmt.Sprintf("('Infinity'::float8, l.id) %s (%s, '%s'::uuid)", operator, bound, cursor.ID)
 	}
 
-	// Обработка стандартных выражений сортировки
+	query := `
+			(
+				SELECT ` + listingFields + `, ` + distance + ` AS distance
+				FROM locations loc
+				JOIN (` + baseQuery + `) l ON l.id = loc.listing_id
+				WHERE ` + located + `
+				ORDER BY ` + distance + ` ` + direction + `, l.id ` + direction + `
+				LIMIT ` + limitParam + `
+			)
+			UNION ALL
+			(
+				SELECT ` + listingFields + `, 'Infinity'::float8 AS distance
+				FROM (` + baseQuery + `) l
+				WHERE NOT EXISTS (SELECT 1 FROM locations loc WHERE loc.listing_id = l.id)
+				AND ` + unlocated + `
+				ORDER BY l.id ` + direction + `
+				LIMIT ` + limitParam + `
+			)
+			ORDER BY distance ` + direction + `, id ` + direction + `
+			LIMIT ` + limitParam
+
+	if limit > 0 {
+		return query, append(args, limit)
+	}
+
+	// Выборка назад идет от курсора к началу выдачи и переворачивается обратно
+	return `
+			WITH reversed AS (` + query + `
+			)
+			SELECT ` + listingFields + `, l.distance FROM reversed l
+			ORDER BY l.distance ASC, l.id ASC
+			`, append(args, -limit)
+}
+
+// distanceCursorBound расстояние объявления-курсора до покупателя. Если оно не сохранено
+// в курсоре, вычисляется в рамках того же запроса
+func distanceCursorBound(cursor *models.Listing, cursorDistance *float64, point models.Coordinates) string {
+	if cursorDistance != nil {
+		return formatFloat(*cursorDistance) + "::float8"
+	}
+
+	return fmt.Sprintf(`COALESCE((
+					SELECT %s FROM locations loc WHERE loc.listing_id = '%s'
+				), 'Infinity')::float8`, distanceExpr(point), cursor.ID)
+}
+
+// getReverseOrderExpression возвращает обратный порядок сортировки
+func getReverseOrderExpression(orderExpr string) string {
 	if strings.Contains(orderExpr, "ASC") {
 		return strings.Replace(orderExpr, "ASC", "DESC", 1)
 	} else if strings.Contains(orderExpr, "DESC") {
@@ -453,14 +564,6 @@ func getReverseOrderExpression(orderExpr string) string {
 
 // getCursorCondition создает SQL условие для пагинации с курсором
 func getCursorCondition(orderExpr string, cursor *models.Listing, inclusive bool) string {
-	// Специальная обработка для сортировки по релевантности (similarity)
-	if strings.Contains(orderExpr, "similarity") {
-		// Для сортировки по релевантности нам не нужно дополнительное условие,
-		// так как нельзя сравнивать значения функции similarity в условии WHERE
-		// Вместо этого используем OFFSET и LIMIT в buildSQLQuery
-		return "TRUE" // Возвращаем условие, которое не ограничит выборку
-	}
-
 	// Определяем оператор сравнения на основе направления сортировки и включения курсора
 	operator := ">" // По умолчанию для ASC и не включая курсор
 	if strings.Contains(orderExpr, "DESC") {
@@ -508,9 +611,9 @@ func getCursorCondition(orderExpr string, cursor *models.Listing, inclusive bool
 func determineSearchType(query string) SearchType {
 	query = strings.TrimSpace(query)
 
-	// Если запрос пустой, используем нечеткий поиск по умолчанию
+	// Если запрос пустой, отбираем объявления только по фильтрам
 	if query == "" {
-		return FuzzySearch
+		return BrowseSearch
 	}
 
 	// Короткие запросы (1-2 слова) лучше искать нечетким поиском
@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/yaroslavvasilenko/argon/internal/models"
+)
+
+func TestBuildLocationFilter(t *testing.T) {
+	location := models.Location{Area: models.Area{
+		Coordinates: models.Coordinates{Lat: -31.4167, Lng: -64.1833},
+		Radius:      5000,
+	}}
+
+	conditions := buildFilterConditions(CategoryScope{}, nil, location)
+
+	point := "ST_SetSRID(ST_MakePoint(-64.1833, -31.4167), 4326)::geography"
+	assert.Contains(t, conditions, "ST_DWithin(loc.geog, "+point+", 5000)")
+	// Покупатель внутри зоны обслуживания; у объявлений без зоны service_area пуст
+	assert.Contains(t, conditions, "ST_Covers(loc.service_area, "+point+")")
+
+	assert.Empty(t, buildFilterConditions(CategoryScope{}, nil, models.Location{}), "без координат локация не фильтруется")
+}
+
+func TestBuildCategoryFilter(t *testing.T) {
+	assert.Empty(t, CategoryScope{}.condition(), "без категории поиск идет по всем категориям")
+
+	conditions := buildFilterConditions(CategoryScope{ID: "electronics"}, nil, models.Location{})
+	assert.Contains(t, conditions, "JOIN category_closure cc ON cc.descendant_id = lc.category_id")
+	assert.Contains(t, conditions, "cc.ancestor_id = 'electronics'")
+
+	conditions = buildFilterConditions(CategoryScope{ID: "electronics", Exact: true}, nil, models.Location{})
+	assert.NotContains(t, conditions, "category_closure")
+	assert.Contains(t, conditions, "lc.category_id = 'electronics'")
+
+	assert.Contains(t, CategoryScope{ID: "o'clock"}.condition(), "'o''clock'", "кавычки экранируются")
+}
+
+func TestBuildDistanceQuery(t *testing.T) {
+	cursor := &models.Listing{ID: uuid.New()}
+	point := models.Coordinates{Lat: -31.4167, Lng: -64.1833}
+	distance := "(loc.geog <-> ST_SetSRID(ST_MakePoint(-64.1833, -31.4167), 4326)::geography)"
+
+	t.Run("Курсор с сохраненным расстоянием", func(t *testing.T) {
+		saved := 1520.75
+		sql, queryArgs := buildDistanceQuery("SELECT 1", []interface{}{"iphone"}, 10, cursor, &saved, point)
+
+		// Порядок задается оператором <->, чтобы использовался KNN-индекс по geog
+		assert.Contains(t, sql, "ORDER BY "+distance+" ASC, l.id ASC")
+		assert.NotContains(t, sql, "ST_Distance")
+		assert.Contains(t, sql, "("+distance+", l.id) > (1520.75::float8, '"+cursor.ID.String()+"'::uuid)")
+		assert.Contains(t, sql, "('Infinity'::float8, l.id) > (1520.75::float8", "объявления без локации идут после курсора")
+		assert.Equal(t, []interface{}{"iphone", 10}, queryArgs)
+	})
+
+	t.Run("Выборка назад", func(t *testing.T) {
+		sql, queryArgs := buildDistanceQuery("SELECT 1", nil, -10, cursor, nil, point)
+
+		assert.Contains(t, sql, "("+distance+", l.id) <= (COALESCE((")
+		assert.Contains(t, sql, "WHERE loc.listing_id = '"+cursor.ID.String()+"'")
+		assert.True(t, strings.Index(sql, "DESC") < strings.LastIndex(sql, "ASC"), "выборка назад должна переворачиваться")
+		assert.Equal(t, []interface{}{10}, queryArgs)
+	})
+}
@@ -0,0 +1,159 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/yaroslavvasilenko/argon/config"
+	"github.com/yaroslavvasilenko/argon/internal/models"
+)
+
+// defaultListingTTL срок публикации объявления, если listings.ttlDays не задан
+const defaultListingTTL = 30 * 24 * time.Hour
+
+// Lifecycle правила жизненного цикла объявлений из конфигурации
+type Lifecycle struct {
+	// Moderation отправленные на публикацию объявления сначала проверяет модератор
+	Moderation bool
+	// TTL срок действия публикации
+	TTL time.Duration
+}
+
+func NewLifecycle(cfg config.Config) Lifecycle {
+	lifecycle := Lifecycle{
+		Moderation: cfg.Listings.Moderation,
+		TTL:        time.Duration(cfg.Listings.TTLDays) * 24 * time.Hour,
+	}
+	if lifecycle.TTL <= 0 {
+		lifecycle.TTL = defaultListingTTL
+	}
+	return lifecycle
+}
+
+// SubmitStatus состояние, в которое переходит отправленное на публикацию объявление
+func (l Lifecycle) SubmitStatus() models.ListingStatus {
+	if l.Moderation {
+		return models.ListingStatusPendingReview
+	}
+	return models.ListingStatusPublished
+}
+
+// Submit переводит новое объявление в состояние отправленного на публикацию в момент at.
+// Сразу опубликованное объявление получает момент публикации и срок ее действия
+func (l Lifecycle) Submit(listing *models.Listing, at time.Time) {
+	listing.Status = l.SubmitStatus()
+	listing.StatusChangedAt = at
+	if listing.Status == models.ListingStatusPublished {
+		expiresAt := at.Add(l.TTL)
+		listing.PublishedAt = &at
+		listing.ExpiresAt = &expiresAt
+	}
+}
+
+// Publication заполняет момент публикации и срок ее действия для перехода в published.
+// Снятое с паузы объявление сохраняет прежний срок
+func (l Lifecycle) Publication(change *StatusChange) {
+	if change.To != models.ListingStatusPublished || change.From == models.ListingStatusPaused {
+		return
+	}
+
+	expiresAt := change.At.Add(l.TTL)
+	change.PublishedAt = &change.At
+	change.ExpiresAt = &expiresAt
+}
+
+// StatusChange переход объявления из состояния From в состояние To
+type StatusChange struct {
+	From models.ListingStatus
+	To   models.ListingStatus
+	At   time.Time
+	// PublishedAt и ExpiresAt задаются при публикации; пустые значения не меняют колонки
+	PublishedAt *time.Time
+	ExpiresAt   *time.Time
+	// Reason причина отклонения; остальные переходы ее сбрасывают
+	Reason string
+	// Decision решение модератора, которое сохраняется в истории модерации
+	Decision models.ModerationDecision
+}
+
+// ChangeStatus переводит объявление в новое состояние, только если оно все еще в состоянии From.
+// Если состояние успело измениться, возвращается 409
+func (s *Listing) ChangeStatus(ctx context.Context, listingID uuid.UUID, change StatusChange) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE listings
+		SET status = $1,
+			status_changed_at = $2,
+			published_at = COALESCE($3, published_at),
+			expires_at = COALESCE($4, expires_at),
+			moderation_reason = $5,
+			version = version + 1
+		WHERE id = $6 AND status = $7 AND deleted_at IS NULL
+	`, string(change.To), change.At, change.PublishedAt, change.ExpiresAt, change.Reason, listingID, string(change.From))
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return fiber.NewError(fiber.StatusConflict, fmt.Sprintf("listing %s is no longer %s", listingID, change.From))
+	}
+
+	if change.Decision != "" {
+		_, err = tx.Exec(ctx, `
+			INSERT INTO listing_moderations (id, listing_id, decision, reason, created_at)
+			VALUES ($1, $2, $3, $4, $5)
+		`, uuid.New(), listingID, string(change.Decision), change.Reason, change.At)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetModerationQueue возвращает объявления, ожидающие модерации, в порядке отправки на проверку
+func (s *Listing) GetModerationQueue(ctx context.Context, limit, offset int) ([]uuid.UUID, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT id FROM listings
+		WHERE status = $1 AND deleted_at IS NULL
+		ORDER BY status_changed_at, id
+		LIMIT $2 OFFSET $3
+	`, string(models.ListingStatusPendingReview), limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []uuid.UUID{}
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// ExpireListings снимает с публикации объявления, срок которых истек к моменту now
+func (s *Listing) ExpireListings(ctx context.Context, now time.Time) (int64, error) {
+	tag, err := s.pool.Exec(ctx, `
+		UPDATE listings
+		SET status = $1, status_changed_at = $2, version = version + 1
+		WHERE status = $3 AND expires_at <= $2 AND deleted_at IS NULL
+	`, string(models.ListingStatusExpired), now, string(models.ListingStatusPublished))
+	if err != nil {
+		return 0, err
+	}
+
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yaroslavvasilenko/argon/config"
+	"github.com/yaroslavvasilenko/argon/internal/models"
+)
+
+func TestLifecycleSubmit(t *testing.T) {
+	at := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Без модерации объявление публикуется на срок ttlDays", func(t *testing.T) {
+		cfg := config.Config{}
+		cfg.Listings.TTLDays = 10
+
+		var listing models.Listing
+		NewLifecycle(cfg).Submit(&listing, at)
+
+		assert.Equal(t, models.ListingStatusPublished, listing.Status)
+		assert.Equal(t, at, listing.StatusChangedAt)
+		require.NotNil(t, listing.PublishedAt)
+		assert.Equal(t, at, *listing.PublishedAt)
+		require.NotNil(t, listing.ExpiresAt)
+		assert.Equal(t, at.Add(10*24*time.Hour), *listing.ExpiresAt)
+	})
+
+	t.Run("С модерацией объявление ждет проверки без срока публикации", func(t *testing.T) {
+		cfg := config.Config{}
+		cfg.Listings.Moderation = true
+
+		var listing models.Listing
+		NewLifecycle(cfg).Submit(&listing, at)
+
+		assert.Equal(t, models.ListingStatusPendingReview, listing.Status)
+		assert.Nil(t, listing.PublishedAt)
+		assert.Nil(t, listing.ExpiresAt)
+	})
+
+	t.Run("Срок по умолчанию", func(t *testing.T) {
+		assert.Equal(t, defaultListingTTL, NewLifecycle(config.Config{}).TTL)
+	})
+}
@@ -12,19 +12,21 @@ import (
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/yaroslavvasilenko/argon/config"
 	"github.com/yaroslavvasilenko/argon/internal/models"
 	bstorage "github.com/yaroslavvasilenko/argon/internal/modules/boost/storage"
 	"gorm.io/gorm"
 )
 
 type Listing struct {
-	gorm  *gorm.DB
-	pool  *pgxpool.Pool
-	boost *bstorage.Boost
+	gorm    *gorm.DB
+	pool    *pgxpool.Pool
+	boost   *bstorage.Boost
+	ranking Ranking
 }
 
-func NewListing(db *gorm.DB, pool *pgxpool.Pool, boost *bstorage.Boost) *Listing {
-	return &Listing{gorm: db, pool: pool, boost: boost}
+func NewListing(db *gorm.DB, pool *pgxpool.Pool, boost *bstorage.Boost, ranking Ranking) *Listing {
+	return &Listing{gorm: db, pool: pool, boost: boost, ranking: ranking}
 }
 
 // ListingDetails содержит все данные для создания объявления
@@ -35,8 +37,11 @@ type ListingDetails struct {
 	Characteristics map[string]interface{}
 }
 
-// BatchCreateListingsWithDetails создает несколько объявлений с их категориями, локациями и характеристиками
+// BatchCreateListingsWithDetails создает несколько объявлений с их категориями, локациями и характеристиками.
+// Объявления без состояния отправляются на публикацию по правилам [listings] конфигурации, как при создании через API
 func (s *Listing) BatchCreateListingsWithDetails(ctx context.Context, listingsDetails []ListingDetails) error {
+	lifecycle := NewLifecycle(config.GetConfig())
+
 	// Начинаем общую транзакцию для всех операций
 	tx, err := s.pool.Begin(ctx)
 	if err != nil {
@@ -46,31 +51,41 @@ func (s *Listing) BatchCreateListingsWithDetails(ctx context.Context, listingsDe
 
 	// Для каждого объявления создаем его и связанные с ним данные
 	for _, details := range listingsDetails {
+		if details.Listing.Status == "" {
+			lifecycle.Submit(&details.Listing, details.Listing.CreatedAt)
+		}
+
 		// 1. Вставляем основные данные листинга
 		_, err = tx.Exec(ctx, `
 			INSERT INTO listings (
 				id, 
 				title, 
 				original_description, 
-				images,
 				price,
 				views_count,
 				currency,
 				created_at, 
 				updated_at, 
-				deleted_at
-			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+				deleted_at,
+				status,
+				status_changed_at,
+				published_at,
+				expires_at
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
 		`,
 			details.Listing.ID,
 			details.Listing.Title,
 			details.Listing.Description,
-			details.Listing.Images,
 			details.Listing.Price,
 			details.Listing.ViewsCount,
 			details.Listing.Currency,
 			details.Listing.CreatedAt,
 			details.Listing.UpdatedAt,
 			details.Listing.DeletedAt,
+			string(details.Listing.Status),
+			details.Listing.StatusChangedAt,
+			details.Listing.PublishedAt,
+			details.Listing.ExpiresAt,
 		)
 		if err != nil {
 			return err
@@ -83,10 +98,11 @@ func (s *Listing) BatchCreateListingsWithDetails(ctx context.Context, listingsDe
 					id,
 					listing_id,
 					name,
-					latitude,
-					longitude,
-					radius
-				) VALUES ($1, $2, $3, $4, $5, $6)
+					geog,
+					radius,
+					address,
+					is_service_area
+				) VALUES ($1, $2, $3, `+pointParam+`, $6, $7, $8)
 			`,
 				details.Location.ID,
 				details.Listing.ID,
@@ -94,6 +110,8 @@ func (s *Listing) BatchCreateListingsWithDetails(ctx context.Context, listingsDe
 				details.Location.Area.Coordinates.Lat,
 				details.Location.Area.Coordinates.Lng,
 				int32(details.Location.Area.Radius),
+				addressJSON(details.Location.Address),
+				details.Location.Area.ServiceArea,
 			)
 			if err != nil {
 				return err
@@ -160,7 +178,11 @@ func (s *Listing) BatchCreateListingsWithDetails(ctx context.Context, listingsDe
 	return tx.Commit(ctx)
 }
 
-func (s *Listing) CreateListing(ctx context.Context, listing models.Listing, categories []string, location models.Location, characteristics map[string]interface{}) error {
+// CreateListing создает объявление со связанными данными и первыми ревизиями истории
+// TxStep запись другого модуля, которая выполняется в транзакции объявления
+type TxStep func(ctx context.Context, tx pgx.Tx) error
+
+func (s *Listing) CreateListing(ctx context.Context, listing models.Listing, categories []string, location models.Location, characteristics map[string]interface{}, images TxStep, revisions ...models.ListingRevision) error {
 	tx, err := s.pool.Begin(ctx)
 	if err != nil {
 		return err
@@ -173,25 +195,33 @@ func (s *Listing) CreateListing(ctx context.Context, listing models.Listing, cat
 			id, 
 			title, 
 			original_description, 
-			images,
 			price,
 			views_count,
 			currency,
 			created_at, 
 			updated_at, 
-			deleted_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			deleted_at,
+			status,
+			status_changed_at,
+			published_at,
+			expires_at,
+			original_lang
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
 	`,
 		listing.ID,
 		listing.Title,
 		listing.Description,
-		listing.Images,
 		listing.Price,
 		listing.ViewsCount,
 		listing.Currency,
 		listing.CreatedAt,
 		listing.UpdatedAt,
 		listing.DeletedAt,
+		string(listing.Status),
+		listing.StatusChangedAt,
+		listing.PublishedAt,
+		listing.ExpiresAt,
+		string(listing.OriginalLang),
 	)
 	if err != nil {
 		return err
@@ -225,10 +255,11 @@ func (s *Listing) CreateListing(ctx context.Context, listing models.Listing, cat
 				id,
 				listing_id,
 				name,
-				latitude,
-				longitude,
-				radius
-			) VALUES ($1, $2, $3, $4, $5, $6)
+				geog,
+				radius,
+				address,
+				is_service_area
+			) VALUES ($1, $2, $3, `+pointParam+`, $6, $7, $8)
 		`,
 			locationID.String,
 			listing.ID,
@@ -236,6 +267,8 @@ func (s *Listing) CreateListing(ctx context.Context, listing models.Listing, cat
 			latitude.Float64,
 			longitude.Float64,
 			radius.Int32,
+			addressJSON(location.Address),
+			location.Area.ServiceArea,
 		)
 		if err != nil {
 			return err
@@ -301,6 +334,16 @@ func (s *Listing) CreateListing(ctx context.Context, listing models.Listing, cat
 		}
 	}
 
+	if images != nil {
+		if err := images(ctx, tx); err != nil {
+			return err
+		}
+	}
+
+	if err := insertRevisions(ctx, tx, revisions); err != nil {
+		return err
+	}
+
 	// Если все операции успешны, фиксируем транзакцию
 	return tx.Commit(ctx)
 }
@@ -353,19 +396,28 @@ func (s *Listing) GetFullListing(ctx context.Context, pID string) (FullListing,
 			l.id, 
 			l.title, 
 			l.original_description, 
-			l.images,
 			l.created_at, 
 			l.updated_at, 
 			l.deleted_at,
 			l.price,
 			l.views_count,
 			l.currency,
+			l.is_nsfw,
+			l.status,
+			l.status_changed_at,
+			l.published_at,
+			l.expires_at,
+			l.moderation_reason,
+			l.version,
+			l.original_lang,
 			c.category_ids,
 			loc.id,
 			loc.name,
-			loc.latitude,
-			loc.longitude,
-			loc.radius
+			ST_Y(loc.geog::geometry),
+			ST_X(loc.geog::geometry),
+			loc.radius,
+			loc.address,
+			loc.is_service_area
 		FROM listings l
 		LEFT JOIN categories c ON l.id = c.listing_id
 		LEFT JOIN locations loc ON l.id = loc.listing_id
@@ -377,6 +429,8 @@ func (s *Listing) GetFullListing(ctx context.Context, pID string) (FullListing,
 	var listing models.Listing
 	var deletedAt sql.NullTime
 	var currencyStr string
+	var status string
+	var originalLang string
 	var categoryIDs []string
 
 	// Для локации используем Nullable-типы, так как данные могут отсутствовать из-за LEFT JOIN
@@ -384,24 +438,35 @@ func (s *Listing) GetFullListing(ctx context.Context, pID string) (FullListing,
 	var locationName sql.NullString
 	var latitude, longitude sql.NullFloat64
 	var radius sql.NullInt32
+	var address []byte
+	var serviceArea sql.NullBool
 
 	err = row.Scan(
 		&listing.ID,
 		&listing.Title,
 		&listing.Description,
-		&listing.Images,
 		&listing.CreatedAt,
 		&listing.UpdatedAt,
 		&deletedAt,
 		&listing.Price,
 		&listing.ViewsCount,
 		&currencyStr,
+		&listing.IsNSFW,
+		&status,
+		&listing.StatusChangedAt,
+		&listing.PublishedAt,
+		&listing.ExpiresAt,
+		&listing.ModerationReason,
+		&listing.Version,
+		&originalLang,
 		&categoryIDs,
 		&locationID,
 		&locationName,
 		&latitude,
 		&longitude,
 		&radius,
+		&address,
+		&serviceArea,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -415,6 +480,8 @@ func (s *Listing) GetFullListing(ctx context.Context, pID string) (FullListing,
 		listing.DeletedAt = &deletedAt.Time
 	}
 	listing.Currency = models.Currency(currencyStr)
+	listing.Status = models.ListingStatus(status)
+	listing.OriginalLang = models.Localization(originalLang)
 	resp.Listing = listing
 
 	// Заполняем информацию о категориях
@@ -430,10 +497,14 @@ func (s *Listing) GetFullListing(ctx context.Context, pID string) (FullListing,
 		location.ListingID = listingID
 		location.Name = locationName.String
 		location.Area = models.Area{
-			Radius: int(radius.Int32),
+			Radius:      int(radius.Int32),
+			ServiceArea: serviceArea.Bool,
 		}
 		location.Area.Coordinates.Lat = latitude.Float64
 		location.Area.Coordinates.Lng = longitude.Float64
+		if location.Address, err = parseAddress(address); err != nil {
+			return resp, err
+		}
 		resp.Location = location
 	}
 
@@ -446,7 +517,7 @@ func (s *Listing) GetFullListing(ctx context.Context, pID string) (FullListing,
 		resp.Characteristics = make(map[string]interface{})
 	}
 
-	boost, err := s.boost.GetBoosts(ctx, listingID)
+	boost, err := s.boost.GetActiveBoosts(ctx, listingID)
 	if err != nil {
 		return resp, err
 	}
@@ -455,48 +526,107 @@ func (s *Listing) GetFullListing(ctx context.Context, pID string) (FullListing,
 	return resp, nil
 }
 
-func (s *Listing) DeleteListing(ctx context.Context, pID string) error {
-	err := s.gorm.Table(itemTable).WithContext(ctx).
-		Where("id = ? AND deleted_at IS NULL", pID).
-		Update("deleted_at", time.Now()).
-		Error
+// DeleteListing помечает объявление удаленным и в той же транзакции отвязывает его изображения images
+func (s *Listing) DeleteListing(ctx context.Context, listingID uuid.UUID, images TxStep) error {
+	tx, err := s.pool.Begin(ctx)
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		UPDATE listings
+		SET deleted_at = $2
+		WHERE id = $1 AND deleted_at IS NULL
+	`, listingID, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if err := images(ctx, tx); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// ErrStaleVersion объявление изменилось после того, как клиент прочитал версию listing.Version
+var ErrStaleVersion = fiber.NewError(fiber.StatusConflict, "listing was modified by another request")
+
+// UpdateListingImages записывает изменение изображений images в транзакции объявления и повышает
+// его версию. При version > 0 изменение применяется только к этой версии, иначе возвращается
+// ErrStaleVersion. Возвращает новую версию объявления
+func (s *Listing) UpdateListingImages(ctx context.Context, listingID uuid.UUID, version int, images TxStep) (int, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	var newVersion int
+	err = tx.QueryRow(ctx, `
+		UPDATE listings
+		SET version = version + 1
+		WHERE id = $1 AND deleted_at IS NULL AND ($2 = 0 OR version = $2)
+		RETURNING version
+	`, listingID, version).Scan(&newVersion)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, ErrStaleVersion
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if err := images(ctx, tx); err != nil {
+		return 0, err
+	}
 
-	return nil
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+
+	return newVersion, nil
 }
 
-func (s *Listing) UpdateFullListing(ctx context.Context, listing models.Listing, categories []string, location models.Location, characteristics map[string]interface{}) error {
+// UpdateFullListing заменяет содержимое объявления версии listing.Version, записывает изображения images
+// и дописывает ревизии в историю в той же транзакции. Без listing.OriginalLang язык текста не меняется,
+// без images изображения остаются прежними.
+// Если версия устарела, возвращается ErrStaleVersion
+func (s *Listing) UpdateFullListing(ctx context.Context, listing models.Listing, categories []string, location models.Location, characteristics map[string]interface{}, images TxStep, revisions ...models.ListingRevision) error {
 	tx, err := s.pool.Begin(ctx)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback(ctx)
 
-	// Обновляем основные данные листинга
-	_, err = tx.Exec(ctx, `
+	// Обновляем основные данные листинга, если с момента чтения его никто не изменил
+	tag, err := tx.Exec(ctx, `
 		UPDATE listings 
 		SET 
 			title = $1, 
 			original_description = $2, 
-			images = $3,
-			updated_at = $4, 
-			price = $5,
-			currency = $6
-		WHERE id = $7 AND deleted_at IS NULL
+			updated_at = $3, 
+			price = $4,
+			currency = $5,
+			original_lang = COALESCE(NULLIF($8, ''), original_lang),
+			version = version + 1
+		WHERE id = $6 AND version = $7 AND deleted_at IS NULL
 	`,
 		listing.Title,
 		listing.Description,
-		listing.Images,
 		listing.UpdatedAt,
 		listing.Price,
 		listing.Currency,
 		listing.ID,
+		listing.Version,
+		string(listing.OriginalLang),
 	)
 	if err != nil {
 		return err
 	}
+	if tag.RowsAffected() == 0 {
+		return ErrStaleVersion
+	}
 
 	// Удаляем существующие категории для этого листинга
 	_, err = tx.Exec(ctx, `DELETE FROM listing_categories WHERE listing_id = $1`, listing.ID)
@@ -548,9 +678,10 @@ func (s *Listing) UpdateFullListing(ctx context.Context, listing models.Listing,
 				SET 
 					id = $1,
 					name = $2,
-					latitude = $3,
-					longitude = $4,
-					radius = $5
+					geog = ST_SetSRID(ST_MakePoint($4::float8, $3::float8), 4326)::geography,
+					radius = $5,
+					address = $7,
+					is_service_area = $8
 				WHERE listing_id = $6
 			`,
 				location.ID,
@@ -559,6 +690,8 @@ func (s *Listing) UpdateFullListing(ctx context.Context, listing models.Listing,
 				location.Area.Coordinates.Lng,
 				int32(location.Area.Radius),
 				listing.ID,
+				addressJSON(location.Address),
+				location.Area.ServiceArea,
 			)
 		} else {
 			// Вставляем новую локацию
@@ -567,10 +700,11 @@ func (s *Listing) UpdateFullListing(ctx context.Context, listing models.Listing,
 					id,
 					listing_id,
 					name,
-					latitude,
-					longitude,
-					radius
-				) VALUES ($1, $2, $3, $4, $5, $6)
+					geog,
+					radius,
+					address,
+					is_service_area
+				) VALUES ($1, $2, $3, `+pointParam+`, $6, $7, $8)
 			`,
 				location.ID,
 				listing.ID,
@@ -578,6 +712,8 @@ func (s *Listing) UpdateFullListing(ctx context.Context, listing models.Listing,
 				location.Area.Coordinates.Lat,
 				location.Area.Coordinates.Lng,
 				location.Area.Radius,
+				addressJSON(location.Address),
+				location.Area.ServiceArea,
 			)
 		}
 
@@ -633,6 +769,16 @@ func (s *Listing) UpdateFullListing(ctx context.Context, listing models.Listing,
 		}
 	}
 
+	if images != nil {
+		if err := images(ctx, tx); err != nil {
+			return err
+		}
+	}
+
+	if err := insertRevisions(ctx, tx, revisions); err != nil {
+		return err
+	}
+
 	// Фиксируем транзакцию
 	return tx.Commit(ctx)
 }
@@ -666,17 +812,19 @@ func (s *Listing) GetListingCharacteristics(ctx context.Context, listingID uuid.
 	return characteristics, nil
 }
 
-// GetCategoryFilters получает все доступные фильтры для указанной категории
-func (s *Listing) GetCategoryFilters(ctx context.Context, categoryID string) (models.Filters, error) {
+// GetCategoryFilters получает все доступные фильтры для указанной категории. Как и поиск,
+// учитывает объявления подкатегорий, если не задан category.Exact.
+// units задает единицы, в которых отдаются диапазоны величин, по ролям характеристик
+func (s *Listing) GetCategoryFilters(ctx context.Context, category CategoryScope, units map[string]models.Dimension) (models.Filters, error) {
 	// Создаем результирующую карту для хранения фильтров
 	result := make(models.Filters)
 
-	// Сначала проверим, существует ли категория
+	// Сначала проверим, есть ли объявления в категории
 	var categoryExists bool
-	checkQuery := `SELECT EXISTS(SELECT 1 FROM listing_categories WHERE category_id = $1)`
-	err := s.pool.QueryRow(ctx, checkQuery, categoryID).Scan(&categoryExists)
+	checkQuery := `SELECT EXISTS(SELECT 1 FROM listings l WHERE TRUE` + category.condition() + `)`
+	err := s.pool.QueryRow(ctx, checkQuery).Scan(&categoryExists)
 	if err != nil {
-		return nil, fmt.Errorf("ошибка при проверке существования категории %s: %w", categoryID, err)
+		return nil, fmt.Errorf("ошибка при проверке существования категории %s: %w", category.ID, err)
 	}
 
 	// Если категория не существует или нет товаров в этой категории, вернем пустые фильтры
@@ -684,14 +832,15 @@ func (s *Listing) GetCategoryFilters(ctx context.Context, categoryID string) (mo
 		return result, nil
 	}
 
+	// Агрегаты по характеристикам строятся по реестру
+	registry := models.Characteristics()
+
 	// SQL-запрос для получения минимальной и максимальной цены, а также всех характеристик в категории
 	query := `
 	WITH category_listings AS (
 		SELECT l.id, l.price
 		FROM listings l
-		JOIN listing_categories lc ON l.id = lc.listing_id
-		WHERE lc.category_id = $1
-		AND l.deleted_at IS NULL
+		WHERE ` + visibleCondition + category.condition() + `
 	)
 	SELECT 
 		MIN(cl.price) AS min_price,
@@ -706,118 +855,7 @@ func (s *Listing) GetCategoryFilters(ctx context.Context, categoryID string) (mo
 						'min', MIN(cl.price),
 						'max', MAX(cl.price)
 					)
-					-- Для цветов возвращаем массив уникальных значений
-					WHEN key = 'color' THEN (
-						SELECT jsonb_agg(DISTINCT value)
-						FROM (
-							-- Обработка массивов
-							SELECT jsonb_array_elements_text(lch.characteristics->'color') AS value
-							FROM listing_characteristics lch
-							JOIN listing_categories lc ON lch.listing_id = lc.listing_id
-							WHERE lc.category_id = $1
-							AND lch.characteristics ? 'color'
-							AND jsonb_typeof(lch.characteristics->'color') = 'array'
-							UNION ALL
-							-- Обработка скалярных значений
-							SELECT lch.characteristics->>'color' AS value
-							FROM listing_characteristics lch
-							JOIN listing_categories lc ON lch.listing_id = lc.listing_id
-							WHERE lc.category_id = $1
-							AND lch.characteristics ? 'color'
-							AND jsonb_typeof(lch.characteristics->'color') != 'array'
-						) subq
-						WHERE value IS NOT NULL
-					)
-					-- Для брендов возвращаем массив уникальных значений
-					WHEN key = 'brand' THEN (
-						SELECT jsonb_agg(DISTINCT value)
-						FROM (
-							-- Обработка массивов
-							SELECT jsonb_array_elements_text(lch.characteristics->'brand') AS value
-							FROM listing_characteristics lch
-							JOIN listing_categories lc ON lch.listing_id = lc.listing_id
-							WHERE lc.category_id = $1
-							AND lch.characteristics ? 'brand'
-							AND jsonb_typeof(lch.characteristics->'brand') = 'array'
-							UNION ALL
-							-- Обработка скалярных значений
-							SELECT lch.characteristics->>'brand' AS value
-							FROM listing_characteristics lch
-							JOIN listing_categories lc ON lch.listing_id = lc.listing_id
-							WHERE lc.category_id = $1
-							AND lch.characteristics ? 'brand'
-							AND jsonb_typeof(lch.characteristics->'brand') != 'array'
-						) subq
-						WHERE value IS NOT NULL
-					)
-					-- Для состояния (новый/б/у) возвращаем массив уникальных значений
-					WHEN key = 'condition' THEN (
-						SELECT jsonb_agg(DISTINCT value)
-						FROM (
-							-- Обработка массивов
-							SELECT jsonb_array_elements_text(lch.characteristics->'condition') AS value
-							FROM listing_characteristics lch
-							JOIN listing_categories lc ON lch.listing_id = lc.listing_id
-							WHERE lc.category_id = $1
-							AND lch.characteristics ? 'condition'
-							AND jsonb_typeof(lch.characteristics->'condition') = 'array'
-							UNION ALL
-							-- Обработка скалярных значений
-							SELECT lch.characteristics->>'condition' AS value
-							FROM listing_characteristics lch
-							JOIN listing_categories lc ON lch.listing_id = lc.listing_id
-							WHERE lc.category_id = $1
-							AND lch.characteristics ? 'condition'
-							AND jsonb_typeof(lch.characteristics->'condition') != 'array'
-						) subq
-						WHERE value IS NOT NULL
-					)
-					-- Для сезона возвращаем массив уникальных значений
-					WHEN key = 'season' THEN (
-						SELECT jsonb_agg(DISTINCT value)
-						FROM (
-							-- Обработка массивов
-							SELECT jsonb_array_elements_text(lch.characteristics->'season') AS value
-							FROM listing_characteristics lch
-							JOIN listing_categories lc ON lch.listing_id = lc.listing_id
-							WHERE lc.category_id = $1
-							AND lch.characteristics ? 'season'
-							AND jsonb_typeof(lch.characteristics->'season') = 'array'
-							UNION ALL
-							-- Обработка скалярных значений
-							SELECT lch.characteristics->>'season' AS value
-							FROM listing_characteristics lch
-							JOIN listing_categories lc ON lch.listing_id = lc.listing_id
-							WHERE lc.category_id = $1
-							AND lch.characteristics ? 'season'
-							AND jsonb_typeof(lch.characteristics->'season') != 'array'
-						) subq
-						WHERE value IS NOT NULL
-					)
-					-- Для булевых значений (например, "в наличии")
-					WHEN key = 'stocked' THEN (
-						SELECT jsonb_agg(DISTINCT (lch.characteristics->'stocked'->>'checkbox_value')::boolean)
-						FROM listing_characteristics lch
-						JOIN listing_categories lc ON lch.listing_id = lc.listing_id
-						WHERE lc.category_id = $1
-						AND lch.characteristics ? 'stocked'
-					)
-					-- Для размерных характеристик (высота, ширина и т.д.)
-					WHEN key IN ('height', 'width', 'depth', 'weight', 'area', 'volume') THEN (
-						SELECT jsonb_build_object(
-							'min', MIN((lch.characteristics->key->>'value')::float),
-							'max', MAX((lch.characteristics->key->>'value')::float),
-							'dimension', (SELECT DISTINCT lch.characteristics->key->>'dimension'
-								FROM listing_characteristics lch
-								JOIN listing_categories lc ON lch.listing_id = lc.listing_id
-								WHERE lc.category_id = $1
-								AND lch.characteristics ? key
-								LIMIT 1)
-						)
-						FROM listing_characteristics lch
-						JOIN listing_categories lc ON lch.listing_id = lc.listing_id
-						WHERE lc.category_id = $1
-					)
+` + facetCases(registry) + `
 					ELSE NULL
 				END
 			)
@@ -827,8 +865,7 @@ func (s *Listing) GetCategoryFilters(ctx context.Context, categoryID string) (mo
 				FROM (
 					SELECT jsonb_object_keys(lch.characteristics) AS key
 					FROM listing_characteristics lch
-					JOIN listing_categories lc ON lch.listing_id = lc.listing_id
-					WHERE lc.category_id = $1
+					JOIN category_listings fl ON fl.id = lch.listing_id
 				) subq
 			) keys
 		) AS characteristics
@@ -839,13 +876,13 @@ func (s *Listing) GetCategoryFilters(ctx context.Context, categoryID string) (mo
 	var minPrice, maxPrice *float64
 	var characteristicsJSON []byte
 
-	err = s.pool.QueryRow(ctx, query, categoryID).Scan(&minPrice, &maxPrice, &characteristicsJSON)
+	err = s.pool.QueryRow(ctx, query).Scan(&minPrice, &maxPrice, &characteristicsJSON)
 	if err != nil {
 		if err == pgx.ErrNoRows {
 			// Если нет данных, возвращаем пустую карту фильтров
 			return result, nil
 		}
-		return nil, fmt.Errorf("ошибка при получении фильтров для категории %s: %w", categoryID, err)
+		return nil, fmt.Errorf("ошибка при получении фильтров для категории %s: %w", category.ID, err)
 	}
 
 	// Создаем фильтр цены
@@ -869,72 +906,79 @@ func (s *Listing) GetCategoryFilters(ctx context.Context, categoryID string) (mo
 
 		// Обрабатываем каждую характеристику
 		for key, value := range characteristics {
-			switch key {
-			case models.CHAR_COLOR:
+			def, ok := registry.Get(key)
+			if !ok || !def.Filterable() {
+				continue
+			}
+
+			switch def.Kind {
+			case models.KindColor:
 				// Для цвета
 				var colors []string
 				if err := json.Unmarshal(value, &colors); err != nil {
 					continue
 				}
-				if colors != nil && len(colors) > 0 {
+				if len(colors) > 0 {
 					result[key] = models.ColorFilter{Options: colors}
 				}
 
-			case models.CHAR_BRAND, models.CHAR_CONDITION, models.CHAR_SEASON:
-				// Для выпадающих списков
+			case models.KindDropdown, models.KindMultiSelect:
+				// Для выпадающих списков и множественного выбора
 				var options []string
 				if err := json.Unmarshal(value, &options); err != nil {
 					continue
 				}
-				if options != nil && len(options) > 0 {
+				if len(options) > 0 {
 					result[key] = models.DropdownFilter(options)
 				}
 
-			case models.CHAR_STOCKED:
+			case models.KindCheckbox:
 				// Для булевых значений
 				var boolValues []bool
 				if err := json.Unmarshal(value, &boolValues); err != nil {
 					continue
 				}
-				if boolValues != nil && len(boolValues) > 0 {
+				if len(boolValues) > 0 {
 					boolValue := boolValues[0]
 					result[key] = models.CheckboxFilter(&boolValue)
 				}
 
-			case models.CHAR_HEIGHT, models.CHAR_WIDTH, models.CHAR_WEIGHT, models.CHAR_DEPTH, models.CHAR_AREA, models.CHAR_VOLUME:
-				// Для размерных характеристик
-				var dimensionFilter struct {
-					Min       float64 `json:"min"`
-					Max       float64 `json:"max"`
-					Dimension string  `json:"dimension"`
+			case models.KindAmount:
+				// Для размерных характеристик границы приходят в базовой единице
+				// и отдаются в запрошенной единице или в единице по умолчанию
+				var baseRange struct {
+					Min float64 `json:"min"`
+					Max float64 `json:"max"`
 				}
-				if err := json.Unmarshal(value, &dimensionFilter); err != nil {
+				if err := json.Unmarshal(value, &baseRange); err != nil {
 					continue
 				}
-				result[key] = models.DimensionFilter{
-					Min:       int(dimensionFilter.Min),
-					Max:       int(dimensionFilter.Max),
-					Dimension: dimensionFilter.Dimension,
+				unit, ok := units[key]
+				if !ok {
+					unit = def.DefaultUnit
 				}
+				if dimensionFilter, ok := models.DimensionFilterFromBase(baseRange.Min, baseRange.Max, unit); ok {
+					result[key] = dimensionFilter
+				}
+
+			case models.KindInteger:
+				// Для целых значений
+				var intFilter models.IntegerRangeFilter
+				if err := json.Unmarshal(value, &intFilter); err != nil {
+					continue
+				}
+				result[key] = intFilter
+
+			case models.KindDate:
+				// Для дат
+				var dateFilter models.DateRangeFilter
+				if err := json.Unmarshal(value, &dateFilter); err != nil {
+					continue
+				}
+				result[key] = dateFilter
 			}
 		}
 	}
 
 	return result, nil
 }
-
-// getDimensionUnit возвращает единицу измерения для указанной характеристики
-func getDimensionUnit(key string) string {
-	switch key {
-	case models.CHAR_HEIGHT, models.CHAR_WIDTH, models.CHAR_DEPTH:
-		return models.CM
-	case models.CHAR_WEIGHT:
-		return models.KG
-	case models.CHAR_AREA:
-		return models.M2
-	case models.CHAR_VOLUME:
-		return models.L
-	default:
-		return ""
-	}
-}
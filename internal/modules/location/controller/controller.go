@@ -34,3 +34,22 @@ func (h *Location) GetLocation(c *fiber.Ctx) error {
 
 	return c.JSON(locResp)
 }
+
+func (h *Location) SearchLocations(c *fiber.Ctx) error {
+	req := location.SearchLocationsRequest{}
+	if err := parser.BodyParser(c, &req); err != nil {
+		return err
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	resp, err := h.s.SearchLocations(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(resp)
+}
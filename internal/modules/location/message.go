@@ -5,14 +5,23 @@ import (
 )
 
 type GetLocationRequest struct {
-	Area     models.Area   `json:"area" validate:"required"`
+	Area models.Area `json:"area" validate:"required"`
 }
 
-
-
+type SearchLocationsRequest struct {
+	Query string `json:"query" validate:"required"`
+	// Limit количество кандидатов, по умолчанию 5
+	Limit int `json:"limit" validate:"omitempty,min=1,max=20"`
+}
 
 type GetLocationResponse struct {
-	ID   string `json:"id"`
-	Name string `json:"name"`
-	Area models.Area `json:"area"`
-}
\ No newline at end of file
+	ID          string              `json:"id"`
+	Name        string              `json:"name"`
+	Area        models.Area         `json:"area"`
+	Address     *models.Address     `json:"address,omitempty"`
+	BoundingBox *models.BoundingBox `json:"bounding_box,omitempty"`
+}
+
+type SearchLocationsResponse struct {
+	Results []GetLocationResponse `json:"results"`
+}
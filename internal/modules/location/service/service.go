@@ -3,19 +3,25 @@ package service
 import (
 	"context"
 	"math"
-	"strconv"
 
 	"github.com/yaroslavvasilenko/argon/internal/core/logger"
+	"github.com/yaroslavvasilenko/argon/internal/models"
 	"github.com/yaroslavvasilenko/argon/internal/modules/location"
 	"github.com/yaroslavvasilenko/argon/internal/modules/location/storage"
 )
 
+// defaultSearchLimit количество кандидатов прямого геокодирования по умолчанию
+const defaultSearchLimit = 5
+
+// defaultPlaceRadius радиус места в метрах, если геокодер не вернул его границы
+const defaultPlaceRadius = 1000
+
 type Location struct {
-	s      *storage.Location
+	s      storage.Geocoder
 	logger *logger.Glog
 }
 
-func NewLocation(s *storage.Location, logger *logger.Glog) *Location {
+func NewLocation(s storage.Geocoder, logger *logger.Glog) *Location {
 	srv := &Location{
 		s:      s,
 		logger: logger,
@@ -25,18 +31,71 @@ func NewLocation(s *storage.Location, logger *logger.Glog) *Location {
 }
 
 func (l *Location) GetLocation(ctx context.Context, req location.GetLocationRequest) (location.GetLocationResponse, error) {
-	locResp, err := l.s.GetLocation(ctx, req.Area.Coordinates.Lat, req.Area.Coordinates.Lng, calculateZoomForRadius(req.Area.Radius, req.Area.Coordinates.Lat))
+	place, err := l.s.Reverse(ctx, req.Area.Coordinates.Lat, req.Area.Coordinates.Lng, calculateZoomForRadius(req.Area.Radius, req.Area.Coordinates.Lat))
 	if err != nil {
 		return location.GetLocationResponse{}, err
 	}
 
-	response := location.GetLocationResponse{
-		ID:   strconv.FormatInt(locResp.PlaceID, 10),
-		Name: locResp.DisplayName,
-		Area: req.Area,
+	// Область выбрана пользователем, поэтому возвращаем ее без изменений
+	return newLocationResponse(place, req.Area), nil
+}
+
+// SearchLocations находит места-кандидаты по текстовому запросу. Радиус области
+// кандидата покрывает его границы, чтобы город находился целиком, а улица — нет
+func (l *Location) SearchLocations(ctx context.Context, req location.SearchLocationsRequest) (location.SearchLocationsResponse, error) {
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+
+	places, err := l.s.Search(ctx, req.Query, limit)
+	if err != nil {
+		return location.SearchLocationsResponse{}, err
+	}
+
+	resp := location.SearchLocationsResponse{Results: make([]location.GetLocationResponse, 0, len(places))}
+	for _, place := range places {
+		area := models.Area{
+			Coordinates: place.Coordinates,
+			Radius:      placeRadius(place),
+		}
+		resp.Results = append(resp.Results, newLocationResponse(place, area))
+	}
+
+	return resp, nil
+}
+
+func newLocationResponse(place storage.Place, area models.Area) location.GetLocationResponse {
+	address := place.Address
+
+	return location.GetLocationResponse{
+		ID:          place.ID,
+		Name:        place.Name,
+		Area:        area,
+		Address:     &address,
+		BoundingBox: place.BoundingBox,
+	}
+}
+
+// placeRadius возвращает расстояние в метрах от центра места до самого дальнего угла его границ
+func placeRadius(place storage.Place) int {
+	box := place.BoundingBox
+	if box == nil {
+		return defaultPlaceRadius
+	}
+
+	radius := 0.0
+	for _, lat := range []float64{box.South, box.North} {
+		for _, lng := range []float64{box.West, box.East} {
+			radius = math.Max(radius, place.Coordinates.DistanceTo(models.Coordinates{Lat: lat, Lng: lng}))
+		}
+	}
+
+	if radius < 1 {
+		return defaultPlaceRadius
 	}
 
-	return response, nil
+	return int(math.Ceil(radius))
 }
 
 func calculateZoomForRadius(radius int, latitude float64) int {
@@ -0,0 +1,22 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yaroslavvasilenko/argon/internal/models"
+	"github.com/yaroslavvasilenko/argon/internal/modules/location/storage"
+)
+
+func TestPlaceRadius(t *testing.T) {
+	center := models.Coordinates{Lat: 55.75, Lng: 37.62}
+
+	assert.Equal(t, defaultPlaceRadius, placeRadius(storage.Place{Coordinates: center}), "без границ используется радиус по умолчанию")
+
+	// Границы ±0.01° вокруг центра: около 1.1 км по широте и 0.63 км по долготе
+	radius := placeRadius(storage.Place{
+		Coordinates: center,
+		BoundingBox: &models.BoundingBox{South: 55.74, North: 55.76, West: 37.61, East: 37.63},
+	})
+	assert.InDelta(t, 1280, radius, 20)
+}
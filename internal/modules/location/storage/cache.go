@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// coarseWindowDegrees окрестность, в которой при недоступности геокодера ищется
+// закэшированный результат меньшей детализации, около 5 км
+const coarseWindowDegrees = 0.05
+
+// GeocodeCacheEntry закэшированный ответ геокодера
+type GeocodeCacheEntry struct {
+	Key  string
+	Kind string
+	Lang string
+	// Lat, Lng и Zoom заполняются для обратного геокодирования
+	Lat      *float64
+	Lng      *float64
+	Zoom     *int
+	Response []byte
+	// CreatedAt момент получения ответа от геокодера
+	CreatedAt time.Time
+}
+
+// GeocodeCache постоянный кэш ответов геокодера в таблице geocode_cache
+type GeocodeCache struct {
+	pool *pgxpool.Pool
+}
+
+func NewGeocodeCache(pool *pgxpool.Pool) *GeocodeCache {
+	return &GeocodeCache{pool: pool}
+}
+
+// Get возвращает запись по ключу; found = false, если ее нет
+func (c *GeocodeCache) Get(ctx context.Context, key string) (GeocodeCacheEntry, bool, error) {
+	entry := GeocodeCacheEntry{Key: key}
+
+	err := c.pool.QueryRow(ctx, `
+		SELECT kind, lang, latitude, longitude, zoom, response, created_at
+		FROM geocode_cache
+		WHERE cache_key = $1
+	`, key).Scan(&entry.Kind, &entry.Lang, &entry.Lat, &entry.Lng, &entry.Zoom, &entry.Response, &entry.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return GeocodeCacheEntry{}, false, nil
+	}
+	if err != nil {
+		return GeocodeCacheEntry{}, false, err
+	}
+
+	return entry, true, nil
+}
+
+// Put сохраняет ответ, заменяя устаревшую запись с тем же ключом
+func (c *GeocodeCache) Put(ctx context.Context, entry GeocodeCacheEntry) error {
+	_, err := c.pool.Exec(ctx, `
+		INSERT INTO geocode_cache (cache_key, kind, lang, latitude, longitude, zoom, response, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (cache_key) DO UPDATE
+		SET response = EXCLUDED.response, created_at = EXCLUDED.created_at
+	`, entry.Key, entry.Kind, entry.Lang, entry.Lat, entry.Lng, entry.Zoom, entry.Response, entry.CreatedAt)
+
+	return err
+}
+
+// GetCoarse ищет рядом с точкой ответ обратного геокодирования меньшей детализации:
+// сначала с наибольшим zoom, затем ближайший
+func (c *GeocodeCache) GetCoarse(ctx context.Context, lang string, lat, lng float64, zoom int) (GeocodeCacheEntry, bool, error) {
+	var entry GeocodeCacheEntry
+
+	err := c.pool.QueryRow(ctx, `
+		SELECT cache_key, kind, lang, latitude, longitude, zoom, response, created_at
+		FROM geocode_cache
+		WHERE kind = $1 AND lang = $2 AND zoom < $3
+			AND latitude BETWEEN $4::float8 - $6::float8 AND $4::float8 + $6::float8
+			AND longitude BETWEEN $5::float8 - $6::float8 AND $5::float8 + $6::float8
+		ORDER BY zoom DESC, power(latitude - $4::float8, 2) + power(longitude - $5::float8, 2)
+		LIMIT 1
+	`, geocodeKindReverse, lang, zoom, lat, lng, coarseWindowDegrees).Scan(
+		&entry.Key, &entry.Kind, &entry.Lang, &entry.Lat, &entry.Lng, &entry.Zoom, &entry.Response, &entry.CreatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return GeocodeCacheEntry{}, false, nil
+	}
+	if err != nil {
+		return GeocodeCacheEntry{}, false, err
+	}
+
+	return entry, true, nil
+}
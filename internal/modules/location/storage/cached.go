@@ -0,0 +1,170 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yaroslavvasilenko/argon/config"
+	"github.com/yaroslavvasilenko/argon/internal/core/parser"
+)
+
+const (
+	geocodeKindReverse = "reverse"
+	geocodeKindSearch  = "search"
+)
+
+const defaultGeocodeCacheTTL = 30 * 24 * time.Hour
+
+// geocodeCache хранилище ответов геокодера
+type geocodeCache interface {
+	Get(ctx context.Context, key string) (GeocodeCacheEntry, bool, error)
+	Put(ctx context.Context, entry GeocodeCacheEntry) error
+	GetCoarse(ctx context.Context, lang string, lat, lng float64, zoom int) (GeocodeCacheEntry, bool, error)
+}
+
+// CachedGeocoder отвечает из кэша, пока запись актуальна, и обращается к геокодеру только
+// при промахе. Если геокодер недоступен, возвращается устаревшая запись, а для обратного
+// геокодирования — ответ меньшей детализации по соседней точке
+type CachedGeocoder struct {
+	next  Geocoder
+	cache geocodeCache
+	ttl   time.Duration
+	now   func() time.Time
+}
+
+func NewCachedGeocoder(next Geocoder, cache *GeocodeCache, cfg config.Config) *CachedGeocoder {
+	ttl := time.Duration(cfg.Nominatim.CacheTTLHours) * time.Hour
+	if ttl <= 0 {
+		ttl = defaultGeocodeCacheTTL
+	}
+
+	return &CachedGeocoder{
+		next:  next,
+		cache: cache,
+		ttl:   ttl,
+		now:   time.Now,
+	}
+}
+
+func (g *CachedGeocoder) Reverse(ctx context.Context, lat, lng float64, zoom int) (Place, error) {
+	lang := string(parser.GetLang(ctx))
+	precision := coordinatePrecision(zoom)
+	lat, lng = roundCoordinate(lat, precision), roundCoordinate(lng, precision)
+	key := fmt.Sprintf("%s:%s:%s:%s:%d", geocodeKindReverse, lang,
+		strconv.FormatFloat(lat, 'f', precision, 64), strconv.FormatFloat(lng, 'f', precision, 64), zoom)
+
+	var place Place
+	cached, found := g.lookup(ctx, key, &place)
+	if found && g.isFresh(cached) {
+		return place, nil
+	}
+
+	fetched, err := g.next.Reverse(ctx, lat, lng, zoom)
+	if err == nil {
+		g.store(ctx, GeocodeCacheEntry{Key: key, Kind: geocodeKindReverse, Lang: lang, Lat: &lat, Lng: &lng, Zoom: &zoom}, fetched)
+		return fetched, nil
+	}
+
+	if !isUnavailable(err) {
+		return Place{}, err
+	}
+	if found {
+		return place, nil
+	}
+
+	// Геокодер недоступен и точного ответа нет: отдаем ближайший ответ меньшей детализации
+	coarse, ok, cacheErr := g.cache.GetCoarse(ctx, lang, lat, lng, zoom)
+	if cacheErr == nil && ok && json.Unmarshal(coarse.Response, &place) == nil {
+		return place, nil
+	}
+
+	return Place{}, err
+}
+
+func (g *CachedGeocoder) Search(ctx context.Context, query string, limit int) ([]Place, error) {
+	lang := string(parser.GetLang(ctx))
+	normalized := strings.ToLower(strings.Join(strings.Fields(query), " "))
+	key := fmt.Sprintf("%s:%s:%d:%s", geocodeKindSearch, lang, limit, normalized)
+
+	var places []Place
+	cached, found := g.lookup(ctx, key, &places)
+	if found && g.isFresh(cached) {
+		return places, nil
+	}
+
+	fetched, err := g.next.Search(ctx, query, limit)
+	if err == nil {
+		g.store(ctx, GeocodeCacheEntry{Key: key, Kind: geocodeKindSearch, Lang: lang}, fetched)
+		return fetched, nil
+	}
+
+	if found && isUnavailable(err) {
+		return places, nil
+	}
+
+	return nil, err
+}
+
+// lookup читает запись кэша в result. Кэш только ускоряет ответ, поэтому ошибка
+// чтения или поврежденная запись считаются промахом
+func (g *CachedGeocoder) lookup(ctx context.Context, key string, result interface{}) (GeocodeCacheEntry, bool) {
+	entry, found, err := g.cache.Get(ctx, key)
+	if err != nil || !found {
+		return GeocodeCacheEntry{}, false
+	}
+
+	if err := json.Unmarshal(entry.Response, result); err != nil {
+		return GeocodeCacheEntry{}, false
+	}
+
+	return entry, true
+}
+
+// store сохраняет ответ геокодера; ошибка записи не должна мешать вернуть ответ
+func (g *CachedGeocoder) store(ctx context.Context, entry GeocodeCacheEntry, result interface{}) {
+	response, err := json.Marshal(result)
+	if err != nil {
+		return
+	}
+
+	entry.Response = response
+	entry.CreatedAt = g.now().UTC()
+	_ = g.cache.Put(ctx, entry)
+}
+
+func (g *CachedGeocoder) isFresh(entry GeocodeCacheEntry) bool {
+	return g.now().Sub(entry.CreatedAt) < g.ttl
+}
+
+// isUnavailable отличает сбой геокодера от ответа «место не найдено»
+func isUnavailable(err error) bool {
+	var fiberErr *fiber.Error
+	return !errors.As(err, &fiberErr) || fiberErr.Code != fiber.StatusNotFound
+}
+
+// coordinatePrecision число знаков после запятой в ключе кэша: чем меньше zoom,
+// тем крупнее результат геокодера и тем грубее можно округлять координаты
+func coordinatePrecision(zoom int) int {
+	switch {
+	case zoom >= 16:
+		return 4 // около 11 м
+	case zoom >= 13:
+		return 3 // около 110 м
+	case zoom >= 10:
+		return 2 // около 1.1 км
+	default:
+		return 1 // около 11 км
+	}
+}
+
+func roundCoordinate(v float64, precision int) float64 {
+	scale := math.Pow(10, float64(precision))
+	return math.Round(v*scale) / scale
+}
@@ -0,0 +1,204 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yaroslavvasilenko/argon/internal/models"
+)
+
+// memoryCache кэш геокодера в памяти
+type memoryCache struct {
+	entries map[string]GeocodeCacheEntry
+}
+
+func (c *memoryCache) Get(_ context.Context, key string) (GeocodeCacheEntry, bool, error) {
+	entry, ok := c.entries[key]
+	return entry, ok, nil
+}
+
+func (c *memoryCache) Put(_ context.Context, entry GeocodeCacheEntry) error {
+	c.entries[entry.Key] = entry
+	return nil
+}
+
+func (c *memoryCache) GetCoarse(_ context.Context, lang string, lat, lng float64, zoom int) (GeocodeCacheEntry, bool, error) {
+	var best GeocodeCacheEntry
+	found := false
+	for _, entry := range c.entries {
+		if entry.Kind != geocodeKindReverse || entry.Lang != lang || *entry.Zoom >= zoom {
+			continue
+		}
+		if *entry.Lat < lat-coarseWindowDegrees || *entry.Lat > lat+coarseWindowDegrees ||
+			*entry.Lng < lng-coarseWindowDegrees || *entry.Lng > lng+coarseWindowDegrees {
+			continue
+		}
+		if !found || *entry.Zoom > *best.Zoom {
+			best, found = entry, true
+		}
+	}
+	return best, found, nil
+}
+
+// stubGeocoder считает обращения и отвечает заданной ошибкой, если она указана
+type stubGeocoder struct {
+	calls int
+	err   error
+}
+
+func (g *stubGeocoder) Reverse(_ context.Context, lat, lng float64, zoom int) (Place, error) {
+	g.calls++
+	if g.err != nil {
+		return Place{}, g.err
+	}
+	return Place{ID: "reverse", Coordinates: models.Coordinates{Lat: lat, Lng: lng}}, nil
+}
+
+func (g *stubGeocoder) Search(_ context.Context, query string, limit int) ([]Place, error) {
+	g.calls++
+	if g.err != nil {
+		return nil, g.err
+	}
+	return []Place{{ID: query}}, nil
+}
+
+func TestCachedGeocoder(t *testing.T) {
+	ctx := context.WithValue(context.Background(), models.KeyLanguage, models.Localization("es"))
+	now := time.Date(2025, 5, 24, 12, 0, 0, 0, time.UTC)
+
+	newGeocoder := func() (*CachedGeocoder, *stubGeocoder) {
+		upstream := &stubGeocoder{}
+		return &CachedGeocoder{
+			next:  upstream,
+			cache: &memoryCache{entries: map[string]GeocodeCacheEntry{}},
+			ttl:   time.Hour,
+			now:   func() time.Time { return now },
+		}, upstream
+	}
+
+	t.Run("Близкие координаты попадают в один ключ", func(t *testing.T) {
+		g, upstream := newGeocoder()
+
+		first, err := g.Reverse(ctx, -31.41671, -64.18331, 13)
+		require.NoError(t, err)
+		second, err := g.Reverse(ctx, -31.41689, -64.18349, 13)
+		require.NoError(t, err)
+
+		assert.Equal(t, 1, upstream.calls)
+		assert.Equal(t, first, second)
+		assert.Equal(t, models.Coordinates{Lat: -31.417, Lng: -64.183}, first.Coordinates)
+
+		// Другой язык кэшируется отдельно
+		_, err = g.Reverse(context.WithValue(ctx, models.KeyLanguage, models.Localization("en")), -31.41671, -64.18331, 13)
+		require.NoError(t, err)
+		assert.Equal(t, 2, upstream.calls)
+	})
+
+	t.Run("Устаревшая запись обновляется", func(t *testing.T) {
+		g, upstream := newGeocoder()
+
+		_, err := g.Search(ctx, "Córdoba", 5)
+		require.NoError(t, err)
+		_, err = g.Search(ctx, "  córdoba ", 5)
+		require.NoError(t, err)
+		assert.Equal(t, 1, upstream.calls, "запрос нормализуется")
+
+		g.now = func() time.Time { return now.Add(2 * time.Hour) }
+		_, err = g.Search(ctx, "Córdoba", 5)
+		require.NoError(t, err)
+		assert.Equal(t, 2, upstream.calls)
+	})
+
+	t.Run("Недоступный геокодер", func(t *testing.T) {
+		g, upstream := newGeocoder()
+
+		_, err := g.Reverse(ctx, -31.4167, -64.1833, 10)
+		require.NoError(t, err)
+		_, err = g.Search(ctx, "Córdoba", 5)
+		require.NoError(t, err)
+
+		upstream.err = errors.New("connection refused")
+		g.now = func() time.Time { return now.Add(2 * time.Hour) }
+
+		// Устаревшая запись лучше ошибки
+		places, err := g.Search(ctx, "Córdoba", 5)
+		require.NoError(t, err)
+		assert.Len(t, places, 1)
+
+		// Для более детального запроса отдаем результат меньшего zoom по соседней точке
+		place, err := g.Reverse(ctx, -31.42, -64.19, 16)
+		require.NoError(t, err)
+		assert.Equal(t, models.Coordinates{Lat: -31.42, Lng: -64.18}, place.Coordinates)
+
+		// Вдали от закэшированных точек деградировать не к чему
+		_, err = g.Reverse(ctx, 40.4168, -3.7038, 16)
+		assert.Error(t, err)
+	})
+
+	t.Run("Место не найдено", func(t *testing.T) {
+		g, upstream := newGeocoder()
+
+		_, err := g.Reverse(ctx, -31.4167, -64.1833, 10)
+		require.NoError(t, err)
+
+		upstream.err = fiber.NewError(fiber.StatusNotFound, "Unable to geocode")
+		_, err = g.Reverse(ctx, -31.4171, -64.1862, 16)
+		assert.Error(t, err, "ответ «не найдено» не подменяется грубым результатом")
+	})
+}
+
+func TestThrottle(t *testing.T) {
+	throttle := newThrottle(20*time.Millisecond, time.Second)
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		require.NoError(t, throttle.Wait(ctx))
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond)
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	throttle = newThrottle(time.Hour, 2*time.Hour)
+	require.NoError(t, throttle.Wait(cancelled), "первый запрос проходит сразу")
+	assert.ErrorIs(t, throttle.Wait(cancelled), context.Canceled)
+	assert.Empty(t, throttle.released, "слот отмененного ожидания возвращается в очередь")
+
+	short, cancelShort := context.WithTimeout(ctx, time.Minute)
+	defer cancelShort()
+	start = time.Now()
+	assert.ErrorIs(t, throttle.Wait(short), errThrottled, "слот позже дедлайна не ждем")
+	assert.Less(t, time.Since(start), time.Second)
+
+	t.Run("Освобожденные слоты достаются следующим запросам", func(t *testing.T) {
+		throttle := newThrottle(time.Second, time.Minute)
+		now := time.Now()
+		deadline := now.Add(time.Minute)
+
+		var slots []time.Time
+		for i := 0; i < 3; i++ {
+			slot, ok := throttle.reserve(now, deadline)
+			require.True(t, ok)
+			slots = append(slots, slot)
+		}
+
+		throttle.release(slots[1])
+		slot, ok := throttle.reserve(now, deadline)
+		require.True(t, ok)
+		assert.Equal(t, slots[1], slot, "занимается освобожденный слот, а не новый")
+
+		throttle.release(slot)
+		throttle.release(slots[2])
+		assert.Equal(t, slots[1], throttle.next, "хвост очереди сворачивается")
+		assert.Empty(t, throttle.released)
+
+		_, ok = throttle.reserve(now, now.Add(500*time.Millisecond))
+		assert.False(t, ok, "после дедлайна слот не резервируется")
+		assert.Equal(t, slots[1], throttle.next)
+	})
+}
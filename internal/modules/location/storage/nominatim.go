@@ -0,0 +1,216 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/pkg/errors"
+	"github.com/yaroslavvasilenko/argon/config"
+	"github.com/yaroslavvasilenko/argon/internal/core/parser"
+	"github.com/yaroslavvasilenko/argon/internal/models"
+)
+
+type LocationResponse struct {
+	PlaceID     int64    `json:"place_id"`
+	Lat         string   `json:"lat"`
+	Lon         string   `json:"lon"`
+	DisplayName string   `json:"display_name"`
+	Address     Address  `json:"address"`
+	BoundingBox []string `json:"boundingbox"`
+	// Error заполняется, если Nominatim не нашел место по координатам
+	Error string `json:"error,omitempty"`
+}
+
+type Address struct {
+	HouseNumber string `json:"house_number,omitempty"`
+	Road        string `json:"road,omitempty"`
+	Suburb      string `json:"suburb,omitempty"`
+	City        string `json:"city,omitempty"`
+	Town        string `json:"town,omitempty"`
+	Village     string `json:"village,omitempty"`
+	Hamlet      string `json:"hamlet,omitempty"`
+	State       string `json:"state,omitempty"`
+	Postcode    string `json:"postcode,omitempty"`
+	Country     string `json:"country,omitempty"`
+	CountryCode string `json:"country_code,omitempty"`
+}
+
+const (
+	defaultNominatimUserAgent   = "argon-marketplace/1.0"
+	defaultNominatimTimeout     = 5 * time.Second
+	defaultNominatimMinInterval = time.Second
+)
+
+// Nominatim геокодер на основе API Nominatim. Запросы идут с User-Agent приложения
+// и не чаще одного за MinIntervalMs, как требует политика использования
+type Nominatim struct {
+	baseUrl   string
+	userAgent string
+	client    *http.Client
+	throttle  *throttle
+}
+
+func NewNominatim(cfg config.Config) *Nominatim {
+	userAgent := cfg.Nominatim.UserAgent
+	if userAgent == "" {
+		userAgent = defaultNominatimUserAgent
+	}
+
+	timeout := time.Duration(cfg.Nominatim.TimeoutMs) * time.Millisecond
+	if timeout <= 0 {
+		timeout = defaultNominatimTimeout
+	}
+
+	interval := time.Duration(cfg.Nominatim.MinIntervalMs) * time.Millisecond
+	if interval <= 0 {
+		interval = defaultNominatimMinInterval
+	}
+
+	return &Nominatim{
+		baseUrl:   strings.TrimRight(cfg.Nominatim.BaseUrl, "/"),
+		userAgent: userAgent,
+		client:    &http.Client{Timeout: timeout},
+		throttle:  newThrottle(interval, timeout),
+	}
+}
+
+func (n *Nominatim) Reverse(ctx context.Context, lat, lng float64, zoom int) (Place, error) {
+	params := url.Values{}
+	params.Set("lat", strconv.FormatFloat(lat, 'f', -1, 64))
+	params.Set("lon", strconv.FormatFloat(lng, 'f', -1, 64))
+	params.Set("zoom", strconv.Itoa(zoom))
+
+	var result LocationResponse
+	if err := n.get(ctx, "/reverse", params, &result); err != nil {
+		return Place{}, err
+	}
+
+	if result.Error != "" {
+		return Place{}, fiber.NewError(fiber.StatusNotFound, result.Error)
+	}
+
+	return result.toPlace()
+}
+
+func (n *Nominatim) Search(ctx context.Context, query string, limit int) ([]Place, error) {
+	params := url.Values{}
+	params.Set("q", query)
+	params.Set("limit", strconv.Itoa(limit))
+
+	var results []LocationResponse
+	if err := n.get(ctx, "/search", params, &results); err != nil {
+		return nil, err
+	}
+
+	places := make([]Place, 0, len(results))
+	for _, result := range results {
+		place, err := result.toPlace()
+		if err != nil {
+			return nil, err
+		}
+		places = append(places, place)
+	}
+
+	return places, nil
+}
+
+func (n *Nominatim) get(ctx context.Context, path string, params url.Values, result interface{}) error {
+	params.Set("format", "json")
+	params.Set("addressdetails", "1")
+	lang := string(parser.GetLang(ctx))
+	params.Set("accept-language", lang)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, n.baseUrl+path+"?"+params.Encode(), nil)
+	if err != nil {
+		return errors.Wrap(err, "creating request")
+	}
+
+	req.Header.Set(models.HeaderLanguage, lang)
+	req.Header.Set("User-Agent", n.userAgent)
+
+	if err := n.throttle.Wait(ctx); err != nil {
+		return errors.Wrap(err, "waiting for request slot")
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "making request")
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "reading response body")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(body))
+	}
+
+	if err := json.Unmarshal(body, result); err != nil {
+		return errors.Wrap(err, "unmarshaling response")
+	}
+
+	return nil
+}
+
+func (r LocationResponse) toPlace() (Place, error) {
+	lat, err := strconv.ParseFloat(r.Lat, 64)
+	if err != nil {
+		return Place{}, errors.Wrap(err, "parsing latitude")
+	}
+	lng, err := strconv.ParseFloat(r.Lon, 64)
+	if err != nil {
+		return Place{}, errors.Wrap(err, "parsing longitude")
+	}
+
+	place := Place{
+		ID:          strconv.FormatInt(r.PlaceID, 10),
+		Name:        r.DisplayName,
+		Coordinates: models.Coordinates{Lat: lat, Lng: lng},
+		Address:     r.Address.toModel(),
+	}
+
+	// boundingbox приходит в порядке south, north, west, east
+	if len(r.BoundingBox) == 4 {
+		var box [4]float64
+		for i, v := range r.BoundingBox {
+			if box[i], err = strconv.ParseFloat(v, 64); err != nil {
+				return Place{}, errors.Wrap(err, fmt.Sprintf("parsing bounding box %v", r.BoundingBox))
+			}
+		}
+		place.BoundingBox = &models.BoundingBox{South: box[0], North: box[1], West: box[2], East: box[3]}
+	}
+
+	return place, nil
+}
+
+// toModel приводит адрес к общему виду: населенный пункт Nominatim возвращает
+// в одном из полей city, town, village или hamlet в зависимости от его размера
+func (a Address) toModel() models.Address {
+	city := a.City
+	for _, alt := range []string{a.Town, a.Village, a.Hamlet} {
+		if city == "" {
+			city = alt
+		}
+	}
+
+	return models.Address{
+		HouseNumber: a.HouseNumber,
+		Road:        a.Road,
+		Suburb:      a.Suburb,
+		City:        city,
+		State:       a.State,
+		Postcode:    a.Postcode,
+		Country:     a.Country,
+		CountryCode: a.CountryCode,
+	}
+}
@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yaroslavvasilenko/argon/config"
+	"github.com/yaroslavvasilenko/argon/internal/models"
+)
+
+// newStubNominatim поднимает локальный сервер с ответами в формате Nominatim
+func newStubNominatim(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/reverse", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "1", r.URL.Query().Get("addressdetails"))
+		assert.Equal(t, "ru", r.URL.Query().Get("accept-language"))
+		assert.Equal(t, defaultNominatimUserAgent, r.Header.Get("User-Agent"))
+
+		if r.URL.Query().Get("lat") == "0" {
+			w.Write([]byte(`{"error":"Unable to geocode"}`))
+			return
+		}
+
+		w.Write([]byte(`{
+			"place_id": 42,
+			"lat": "55.7558",
+			"lon": "37.6173",
+			"display_name": "Тверская улица, Москва, Россия",
+			"address": {"road": "Тверская улица", "city": "Москва", "state": "Москва", "country": "Россия", "country_code": "ru"},
+			"boundingbox": ["55.75", "55.76", "37.61", "37.62"]
+		}`))
+	})
+	mux.HandleFunc("/search", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Химки", r.URL.Query().Get("q"))
+		assert.Equal(t, "3", r.URL.Query().Get("limit"))
+
+		w.Write([]byte(`[{
+			"place_id": 7,
+			"lat": "55.8887",
+			"lon": "37.4303",
+			"display_name": "Химки, Московская область, Россия",
+			"address": {"town": "Химки", "state": "Московская область", "country": "Россия", "country_code": "ru"},
+			"boundingbox": ["55.85", "55.95", "37.35", "37.50"]
+		}]`))
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestNominatim(t *testing.T) {
+	server := newStubNominatim(t)
+	cfg := config.Config{}
+	cfg.Nominatim.BaseUrl = server.URL + "/"
+	cfg.Nominatim.MinIntervalMs = 1
+	geocoder := NewNominatim(cfg)
+	ctx := context.WithValue(context.Background(), models.KeyLanguage, models.Localization("ru"))
+
+	t.Run("Обратное геокодирование", func(t *testing.T) {
+		place, err := geocoder.Reverse(ctx, 55.7558, 37.6173, 16)
+		require.NoError(t, err)
+
+		assert.Equal(t, "42", place.ID)
+		assert.Equal(t, models.Coordinates{Lat: 55.7558, Lng: 37.6173}, place.Coordinates)
+		assert.Equal(t, "Москва", place.Address.City)
+		assert.Equal(t, "ru", place.Address.CountryCode)
+		require.NotNil(t, place.BoundingBox)
+		assert.Equal(t, models.BoundingBox{South: 55.75, North: 55.76, West: 37.61, East: 37.62}, *place.BoundingBox)
+	})
+
+	t.Run("Место не найдено", func(t *testing.T) {
+		_, err := geocoder.Reverse(ctx, 0, 0, 16)
+		assert.Error(t, err)
+	})
+
+	t.Run("Прямое геокодирование", func(t *testing.T) {
+		places, err := geocoder.Search(ctx, "Химки", 3)
+		require.NoError(t, err)
+		require.Len(t, places, 1)
+
+		// Небольшой город Nominatim возвращает в поле town
+		assert.Equal(t, "Химки", places[0].Address.City)
+		assert.Equal(t, "Московская область", places[0].Address.State)
+	})
+}
@@ -2,33 +2,26 @@ package storage
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
-	"io"
-	"net/http"
 
-	"github.com/pkg/errors"
-	"github.com/yaroslavvasilenko/argon/internal/core/parser"
 	"github.com/yaroslavvasilenko/argon/internal/models"
 )
 
-type LocationResponse struct {
-	PlaceID     int64    `json:"place_id"`
-	Lat         string   `json:"lat"`
-	Lon         string   `json:"lon"`
-	DisplayName string   `json:"display_name"`
-	Address     Address  `json:"address"`
-	BoundingBox []string `json:"boundingbox"`
+// Geocoder провайдер геокодирования. Основная реализация — Nominatim,
+// в тестах ее можно заменить локальной заглушкой
+type Geocoder interface {
+	// Reverse находит место по координатам; zoom задает детализацию результата
+	Reverse(ctx context.Context, lat, lng float64, zoom int) (Place, error)
+	// Search находит места-кандидаты по текстовому запросу
+	Search(ctx context.Context, query string, limit int) ([]Place, error)
 }
 
-type Address struct {
-	HouseNumber string `json:"house_number,omitempty"`
-	Road        string `json:"road,omitempty"`
-	Suburb      string `json:"suburb,omitempty"`
-	City        string `json:"city,omitempty"`
-	State       string `json:"state,omitempty"`
-	Postcode    string `json:"postcode,omitempty"`
-	Country     string `json:"country,omitempty"`
+// Place место, найденное геокодером, с адресом на языке запроса
+type Place struct {
+	ID          string              `json:"id"`
+	Name        string              `json:"name"`
+	Coordinates models.Coordinates  `json:"coordinates"`
+	Address     models.Address      `json:"address"`
+	BoundingBox *models.BoundingBox `json:"bounding_box,omitempty"`
 }
 
 // Centroid содержит координаты центра локации
@@ -36,46 +29,3 @@ type Centroid struct {
 	Type        string    `json:"type"`
 	Coordinates []float64 `json:"coordinates"`
 }
-
-type Location struct {
-	baseUrl string
-}
-
-func NewLocation(baseUrlNominatim string) *Location {
-	return &Location{
-		baseUrl: baseUrlNominatim,
-	}
-}
-
-func (l *Location) GetLocation(ctx context.Context, lat, lng float64, zoom int) (*LocationResponse, error) {
-	url := fmt.Sprintf("%s/reverse?lat=%f&lon=%f&zoom=%d&format=json", l.baseUrl, lat, lng, zoom)
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-	if err != nil {
-		return nil, errors.Wrap(err, "creating request")
-	}
-
-	req.Header.Set(models.HeaderLanguage, string(parser.GetLang(ctx)))
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, errors.Wrap(err, "making request")
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, errors.Wrap(err, "reading response body")
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, errors.Wrapf(err, "unexpected status code: %d, body: %s", resp.StatusCode, string(body))
-	}
-
-	var result LocationResponse
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, errors.Wrap(err, "unmarshaling response")
-	}
-
-	return &result, nil
-}
@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// errThrottled слот геокодера наступит позже, чем запрос готов ждать
+var errThrottled = errors.New("no request slot before deadline")
+
+// throttle пропускает запросы не чаще одного за interval. Очередь общая для всех
+// горутин процесса: каждый вызов Wait резервирует ближайший свободный слот. Слоты
+// отмененных ожиданий возвращаются в очередь и достаются следующим запросам
+type throttle struct {
+	mu       sync.Mutex
+	interval time.Duration
+	maxWait  time.Duration
+	next     time.Time
+	released []time.Time
+}
+
+// newThrottle создает очередь; запросы, которым пришлось бы ждать дольше maxWait
+// или дольше дедлайна контекста, сразу получают отказ
+func newThrottle(interval, maxWait time.Duration) *throttle {
+	return &throttle{interval: interval, maxWait: maxWait}
+}
+
+// Wait ждет своей очереди или отмены контекста. Если свободный слот наступит позже
+// дедлайна, возвращает errThrottled, не занимая очередь
+func (t *throttle) Wait(ctx context.Context) error {
+	now := time.Now()
+	deadline := now.Add(t.maxWait)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+
+	slot, ok := t.reserve(now, deadline)
+	if !ok {
+		return errThrottled
+	}
+
+	delay := slot.Sub(now)
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		t.release(slot)
+		return ctx.Err()
+	}
+}
+
+// reserve занимает самый ранний освобожденный слот, а если таких нет — следующий в очереди
+func (t *throttle) reserve(now, deadline time.Time) (time.Time, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// Прошедшие слоты уже не нужны: новый запрос и так получит слот не раньше now
+	expired := sort.Search(len(t.released), func(i int) bool { return !t.released[i].Before(now) })
+	t.released = t.released[expired:]
+
+	if len(t.released) > 0 {
+		slot := t.released[0]
+		if slot.After(deadline) {
+			return time.Time{}, false
+		}
+		t.released = t.released[1:]
+		return slot, true
+	}
+
+	slot := t.next
+	if slot.Before(now) {
+		slot = now
+	}
+	if slot.After(deadline) {
+		return time.Time{}, false
+	}
+	t.next = slot.Add(t.interval)
+
+	return slot, true
+}
+
+// release возвращает слот отмененного ожидания в очередь
+func (t *throttle) release(slot time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if slot.Add(t.interval).Equal(t.next) {
+		t.next = slot
+		// Освобожденные ранее слоты в хвосте очереди тоже сворачиваются
+		for n := len(t.released); n > 0 && t.released[n-1].Add(t.interval).Equal(t.next); n-- {
+			t.next = t.released[n-1]
+			t.released = t.released[:n-1]
+		}
+		return
+	}
+
+	i := sort.Search(len(t.released), func(i int) bool { return !t.released[i].Before(slot) })
+	t.released = append(t.released, time.Time{})
+	copy(t.released[i+1:], t.released[i:])
+	t.released[i] = slot
+}
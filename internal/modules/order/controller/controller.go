@@ -0,0 +1,96 @@
+package controller
+
+import (
+	"context"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/yaroslavvasilenko/argon/internal/modules/order"
+	"github.com/yaroslavvasilenko/argon/internal/modules/order/service"
+)
+
+type Order struct {
+	s *service.Order
+}
+
+func NewOrder(s *service.Order) *Order {
+	return &Order{s: s}
+}
+
+func (o *Order) CreateOrder(c *fiber.Ctx) error {
+	req := order.CreateOrderRequest{}
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	resp, err := o.s.CreateOrder(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(resp)
+}
+
+func (o *Order) GetOrder(c *fiber.Ctx) error {
+	return o.handle(c, o.s.GetOrder)
+}
+
+func (o *Order) PayOrder(c *fiber.Ctx) error {
+	return o.handle(c, o.s.PayOrder)
+}
+
+func (o *Order) ShipOrder(c *fiber.Ctx) error {
+	return o.handle(c, o.s.ShipOrder)
+}
+
+func (o *Order) CompleteOrder(c *fiber.Ctx) error {
+	return o.handle(c, o.s.CompleteOrder)
+}
+
+func (o *Order) CancelOrder(c *fiber.Ctx) error {
+	req := order.CancelOrderRequest{}
+
+	// тело запроса необязательно: причина отмены может быть не указана
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+	}
+
+	id, err := uuid.Parse(c.Params("order_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid order ID",
+		})
+	}
+	req.ID = id
+
+	resp, err := o.s.CancelOrder(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(resp)
+}
+
+// handle разбирает идентификатор заказа из пути и вызывает действие сервиса
+func (o *Order) handle(c *fiber.Ctx, action func(ctx context.Context, id uuid.UUID) (order.OrderResponse, error)) error {
+	id, err := uuid.Parse(c.Params("order_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid order ID",
+		})
+	}
+
+	resp, err := action(c.UserContext(), id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(resp)
+}
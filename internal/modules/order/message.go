@@ -0,0 +1,34 @@
+package order
+
+import (
+	"github.com/google/uuid"
+	"github.com/yaroslavvasilenko/argon/internal/models"
+)
+
+type CreateOrderRequest struct {
+	ListingID uuid.UUID `json:"listing_id" validate:"required"`
+}
+
+type CancelOrderRequest struct {
+	ID     uuid.UUID `json:"-"`
+	Reason string    `json:"reason"`
+}
+
+type OrderResponse struct {
+	ID                uuid.UUID          `json:"id"`
+	ListingID         uuid.UUID          `json:"listing_id"`
+	Buyer             models.Actor       `json:"buyer"`
+	Status            models.OrderStatus `json:"status"`
+	Amount            float64            `json:"amount"`
+	Currency          models.Currency    `json:"currency"`
+	CommissionPercent float64            `json:"commission_percent"`
+	CommissionAmount  float64            `json:"commission_amount"`
+	PaymentID         string             `json:"payment_id"`
+	CancelReason      string             `json:"cancel_reason,omitempty"`
+	CreatedAt         int64              `json:"created_at"`
+	UpdatedAt         int64              `json:"updated_at"`
+	PaidAt            *int64             `json:"paid_at,omitempty"`
+	ShippedAt         *int64             `json:"shipped_at,omitempty"`
+	CompletedAt       *int64             `json:"completed_at,omitempty"`
+	CancelledAt       *int64             `json:"cancelled_at,omitempty"`
+}
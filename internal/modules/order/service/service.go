@@ -0,0 +1,305 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/yaroslavvasilenko/argon/internal/core/logger"
+	"github.com/yaroslavvasilenko/argon/internal/core/parser"
+	"github.com/yaroslavvasilenko/argon/internal/models"
+	"github.com/yaroslavvasilenko/argon/internal/modules/order"
+	"github.com/yaroslavvasilenko/argon/internal/modules/order/storage"
+)
+
+type Order struct {
+	s        *storage.Order
+	payments storage.IPayments
+	logger   *logger.Glog
+}
+
+func NewOrder(s *storage.Order, payments storage.IPayments, logger *logger.Glog) *Order {
+	return &Order{
+		s:        s,
+		payments: payments,
+		logger:   logger,
+	}
+}
+
+// CreateOrder резервирует товар объявления и блокирует оплату у провайдера.
+// Купить можно только опубликованное объявление с действующим бустом upfront.
+// Покупателем заказа становится пользователь запроса
+func (s *Order) CreateOrder(ctx context.Context, req order.CreateOrderRequest) (order.OrderResponse, error) {
+	if req.ListingID == uuid.Nil {
+		return order.OrderResponse{}, fiber.NewError(fiber.StatusBadRequest, "listing_id is required")
+	}
+
+	price, currency, err := s.s.GetListingPrice(ctx, req.ListingID)
+	if err != nil {
+		return order.OrderResponse{}, err
+	}
+
+	boosts, err := s.s.Boost.GetActiveBoosts(ctx, req.ListingID)
+	if err != nil {
+		return order.OrderResponse{}, err
+	}
+	if !hasUpfrontBoost(boosts) {
+		return order.OrderResponse{}, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("listing %s is not buyable", req.ListingID))
+	}
+
+	commission, err := s.commissionAmount(ctx, boosts, price, currency)
+	if err != nil {
+		return order.OrderResponse{}, err
+	}
+
+	now := time.Now().UTC()
+	o := models.Order{
+		ID:                uuid.New(),
+		ListingID:         req.ListingID,
+		Buyer:             parser.GetActor(ctx),
+		Status:            models.OrderStatusPending,
+		Amount:            price,
+		Currency:          currency,
+		CommissionPercent: commissionPercent(boosts),
+		CommissionAmount:  commission,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+
+	if err := s.s.CreateOrder(ctx, o); err != nil {
+		return order.OrderResponse{}, err
+	}
+
+	paymentID, err := s.payments.CreatePayment(ctx, o)
+	if err != nil {
+		// без платежа заказ не может быть оплачен, поэтому сразу освобождаем товар
+		if cancelErr := s.setStatus(ctx, &o, models.OrderStatusCancelled, "payment failed"); cancelErr != nil {
+			s.logger.Errorf("failed to cancel order %s after payment error: %v", o.ID, cancelErr)
+		}
+		return order.OrderResponse{}, err
+	}
+
+	if err := s.s.SetPaymentID(ctx, o.ID, paymentID); err != nil {
+		// платеж, не сохраненный в заказе, уже нельзя списать или вернуть: снимаем блокировку
+		if voidErr := s.payments.RefundPayment(ctx, paymentID); voidErr != nil {
+			s.logger.Errorf("failed to void payment %s of order %s: %v", paymentID, o.ID, voidErr)
+		}
+		if cancelErr := s.setStatus(ctx, &o, models.OrderStatusCancelled, "payment failed"); cancelErr != nil {
+			s.logger.Errorf("failed to cancel order %s after payment error: %v", o.ID, cancelErr)
+		}
+		return order.OrderResponse{}, err
+	}
+	o.PaymentID = paymentID
+
+	return newOrderResponse(o), nil
+}
+
+// GetOrder возвращает заказ
+func (s *Order) GetOrder(ctx context.Context, id uuid.UUID) (order.OrderResponse, error) {
+	o, err := s.s.GetOrder(ctx, id)
+	if err != nil {
+		return order.OrderResponse{}, err
+	}
+
+	return newOrderResponse(o), nil
+}
+
+// PayOrder списывает заблокированные средства покупателя. Сначала заказ переводится в paid,
+// поэтому параллельная отмена либо не пройдет, либо вернет уже списанные средства.
+// Если списание не удалось, заказ возвращается в pending
+func (s *Order) PayOrder(ctx context.Context, id uuid.UUID) (order.OrderResponse, error) {
+	o, err := s.transitionable(ctx, id, models.OrderStatusPaid)
+	if err != nil {
+		return order.OrderResponse{}, err
+	}
+
+	pending := o
+	if err := s.setStatus(ctx, &o, models.OrderStatusPaid, ""); err != nil {
+		return order.OrderResponse{}, err
+	}
+
+	if err := s.payments.CapturePayment(ctx, o.PaymentID); err != nil {
+		pending.UpdatedAt = time.Now().UTC()
+		if revertErr := s.s.UpdateStatus(ctx, pending, models.OrderStatusPaid); revertErr != nil {
+			s.logger.Errorf("failed to return order %s to pending after capture error: %v", o.ID, revertErr)
+		}
+		return order.OrderResponse{}, fiber.NewError(fiber.StatusPaymentRequired, err.Error())
+	}
+
+	return newOrderResponse(o), nil
+}
+
+// ShipOrder отмечает, что продавец отправил товар
+func (s *Order) ShipOrder(ctx context.Context, id uuid.UUID) (order.OrderResponse, error) {
+	return s.transition(ctx, id, models.OrderStatusShipped)
+}
+
+// CompleteOrder отмечает, что покупатель получил товар
+func (s *Order) CompleteOrder(ctx context.Context, id uuid.UUID) (order.OrderResponse, error) {
+	return s.transition(ctx, id, models.OrderStatusCompleted)
+}
+
+// CancelOrder отменяет заказ: снимает резерв товара и возвращает оплату покупателю.
+// Оплата возвращается только после отмены заказа, чтобы параллельная оплата
+// не оставила оплаченный заказ с возвращенным платежом
+func (s *Order) CancelOrder(ctx context.Context, req order.CancelOrderRequest) (order.OrderResponse, error) {
+	o, err := s.transitionable(ctx, req.ID, models.OrderStatusCancelled)
+	if err != nil {
+		return order.OrderResponse{}, err
+	}
+
+	if err := s.setStatus(ctx, &o, models.OrderStatusCancelled, req.Reason); err != nil {
+		return order.OrderResponse{}, err
+	}
+
+	if o.PaymentID != "" {
+		if err := s.payments.RefundPayment(ctx, o.PaymentID); err != nil {
+			s.logger.Errorf("order %s is cancelled but payment %s was not refunded: %v", o.ID, o.PaymentID, err)
+			return order.OrderResponse{}, err
+		}
+	}
+
+	return newOrderResponse(o), nil
+}
+
+func (s *Order) transition(ctx context.Context, id uuid.UUID, next models.OrderStatus) (order.OrderResponse, error) {
+	o, err := s.transitionable(ctx, id, next)
+	if err != nil {
+		return order.OrderResponse{}, err
+	}
+
+	if err := s.setStatus(ctx, &o, next, ""); err != nil {
+		return order.OrderResponse{}, err
+	}
+
+	return newOrderResponse(o), nil
+}
+
+// transitionable загружает заказ и проверяет, что его можно перевести в состояние next
+func (s *Order) transitionable(ctx context.Context, id uuid.UUID, next models.OrderStatus) (models.Order, error) {
+	o, err := s.s.GetOrder(ctx, id)
+	if err != nil {
+		return models.Order{}, err
+	}
+
+	if !o.Status.CanTransitionTo(next) {
+		return models.Order{}, fiber.NewError(fiber.StatusConflict, fmt.Sprintf("order %s cannot change status from %s to %s", id, o.Status, next))
+	}
+
+	return o, nil
+}
+
+// setStatus сохраняет новое состояние заказа вместе с моментом перехода
+func (s *Order) setStatus(ctx context.Context, o *models.Order, next models.OrderStatus, reason string) error {
+	from := o.Status
+	applyStatus(o, next, reason, time.Now().UTC())
+
+	return s.s.UpdateStatus(ctx, *o, from)
+}
+
+func applyStatus(o *models.Order, next models.OrderStatus, reason string, at time.Time) {
+	o.Status = next
+	o.UpdatedAt = at
+
+	switch next {
+	case models.OrderStatusPaid:
+		o.PaidAt = &at
+	case models.OrderStatusShipped:
+		o.ShippedAt = &at
+	case models.OrderStatusCompleted:
+		o.CompletedAt = &at
+	case models.OrderStatusCancelled:
+		o.CancelledAt = &at
+		o.CancelReason = reason
+	}
+}
+
+func hasUpfrontBoost(boosts []models.Boost) bool {
+	for _, b := range boosts {
+		if b.Type == models.BoostTypeUpfront {
+			return true
+		}
+	}
+	return false
+}
+
+// commissionPercent суммарная комиссия действующих бустов объявления
+func commissionPercent(boosts []models.Boost) float64 {
+	var percent float64
+	for _, b := range boosts {
+		percent += b.Commission
+	}
+	return percent
+}
+
+// commissionAmount суммарная комиссия действующих бустов с учетом минимальных сумм тарифов,
+// по которым они куплены
+func (s *Order) commissionAmount(ctx context.Context, boosts []models.Boost, price float64, currency models.Currency) (float64, error) {
+	boostIDs := make([]uuid.UUID, 0, len(boosts))
+	for _, b := range boosts {
+		boostIDs = append(boostIDs, b.ID)
+	}
+
+	purchases, err := s.s.Boost.GetPurchasesByBoostIDs(ctx, boostIDs)
+	if err != nil {
+		return 0, err
+	}
+
+	tariffs, err := s.s.Boost.GetTariffs(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return boostsCommission(boosts, purchases, tariffs, price, currency), nil
+}
+
+// boostsCommission складывает комиссии бустов: процент берется из буста, минимальная сумма — из тарифа покупки
+func boostsCommission(boosts []models.Boost, purchases map[uuid.UUID]models.BoostPurchase, tariffs []models.BoostTariff, price float64, currency models.Currency) float64 {
+	byID := make(map[uuid.UUID]models.BoostTariff, len(tariffs))
+	for _, tariff := range tariffs {
+		byID[tariff.ID] = tariff
+	}
+
+	var amount float64
+	for _, b := range boosts {
+		tariff := models.BoostTariff{CommissionPercent: b.Commission}
+		if purchase, ok := purchases[b.ID]; ok && purchase.TariffID != nil {
+			tariff.MinAmounts = byID[*purchase.TariffID].MinAmounts
+		}
+		amount += tariff.CommissionAmount(price, currency)
+	}
+
+	return math.Round(amount*100) / 100
+}
+
+func newOrderResponse(o models.Order) order.OrderResponse {
+	return order.OrderResponse{
+		ID:                o.ID,
+		ListingID:         o.ListingID,
+		Buyer:             o.Buyer,
+		Status:            o.Status,
+		Amount:            o.Amount,
+		Currency:          o.Currency,
+		CommissionPercent: o.CommissionPercent,
+		CommissionAmount:  o.CommissionAmount,
+		PaymentID:         o.PaymentID,
+		CancelReason:      o.CancelReason,
+		CreatedAt:         o.CreatedAt.UnixMilli(),
+		UpdatedAt:         o.UpdatedAt.UnixMilli(),
+		PaidAt:            timeToMillis(o.PaidAt),
+		ShippedAt:         timeToMillis(o.ShippedAt),
+		CompletedAt:       timeToMillis(o.CompletedAt),
+		CancelledAt:       timeToMillis(o.CancelledAt),
+	}
+}
+
+func timeToMillis(t *time.Time) *int64 {
+	if t == nil {
+		return nil
+	}
+	ms := t.UnixMilli()
+	return &ms
+}
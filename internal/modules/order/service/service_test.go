@@ -0,0 +1,28 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/yaroslavvasilenko/argon/internal/models"
+)
+
+func TestBoostsCommission(t *testing.T) {
+	tariff := models.BoostTariff{ID: uuid.New(), CommissionPercent: 0.12, MinAmounts: map[models.Currency]float64{models.RUB: 100}}
+	upfront := models.Boost{ID: uuid.New(), Type: models.BoostTypeUpfront, Commission: 0.12}
+	highlight := models.Boost{ID: uuid.New(), Type: models.BoostTypeHighlight, Commission: 0.07}
+
+	purchases := map[uuid.UUID]models.BoostPurchase{
+		upfront.ID: {BoostID: upfront.ID, TariffID: &tariff.ID},
+	}
+	boosts := []models.Boost{upfront, highlight}
+
+	// Для дешевого товара комиссия upfront поднимается до минимальной суммы тарифа
+	assert.Equal(t, 103.5, boostsCommission(boosts, purchases, []models.BoostTariff{tariff}, 50, models.RUB))
+	assert.Equal(t, 190.0, boostsCommission(boosts, purchases, []models.BoostTariff{tariff}, 1000, models.RUB))
+
+	// Минимальная сумма задана только для рублей, у буста без покупки ее нет
+	assert.Equal(t, 9.5, boostsCommission(boosts, purchases, []models.BoostTariff{tariff}, 50, models.USD))
+	assert.Equal(t, 9.5, boostsCommission(boosts, nil, []models.BoostTariff{tariff}, 50, models.RUB))
+}
@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/yaroslavvasilenko/argon/config"
+	"github.com/yaroslavvasilenko/argon/internal/models"
+)
+
+// IPayments платежный провайдер заказов. Оплата двухстадийная: при создании заказа
+// средства покупателя блокируются, при оплате заказа списываются
+type IPayments interface {
+	// CreatePayment блокирует сумму заказа и возвращает идентификатор платежа
+	CreatePayment(ctx context.Context, order models.Order) (string, error)
+	// CapturePayment списывает заблокированные средства
+	CapturePayment(ctx context.Context, paymentID string) error
+	// RefundPayment снимает блокировку или возвращает списанные средства
+	RefundPayment(ctx context.Context, paymentID string) error
+}
+
+type localPaymentStatus string
+
+const (
+	localPaymentAuthorized localPaymentStatus = "authorized"
+	localPaymentCaptured   localPaymentStatus = "captured"
+	localPaymentRefunded   localPaymentStatus = "refunded"
+)
+
+// LocalPayments заглушка провайдера для локального запуска и тестов: платежи хранятся в памяти
+// и всегда проходят, если не нарушают порядок блокировка → списание → возврат
+type LocalPayments struct {
+	mu       sync.Mutex
+	payments map[string]localPaymentStatus
+}
+
+func NewLocalPayments() *LocalPayments {
+	return &LocalPayments{payments: make(map[string]localPaymentStatus)}
+}
+
+func (p *LocalPayments) CreatePayment(_ context.Context, order models.Order) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	paymentID := "local_" + uuid.New().String()
+	p.payments[paymentID] = localPaymentAuthorized
+
+	return paymentID, nil
+}
+
+func (p *LocalPayments) CapturePayment(_ context.Context, paymentID string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	status, ok := p.payments[paymentID]
+	if !ok {
+		return fmt.Errorf("платеж %s не найден", paymentID)
+	}
+	if status != localPaymentAuthorized {
+		return fmt.Errorf("платеж %s нельзя списать в статусе %s", paymentID, status)
+	}
+
+	p.payments[paymentID] = localPaymentCaptured
+	return nil
+}
+
+func (p *LocalPayments) RefundPayment(_ context.Context, paymentID string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	status, ok := p.payments[paymentID]
+	if !ok {
+		return fmt.Errorf("платеж %s не найден", paymentID)
+	}
+	if status == localPaymentRefunded {
+		return nil
+	}
+
+	p.payments[paymentID] = localPaymentRefunded
+	return nil
+}
+
+func NewPayments(cfg config.Config) IPayments {
+	if cfg.Payments.Local {
+		return NewLocalPayments()
+	}
+
+	panic("необходимо настроить платежного провайдера или включить payments.local")
+}
@@ -0,0 +1,29 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yaroslavvasilenko/argon/internal/models"
+)
+
+func TestLocalPayments(t *testing.T) {
+	ctx := context.Background()
+	p := NewLocalPayments()
+
+	paymentID, err := p.CreatePayment(ctx, models.Order{Amount: 100, Currency: models.RUB})
+	require.NoError(t, err)
+	assert.NotEmpty(t, paymentID)
+
+	require.NoError(t, p.CapturePayment(ctx, paymentID))
+	assert.Error(t, p.CapturePayment(ctx, paymentID), "платеж нельзя списать дважды")
+
+	require.NoError(t, p.RefundPayment(ctx, paymentID))
+	require.NoError(t, p.RefundPayment(ctx, paymentID), "повторный возврат ничего не делает")
+	assert.Error(t, p.CapturePayment(ctx, paymentID), "возвращенный платеж нельзя списать")
+
+	assert.Error(t, p.CapturePayment(ctx, "unknown"))
+	assert.Error(t, p.RefundPayment(ctx, "unknown"))
+}
@@ -0,0 +1,193 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/yaroslavvasilenko/argon/internal/models"
+	bstorage "github.com/yaroslavvasilenko/argon/internal/modules/boost/storage"
+)
+
+type Order struct {
+	pool  *pgxpool.Pool
+	Boost *bstorage.Boost
+}
+
+func NewOrder(pool *pgxpool.Pool, boost *bstorage.Boost) *Order {
+	return &Order{pool: pool, Boost: boost}
+}
+
+// uniqueViolation код ошибки Postgres при нарушении уникального индекса
+const uniqueViolation = "23505"
+
+const orderFields = `id, listing_id, buyer, status, amount, currency, commission_percent, commission_amount,
+	payment_id, cancel_reason, created_at, updated_at, paid_at, shipped_at, completed_at, cancelled_at`
+
+// CreateOrder резервирует товар и создает заказ в одной транзакции. Резерв снимает
+// характеристику stocked, поэтому на один товар может быть только один открытый заказ
+func (s *Order) CreateOrder(ctx context.Context, order models.Order) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := setStocked(ctx, tx, order.ListingID, false); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO orders (`+orderFields+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+	`, order.ID, order.ListingID, string(order.Buyer), string(order.Status), order.Amount, string(order.Currency),
+		order.CommissionPercent, order.CommissionAmount, order.PaymentID, order.CancelReason,
+		order.CreatedAt, order.UpdatedAt, order.PaidAt, order.ShippedAt, order.CompletedAt, order.CancelledAt)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return fiber.NewError(fiber.StatusConflict, fmt.Sprintf("listing %s already has an open order", order.ListingID))
+	}
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetListingPrice возвращает цену и валюту объявления, которое можно купить. Купить можно
+// только опубликованное объявление, для остальных возвращается конфликт
+func (s *Order) GetListingPrice(ctx context.Context, listingID uuid.UUID) (float64, models.Currency, error) {
+	var price float64
+	var currency, status string
+
+	err := s.pool.QueryRow(ctx, `
+		SELECT price, currency, status FROM listings WHERE id = $1 AND deleted_at IS NULL
+	`, listingID).Scan(&price, &currency, &status)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, "", fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("listing %s not found", listingID))
+	}
+	if err != nil {
+		return 0, "", err
+	}
+
+	if models.ListingStatus(status) != models.ListingStatusPublished {
+		return 0, "", fiber.NewError(fiber.StatusConflict, fmt.Sprintf("listing %s is %s and cannot be bought", listingID, status))
+	}
+
+	return price, models.Currency(currency), nil
+}
+
+// GetOrder возвращает заказ по идентификатору
+func (s *Order) GetOrder(ctx context.Context, id uuid.UUID) (models.Order, error) {
+	row := s.pool.QueryRow(ctx, `SELECT `+orderFields+` FROM orders WHERE id = $1`, id)
+
+	order, err := scanOrder(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.Order{}, fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("order %s not found", id))
+	}
+
+	return order, err
+}
+
+// SetPaymentID сохраняет идентификатор платежа, созданного для заказа
+func (s *Order) SetPaymentID(ctx context.Context, id uuid.UUID, paymentID string) error {
+	_, err := s.pool.Exec(ctx, `
+		UPDATE orders SET payment_id = $2, updated_at = NOW() WHERE id = $1
+	`, id, paymentID)
+
+	return err
+}
+
+// UpdateStatus переводит заказ из состояния from в состояние order.Status. Если заказ
+// уже изменили параллельно, возвращается конфликт. При отмене резерв товара снимается
+func (s *Order) UpdateStatus(ctx context.Context, order models.Order, from models.OrderStatus) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE orders
+		SET status = $3, cancel_reason = $4, updated_at = $5,
+			paid_at = $6, shipped_at = $7, completed_at = $8, cancelled_at = $9
+		WHERE id = $1 AND status = $2
+	`, order.ID, string(from), string(order.Status), order.CancelReason, order.UpdatedAt,
+		order.PaidAt, order.ShippedAt, order.CompletedAt, order.CancelledAt)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return fiber.NewError(fiber.StatusConflict, fmt.Sprintf("order %s is no longer %s", order.ID, from))
+	}
+
+	if order.Status == models.OrderStatusCancelled {
+		if err := setStocked(ctx, tx, order.ListingID, true); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// setStocked меняет наличие товара. Резервирование проходит только если товар
+// сейчас в наличии, иначе возвращается конфликт
+func setStocked(ctx context.Context, tx pgx.Tx, listingID uuid.UUID, stocked bool) error {
+	query := `
+		UPDATE listing_characteristics
+		SET characteristics = jsonb_set(characteristics, '{` + models.CHAR_STOCKED + `,checkbox_value}', to_jsonb($2::boolean))
+		WHERE listing_id = $1
+	`
+	if !stocked {
+		query += ` AND (characteristics->'` + models.CHAR_STOCKED + `'->>'checkbox_value')::boolean IS TRUE`
+	}
+
+	tag, err := tx.Exec(ctx, query, listingID, stocked)
+	if err != nil {
+		return err
+	}
+
+	if !stocked && tag.RowsAffected() == 0 {
+		return fiber.NewError(fiber.StatusConflict, fmt.Sprintf("listing %s is out of stock", listingID))
+	}
+
+	return nil
+}
+
+func scanOrder(row pgx.Row) (models.Order, error) {
+	var order models.Order
+	var buyer, status, currency string
+
+	if err := row.Scan(
+		&order.ID,
+		&order.ListingID,
+		&buyer,
+		&status,
+		&order.Amount,
+		&currency,
+		&order.CommissionPercent,
+		&order.CommissionAmount,
+		&order.PaymentID,
+		&order.CancelReason,
+		&order.CreatedAt,
+		&order.UpdatedAt,
+		&order.PaidAt,
+		&order.ShippedAt,
+		&order.CompletedAt,
+		&order.CancelledAt,
+	); err != nil {
+		return models.Order{}, err
+	}
+
+	order.Buyer = models.Actor(buyer)
+	order.Status = models.OrderStatus(status)
+	order.Currency = models.Currency(currency)
+
+	return order, nil
+}
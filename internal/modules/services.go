@@ -2,30 +2,42 @@ package modules
 
 import (
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/yaroslavvasilenko/argon/config"
 	"github.com/yaroslavvasilenko/argon/internal/core/logger"
 	bservice "github.com/yaroslavvasilenko/argon/internal/modules/boost/service"
+	catservice "github.com/yaroslavvasilenko/argon/internal/modules/category/service"
 	cservice "github.com/yaroslavvasilenko/argon/internal/modules/currency/service"
 	iservice "github.com/yaroslavvasilenko/argon/internal/modules/image/service"
 	lservice "github.com/yaroslavvasilenko/argon/internal/modules/listing/service"
 	locservice "github.com/yaroslavvasilenko/argon/internal/modules/location/service"
+	oservice "github.com/yaroslavvasilenko/argon/internal/modules/order/service"
+	tservice "github.com/yaroslavvasilenko/argon/internal/modules/translation/service"
 )
 
 type Services struct {
-	listing  *lservice.Listing
-	currency *cservice.Currency
-	location *locservice.Location
-	boost    *bservice.Boost
-	Image    *iservice.Image
+	Listing     *lservice.Listing
+	currency    *cservice.Currency
+	location    *locservice.Location
+	Boost       *bservice.Boost
+	Image       *iservice.Image
+	Order       *oservice.Order
+	Category    *catservice.Category
+	Translation *tservice.Translation
 }
 
 func NewServices(storages *Storages, pool *pgxpool.Pool, lg *logger.Glog) *Services {
 	locationService := locservice.NewLocation(storages.Location, lg)
+	imageService := iservice.NewImage(storages.image, iservice.NewRulesModerator(config.GetConfig(), lg), config.GetConfig(), lg)
+	translationService := tservice.NewTranslation(storages.Translation, storages.Translator, config.GetConfig(), lg)
 
 	return &Services{
-		listing:  lservice.NewListing(storages.Listing, storages.image, pool, lg, locationService),
-		currency: cservice.NewCurrency(storages.Currency, storages.CurrencyBinance, lg),
-		location: locationService,
-		boost:    bservice.NewBoost(storages.Boost, lg),
-		Image:    iservice.NewImage(storages.image, lg),
+		Listing:     lservice.NewListing(storages.Listing, imageService, translationService, pool, lg, locationService),
+		currency:    cservice.NewCurrency(storages.Currency, storages.CurrencyBinance, lg),
+		location:    locationService,
+		Boost:       bservice.NewBoost(storages.Boost, lg),
+		Image:       imageService,
+		Order:       oservice.NewOrder(storages.Order, storages.Payments, lg),
+		Category:    catservice.NewCategory(storages.Category, lg),
+		Translation: translationService,
 	}
 }
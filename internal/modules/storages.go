@@ -4,10 +4,13 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/yaroslavvasilenko/argon/config"
 	bstorage "github.com/yaroslavvasilenko/argon/internal/modules/boost/storage"
+	catstorage "github.com/yaroslavvasilenko/argon/internal/modules/category/storage"
 	cstorage "github.com/yaroslavvasilenko/argon/internal/modules/currency/storage"
 	istorage "github.com/yaroslavvasilenko/argon/internal/modules/image/storage"
 	lstorage "github.com/yaroslavvasilenko/argon/internal/modules/listing/storage"
 	locstorage "github.com/yaroslavvasilenko/argon/internal/modules/location/storage"
+	ostorage "github.com/yaroslavvasilenko/argon/internal/modules/order/storage"
+	tstorage "github.com/yaroslavvasilenko/argon/internal/modules/translation/storage"
 	"gorm.io/gorm"
 )
 
@@ -15,20 +18,30 @@ type Storages struct {
 	Listing         *lstorage.Listing
 	Currency        *cstorage.Currency
 	CurrencyBinance cstorage.IBinance
-	Location        *locstorage.Location
+	Location        locstorage.Geocoder
 	Boost           *bstorage.Boost
 	image           *istorage.Image
+	Order           *ostorage.Order
+	Payments        ostorage.IPayments
+	Category        *catstorage.Category
+	Translation     *tstorage.Translation
+	Translator      tstorage.Translator
 }
 
-func NewStorages(cfg config.Config, db *gorm.DB, pool *pgxpool.Pool, minio *istorage.Minio) *Storages {
+func NewStorages(cfg config.Config, db *gorm.DB, pool *pgxpool.Pool, blob istorage.Blob) *Storages {
 	boost := bstorage.NewBoost(db, pool)
 
 	return &Storages{
-		Listing:         lstorage.NewListing(db, pool, boost),
+		Listing:         lstorage.NewListing(db, pool, boost, lstorage.NewRanking(cfg)),
 		Currency:        cstorage.NewCurrency(db, pool),
 		CurrencyBinance: cstorage.NewBinance(cfg),
-		Location:        locstorage.NewLocation(cfg.Nominatim.BaseUrl),
+		Location:        locstorage.NewCachedGeocoder(locstorage.NewNominatim(cfg), locstorage.NewGeocodeCache(pool), cfg),
 		Boost:           boost,
-		image:           istorage.NewImage(db, pool, minio),
+		image:           istorage.NewImage(db, pool, blob),
+		Order:           ostorage.NewOrder(pool, boost),
+		Payments:        ostorage.NewPayments(cfg),
+		Category:        catstorage.NewCategory(pool),
+		Translation:     tstorage.NewTranslation(pool),
+		Translator:      tstorage.NewTranslator(cfg),
 	}
 }
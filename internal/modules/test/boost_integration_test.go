@@ -2,10 +2,12 @@ package modules
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"sync"
 	"testing"
 
 	"github.com/google/uuid"
@@ -60,6 +62,15 @@ func (u *user) updateBoost(t *testing.T, listingID uuid.UUID, boosts []models.Bo
 	return resp, nil
 }
 
+// defaultBoostCommissions комиссии тарифов по умолчанию из миграции каталога бустов
+func defaultBoostCommissions() map[models.BoostType]float64 {
+	return map[models.BoostType]float64{
+		models.BoostTypeBase:      0.02,
+		models.BoostTypeHighlight: 0.07,
+		models.BoostTypeUpfront:   0.12,
+	}
+}
+
 // Тест для проверки работы контроллера буста
 func TestBoostController(t *testing.T) {
 	app := createTestApp(t)
@@ -137,12 +148,20 @@ func TestBoostController(t *testing.T) {
 		require.Len(t, updateResp.AvailableBoosts, 3, "Должно быть 3 доступных типа буста")
 		
 		// Проверяем, что комиссии установлены правильно для всех доступных бустов
-		commissions := models.GetBoostTypesWithCommissions()
+		commissions := defaultBoostCommissions()
 		for _, b := range updateResp.AvailableBoosts {
 			expectedCommission := commissions[b.Type] // Комиссия уже в десятичном формате
 			assert.Equal(t, expectedCommission, b.CommissionPercent, 
 				"Комиссия для буста типа %s должна быть %.2f", b.Type, expectedCommission)
+			// Комиссия считается от цены объявления в его валюте
+			assert.InDelta(t, listingReq.Price*expectedCommission, b.CommissionAmount, 0.005)
+			assert.Equal(t, models.RUB, b.Currency)
 		}
+
+		// Доступные бусты выводятся в порядке каталога
+		assert.Equal(t, models.BoostTypeBase, updateResp.AvailableBoosts[0].Type)
+		assert.Equal(t, models.BoostTypeHighlight, updateResp.AvailableBoosts[1].Type)
+		assert.Equal(t, models.BoostTypeUpfront, updateResp.AvailableBoosts[2].Type)
 	})
 	
 	t.Run("Обновление бустов для объявления", func(t *testing.T) {
@@ -165,7 +184,7 @@ func TestBoostController(t *testing.T) {
 		require.Len(t, updateResp.AvailableBoosts, 3, "Должно быть 3 доступных типа буста")
 		
 		// Проверяем, что комиссии установлены правильно
-		commissions := models.GetBoostTypesWithCommissions()
+		commissions := defaultBoostCommissions()
 		
 		// Находим буст типа Base в доступных бустах
 		var baseBoost *boost.BoostResp
@@ -182,6 +201,40 @@ func TestBoostController(t *testing.T) {
 			"Комиссия для буста типа %s должна быть %.2f", models.BoostTypeBase, expectedCommission)
 	})
 	
+	t.Run("Параллельная покупка буста списывает комиссию один раз", func(t *testing.T) {
+		boosts := []models.BoostType{models.BoostTypeBase, models.BoostTypeHighlight}
+
+		statuses := make([]int, 2)
+		var wg sync.WaitGroup
+		for i := range statuses {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				resp, err := user.updateBoost(t, listingID, boosts)
+				if err == nil {
+					statuses[i] = resp.StatusCode
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		for _, status := range statuses {
+			assert.Contains(t, []int{http.StatusOK, http.StatusConflict}, status)
+		}
+		assert.Contains(t, statuses, http.StatusOK)
+
+		// Первая покупка highlight была в подтесте добавления бустов
+		var live, purchases int
+		err := app.pool.QueryRow(context.Background(), `
+			SELECT
+				(SELECT count(*) FROM listing_boosts WHERE listing_id = $1 AND boost_type = $2 AND status IN ('active', 'scheduled')),
+				(SELECT count(*) FROM boost_purchases WHERE listing_id = $1 AND boost_type = $2)
+		`, listingID, string(models.BoostTypeHighlight)).Scan(&live, &purchases)
+		require.NoError(t, err)
+		assert.Equal(t, 1, live)
+		assert.Equal(t, 2, purchases)
+	})
+
 	t.Run("Проверка ошибки при неверном ID объявления", func(t *testing.T) {
 		// Пробуем обновить бусты для несуществующего объявления
 		boosts := []models.BoostType{models.BoostTypeBase}
@@ -0,0 +1,206 @@
+package modules
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yaroslavvasilenko/argon/config"
+	"github.com/yaroslavvasilenko/argon/internal/models"
+	"github.com/yaroslavvasilenko/argon/internal/modules/category"
+	"github.com/yaroslavvasilenko/argon/internal/modules/listing"
+)
+
+func (user *user) adminRequest(t *testing.T, method, url string, body interface{}) *http.Response {
+	var reader *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		require.NoError(t, err)
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+
+	req := httptest.NewRequest(method, url, reader)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(models.HeaderActor, testAdmin)
+
+	resp, err := user.fiber.Test(req, -1)
+	require.NoError(t, err)
+	return resp
+}
+
+func (user *user) getCategories(t *testing.T, lang string) listing.ResponseGetCategories {
+	req := httptest.NewRequest("GET", "/api/v1/categories", nil)
+	req.Header.Set(models.HeaderLanguage, lang)
+
+	resp, err := user.fiber.Test(req, -1)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var categories listing.ResponseGetCategories
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&categories))
+	return categories
+}
+
+func findCategoryNode(nodes []listing.CategoryNode, id string) (listing.CategoryNode, bool) {
+	for _, node := range nodes {
+		if node.Category.ID == id {
+			return node, true
+		}
+		if found, ok := findCategoryNode(node.Subcategories, id); ok {
+			return found, true
+		}
+	}
+	return listing.CategoryNode{}, false
+}
+
+func TestCategoryAdmin(t *testing.T) {
+	app := createTestApp(t)
+	defer app.cleanDb(t)
+
+	user := app.createUser(t)
+
+	// Возвращаем таксономию из файлов, чтобы изменения не влияли на другие тесты.
+	// Категории с объявлениями импорт не удаляет, поэтому сначала очищаем объявления
+	defer func() {
+		app.cleanDb(t)
+		resp := user.adminRequest(t, "POST", "/api/v1/admin/categories/reload", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	}()
+
+	electronics := "electronics"
+	required := true
+
+	t.Run("Изменения доступны только администраторам", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/api/v1/admin/categories/reload", nil)
+		resp, err := user.fiber.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+		req = httptest.NewRequest("DELETE", "/api/v1/admin/characteristics/"+models.CHAR_BRAND, nil)
+		req.Header.Set(models.HeaderActor, "user-1")
+		resp, err = user.fiber.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("Новая подкатегория доступна без перезапуска", func(t *testing.T) {
+		resp := user.adminRequest(t, "POST", "/api/v1/admin/categories", category.CategoryRequest{
+			ID:       "e_readers",
+			ParentID: &electronics,
+			Position: new(int),
+			Names:    map[string]string{"ru": "Электронные книги", "en": "E-readers", "es": "Lectores"},
+			Characteristics: []config.CharacteristicNode{
+				{Role: models.CHAR_BRAND, Options: []string{"apple", "samsung"}, Required: &required},
+			},
+		})
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		var created category.CategoryResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+		assert.Equal(t, &electronics, created.ParentID)
+		assert.Equal(t, 0, created.Position)
+
+		node, ok := findCategoryNode(user.getCategories(t, "ru").Categories, "e_readers")
+		require.True(t, ok)
+		assert.Equal(t, "Электронные книги", node.Category.Name)
+
+		listingResp := user.createListing(t, listing.CreateListingRequest{
+			Title:      "Электронная книга",
+			Price:      5000,
+			Currency:   models.RUB,
+			Categories: []string{"e_readers"},
+			Location: &models.Location{
+				ID:   uuid.New().String(),
+				Name: "Москва, Россия",
+				Area: models.Area{Coordinates: models.Coordinates{Lat: 55.7558, Lng: 37.6173}, Radius: 10000},
+			},
+			Characteristics: models.CharacteristicValue{
+				models.CHAR_BRAND: models.DropdownOption{Value: "apple", Label: "Apple"},
+			},
+		})
+		assert.Equal(t, http.StatusOK, listingResp.StatusCode)
+	})
+
+	t.Run("Битая ссылка не применяется", func(t *testing.T) {
+		resp := user.adminRequest(t, "PUT", "/api/v1/admin/categories/e_readers", category.CategoryRequest{
+			Names: map[string]string{"ru": "Электронные книги", "en": "E-readers", "es": "Lectores"},
+			Characteristics: []config.CharacteristicNode{
+				{Role: models.CHAR_BRAND, Options: []string{"nokia"}},
+			},
+		})
+		require.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+
+		var body struct {
+			Code   string                 `json:"code"`
+			Issues []config.TaxonomyIssue `json:"issues"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, "InvalidTaxonomy", body.Code)
+		assert.Contains(t, body.Issues, config.TaxonomyIssue{
+			Level: config.TaxonomyError, File: "categories.toml",
+			Message: `category "e_readers": characteristic "brand" has unknown option "nokia"`,
+		})
+
+		resp = user.adminRequest(t, "GET", "/api/v1/admin/categories/e_readers", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var current category.CategoryResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&current))
+		assert.Equal(t, []string{"apple", "samsung"}, current.Characteristics[0].Options)
+	})
+
+	t.Run("Перенос в дереве", func(t *testing.T) {
+		resp := user.adminRequest(t, "POST", "/api/v1/admin/categories/electronics/move", category.MoveCategoryRequest{
+			ParentID: strPtr("e_readers"),
+		})
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		resp = user.adminRequest(t, "POST", "/api/v1/admin/categories/e_readers/move", category.MoveCategoryRequest{
+			Position: 1,
+		})
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		categories := user.getCategories(t, "en").Categories
+		require.Greater(t, len(categories), 1)
+		assert.Equal(t, "e_readers", categories[1].Category.ID)
+
+		resp = user.adminRequest(t, "DELETE", "/api/v1/admin/categories/e_readers", nil)
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	})
+
+	t.Run("Импорт не удаляет категории с объявлениями", func(t *testing.T) {
+		// В файлах нет e_readers, а в ней уже есть объявление
+		resp := user.adminRequest(t, "POST", "/api/v1/admin/categories/reload", nil)
+		require.Equal(t, http.StatusConflict, resp.StatusCode)
+
+		_, ok := findCategoryNode(user.getCategories(t, "en").Categories, "e_readers")
+		assert.True(t, ok)
+	})
+
+	t.Run("Выгрузка и импорт", func(t *testing.T) {
+		resp := user.adminRequest(t, "GET", "/api/v1/admin/categories/export", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var files config.TaxonomyFiles
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&files))
+		assert.Contains(t, files["categories.toml"], `id = "e_readers"`)
+
+		delete(files, "lang/en.json")
+		resp = user.adminRequest(t, "POST", "/api/v1/admin/categories/import", files)
+		require.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+
+		var result category.ImportResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		assert.False(t, result.Applied)
+	})
+}
+
+func strPtr(s string) *string {
+	return &s
+}
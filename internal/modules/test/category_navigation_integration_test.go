@@ -0,0 +1,113 @@
+package modules
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yaroslavvasilenko/argon/internal/models"
+	"github.com/yaroslavvasilenko/argon/internal/modules/listing"
+)
+
+func (user *user) getCategoryByPath(t *testing.T, path, lang string) *http.Response {
+	req := httptest.NewRequest("GET", "/api/v1/categories/by-path?path="+url.QueryEscape(path), nil)
+	req.Header.Set(models.HeaderLanguage, lang)
+
+	resp, err := user.fiber.Test(req, -1)
+	require.NoError(t, err)
+	return resp
+}
+
+func TestCategoryNavigation(t *testing.T) {
+	app := createTestApp(t)
+	defer app.cleanDb(t)
+
+	user := app.createUser(t)
+
+	createListing := func(t *testing.T, title string, categories []string, draft bool) {
+		resp := user.createListing(t, listing.CreateListingRequest{
+			Title:    title,
+			Price:    900.0,
+			Currency: models.RUB,
+			Location: &models.Location{
+				ID:   uuid.New().String(),
+				Name: "Москва, Россия",
+				Area: models.Area{
+					Coordinates: models.Coordinates{Lat: 55.7558, Lng: 37.6173},
+					Radius:      10000,
+				},
+			},
+			Categories: categories,
+			Draft:      draft,
+		})
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+
+	createListing(t, "Смартфон навигационный", []string{"electronics", "smartphones", "iphone"}, false)
+	createListing(t, "Смартфон навигационный второй", []string{"electronics", "smartphones"}, false)
+	createListing(t, "Смартфон навигационный черновик", []string{"electronics", "smartphones"}, true)
+
+	t.Run("Слаги, хлебные крошки и число объявлений в дереве", func(t *testing.T) {
+		categories := user.getCategories(t, "ru")
+
+		node, ok := findCategoryNode(categories.Categories, "iphone")
+		require.True(t, ok)
+		assert.Equal(t, "iphone", node.Category.Slug)
+		assert.Equal(t, "elektronika/smartfony/iphone", node.Category.Path)
+		require.Len(t, node.Category.Breadcrumb, 3)
+		assert.Equal(t, listing.CategoryCrumb{ID: "electronics", Name: "Электроника", Slug: "elektronika"}, node.Category.Breadcrumb[0])
+		require.NotNil(t, node.Category.ListingCount)
+		assert.Equal(t, 1, *node.Category.ListingCount)
+
+		node, ok = findCategoryNode(categories.Categories, "electronics")
+		require.True(t, ok)
+		require.NotNil(t, node.Category.ListingCount)
+		assert.Equal(t, 2, *node.Category.ListingCount, "черновик не считается, объявление подкатегории считается один раз")
+
+		node, ok = findCategoryNode(categories.Categories, "clothing")
+		require.True(t, ok)
+		require.NotNil(t, node.Category.ListingCount)
+		assert.Zero(t, *node.Category.ListingCount)
+	})
+
+	t.Run("Слаги зависят от языка", func(t *testing.T) {
+		node, ok := findCategoryNode(user.getCategories(t, "en").Categories, "smartphones")
+		require.True(t, ok)
+		assert.Equal(t, "electronics/smartphones", node.Category.Path)
+	})
+
+	t.Run("Категория по пути", func(t *testing.T) {
+		resp := user.getCategoryByPath(t, "elektronika/smartfony", "ru")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var category listing.Category
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&category))
+		assert.Equal(t, "smartphones", category.ID)
+		assert.Equal(t, "Смартфоны", category.Name)
+		require.NotNil(t, category.ListingCount)
+		assert.Equal(t, 2, *category.ListingCount)
+
+		resp = user.getCategoryByPath(t, "elektronika/missing", "ru")
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("В поиске самая глубокая категория", func(t *testing.T) {
+		searchResp := user.searchListings(t, getSearchListingsRequest("Смартфон навигационный", 20, "", "", ""))
+		var found bool
+		for _, item := range searchResp.Results {
+			if item.Title != "Смартфон навигационный" {
+				continue
+			}
+			found = true
+			assert.Equal(t, "iphone", item.Category.ID)
+			require.Len(t, item.Category.Breadcrumb, 3)
+			assert.Equal(t, "smartphones", item.Category.Breadcrumb[1].ID)
+		}
+		assert.True(t, found)
+	})
+}
@@ -0,0 +1,109 @@
+package modules
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yaroslavvasilenko/argon/internal/models"
+	"github.com/yaroslavvasilenko/argon/internal/modules/listing"
+)
+
+func (user *user) createListingInLang(t *testing.T, l listing.CreateListingRequest, lang string) *http.Response {
+	body, err := json.Marshal(l)
+	require.NoError(t, err)
+	req := httptest.NewRequest("POST", "/api/v1/listing", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(models.HeaderLanguage, lang)
+
+	resp, err := user.fiber.Test(req, -1)
+	require.NoError(t, err)
+	return resp
+}
+
+func TestCategoryRules(t *testing.T) {
+	app := createTestApp(t)
+	defer app.cleanDb(t)
+
+	user := app.createUser(t)
+
+	newListing := func(categories []string, characteristics models.CharacteristicValue) listing.CreateListingRequest {
+		return listing.CreateListingRequest{
+			Title:      "Объявление с правилами категории",
+			Price:      1000,
+			Currency:   models.RUB,
+			Categories: categories,
+			Location: &models.Location{
+				ID:   uuid.New().String(),
+				Name: "Москва, Россия",
+				Area: models.Area{
+					Coordinates: models.Coordinates{Lat: 55.7558, Lng: 37.6173},
+					Radius:      10000,
+				},
+			},
+			Characteristics: characteristics,
+		}
+	}
+
+	fieldErrors := func(t *testing.T, resp *http.Response) []models.FieldError {
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		var body struct {
+			Code   string              `json:"code"`
+			Errors []models.FieldError `json:"errors"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, "ValidationFailed", body.Code)
+		return body.Errors
+	}
+
+	t.Run("Обязательные характеристики", func(t *testing.T) {
+		resp := user.createListingInLang(t, newListing([]string{"vehicles"}, models.CharacteristicValue{
+			"mileage": 1000,
+		}), "en")
+
+		errs := fieldErrors(t, resp)
+		require.Len(t, errs, 2)
+		assert.Equal(t, models.FieldError{
+			Field: "characteristics.model", Code: models.FieldErrorRequired, Message: "This characteristic is required",
+		}, errs[0])
+		assert.Equal(t, "characteristics.year", errs[1].Field)
+	})
+
+	t.Run("Границы и опции категории", func(t *testing.T) {
+		resp := user.createListingInLang(t, newListing([]string{"furniture"}, models.CharacteristicValue{
+			models.CHAR_BRAND:  models.DropdownOption{Value: "apple", Label: "Apple"},
+			models.CHAR_WEIGHT: models.Amount{Value: 600, Dimension: models.KG},
+		}), "ru")
+
+		errs := fieldErrors(t, resp)
+		require.Len(t, errs, 2)
+		assert.Equal(t, models.FieldErrorOption, errs[0].Code)
+		assert.Equal(t, models.FieldError{
+			Field: "characteristics.weight", Code: models.FieldErrorMax, Message: "Значение должно быть не больше 500 kg",
+		}, errs[1])
+	})
+
+	t.Run("Значение в другой единице сравнивается с границами", func(t *testing.T) {
+		resp := user.createListingInLang(t, newListing([]string{"furniture"}, models.CharacteristicValue{
+			models.CHAR_WEIGHT: models.Amount{Value: 300, Dimension: models.G},
+		}), "ru")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		params, err := user.getCharacteristicsForCategory(t, []string{"furniture"}, "ru")
+		require.NoError(t, err)
+		for _, item := range params {
+			if item.Role != models.CHAR_WEIGHT {
+				continue
+			}
+			weight, ok := item.Param.(map[string]interface{})
+			require.True(t, ok)
+			assert.EqualValues(t, 0.1, weight["min"])
+			assert.EqualValues(t, 500, weight["max"])
+		}
+	})
+}
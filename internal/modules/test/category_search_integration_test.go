@@ -0,0 +1,129 @@
+package modules
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yaroslavvasilenko/argon/internal/models"
+	"github.com/yaroslavvasilenko/argon/internal/modules/category"
+	"github.com/yaroslavvasilenko/argon/internal/modules/listing"
+)
+
+func TestCategorySearch(t *testing.T) {
+	app := createTestApp(t)
+	defer app.cleanDb(t)
+
+	user := app.createUser(t)
+
+	// Возвращаем таксономию из файлов, чтобы изменения не влияли на другие тесты.
+	// Категории с объявлениями импорт не удаляет, поэтому сначала очищаем объявления
+	defer func() {
+		app.cleanDb(t)
+		resp := user.adminRequest(t, "POST", "/api/v1/admin/categories/reload", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	}()
+
+	createListing := func(t *testing.T, title string, categories []string) uuid.UUID {
+		resp := user.createListing(t, listing.CreateListingRequest{
+			Title:    title,
+			Price:    700.0,
+			Currency: models.RUB,
+			Location: &models.Location{
+				ID:   uuid.New().String(),
+				Name: "Москва, Россия",
+				Area: models.Area{
+					Coordinates: models.Coordinates{Lat: 55.7558, Lng: 37.6173},
+					Radius:      10000,
+				},
+			},
+			Categories: categories,
+		})
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		return decodeFullListing(t, resp).ID
+	}
+
+	search := func(t *testing.T, categoryID string, exact bool) []uuid.UUID {
+		req := getSearchListingsRequest("", 50, "", "", "")
+		req.CategoryID = categoryID
+		req.ExactCategory = exact
+
+		var ids []uuid.UUID
+		for _, item := range user.searchListings(t, req).Results {
+			ids = append(ids, item.ItemID)
+		}
+		return ids
+	}
+
+	electronics := createListing(t, "Зарядное устройство", []string{"electronics"})
+	iphone := createListing(t, "Смартфон яблочный", []string{"iphone"})
+	clothing := createListing(t, "Куртка зимняя", []string{"clothing"})
+
+	t.Run("Поиск по категории включает подкатегории", func(t *testing.T) {
+		ids := search(t, "electronics", false)
+		assert.Contains(t, ids, electronics)
+		assert.Contains(t, ids, iphone, "iphone — подкатегория смартфонов в электронике")
+		assert.NotContains(t, ids, clothing)
+
+		ids = search(t, "smartphones", false)
+		assert.Contains(t, ids, iphone)
+		assert.NotContains(t, ids, electronics, "предок не попадает в выдачу подкатегории")
+	})
+
+	t.Run("Точное совпадение категории", func(t *testing.T) {
+		ids := search(t, "electronics", true)
+		assert.Contains(t, ids, electronics)
+		assert.NotContains(t, ids, iphone)
+	})
+
+	t.Run("Фильтры категории учитывают подкатегории", func(t *testing.T) {
+		resp := user.createListing(t, listing.CreateListingRequest{
+			Title:      "Смартфон яблочный большой",
+			Price:      1500,
+			Currency:   models.RUB,
+			Categories: []string{"iphone"},
+		})
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		// У smartphones нет своих объявлений, только объявления подкатегории iphone
+		filters, err := user.getFiltersForCategory(t, "smartphones", "")
+		require.NoError(t, err)
+		price, ok := filters.Filters.GetPriceFilter(models.CHAR_PRICE)
+		require.True(t, ok)
+		assert.Equal(t, models.PriceFilter{Min: 700, Max: 1500}, price)
+
+		req := httptest.NewRequest("GET", "/api/v1/categories/filters?category_id=smartphones&exact_category=true", nil)
+		resp, err = user.fiber.Test(req, -1)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var exact listing.GetFiltersForCategoryResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&exact))
+		assert.Empty(t, exact.Filters)
+	})
+
+	t.Run("Новая подкатегория сразу попадает в поиск предка", func(t *testing.T) {
+		smartphones := "smartphones"
+		resp := user.adminRequest(t, "POST", "/api/v1/admin/categories", category.CategoryRequest{
+			ID:       "foldables",
+			ParentID: &smartphones,
+			Names:    map[string]string{"ru": "Складные смартфоны", "en": "Foldables", "es": "Plegables"},
+		})
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		foldable := createListing(t, "Смартфон складной", []string{"foldables"})
+		assert.Contains(t, search(t, "electronics", false), foldable)
+
+		// После переноса ветки объявление ищется уже в новой
+		resp = user.adminRequest(t, "POST", "/api/v1/admin/categories/foldables/move", category.MoveCategoryRequest{
+			ParentID: strPtr("clothing"),
+		})
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.NotContains(t, search(t, "electronics", false), foldable)
+		assert.Contains(t, search(t, "clothing", false), foldable)
+	})
+}
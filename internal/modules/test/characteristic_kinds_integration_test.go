@@ -0,0 +1,125 @@
+package modules
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yaroslavvasilenko/argon/internal/models"
+	"github.com/yaroslavvasilenko/argon/internal/modules/listing"
+)
+
+func TestCharacteristicKinds(t *testing.T) {
+	app := createTestApp(t)
+	defer app.cleanDb(t)
+
+	user := app.createUser(t)
+
+	location := &models.Location{
+		ID:   uuid.New().String(),
+		Name: "Москва, Россия",
+		Area: models.Area{
+			Coordinates: models.Coordinates{Lat: 55.7558, Lng: 37.6173},
+			Radius:      10000,
+		},
+	}
+
+	resp := user.createListing(t, listing.CreateListingRequest{
+		Title:      "Тойота Королла",
+		Price:      1500000,
+		Currency:   models.RUB,
+		Location:   location,
+		Categories: []string{"vehicles"},
+		Characteristics: models.CharacteristicValue{
+			"model":   "Corolla",
+			"year":    2018,
+			"mileage": 85000,
+		},
+	})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = user.createListing(t, listing.CreateListingRequest{
+		Title:      "Квартира у парка",
+		Price:      50000,
+		Currency:   models.RUB,
+		Location:   location,
+		Categories: []string{"real_estate"},
+		Characteristics: models.CharacteristicValue{
+			"amenities":      []string{"parking", "balcony"},
+			"available_from": "2025-07-01",
+		},
+	})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	t.Run("Некорректные значения отклоняются", func(t *testing.T) {
+		invalid := []models.CharacteristicValue{
+			{"year": 1800},
+			{"year": 2018.5},
+			{"model": ""},
+			{"available_from": "01.07.2025"},
+			{"amenities": []string{"pool"}},
+		}
+		for _, characteristics := range invalid {
+			categories := []string{"vehicles"}
+			if _, ok := characteristics["amenities"]; ok {
+				categories = []string{"real_estate"}
+			}
+			if _, ok := characteristics["available_from"]; ok {
+				categories = []string{"real_estate"}
+			}
+			resp := user.createListing(t, listing.CreateListingRequest{
+				Title:           "Некорректное объявление",
+				Price:           1000,
+				Currency:        models.RUB,
+				Location:        location,
+				Categories:      categories,
+				Characteristics: characteristics,
+			})
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "значение %v", characteristics)
+		}
+	})
+
+	t.Run("Параметры характеристик", func(t *testing.T) {
+		params, err := user.getCharacteristicsForCategory(t, []string{"vehicles"}, "ru")
+		require.NoError(t, err)
+
+		byRole := make(map[string]interface{})
+		for _, item := range params {
+			byRole[item.Role] = item.Param
+		}
+		require.Contains(t, byRole, "year")
+		year, ok := byRole["year"].(map[string]interface{})
+		require.True(t, ok)
+		assert.EqualValues(t, 1900, year["min"])
+
+		model, ok := byRole["model"].(map[string]interface{})
+		require.True(t, ok)
+		assert.EqualValues(t, 50, model["max_length"])
+	})
+
+	t.Run("Фильтры по диапазону года и тексту", func(t *testing.T) {
+		req := getSearchListingsRequest("Тойота", 10, "", "", "")
+		req.Filters = models.FilterParams{
+			"year":  {Role: "year", Value: map[string]interface{}{"min": 2015, "max": 2020}},
+			"model": {Role: "model", Value: "coro"},
+		}
+		assert.NotEmpty(t, user.searchListings(t, req).Results)
+
+		req.Filters["year"] = models.FilterItem{Role: "year", Value: map[string]interface{}{"min": 2019}}
+		assert.Empty(t, user.searchListings(t, req).Results)
+	})
+
+	t.Run("Фильтры по удобствам и дате", func(t *testing.T) {
+		req := getSearchListingsRequest("Квартира", 10, "", "", "")
+		req.Filters = models.FilterParams{
+			"amenities":      {Role: "amenities", Value: []string{"parking"}},
+			"available_from": {Role: "available_from", Value: map[string]interface{}{"to": "2025-08-01"}},
+		}
+		assert.NotEmpty(t, user.searchListings(t, req).Results)
+
+		req.Filters["amenities"] = models.FilterItem{Role: "amenities", Value: []string{"elevator"}}
+		assert.Empty(t, user.searchListings(t, req).Results)
+	})
+}
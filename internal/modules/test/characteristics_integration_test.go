@@ -104,6 +104,31 @@ func TestGetCharacteristicsForCategory(t *testing.T) {
 		}
 	})
 
+	t.Run("Подкатегория наследует характеристики предков", func(t *testing.T) {
+		characteristics, err := user.getCharacteristicsForCategory(t, []string{"iphone"}, "ru")
+		require.NoError(t, err)
+
+		roles := make([]string, 0, len(characteristics))
+		for _, item := range characteristics {
+			roles = append(roles, item.Role)
+		}
+		// Сначала характеристики электроники, затем смартфонов
+		assert.Equal(t, []string{"brand", "condition", "stocked", "weight", "color", "height", "width", "depth", "volume"}, roles)
+	})
+
+	t.Run("Обязательные характеристики отмечены в схеме", func(t *testing.T) {
+		characteristics, err := user.getCharacteristicsForCategory(t, []string{"vehicles"}, "ru")
+		require.NoError(t, err)
+
+		required := make(map[string]bool)
+		for _, item := range characteristics {
+			required[item.Role] = item.Required
+		}
+		assert.True(t, required["year"])
+		assert.True(t, required["model"])
+		assert.False(t, required["color"])
+	})
+
 	t.Run("Получение характеристик для несуществующей категории", func(t *testing.T) {
 		// Подготавливаем входные данные с несуществующей категорией
 		categoryIds := []string{
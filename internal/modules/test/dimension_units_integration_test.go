@@ -0,0 +1,80 @@
+package modules
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yaroslavvasilenko/argon/internal/models"
+	"github.com/yaroslavvasilenko/argon/internal/modules/listing"
+)
+
+func (user *user) getFiltersInUnits(t *testing.T, categoryId, units string) *http.Response {
+	req := httptest.NewRequest("GET", fmt.Sprintf("/api/v1/categories/filters?category_id=%s&units=%s", categoryId, units), nil)
+
+	resp, err := user.fiber.Test(req, -1)
+	require.NoError(t, err)
+	return resp
+}
+
+func TestDimensionUnits(t *testing.T) {
+	app := createTestApp(t)
+	defer app.cleanDb(t)
+
+	user := app.createUser(t)
+
+	resp := user.createListing(t, listing.CreateListingRequest{
+		Title:      "Шкаф высокий",
+		Price:      20000,
+		Currency:   models.RUB,
+		Categories: []string{"furniture"},
+		Location: &models.Location{
+			ID:   uuid.New().String(),
+			Name: "Москва, Россия",
+			Area: models.Area{
+				Coordinates: models.Coordinates{Lat: 55.7558, Lng: 37.6173},
+				Radius:      10000,
+			},
+		},
+		Characteristics: models.CharacteristicValue{
+			models.CHAR_HEIGHT: models.Amount{Value: 2, Dimension: models.M},
+		},
+	})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	t.Run("Фильтр в сантиметрах находит объявление в метрах", func(t *testing.T) {
+		req := getSearchListingsRequest("Шкаф", 10, "", "", "")
+		req.Filters = models.FilterParams{
+			models.CHAR_HEIGHT: {Role: models.CHAR_HEIGHT, Value: map[string]interface{}{"min": 150, "max": 200, "dimension": "cm"}},
+		}
+		assert.NotEmpty(t, user.searchListings(t, req).Results)
+
+		req.Filters[models.CHAR_HEIGHT] = models.FilterItem{
+			Role:  models.CHAR_HEIGHT,
+			Value: map[string]interface{}{"max": 199, "dimension": "cm"},
+		}
+		assert.Empty(t, user.searchListings(t, req).Results)
+	})
+
+	t.Run("Диапазоны в запрошенной единице", func(t *testing.T) {
+		resp := user.getFiltersInUnits(t, "furniture", "height:cm")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var filters listing.GetFiltersForCategoryResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&filters))
+		height, ok := filters.Filters.GetDimensionFilter(models.CHAR_HEIGHT)
+		require.True(t, ok)
+		assert.Equal(t, "cm", height.Dimension)
+		assert.Equal(t, 200, height.Max)
+	})
+
+	t.Run("Недопустимая единица", func(t *testing.T) {
+		resp := user.getFiltersInUnits(t, "furniture", "height:kg")
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
@@ -20,6 +20,7 @@ import (
 	"github.com/yaroslavvasilenko/argon/internal/core/logger"
 	"github.com/yaroslavvasilenko/argon/internal/models"
 	"github.com/yaroslavvasilenko/argon/internal/modules"
+	istorage "github.com/yaroslavvasilenko/argon/internal/modules/image/storage"
 	"github.com/yaroslavvasilenko/argon/internal/modules/listing"
 	"github.com/yaroslavvasilenko/argon/internal/modules/listing/storage"
 	"github.com/yaroslavvasilenko/argon/internal/router"
@@ -41,8 +42,14 @@ func (app *TestApp) createUser(t *testing.T) *user {
 	}
 }
 
+// testAdmin идентификатор администратора, которому тесты разрешают изменять таксономию
+const testAdmin = "test-admin"
+
 func createTestApp(t *testing.T) *TestApp {
 	// Init configuration
+	t.Setenv("APP_ADMIN_ACTORS", testAdmin)
+	// Пословный словарь дает предсказуемые переводы для проверок
+	t.Setenv("APP_TRANSLATION_PROVIDER", "dictionary")
 	config.LoadConfig()
 
 	cfg := config.GetConfig()
@@ -56,8 +63,14 @@ func createTestApp(t *testing.T) *TestApp {
 	err = database.Migrate(cfg.DB.Url)
 	require.NoError(t, err)
 
-	storages := modules.NewStorages(cfg, gorm, pool, nil)
+	storages := modules.NewStorages(cfg, gorm, pool, istorage.NewMemory())
 	services := modules.NewServices(storages, pool, lg)
+	require.NoError(t, services.Category.Init(context.Background()))
+
+	// Фоновый перевод объявлений работает, пока идет тест
+	stopChan := make(chan struct{})
+	t.Cleanup(func() { close(stopChan) })
+	go services.Translation.TranslateListingsSync(stopChan)
 	controller := modules.NewControllers(services)
 	// init router
 	r := router.NewApiRouter(controller)
@@ -136,8 +149,9 @@ func (user *user) getCharacteristicsForCategory(t *testing.T, categoryIds []stri
 	// Декодируем ответ API
 	var response struct {
 		CharacteristicParams []struct {
-			Role  string      `json:"role"`
-			Param interface{} `json:"param"`
+			Role     string      `json:"role"`
+			Param    interface{} `json:"param"`
+			Required bool        `json:"required"`
 		} `json:"characteristic_params"`
 	}
 
@@ -148,8 +162,9 @@ func (user *user) getCharacteristicsForCategory(t *testing.T, categoryIds []stri
 	result := make([]models.CharacteristicParamItem, 0, len(response.CharacteristicParams))
 	for _, item := range response.CharacteristicParams {
 		result = append(result, models.CharacteristicParamItem{
-			Role:  item.Role,
-			Param: item.Param,
+			Role:     item.Role,
+			Param:    item.Param,
+			Required: item.Required,
 		})
 	}
 
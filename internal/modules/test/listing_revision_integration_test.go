@@ -0,0 +1,147 @@
+package modules
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yaroslavvasilenko/argon/internal/models"
+	"github.com/yaroslavvasilenko/argon/internal/modules/listing"
+)
+
+// Метод для изменения объявления от имени пользователя actor
+func (u *user) updateListing(t *testing.T, l listing.UpdateListingRequest, actor string) *http.Response {
+	body, err := json.Marshal(l)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest("PUT", fmt.Sprintf("/api/v1/listing/%s", l.ID), bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(models.HeaderActor, actor)
+
+	resp, err := u.fiber.Test(req, -1)
+	require.NoError(t, err)
+	return resp
+}
+
+func (u *user) getRevisions(t *testing.T, listingID uuid.UUID) listing.RevisionsResponse {
+	req := httptest.NewRequest("GET", fmt.Sprintf("/api/v1/listing/%s/revisions", listingID), nil)
+
+	resp, err := u.fiber.Test(req, -1)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var revisions listing.RevisionsResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&revisions))
+	return revisions
+}
+
+func (u *user) restoreRevision(t *testing.T, listingID uuid.UUID, revision int) *http.Response {
+	req := httptest.NewRequest("POST", fmt.Sprintf("/api/v1/listing/%s/revisions/%d/restore", listingID, revision), nil)
+
+	resp, err := u.fiber.Test(req, -1)
+	require.NoError(t, err)
+	return resp
+}
+
+func TestListingRevisions(t *testing.T) {
+	app := createTestApp(t)
+	defer app.cleanDb(t)
+
+	user := app.createUser(t)
+
+	location := &models.Location{
+		ID:   uuid.New().String(),
+		Name: "Москва, Россия",
+		Area: models.Area{
+			Coordinates: models.Coordinates{Lat: 55.7558, Lng: 37.6173},
+			Radius:      10000,
+		},
+	}
+
+	resp := user.createListing(t, listing.CreateListingRequest{
+		Title:      "Велосипед горный",
+		Price:      1000.0,
+		Currency:   models.RUB,
+		Location:   location,
+		Categories: []string{"electronics"},
+	})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	created := decodeFullListing(t, resp)
+	assert.False(t, created.PriceDropped)
+
+	t.Run("Первая ревизия создается вместе с объявлением", func(t *testing.T) {
+		revisions := user.getRevisions(t, created.ID)
+		require.Len(t, revisions.Items, 1)
+		assert.Equal(t, 1, revisions.Items[0].Revision)
+		assert.Contains(t, revisions.Items[0].Changes, "title")
+		assert.Equal(t, "Велосипед горный", revisions.Items[0].Snapshot.Title)
+	})
+
+	t.Run("Изменение сохраняет отличия и автора", func(t *testing.T) {
+		resp := user.updateListing(t, listing.UpdateListingRequest{
+			ID:         created.ID,
+			Title:      "Велосипед горный",
+			Price:      800.0,
+			Currency:   models.RUB,
+			Location:   *location,
+			Categories: []string{"electronics"},
+			Version:    created.Version,
+		}, "seller-1")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		revisions := user.getRevisions(t, created.ID)
+		require.Len(t, revisions.Items, 2)
+
+		last := revisions.Items[0]
+		assert.Equal(t, 2, last.Revision)
+		assert.Equal(t, "seller-1", last.Actor)
+		require.Contains(t, last.Changes, "price")
+		assert.EqualValues(t, 1000.0, last.Changes["price"].Old)
+		assert.EqualValues(t, 800.0, last.Changes["price"].New)
+		assert.NotContains(t, last.Changes, "title")
+	})
+
+	t.Run("Подешевевшее объявление отмечается в карточке и в поиске", func(t *testing.T) {
+		resp := user.listingAction(t, created.ID, "renew")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		l := decodeFullListing(t, resp)
+		assert.True(t, l.PriceDropped)
+		require.NotNil(t, l.PreviousPrice)
+		assert.Equal(t, 1000.0, *l.PreviousPrice)
+
+		searchResp := user.searchListings(t, getSearchListingsRequest("Велосипед", 20, "", "", ""))
+		var item *listing.ListingResponse
+		for i := range searchResp.Results {
+			if searchResp.Results[i].ItemID == created.ID {
+				item = &searchResp.Results[i]
+			}
+		}
+		require.NotNil(t, item)
+		assert.True(t, item.PriceDropped)
+	})
+
+	t.Run("Восстановление добавляет новую ревизию", func(t *testing.T) {
+		resp := user.restoreRevision(t, created.ID, 1)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		l := decodeFullListing(t, resp)
+		assert.Equal(t, 1000.0, l.Price)
+		assert.False(t, l.PriceDropped)
+
+		revisions := user.getRevisions(t, created.ID)
+		require.Len(t, revisions.Items, 3)
+		require.NotNil(t, revisions.Items[0].RestoredFrom)
+		assert.Equal(t, 1, *revisions.Items[0].RestoredFrom)
+		assert.Equal(t, 800.0, revisions.Items[1].Snapshot.Price)
+	})
+
+	t.Run("Восстановление несуществующей ревизии", func(t *testing.T) {
+		resp := user.restoreRevision(t, created.ID, 42)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+}
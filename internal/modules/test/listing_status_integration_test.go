@@ -0,0 +1,179 @@
+package modules
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yaroslavvasilenko/argon/internal/models"
+	"github.com/yaroslavvasilenko/argon/internal/modules/listing"
+)
+
+// Метод для перевода объявления в новое состояние: publish, pause, sold или renew
+func (u *user) listingAction(t *testing.T, listingID uuid.UUID, action string) *http.Response {
+	req := httptest.NewRequest("POST", fmt.Sprintf("/api/v1/listing/%s/%s", listingID, action), nil)
+
+	resp, err := u.fiber.Test(req, -1)
+	require.NoError(t, err)
+	return resp
+}
+
+// Метод для решения модератора: approve или reject
+func (u *user) moderateListing(t *testing.T, listingID uuid.UUID, action, reason string) *http.Response {
+	var body []byte
+	if reason != "" {
+		body, _ = json.Marshal(map[string]string{"reason": reason})
+	}
+
+	req := httptest.NewRequest("POST", fmt.Sprintf("/api/v1/admin/moderation/listings/%s/%s", listingID, action), bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(models.HeaderActor, testAdmin)
+
+	resp, err := u.fiber.Test(req, -1)
+	require.NoError(t, err)
+	return resp
+}
+
+func decodeFullListing(t *testing.T, resp *http.Response) listing.FullListingResponse {
+	var l listing.FullListingResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&l))
+	return l
+}
+
+func TestListingLifecycle(t *testing.T) {
+	app := createTestApp(t)
+	defer app.cleanDb(t)
+
+	user := app.createUser(t)
+
+	createListing := func(t *testing.T, title string, draft bool) listing.FullListingResponse {
+		resp := user.createListing(t, listing.CreateListingRequest{
+			Title:    title,
+			Price:    500.0,
+			Currency: models.RUB,
+			Location: &models.Location{
+				ID:   uuid.New().String(),
+				Name: "Москва, Россия",
+				Area: models.Area{
+					Coordinates: models.Coordinates{Lat: 55.7558, Lng: 37.6173},
+					Radius:      10000,
+				},
+			},
+			Categories: []string{"electronics"},
+			Draft:      draft,
+		})
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		return decodeFullListing(t, resp)
+	}
+
+	found := func(t *testing.T, title string, id uuid.UUID) bool {
+		searchResp := user.searchListings(t, getSearchListingsRequest(title, 20, "", "", ""))
+		for _, item := range searchResp.Results {
+			if item.ItemID == id {
+				return true
+			}
+		}
+		return false
+	}
+
+	t.Run("Черновик не виден в поиске до публикации", func(t *testing.T) {
+		created := createListing(t, "Фотоаппарат зеркальный", true)
+		assert.Equal(t, models.ListingStatusDraft, created.Status)
+		assert.False(t, found(t, "Фотоаппарат зеркальный", created.ID))
+
+		resp := user.listingAction(t, created.ID, "publish")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		published := decodeFullListing(t, resp)
+		assert.Equal(t, models.ListingStatusPublished, published.Status)
+		require.NotNil(t, published.ExpiresAt)
+		assert.True(t, found(t, "Фотоаппарат зеркальный", created.ID))
+	})
+
+	t.Run("Пауза, возврат и продажа", func(t *testing.T) {
+		created := createListing(t, "Велосипед горный", false)
+		assert.Equal(t, models.ListingStatusPublished, created.Status)
+
+		resp := user.listingAction(t, created.ID, "pause")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.False(t, found(t, "Велосипед горный", created.ID))
+
+		// Снятое объявление нельзя продлить
+		resp = user.listingAction(t, created.ID, "renew")
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+		resp = user.listingAction(t, created.ID, "publish")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, created.ExpiresAt, decodeFullListing(t, resp).ExpiresAt, "пауза не продлевает срок")
+
+		resp = user.listingAction(t, created.ID, "sold")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, models.ListingStatusSold, decodeFullListing(t, resp).Status)
+
+		// Проданное объявление больше не публикуется
+		resp = user.listingAction(t, created.ID, "publish")
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	})
+
+	t.Run("Истекшее объявление продлевается", func(t *testing.T) {
+		created := createListing(t, "Палатка туристическая", false)
+
+		_, err := app.pool.Exec(context.Background(),
+			`UPDATE listings SET expires_at = NOW() - INTERVAL '1 minute' WHERE id = $1`, created.ID)
+		require.NoError(t, err)
+		// Срок истек, даже если крон еще не сменил состояние
+		assert.False(t, found(t, "Палатка туристическая", created.ID))
+
+		resp := user.listingAction(t, created.ID, "renew")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.True(t, found(t, "Палатка туристическая", created.ID))
+	})
+
+	t.Run("Модерация", func(t *testing.T) {
+		approved := createListing(t, "Гитара акустическая", true)
+		rejected := createListing(t, "Гитара электрическая", true)
+		for _, id := range []uuid.UUID{approved.ID, rejected.ID} {
+			_, err := app.pool.Exec(context.Background(),
+				`UPDATE listings SET status = 'pending_review' WHERE id = $1`, id)
+			require.NoError(t, err)
+		}
+
+		req := httptest.NewRequest("GET", "/api/v1/admin/moderation/listings", nil)
+		resp, err := user.fiber.Test(req, -1)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusUnauthorized, resp.StatusCode, "очередь модерации доступна только администраторам")
+
+		req = httptest.NewRequest("GET", "/api/v1/admin/moderation/listings", nil)
+		req.Header.Set(models.HeaderActor, testAdmin)
+		resp, err = user.fiber.Test(req, -1)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var queue listing.ModerationQueueResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&queue))
+		assert.Len(t, queue.Items, 2)
+
+		resp = user.moderateListing(t, approved.ID, "approve", "")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, models.ListingStatusPublished, decodeFullListing(t, resp).Status)
+
+		// Причина отклонения обязательна
+		resp = user.moderateListing(t, rejected.ID, "reject", "")
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		resp = user.moderateListing(t, rejected.ID, "reject", "Фото не соответствует товару")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		draft := decodeFullListing(t, resp)
+		assert.Equal(t, models.ListingStatusDraft, draft.Status)
+		assert.Equal(t, "Фото не соответствует товару", draft.ModerationReason)
+
+		// Повторное решение по уже обработанному объявлению невозможно
+		resp = user.moderateListing(t, approved.ID, "approve", "")
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	})
+}
@@ -0,0 +1,161 @@
+package modules
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yaroslavvasilenko/argon/internal/models"
+	"github.com/yaroslavvasilenko/argon/internal/modules/listing"
+)
+
+func (u *user) getListingInLang(t *testing.T, listingID uuid.UUID, lang string) listing.FullListingResponse {
+	req := httptest.NewRequest("GET", fmt.Sprintf("/api/v1/listing/%s", listingID), nil)
+	req.Header.Set(models.HeaderLanguage, lang)
+
+	resp, err := u.fiber.Test(req, -1)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	return decodeFullListing(t, resp)
+}
+
+func (u *user) updateListingInLang(t *testing.T, l listing.UpdateListingRequest, lang string) *http.Response {
+	body, err := json.Marshal(l)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest("PUT", fmt.Sprintf("/api/v1/listing/%s", l.ID), bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(models.HeaderLanguage, lang)
+
+	resp, err := u.fiber.Test(req, -1)
+	require.NoError(t, err)
+	return resp
+}
+
+func TestListingTranslation(t *testing.T) {
+	app := createTestApp(t)
+	defer app.cleanDb(t)
+
+	user := app.createUser(t)
+
+	location := &models.Location{
+		ID:   uuid.New().String(),
+		Name: "Москва, Россия",
+		Area: models.Area{
+			Coordinates: models.Coordinates{Lat: 55.7558, Lng: 37.6173},
+			Radius:      10000,
+		},
+	}
+
+	resp := user.createListingInLang(t, listing.CreateListingRequest{
+		Title:       "Велосипед горный",
+		Description: "Почти новый велосипед",
+		Price:       1000.0,
+		Currency:    models.RUB,
+		Location:    location,
+		Categories:  []string{"electronics"},
+	}, "ru")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	created := decodeFullListing(t, resp)
+
+	// translated ждет, пока фоновый перевод на язык lang отдаст заголовок title
+	translated := func(t *testing.T, lang, title string) listing.FullListingResponse {
+		var l listing.FullListingResponse
+		require.Eventually(t, func() bool {
+			l = user.getListingInLang(t, created.ID, lang)
+			return l.Title == title
+		}, 5*time.Second, 50*time.Millisecond)
+		return l
+	}
+
+	t.Run("На языке автора объявление не переводится", func(t *testing.T) {
+		l := user.getListingInLang(t, created.ID, "ru")
+		assert.Equal(t, "Велосипед горный", l.Title)
+		assert.Equal(t, models.LanguageRu, l.Language)
+		assert.Equal(t, models.LanguageRu, l.OriginalLanguage)
+		assert.Equal(t, "Почти новый велосипед", l.OriginalDescription)
+	})
+
+	t.Run("Перевод на язык запроса с оригиналом рядом", func(t *testing.T) {
+		l := translated(t, "en", "Bicycle mountain")
+		assert.Equal(t, "Almost new bicycle", l.Description)
+		assert.Equal(t, models.LanguageEn, l.Language)
+		assert.Equal(t, "Велосипед горный", l.OriginalTitle)
+		assert.Equal(t, "Почти новый велосипед", l.OriginalDescription)
+		assert.Equal(t, models.LanguageRu, l.OriginalLanguage)
+
+		l = translated(t, "es", "Bicicleta de montaña")
+		assert.Equal(t, models.LanguageEs, l.Language)
+	})
+
+	t.Run("Выдача поиска на языке запроса", func(t *testing.T) {
+		body, err := json.Marshal(getSearchListingsRequest("", 10, "", "", ""))
+		require.NoError(t, err)
+
+		req := httptest.NewRequest("POST", "/api/v1/search", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(models.HeaderLanguage, "en")
+		resp, err := user.fiber.Test(req, -1)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var search listing.SearchListingsResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&search))
+		require.Len(t, search.Results, 1)
+		assert.Equal(t, "Bicycle mountain", search.Results[0].Title)
+		assert.Equal(t, "Almost new bicycle", search.Results[0].Description)
+	})
+
+	t.Run("Переведенное объявление не попадает в периодическую проверку", func(t *testing.T) {
+		require.Eventually(t, func() bool {
+			var done bool
+			err := app.pool.QueryRow(context.Background(),
+				`SELECT translated FROM listings WHERE id = $1`, created.ID).Scan(&done)
+			return err == nil && done
+		}, 5*time.Second, 50*time.Millisecond)
+	})
+
+	t.Run("Перевод прежнего текста не отдается после изменения", func(t *testing.T) {
+		current := user.getListingInLang(t, created.ID, "ru")
+		resp := user.updateListingInLang(t, listing.UpdateListingRequest{
+			ID:         created.ID,
+			Title:      "Куртка зимняя",
+			Price:      900.0,
+			Currency:   models.RUB,
+			Location:   *location,
+			Categories: []string{"electronics"},
+			Version:    current.Version,
+		}, "ru")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		l := user.getListingInLang(t, created.ID, "en")
+		assert.Contains(t, []string{"Куртка зимняя", "Jacket winter"}, l.Title)
+
+		translated(t, "en", "Jacket winter")
+	})
+
+	t.Run("Изменение цены не меняет язык автора", func(t *testing.T) {
+		current := user.getListingInLang(t, created.ID, "ru")
+		resp := user.updateListingInLang(t, listing.UpdateListingRequest{
+			ID:         created.ID,
+			Title:      current.OriginalTitle,
+			Price:      800.0,
+			Currency:   models.RUB,
+			Location:   *location,
+			Categories: []string{"electronics"},
+			Version:    current.Version,
+		}, "es")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		l := translated(t, "es", "Chaqueta de invierno")
+		assert.Equal(t, models.LanguageRu, l.OriginalLanguage)
+	})
+}
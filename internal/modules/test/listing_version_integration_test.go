@@ -0,0 +1,150 @@
+package modules
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yaroslavvasilenko/argon/internal/models"
+	"github.com/yaroslavvasilenko/argon/internal/modules/listing"
+)
+
+// Метод для изменения объявления с условием If-Match
+func (u *user) updateListingIfMatch(t *testing.T, l listing.UpdateListingRequest, ifMatch string) *http.Response {
+	body, err := json.Marshal(l)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest("PUT", fmt.Sprintf("/api/v1/listing/%s", l.ID), bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if ifMatch != "" {
+		req.Header.Set("If-Match", ifMatch)
+	}
+
+	resp, err := u.fiber.Test(req, -1)
+	require.NoError(t, err)
+	return resp
+}
+
+func TestListingOptimisticLocking(t *testing.T) {
+	app := createTestApp(t)
+	defer app.cleanDb(t)
+
+	user := app.createUser(t)
+
+	location := &models.Location{
+		ID:   uuid.New().String(),
+		Name: "Москва, Россия",
+		Area: models.Area{
+			Coordinates: models.Coordinates{Lat: 55.7558, Lng: 37.6173},
+			Radius:      10000,
+		},
+	}
+
+	resp := user.createListing(t, listing.CreateListingRequest{
+		Title:      "Диван угловой",
+		Price:      20000.0,
+		Currency:   models.RUB,
+		Location:   location,
+		Categories: []string{"electronics"},
+	})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	created := decodeFullListing(t, resp)
+
+	update := func(title string) listing.UpdateListingRequest {
+		return listing.UpdateListingRequest{
+			ID:         created.ID,
+			Title:      title,
+			Price:      20000.0,
+			Currency:   models.RUB,
+			Location:   *location,
+			Categories: []string{"electronics"},
+		}
+	}
+
+	t.Run("GET возвращает ETag с версией", func(t *testing.T) {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/v1/listing/%s", created.ID), nil)
+		resp, err := user.fiber.Test(req, -1)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		l := decodeFullListing(t, resp)
+		assert.Equal(t, 1, l.Version)
+		assert.Equal(t, `"1"`, resp.Header.Get("ETag"))
+	})
+
+	t.Run("Изменение без версии отклоняется", func(t *testing.T) {
+		resp := user.updateListingIfMatch(t, update("Диван"), "")
+		assert.Equal(t, http.StatusPreconditionRequired, resp.StatusCode)
+	})
+
+	t.Run("Изменение с актуальной версией", func(t *testing.T) {
+		resp := user.updateListingIfMatch(t, update("Диван угловой раскладной"), `"1"`)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		l := decodeFullListing(t, resp)
+		assert.Equal(t, 2, l.Version)
+		assert.Equal(t, `"2"`, resp.Header.Get("ETag"))
+	})
+
+	t.Run("Устаревшая версия возвращает 409 с текущим состоянием", func(t *testing.T) {
+		req := update("Диван прямой")
+		req.Version = 1
+
+		resp := user.updateListingIfMatch(t, req, "")
+		require.Equal(t, http.StatusConflict, resp.StatusCode)
+
+		var conflict struct {
+			Code    string                      `json:"code"`
+			Current listing.FullListingResponse `json:"current"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&conflict))
+		assert.Equal(t, "Conflict", conflict.Code)
+		assert.Equal(t, 2, conflict.Current.Version)
+		assert.Equal(t, "Диван угловой раскладной", conflict.Current.Title)
+	})
+
+	t.Run("Смена состояния увеличивает версию", func(t *testing.T) {
+		resp := user.listingAction(t, created.ID, "pause")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, 3, decodeFullListing(t, resp).Version)
+
+		resp = user.updateListingIfMatch(t, update("Диван"), `"2"`)
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	})
+
+	t.Run("Некорректный If-Match", func(t *testing.T) {
+		resp := user.updateListingIfMatch(t, update("Диван"), `W/"3"`)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Изменение изображений увеличивает версию и учитывает If-Match", func(t *testing.T) {
+		reorder := func(ifMatch string) *http.Response {
+			body, err := json.Marshal(listing.ReorderImagesRequest{ImageIDs: []uuid.UUID{}})
+			require.NoError(t, err)
+
+			req := httptest.NewRequest("PUT", fmt.Sprintf("/api/v1/listing/%s/images/order", created.ID), bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("If-Match", ifMatch)
+
+			resp, err := user.fiber.Test(req, -1)
+			require.NoError(t, err)
+			return resp
+		}
+
+		resp := reorder(`"3"`)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, `"4"`, resp.Header.Get("ETag"))
+
+		resp = reorder(`"3"`)
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+		resp = user.updateListingIfMatch(t, update("Диван"), `"3"`)
+		assert.Equal(t, http.StatusConflict, resp.StatusCode, "изменение поверх версии до перестановки изображений отклоняется")
+	})
+}
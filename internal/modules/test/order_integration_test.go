@@ -0,0 +1,178 @@
+package modules
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yaroslavvasilenko/argon/internal/models"
+	"github.com/yaroslavvasilenko/argon/internal/modules/listing"
+	"github.com/yaroslavvasilenko/argon/internal/modules/order"
+)
+
+// testBuyer пользователь, от имени которого создаются заказы
+const testBuyer = "buyer-1"
+
+// Метод для создания заказа на объявление
+func (u *user) createOrder(t *testing.T, listingID uuid.UUID) *http.Response {
+	body, err := json.Marshal(order.CreateOrderRequest{ListingID: listingID})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest("POST", "/api/v1/orders", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(models.HeaderActor, testBuyer)
+
+	resp, err := u.fiber.Test(req, -1)
+	require.NoError(t, err)
+	return resp
+}
+
+// Метод для перевода заказа в следующее состояние: pay, ship, complete или cancel
+func (u *user) orderAction(t *testing.T, orderID uuid.UUID, action string) *http.Response {
+	req := httptest.NewRequest("POST", fmt.Sprintf("/api/v1/orders/%s/%s", orderID, action), nil)
+
+	resp, err := u.fiber.Test(req, -1)
+	require.NoError(t, err)
+	return resp
+}
+
+func decodeOrder(t *testing.T, resp *http.Response) order.OrderResponse {
+	var o order.OrderResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&o))
+	return o
+}
+
+func TestOrderController(t *testing.T) {
+	app := createTestApp(t)
+	defer app.cleanDb(t)
+
+	user := app.createUser(t)
+
+	createListing := func(t *testing.T, boosts []models.BoostType) uuid.UUID {
+		resp := user.createListing(t, listing.CreateListingRequest{
+			Title:       "Смартфон для заказа",
+			Description: "Новый смартфон в наличии",
+			Price:       1000.0,
+			Currency:    models.RUB,
+			Location: &models.Location{
+				ID:   uuid.New().String(),
+				Name: "Москва, Россия",
+				Area: models.Area{
+					Coordinates: models.Coordinates{Lat: 55.7558, Lng: 37.6173},
+					Radius:      10000,
+				},
+			},
+			Categories: []string{"electronics"},
+			Characteristics: models.CharacteristicValue{
+				"stocked": models.CheckboxValue{CheckboxValue: true},
+			},
+		})
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var createResp listing.CreateListingResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&createResp))
+
+		if len(boosts) > 0 {
+			resp, err := user.updateBoost(t, createResp.ID, boosts)
+			require.NoError(t, err)
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+		}
+
+		return createResp.ID
+	}
+
+	t.Run("Объявление без буста upfront купить нельзя", func(t *testing.T) {
+		listingID := createListing(t, []models.BoostType{models.BoostTypeHighlight})
+
+		resp := user.createOrder(t, listingID)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Снятое с публикации объявление купить нельзя", func(t *testing.T) {
+		listingID := createListing(t, []models.BoostType{models.BoostTypeUpfront})
+
+		resp := user.listingAction(t, listingID, "pause")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp = user.createOrder(t, listingID)
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	})
+
+	t.Run("Полный цикл заказа", func(t *testing.T) {
+		listingID := createListing(t, []models.BoostType{models.BoostTypeHighlight, models.BoostTypeUpfront})
+		commissions := defaultBoostCommissions()
+
+		resp := user.createOrder(t, listingID)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		created := decodeOrder(t, resp)
+		assert.Equal(t, models.OrderStatusPending, created.Status)
+		assert.Equal(t, models.Actor(testBuyer), created.Buyer)
+		assert.Equal(t, 1000.0, created.Amount)
+		assert.Equal(t, models.RUB, created.Currency)
+		assert.NotEmpty(t, created.PaymentID)
+		// Комиссия заказа складывается из комиссий действующих бустов
+		expectedPercent := commissions[models.BoostTypeHighlight] + commissions[models.BoostTypeUpfront]
+		assert.InDelta(t, expectedPercent, created.CommissionPercent, 1e-9)
+		assert.InDelta(t, 1000.0*expectedPercent, created.CommissionAmount, 0.005)
+
+		// Товар зарезервирован: второй заказ невозможен
+		resp = user.createOrder(t, listingID)
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+		// Нельзя отправить неоплаченный заказ
+		resp = user.orderAction(t, created.ID, "ship")
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+		for _, step := range []struct {
+			action string
+			status models.OrderStatus
+		}{
+			{"pay", models.OrderStatusPaid},
+			{"ship", models.OrderStatusShipped},
+			{"complete", models.OrderStatusCompleted},
+		} {
+			resp = user.orderAction(t, created.ID, step.action)
+			require.Equal(t, http.StatusOK, resp.StatusCode, step.action)
+			assert.Equal(t, step.status, decodeOrder(t, resp).Status)
+		}
+
+		// Завершенный заказ отменить нельзя
+		resp = user.orderAction(t, created.ID, "cancel")
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	})
+
+	t.Run("Отмена заказа снимает резерв", func(t *testing.T) {
+		listingID := createListing(t, []models.BoostType{models.BoostTypeUpfront})
+
+		resp := user.createOrder(t, listingID)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		created := decodeOrder(t, resp)
+
+		resp = user.orderAction(t, created.ID, "pay")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp = user.orderAction(t, created.ID, "cancel")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		cancelled := decodeOrder(t, resp)
+		assert.Equal(t, models.OrderStatusCancelled, cancelled.Status)
+		assert.NotNil(t, cancelled.CancelledAt)
+
+		// Товар снова в наличии
+		resp = user.createOrder(t, listingID)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	})
+
+	t.Run("Несуществующий заказ", func(t *testing.T) {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/v1/orders/%s", uuid.New()), nil)
+		resp, err := user.fiber.Test(req, -1)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+}
@@ -0,0 +1,62 @@
+package modules
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yaroslavvasilenko/argon/internal/models"
+	"github.com/yaroslavvasilenko/argon/internal/modules/listing"
+)
+
+func TestPromotedSlots(t *testing.T) {
+	app := createTestApp(t)
+	app.cleanDb(t)
+	defer app.cleanDb(t)
+	user := app.createUser(t)
+
+	// Продвигаемых объявлений больше, чем мест на странице, а органической выдачи нет вовсе
+	boosted := make(map[uuid.UUID]struct{})
+	for i := 0; i < 5; i++ {
+		resp := user.createListing(t, listing.CreateListingRequest{
+			Title:       fmt.Sprintf("Велосипед горный %d", i),
+			Description: "Горный велосипед с продвижением",
+			Price:       10000,
+			Currency:    models.RUB,
+		})
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var created listing.CreateListingResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+
+		resp, err := user.updateBoost(t, created.ID, []models.BoostType{models.BoostTypeUpfront})
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		boosted[created.ID] = struct{}{}
+	}
+
+	t.Run("Все продвигаемые объявления попадают в выдачу после конца органики", func(t *testing.T) {
+		seen := make(map[uuid.UUID]struct{})
+		cursor := ""
+		for page := 0; page < 5; page++ {
+			resp := user.searchListings(t, getSearchListingsRequest("Велосипед", 3, cursor, "relevance", ""))
+			for _, item := range resp.Results {
+				assert.True(t, item.IsPromoted, "объявление %s должно быть продвигаемым", item.ItemID)
+				_, repeated := seen[item.ItemID]
+				assert.False(t, repeated, "объявление %s повторяется в выдаче", item.ItemID)
+				seen[item.ItemID] = struct{}{}
+			}
+
+			if resp.CursorAfter == nil {
+				break
+			}
+			cursor = *resp.CursorAfter
+		}
+
+		assert.Equal(t, boosted, seen)
+	})
+}
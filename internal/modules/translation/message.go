@@ -0,0 +1,13 @@
+package translation
+
+import "github.com/yaroslavvasilenko/argon/internal/models"
+
+// ListingText заголовок и описание объявления на языке ответа
+type ListingText struct {
+	// Lang язык текста: запрошенный, если перевод готов, иначе язык автора
+	Lang        models.Localization
+	Title       string
+	Description string
+	// Translated текст переведен с языка автора
+	Translated bool
+}
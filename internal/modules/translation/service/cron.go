@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// translationTimeout время на перевод одного объявления
+	translationTimeout = time.Minute
+	// untranslatedCheckInterval период поиска непереведенных объявлений: они остаются
+	// после переполнения очереди, ошибок провайдера и перезапуска сервиса
+	untranslatedCheckInterval = 5 * time.Minute
+	// untranslatedBatch число объявлений, переводимых за одну проверку
+	untranslatedBatch = 100
+)
+
+// TranslateListingsSync переводит объявления из очереди и периодически
+// находит объявления без актуального перевода
+func (s *Translation) TranslateListingsSync(stopChan chan struct{}) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.logger.Errorf("Panic in TranslateListingsSync: %v", r)
+		}
+	}()
+
+	s.logger.Infof("Starting listing translation task")
+
+	check := time.After(0)
+	for {
+		select {
+		case <-stopChan:
+			s.logger.Infof("Listing translation task received stop signal")
+			return
+		case listingID := <-s.queue:
+			s.translate(listingID)
+		case <-check:
+			s.translateUntranslated()
+			check = time.After(untranslatedCheckInterval)
+		}
+	}
+}
+
+// translate переводит одно объявление, не прерывая задачу при ошибке или панике
+func (s *Translation) translate(listingID uuid.UUID) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.logger.Errorf("Panic in listing translation %s: %v", listingID, r)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), translationTimeout)
+	defer cancel()
+
+	if err := s.TranslateListing(ctx, listingID); err != nil {
+		s.logger.Errorf("Failed to translate listing %s: %v", listingID, err)
+
+		// Попытка учитывается отдельно: контекст перевода мог уже истечь
+		failCtx, failCancel := context.WithTimeout(context.Background(), translationTimeout)
+		defer failCancel()
+		if err := s.s.RecordFailure(failCtx, listingID); err != nil {
+			s.logger.Errorf("Failed to record translation failure of listing %s: %v", listingID, err)
+		}
+	}
+}
+
+func (s *Translation) translateUntranslated() {
+	ctx, cancel := context.WithTimeout(context.Background(), translationTimeout)
+	defer cancel()
+
+	ids, err := s.s.FindUntranslated(ctx, untranslatedBatch)
+	if err != nil {
+		s.logger.Errorf("Failed to find untranslated listings: %v", err)
+		return
+	}
+
+	for _, id := range ids {
+		s.translate(id)
+	}
+	if len(ids) > 0 {
+		s.logger.Infof("Listings translated: %d", len(ids))
+	}
+}
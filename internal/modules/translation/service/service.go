@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/yaroslavvasilenko/argon/config"
+	"github.com/yaroslavvasilenko/argon/internal/core/logger"
+	"github.com/yaroslavvasilenko/argon/internal/models"
+	"github.com/yaroslavvasilenko/argon/internal/modules/translation"
+	"github.com/yaroslavvasilenko/argon/internal/modules/translation/storage"
+)
+
+// defaultQueueSize размер очереди фонового перевода по умолчанию
+const defaultQueueSize = 1000
+
+// Translation переводит заголовки и описания объявлений на языки интерфейса. Перевод
+// выполняется в фоне после создания и изменения объявления, а до его готовности
+// объявление отдается на языке автора
+type Translation struct {
+	s          *storage.Translation
+	translator storage.Translator
+	queue      chan uuid.UUID
+
+	logger *logger.Glog
+}
+
+func NewTranslation(s *storage.Translation, translator storage.Translator, cfg config.Config, logger *logger.Glog) *Translation {
+	queueSize := cfg.Translation.QueueSize
+	if queueSize <= 0 {
+		queueSize = defaultQueueSize
+	}
+
+	return &Translation{
+		s:          s,
+		translator: translator,
+		queue:      make(chan uuid.UUID, queueSize),
+		logger:     logger,
+	}
+}
+
+// Enqueue ставит объявление в очередь фонового перевода. При переполненной очереди
+// объявление переведет периодическая проверка непереведенных объявлений
+func (s *Translation) Enqueue(listingID uuid.UUID) {
+	select {
+	case s.queue <- listingID:
+	default:
+		s.logger.Infof("Translation queue is full, listing %s is left for the periodic check", listingID)
+	}
+}
+
+// TranslateListing переводит объявление на все языки интерфейса, кроме языка автора,
+// и отмечает его переведенным. Актуальные переводы не запрашиваются у провайдера повторно.
+// Провайдер echo текст не переводит, поэтому его результат не сохраняется: объявление
+// отмечается переведенным без переводов и отдается на языке автора
+func (s *Translation) TranslateListing(ctx context.Context, listingID uuid.UUID) error {
+	source, err := s.s.GetListingSource(ctx, listingID)
+	if err != nil {
+		return err
+	}
+	hash := storage.SourceHash(source.Title, source.Description)
+
+	if s.translator.Name() == storage.EchoProvider {
+		return s.s.MarkTranslated(ctx, listingID, hash)
+	}
+
+	for lang := range models.LocalMap {
+		if lang == source.Lang {
+			continue
+		}
+
+		_, found, err := s.s.GetTranslation(ctx, listingID, lang, hash)
+		if err != nil {
+			return err
+		}
+		if found {
+			continue
+		}
+
+		texts, err := s.translator.Translate(ctx, []string{source.Title, source.Description}, source.Lang, lang)
+		if err != nil {
+			return err
+		}
+
+		err = s.s.SaveTranslation(ctx, listingID, hash, storage.ListingTranslation{
+			Lang:        lang,
+			Title:       texts[0],
+			Description: texts[1],
+			Provider:    s.translator.Name(),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.s.MarkTranslated(ctx, listingID, hash)
+}
+
+// Localize возвращает текст объявления на языке lang. Если перевода еще нет или он сделан
+// для прежнего текста, возвращается текст автора
+func (s *Translation) Localize(ctx context.Context, listing models.Listing, lang models.Localization) (translation.ListingText, error) {
+	original := translation.ListingText{
+		Lang:        listing.OriginalLang,
+		Title:       listing.Title,
+		Description: listing.Description,
+	}
+	if lang == listing.OriginalLang {
+		return original, nil
+	}
+
+	translated, found, err := s.s.GetTranslation(ctx, listing.ID, lang, storage.SourceHash(listing.Title, listing.Description))
+	if err != nil || !found {
+		return original, err
+	}
+
+	return translation.ListingText{
+		Lang:        lang,
+		Title:       translated.Title,
+		Description: translated.Description,
+		Translated:  true,
+	}, nil
+}
+
+// LocalizeResults заменяет заголовки и описания объявлений выдачи переводами на язык lang.
+// Переводы всей страницы загружаются одним запросом; объявления без готового перевода
+// остаются на языке автора
+func (s *Translation) LocalizeResults(ctx context.Context, results []models.ListingResult, lang models.Localization) error {
+	hashes := make(map[uuid.UUID]string, len(results))
+	for _, result := range results {
+		hashes[result.Listing.ID] = storage.SourceHash(result.Listing.Title, result.Listing.Description)
+	}
+
+	translations, err := s.s.GetTranslations(ctx, hashes, lang)
+	if err != nil {
+		return err
+	}
+
+	for i := range results {
+		if translated, ok := translations[results[i].Listing.ID]; ok {
+			results[i].Listing.Title = translated.Title
+			results[i].Listing.Description = translated.Description
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/yaroslavvasilenko/argon/config"
+	"github.com/yaroslavvasilenko/argon/internal/models"
+)
+
+// Translator провайдер перевода текстов объявлений
+type Translator interface {
+	// Name имя провайдера; сохраняется вместе с переводом
+	Name() string
+	// Translate переводит тексты с языка from на язык to. Порядок переводов совпадает с texts
+	Translate(ctx context.Context, texts []string, from, to models.Localization) ([]string, error)
+}
+
+// NewTranslator создает провайдера перевода из конфигурации
+func NewTranslator(cfg config.Config) Translator {
+	switch cfg.Translation.Provider {
+	case EchoProvider:
+		return Echo{}
+	case "dictionary":
+		path, err := config.ProjectPath(cfg.Translation.Dictionary)
+		if err != nil {
+			panic(err)
+		}
+		dictionary, err := LoadDictionary(path)
+		if err != nil {
+			panic(err)
+		}
+		return dictionary
+	}
+
+	panic("необходимо настроить провайдера перевода: translation.provider = echo или dictionary")
+}
+
+// EchoProvider имя провайдера Echo
+const EchoProvider = "echo"
+
+// Echo провайдер, возвращающий текст без изменений. Подходит, когда перевод не нужен
+type Echo struct{}
+
+func (Echo) Name() string {
+	return EchoProvider
+}
+
+func (Echo) Translate(_ context.Context, texts []string, _, _ models.Localization) ([]string, error) {
+	return append([]string(nil), texts...), nil
+}
+
+// wordPattern слово текста: буквы, цифры и апострофы
+var wordPattern = regexp.MustCompile(`[\p{L}\p{N}']+`)
+
+// Dictionary провайдер, переводящий текст по словам по локальному словарю. Слова без
+// перевода остаются как есть, а заглавная первая буква слова сохраняется
+type Dictionary struct {
+	// words переводы слов в нижнем регистре: язык оригинала → язык перевода → слово
+	words map[models.Localization]map[models.Localization]map[string]string
+}
+
+// NewDictionary создает словарь; ключи приводятся к нижнему регистру
+func NewDictionary(words map[models.Localization]map[models.Localization]map[string]string) *Dictionary {
+	d := &Dictionary{words: make(map[models.Localization]map[models.Localization]map[string]string, len(words))}
+	for from, targets := range words {
+		d.words[from] = make(map[models.Localization]map[string]string, len(targets))
+		for to, entries := range targets {
+			lower := make(map[string]string, len(entries))
+			for word, translation := range entries {
+				lower[strings.ToLower(word)] = translation
+			}
+			d.words[from][to] = lower
+		}
+	}
+	return d
+}
+
+// LoadDictionary читает словарь из JSON вида {"ru": {"en": {"слово": "word"}}}
+func LoadDictionary(path string) (*Dictionary, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения словаря перевода %s: %w", path, err)
+	}
+
+	var words map[models.Localization]map[models.Localization]map[string]string
+	if err := json.Unmarshal(data, &words); err != nil {
+		return nil, fmt.Errorf("ошибка разбора словаря перевода %s: %w", path, err)
+	}
+
+	return NewDictionary(words), nil
+}
+
+func (d *Dictionary) Name() string {
+	return "dictionary"
+}
+
+func (d *Dictionary) Translate(_ context.Context, texts []string, from, to models.Localization) ([]string, error) {
+	words := d.words[from][to]
+
+	translations := make([]string, 0, len(texts))
+	for _, text := range texts {
+		translations = append(translations, wordPattern.ReplaceAllStringFunc(text, func(word string) string {
+			translation, ok := words[strings.ToLower(word)]
+			if !ok {
+				return word
+			}
+			if first, _ := utf8.DecodeRuneInString(word); unicode.IsUpper(first) {
+				return capitalize(translation)
+			}
+			return translation
+		}))
+	}
+	return translations, nil
+}
+
+func capitalize(s string) string {
+	first, size := utf8.DecodeRuneInString(s)
+	if size == 0 {
+		return s
+	}
+	return string(unicode.ToUpper(first)) + s[size:]
+}
@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yaroslavvasilenko/argon/config"
+	"github.com/yaroslavvasilenko/argon/internal/models"
+)
+
+func TestDictionary(t *testing.T) {
+	dictionary := NewDictionary(map[models.Localization]map[models.Localization]map[string]string{
+		models.LanguageRu: {models.LanguageEn: {"Велосипед": "bicycle", "горный": "mountain", "новый": "new"}},
+	})
+
+	t.Run("Слова переводятся с сохранением заглавной буквы", func(t *testing.T) {
+		texts, err := dictionary.Translate(context.Background(),
+			[]string{"Велосипед горный", "Почти новый велосипед, 2 года."}, models.LanguageRu, models.LanguageEn)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Bicycle mountain", "Почти new bicycle, 2 года."}, texts)
+	})
+
+	t.Run("Без словаря для пары языков текст не меняется", func(t *testing.T) {
+		texts, err := dictionary.Translate(context.Background(), []string{"Велосипед"}, models.LanguageRu, models.LanguageEs)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Велосипед"}, texts)
+	})
+
+	t.Run("Словарь проекта", func(t *testing.T) {
+		path, err := config.ProjectPath("translations", "dictionary.json")
+		require.NoError(t, err)
+		dictionary, err := LoadDictionary(path)
+		require.NoError(t, err)
+
+		texts, err := dictionary.Translate(context.Background(), []string{"Куртка зимняя"}, models.LanguageRu, models.LanguageEs)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Chaqueta de invierno"}, texts)
+	})
+}
+
+func TestEcho(t *testing.T) {
+	texts, err := Echo{}.Translate(context.Background(), []string{"Велосипед", ""}, models.LanguageRu, models.LanguageEn)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Велосипед", ""}, texts)
+}
+
+func TestSourceHash(t *testing.T) {
+	assert.Equal(t, SourceHash("Велосипед", "горный"), SourceHash("Велосипед", "горный"))
+	assert.NotEqual(t, SourceHash("Велосипед", "горный"), SourceHash("Велосипед горный", ""),
+		"граница заголовка и описания входит в хэш")
+}
@@ -0,0 +1,189 @@
+package storage
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/yaroslavvasilenko/argon/internal/models"
+)
+
+// sourceHashExpr md5 исходного текста объявления в SQL; совпадает с SourceHash
+const sourceHashExpr = `md5(l.title || E'\n' || l.original_description)`
+
+// SourceHash возвращает md5 заголовка и описания, по которому перевод сверяется с текстом
+func SourceHash(title, description string) string {
+	sum := md5.Sum([]byte(title + "\n" + description))
+	return hex.EncodeToString(sum[:])
+}
+
+// ListingSource исходный текст объявления на языке автора
+type ListingSource struct {
+	ID          uuid.UUID
+	Lang        models.Localization
+	Title       string
+	Description string
+}
+
+// ListingTranslation перевод заголовка и описания объявления
+type ListingTranslation struct {
+	Lang        models.Localization
+	Title       string
+	Description string
+	Provider    string
+}
+
+// Translation кэш переводов объявлений в таблице listing_translations
+type Translation struct {
+	pool *pgxpool.Pool
+}
+
+func NewTranslation(pool *pgxpool.Pool) *Translation {
+	return &Translation{pool: pool}
+}
+
+// GetListingSource возвращает исходный текст объявления
+func (s *Translation) GetListingSource(ctx context.Context, listingID uuid.UUID) (ListingSource, error) {
+	source := ListingSource{ID: listingID}
+	err := s.pool.QueryRow(ctx, `
+		SELECT original_lang, title, original_description
+		FROM listings
+		WHERE id = $1 AND deleted_at IS NULL
+	`, listingID).Scan(&source.Lang, &source.Title, &source.Description)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ListingSource{}, fiber.NewError(fiber.StatusNotFound, "Объявление не найдено")
+	}
+	return source, err
+}
+
+// GetTranslation возвращает перевод текста с хэшем hash; found = false, если перевода
+// нет или он сделан для прежнего текста. Записи провайдера echo переводом не считаются
+func (s *Translation) GetTranslation(ctx context.Context, listingID uuid.UUID, lang models.Localization, hash string) (ListingTranslation, bool, error) {
+	translation := ListingTranslation{Lang: lang}
+	err := s.pool.QueryRow(ctx, `
+		SELECT title, description, provider
+		FROM listing_translations
+		WHERE listing_id = $1 AND lang = $2 AND source_hash = $3 AND provider <> $4
+	`, listingID, string(lang), hash, EchoProvider).Scan(&translation.Title, &translation.Description, &translation.Provider)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ListingTranslation{}, false, nil
+	}
+	if err != nil {
+		return ListingTranslation{}, false, err
+	}
+	return translation, true, nil
+}
+
+// GetTranslations возвращает переводы объявлений на язык lang одним запросом. hashes задает
+// хэш текущего текста каждого объявления; переводы прежнего текста и объявления, написанные
+// на языке lang, не возвращаются, как и записи провайдера echo
+func (s *Translation) GetTranslations(ctx context.Context, hashes map[uuid.UUID]string, lang models.Localization) (map[uuid.UUID]ListingTranslation, error) {
+	translations := make(map[uuid.UUID]ListingTranslation, len(hashes))
+	if len(hashes) == 0 {
+		return translations, nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(hashes))
+	sums := make([]string, 0, len(hashes))
+	for id, hash := range hashes {
+		ids = append(ids, id)
+		sums = append(sums, hash)
+	}
+
+	rows, err := s.pool.Query(ctx, `
+		SELECT lt.listing_id, lt.title, lt.description, lt.provider
+		FROM unnest($1::uuid[], $2::text[]) AS src(listing_id, source_hash)
+		JOIN listings l ON l.id = src.listing_id
+		JOIN listing_translations lt
+			ON lt.listing_id = src.listing_id AND lt.source_hash = src.source_hash
+		WHERE lt.lang = $3 AND l.original_lang <> $3 AND lt.provider <> $4
+	`, ids, sums, string(lang), EchoProvider)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id uuid.UUID
+		translation := ListingTranslation{Lang: lang}
+		if err := rows.Scan(&id, &translation.Title, &translation.Description, &translation.Provider); err != nil {
+			return nil, err
+		}
+		translations[id] = translation
+	}
+	return translations, rows.Err()
+}
+
+// SaveTranslation сохраняет перевод текста с хэшем hash, заменяя прежний перевод на тот же язык
+func (s *Translation) SaveTranslation(ctx context.Context, listingID uuid.UUID, hash string, translation ListingTranslation) error {
+	_, err := s.pool.Exec(ctx, `
+		INSERT INTO listing_translations (listing_id, lang, title, description, source_hash, provider)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (listing_id, lang) DO UPDATE SET
+			title = EXCLUDED.title,
+			description = EXCLUDED.description,
+			source_hash = EXCLUDED.source_hash,
+			provider = EXCLUDED.provider,
+			updated_at = NOW()
+	`, listingID, string(translation.Lang), translation.Title, translation.Description, hash, translation.Provider)
+	return err
+}
+
+// FindUntranslated возвращает объявления, которые еще не переведены после последнего
+// изменения текста. Первыми идут объявления без попыток перевода, затем давно не
+// переводившиеся; после каждой неудачной попытки пауза до следующей удваивается
+func (s *Translation) FindUntranslated(ctx context.Context, limit int) ([]uuid.UUID, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT id
+		FROM listings
+		WHERE NOT translated
+		AND deleted_at IS NULL
+		AND (translation_attempted_at IS NULL
+			OR translation_attempted_at < NOW() - LEAST(
+				INTERVAL '5 minutes' * power(2, translation_attempts - 1),
+				INTERVAL '1 day'
+			))
+		ORDER BY translation_attempted_at NULLS FIRST
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// MarkTranslated отмечает объявление переведенным, если его текст не изменился
+// с момента перевода: хэш hash должен совпадать с текущим текстом
+func (s *Translation) MarkTranslated(ctx context.Context, listingID uuid.UUID, hash string) error {
+	_, err := s.pool.Exec(ctx, `
+		UPDATE listings l
+		SET translated = TRUE, translation_attempted_at = NOW()
+		WHERE l.id = $1 AND `+sourceHashExpr+` = $2
+	`, listingID, hash)
+	return err
+}
+
+// RecordFailure учитывает неудачную попытку перевода объявления
+func (s *Translation) RecordFailure(ctx context.Context, listingID uuid.UUID) error {
+	_, err := s.pool.Exec(ctx, `
+		UPDATE listings
+		SET translation_attempts = translation_attempts + 1, translation_attempted_at = NOW()
+		WHERE id = $1
+	`, listingID)
+	return err
+}
@@ -1,11 +1,40 @@
 package router
 
 import (
+	"errors"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/phuslu/log"
+	"github.com/yaroslavvasilenko/argon/internal/models"
 )
 
 func ErrorHandler(c *fiber.Ctx, err error) error {
+	// конфликт с текущим состоянием ресурса отдается как 409 вместе с актуальным состоянием
+	var conflict *models.ConflictError
+	if errors.As(err, &conflict) {
+		err = fiber.NewError(fiber.StatusConflict, conflict.Message)
+	}
+
+	// ошибки значений отдельных полей запроса
+	var validationFailed *models.ValidationFailedError
+	if errors.As(err, &validationFailed) {
+		return c.Status(400).JSON(fiber.Map{
+			"code":        "ValidationFailed",
+			"description": validationFailed.Error(),
+			"errors":      validationFailed.Errors,
+		})
+	}
+
+	// изменение таксономии категорий с битыми ссылками
+	var invalidTaxonomy *models.InvalidTaxonomyError
+	if errors.As(err, &invalidTaxonomy) {
+		return c.Status(422).JSON(fiber.Map{
+			"code":        "InvalidTaxonomy",
+			"description": invalidTaxonomy.Error(),
+			"issues":      invalidTaxonomy.Issues,
+		})
+	}
+
 	// check fiber error
 	if e, ok := err.(*fiber.Error); ok {
 		switch e.Code {
@@ -24,16 +53,35 @@ func ErrorHandler(c *fiber.Ctx, err error) error {
 				"code":        "Unauthorized",
 				"description": "Недействительный токен аутентификации",
 			})
+		case fiber.StatusForbidden:
+			return c.Status(403).JSON(fiber.Map{
+				"code":        "AccessDenied",
+				"description": e.Message,
+			})
 		case fiber.StatusMethodNotAllowed:
 			return c.Status(405).JSON(fiber.Map{
 				"code":        "Method Not Allowed",
 				"description": "Метод не поддерживается",
 			})
+		case fiber.StatusPaymentRequired:
+			return c.Status(402).JSON(fiber.Map{
+				"code":        "PaymentRequired",
+				"description": e.Message,
+			})
+		case fiber.StatusPreconditionRequired:
+			return c.Status(428).JSON(fiber.Map{
+				"code":        "PreconditionRequired",
+				"description": e.Message,
+			})
 		case fiber.StatusConflict:
-			return c.Status(409).JSON(fiber.Map{
+			body := fiber.Map{
 				"code":        "Conflict",
 				"description": e.Message,
-			})
+			}
+			if conflict != nil {
+				body["current"] = conflict.Current
+			}
+			return c.Status(409).JSON(body)
 		}
 	}
 
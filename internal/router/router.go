@@ -33,6 +33,9 @@ func NewApiRouter(controllers *modules.Controllers) *fiber.App {
 	// Добавляем middleware для обработки языка
 	r.Use(middleware.Language())
 
+	// Добавляем middleware для идентификатора пользователя
+	r.Use(middleware.Actor())
+
 	r.Get("/ping", controllers.Listing.Ping)
 
 	//  poster
@@ -40,29 +43,78 @@ func NewApiRouter(controllers *modules.Controllers) *fiber.App {
 	r.Get("/api/v1/listing/:listing_id", controllers.Listing.GetListing)
 	r.Delete("/api/v1/listing/:listing_id", controllers.Listing.DeleteListing)
 	r.Put("/api/v1/listing/:listing_id", controllers.Listing.UpdateListing)
+	r.Put("/api/v1/listing/:listing_id/images/order", controllers.Listing.ReorderImages)
+	r.Put("/api/v1/listing/:listing_id/images/:image_id/cover", controllers.Listing.SetCoverImage)
+	r.Post("/api/v1/listing/:listing_id/publish", controllers.Listing.PublishListing)
+	r.Post("/api/v1/listing/:listing_id/pause", controllers.Listing.PauseListing)
+	r.Post("/api/v1/listing/:listing_id/sold", controllers.Listing.MarkListingSold)
+	r.Post("/api/v1/listing/:listing_id/renew", controllers.Listing.RenewListing)
+	r.Get("/api/v1/listing/:listing_id/revisions", controllers.Listing.GetRevisions)
+	r.Post("/api/v1/listing/:listing_id/revisions/:revision/restore", controllers.Listing.RestoreRevision)
+
+	// Административные методы доступны только администраторам
+	admin := middleware.Admin()
+
+	//  moderation
+	r.Get("/api/v1/admin/moderation/listings", admin, controllers.Listing.GetModerationQueue)
+	r.Post("/api/v1/admin/moderation/listings/:listing_id/approve", admin, controllers.Listing.ApproveListing)
+	r.Post("/api/v1/admin/moderation/listings/:listing_id/reject", admin, controllers.Listing.RejectListing)
 
 	//  search
 	r.Post("/api/v1/search", controllers.Listing.SearchListings)
 	r.Get("/api/v1/search/params", controllers.Listing.SearchListingsParams)
+	r.Post("/api/v1/search/map", controllers.Listing.SearchMap)
 
 	//  categories
 	r.Get("/api/v1/categories", controllers.Listing.GetCategories)
+	r.Get("/api/v1/categories/by-path", controllers.Listing.GetCategoryByPath)
 	r.Post("/api/v1/categories/characteristics", controllers.Listing.GetCharacteristicsForCategory)
 	r.Get("/api/v1/categories/filters", controllers.Listing.GetFiltersForCategory)
 
+	//  category taxonomy
+	r.Post("/api/v1/admin/categories/reload", admin, controllers.Category.ReloadCategories)
+	r.Post("/api/v1/admin/categories/import", admin, controllers.Category.ImportCategories)
+	r.Get("/api/v1/admin/categories/export", admin, controllers.Category.ExportCategories)
+	r.Post("/api/v1/admin/categories", admin, controllers.Category.CreateCategory)
+	r.Get("/api/v1/admin/categories/:category_id", admin, controllers.Category.GetCategory)
+	r.Put("/api/v1/admin/categories/:category_id", admin, controllers.Category.UpdateCategory)
+	r.Delete("/api/v1/admin/categories/:category_id", admin, controllers.Category.DeleteCategory)
+	r.Post("/api/v1/admin/categories/:category_id/move", admin, controllers.Category.MoveCategory)
+	r.Get("/api/v1/admin/characteristics/:role", admin, controllers.Category.GetCharacteristic)
+	r.Put("/api/v1/admin/characteristics/:role", admin, controllers.Category.SaveCharacteristic)
+	r.Delete("/api/v1/admin/characteristics/:role", admin, controllers.Category.DeleteCharacteristic)
+
 	//  currency
 	r.Get("/api/v1/currency", controllers.Currency.GetCurrency)
 
 	//  location
 	r.Post("/api/v1/location", controllers.Location.GetLocation)
+	r.Post("/api/v1/location/search", controllers.Location.SearchLocations)
 
 	//  boost
 	r.Post("/api/v1/boost/:listing_id", controllers.Boost.UpdateBoost)
 	r.Get("/api/v1/boost/:listing_id", controllers.Boost.GetBoost)
 
+	//  boost catalog
+	r.Get("/api/v1/admin/boost/catalog", admin, controllers.Boost.GetCatalog)
+	r.Put("/api/v1/admin/boost/types/:boost_type", admin, controllers.Boost.UpsertBoostType)
+	r.Post("/api/v1/admin/boost/tariffs", admin, controllers.Boost.CreateTariff)
+	r.Put("/api/v1/admin/boost/tariffs/:tariff_id", admin, controllers.Boost.UpdateTariff)
+	r.Delete("/api/v1/admin/boost/tariffs/:tariff_id", admin, controllers.Boost.DeleteTariff)
+
+	//  orders
+	r.Post("/api/v1/orders", controllers.Order.CreateOrder)
+	r.Get("/api/v1/orders/:order_id", controllers.Order.GetOrder)
+	r.Post("/api/v1/orders/:order_id/pay", controllers.Order.PayOrder)
+	r.Post("/api/v1/orders/:order_id/ship", controllers.Order.ShipOrder)
+	r.Post("/api/v1/orders/:order_id/complete", controllers.Order.CompleteOrder)
+	r.Post("/api/v1/orders/:order_id/cancel", controllers.Order.CancelOrder)
+
 	// images
 	r.Post("/api/v1/images/upload", controllers.Image.UploadImage)
 	r.Get("/api/v1/images/get/:image_id", controllers.Image.GetImage)
+	r.Get("/api/v1/images/:image_id/duplicates", controllers.Image.GetDuplicates)
+	r.Post("/api/v1/images/:image_id/moderation", admin, controllers.Image.ModerateImage)
 
 	return r
 }